@@ -7,6 +7,10 @@ package main
 
 import (
 	"context"
+	"os"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
 
 	// Uncomment if you want to run locally against remote GKE cluster.
 	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -15,6 +19,7 @@ import (
 	"knative.dev/eventing/pkg/adapter/v2"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/injection"
+	"knative.dev/pkg/logging"
 	"knative.dev/pkg/signals"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
@@ -28,5 +33,19 @@ func main() {
 	ctx := signals.NewContext()
 	kc := kubernetes.NewForConfigOrDie(injection.ParseAndGetRESTConfigOrDie())
 	ctx = context.WithValue(ctx, kubeclient.Key{}, kc)
-	adapter.MainWithContext(ctx, adapterName, vsphere.NewEnvConfig, vsphere.NewAdapter)
+	adapter.MainWithContext(ctx, adapterName, vsphere.NewEnvConfig, newAdapter)
+}
+
+// newAdapter exits with a distinct code for configuration errors so operators
+// can tell them apart from transient failures, e.g. vCenter being unreachable
+func newAdapter(ctx context.Context, env adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+	a, err := vsphere.NewAdapter(ctx, env, ceClient)
+	if err != nil {
+		logger := logging.FromContext(ctx)
+		code := vsphere.ExitCode(err)
+		logger.Errorw("unable to start adapter", zap.Int("exitCode", code), zap.Error(err))
+		_ = logger.Sync()
+		os.Exit(code)
+	}
+	return a
 }
@@ -19,6 +19,9 @@ import (
 func (vs *VSphereSource) SetDefaults(ctx context.Context) {
 	withNS := apis.WithinParent(ctx, vs.ObjectMeta)
 	vs.Spec.Sink.SetDefaults(withNS)
+	for i := range vs.Spec.Routes {
+		vs.Spec.Routes[i].Sink.SetDefaults(withNS)
+	}
 
 	// only checking period, setting maxAge to 0 will disable event replay
 	// to get at-most-once semantics
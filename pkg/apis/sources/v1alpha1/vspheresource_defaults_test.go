@@ -174,6 +174,54 @@ func TestVSphereSourceDefaulting(t *testing.T) {
 				ServiceAccountName: "test-svcacc",
 			},
 		},
+	}, {
+		name: "route ref gets namespace",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: "with-namespace",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Routes: []VSphereSourceRoute{{
+					Name: "vm-power",
+					Sink: duckv1.Destination{
+						Ref: &duckv1.KReference{
+							APIVersion: "serving.knative.dev",
+							Kind:       "Service",
+							Name:       "no-namespace",
+						},
+					},
+				}},
+			},
+		},
+		want: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: "with-namespace",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					MaxAgeSeconds: 0,
+					PeriodSeconds: int64(vsphere.CheckpointDefaultPeriod.Seconds()),
+				},
+				PayloadEncoding: cloudevents.ApplicationXML,
+				Routes: []VSphereSourceRoute{{
+					Name: "vm-power",
+					Sink: duckv1.Destination{
+						Ref: &duckv1.KReference{
+							APIVersion: "serving.knative.dev",
+							Kind:       "Service",
+							Namespace:  "with-namespace",
+							Name:       "no-namespace",
+						},
+					},
+				}},
+			},
+		},
 	}}
 
 	for _, test := range tests {
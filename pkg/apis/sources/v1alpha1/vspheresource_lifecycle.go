@@ -65,3 +65,18 @@ func (vss *VSphereSourceStatus) PropagateAdapterStatus(d appsv1.DeploymentStatus
 
 	condSet.Manage(vss).MarkUnknown(VSphereSourceConditionAdapterReady, "", "")
 }
+
+// PropagateAdapterStatus reflects the availability of the adapter deployment
+// of the route
+func (rs *VSphereSourceRouteStatus) PropagateAdapterStatus(d appsv1.DeploymentStatus) {
+	rs.Ready, rs.Message = corev1.ConditionUnknown, ""
+	for _, cond := range d.Conditions {
+		if cond.Type == appsv1.DeploymentAvailable {
+			rs.Ready = cond.Status
+			if cond.Status != corev1.ConditionTrue {
+				rs.Message = cond.Message
+			}
+			return
+		}
+	}
+}
@@ -112,3 +112,40 @@ func TestTypicalSourceFlow(t *testing.T) {
 	// After all of that, we're finally ready!
 	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
 }
+
+func TestVSphereSourceRouteStatus_PropagateAdapterStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      appsv1.DeploymentStatus
+		wantReady   corev1.ConditionStatus
+		wantMessage string
+	}{{
+		name:      "no availability",
+		wantReady: corev1.ConditionUnknown,
+	}, {
+		name: "available",
+		status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+		}}},
+		wantReady: corev1.ConditionTrue,
+	}, {
+		name: "unavailable",
+		status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentAvailable,
+			Status:  corev1.ConditionFalse,
+			Message: "Deployment does not have minimum availability.",
+		}}},
+		wantReady:   corev1.ConditionFalse,
+		wantMessage: "Deployment does not have minimum availability.",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs := &VSphereSourceRouteStatus{Name: "vm-power", Message: "stale"}
+			rs.PropagateAdapterStatus(test.status)
+			if rs.Ready != test.wantReady || rs.Message != test.wantMessage {
+				t.Errorf("PropagateAdapterStatus() = %s %q, wanted %s %q", rs.Ready, rs.Message, test.wantReady, test.wantMessage)
+			}
+		})
+	}
+}
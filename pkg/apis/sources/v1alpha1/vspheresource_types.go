@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -44,14 +45,42 @@ type VSphereSourceSpec struct {
 	VAuthSpec        `json:",inline"`
 	CheckpointConfig VCheckpointSpec `json:"checkpointConfig"`
 	PayloadEncoding  string          `json:"payloadEncoding"`
+	// CESource overrides the source attribute of the CloudEvents sent by the
+	// adapter. It must be a valid URI-reference. If unspecified, the host of
+	// the vCenter address is used.
+	// +optional
+	CESource string `json:"ceSource,omitempty"`
 	// ServiceAccountName holds the name of the Kubernetes service account
 	// as which the underlying K8s resources should be run. If unspecified
 	// this will default to the "default" service account for the namespace
 	// in which the HorizonSource exists.
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Routes declare additional named sinks, each receiving the events
+	// matching its filter from a dedicated adapter with independent delivery
+	// progress.
+	// +optional
+	Routes []VSphereSourceRoute `json:"routes,omitempty"`
 }
 
+// VSphereSourceRoute delivers the events of a VSphereSource matching its
+// filter to a dedicated sink. Each route is materialized as a separate adapter
+// deployment with its own checkpoint.
+type VSphereSourceRoute struct {
+	// Name uniquely identifies the route within the source
+	Name string `json:"name"`
+	// EventTypes filters the vSphere event types delivered to the sink, e.g.
+	// VmPoweredOnEvent. If unspecified, all events are delivered.
+	// +optional
+	EventTypes []string `json:"eventTypes,omitempty"`
+	// Sink is the destination of the route
+	Sink duckv1.Destination `json:"sink"`
+}
+
+// PayloadEncodingEventV1 is the payload encoding of the stable, versioned JSON
+// representation of vSphere events
+const PayloadEncodingEventV1 = "application/vnd.vsphere.event.v1+json"
+
 type VCheckpointSpec struct {
 	MaxAgeSeconds int64 `json:"maxAgeSeconds"`
 	PeriodSeconds int64 `json:"periodSeconds"`
@@ -74,6 +103,27 @@ const (
 // VSphereSourceStatus communicates the observed state of the VSphereSource (from the controller).
 type VSphereSourceStatus struct {
 	duckv1.SourceStatus `json:",inline"`
+
+	// Routes communicates the observed state of the routes of the
+	// VSphereSource
+	// +optional
+	Routes []VSphereSourceRouteStatus `json:"routes,omitempty"`
+}
+
+// VSphereSourceRouteStatus communicates the observed state of a route
+type VSphereSourceRouteStatus struct {
+	// Name of the route
+	Name string `json:"name"`
+	// SinkURI is the resolved URI of the route sink
+	// +optional
+	SinkURI *apis.URL `json:"sinkUri,omitempty"`
+	// Ready reflects the availability of the adapter deployment of the route
+	// +optional
+	Ready corev1.ConditionStatus `json:"ready,omitempty"`
+	// Message describes why the adapter deployment of the route is not
+	// available
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -7,9 +7,14 @@ package v1alpha1
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
 	"strings"
+	"unicode"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
 )
 
@@ -26,12 +31,68 @@ func (vsss *VSphereSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 			Validate(ctx))
 
 	encoding := strings.ToLower(vsss.PayloadEncoding)
-	if (encoding != cloudevents.ApplicationJSON) && (encoding != cloudevents.ApplicationXML) {
+	if (encoding != cloudevents.ApplicationJSON) && (encoding != cloudevents.ApplicationXML) &&
+		(encoding != PayloadEncodingEventV1) {
 		errs = errs.Also(apis.ErrInvalidValue(encoding, "payloadEncoding"))
 	}
+
+	if vsss.CESource != "" {
+		if err := ValidateCESource(vsss.CESource); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(vsss.CESource, "ceSource", err.Error()))
+		}
+	}
+
+	names := make(map[string]struct{}, len(vsss.Routes))
+	for i, route := range vsss.Routes {
+		errs = errs.Also(route.Validate(ctx).ViaFieldIndex("routes", i))
+
+		if _, ok := names[route.Name]; ok {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("duplicate route name %q", route.Name), "name").ViaFieldIndex("routes", i))
+		}
+		names[route.Name] = struct{}{}
+	}
 	return errs
 }
 
+// Validate implements apis.Validatable
+func (r *VSphereSourceRoute) Validate(ctx context.Context) *apis.FieldError {
+	errs := r.Sink.Validate(ctx).ViaField("sink")
+
+	if r.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("name"))
+	} else if msgs := validation.IsDNS1123Label(r.Name); len(msgs) > 0 {
+		errs = errs.Also(apis.ErrInvalidValue(r.Name, "name", strings.Join(msgs, ", ")))
+	}
+
+	for i, et := range r.EventTypes {
+		if strings.TrimSpace(et) == "" {
+			errs = errs.Also(apis.ErrInvalidArrayValue(et, "eventTypes", i))
+		}
+	}
+	return errs
+}
+
+// ValidateCESource returns an error if the given CloudEvent source is not a
+// URI-reference, e.g. urn:vcenter:vc-01 or /vcenter/vc-01. Unlike url.Parse it
+// rejects whitespace and control characters and references with neither
+// scheme nor path, e.g. a bare query or fragment.
+func ValidateCESource(source string) error {
+	for _, r := range source {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return errors.New("must not contain whitespace or control characters")
+		}
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return fmt.Errorf("must be a URI-reference: %w", err)
+	}
+	if u.Scheme == "" && u.Path == "" {
+		return errors.New("must have a scheme or path")
+	}
+	return nil
+}
+
 func (vcs VCheckpointSpec) Validate(ctx context.Context) (err *apis.FieldError) {
 	if vcs.PeriodSeconds < 0 {
 		err = err.Also(apis.ErrInvalidValue(vcs.PeriodSeconds, "checkpointConfig.periodSeconds"))
@@ -73,6 +73,19 @@ func TestVSphereSourceValidation(t *testing.T) {
 			},
 		},
 		want: nil,
+	}, {
+		name: "valid with versioned JSON payloadEncoding",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: PayloadEncodingEventV1,
+			},
+		},
+		want: nil,
 	}, {
 		name: "invalid payloadEncoding",
 		c: &VSphereSource{
@@ -86,6 +99,48 @@ func TestVSphereSourceValidation(t *testing.T) {
 			},
 		},
 		want: apis.ErrInvalidValue("application/text", "spec.payloadEncoding"),
+	}, {
+		name: "valid ceSource",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				CESource:        "urn:vcenter:vc-01",
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid ceSource",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				CESource:        "vc-01\x07",
+			},
+		},
+		want: apis.ErrInvalidValue("vc-01\x07", "spec.ceSource", "must not contain whitespace or control characters"),
+	}, {
+		name: "ceSource without scheme and path",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				CESource:        "#vc-01",
+			},
+		},
+		want: apis.ErrInvalidValue("#vc-01", "spec.ceSource", "must have a scheme or path"),
 	}, {
 		name: "missing VAuthSpec",
 		c: &VSphereSource{
@@ -130,6 +185,54 @@ func TestVSphereSourceValidation(t *testing.T) {
 		},
 		want: apis.ErrInvalidValue("-10", "spec.checkpointConfig.maxAgeSeconds").Also(apis.ErrInvalidValue("-5",
 			"spec.checkpointConfig.periodSeconds")),
+	}, {
+		name: "valid routes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				Routes: []VSphereSourceRoute{{
+					Name:       "vm-power",
+					EventTypes: []string{"VmPoweredOnEvent", "VmPoweredOffEvent"},
+					Sink:       validSourceSpec.Sink,
+				}, {
+					Name: "all",
+					Sink: validSourceSpec.Sink,
+				}},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid routes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				Routes: []VSphereSourceRoute{{
+					Name:       "vm-power",
+					EventTypes: []string{"VmPoweredOnEvent", " "},
+					Sink:       validSourceSpec.Sink,
+				}, {
+					Name: "vm-power",
+					Sink: validSourceSpec.Sink,
+				}, {
+					Name: "VM_Power",
+				}},
+			},
+		},
+		want: apis.ErrInvalidArrayValue(" ", "spec.routes[0].eventTypes", 1).
+			Also(apis.ErrGeneric(`duplicate route name "vm-power"`, "spec.routes[1].name")).
+			Also(apis.ErrGeneric("expected at least one, got none", "spec.routes[2].sink.ref", "spec.routes[2].sink.uri")).
+			Also(apis.ErrInvalidValue("VM_Power", "spec.routes[2].name",
+				"a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')")),
 	}}
 
 	for _, test := range tests {
@@ -142,3 +245,32 @@ func TestVSphereSourceValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCESource(t *testing.T) {
+	tests := []struct {
+		source  string
+		wantErr bool
+	}{
+		{source: "urn:vcenter:vc-01"},
+		{source: "https://vcenter.example.com/sdk"},
+		{source: "/vcenter/vc-01"},
+		{source: "vc-01"},
+		{source: "vc 01", wantErr: true},
+		{source: " vc-01", wantErr: true},
+		{source: "vc-01\n", wantErr: true},
+		{source: "vc-01\t", wantErr: true},
+		{source: "vc-01\x7f", wantErr: true},
+		{source: "vc-01\u00a0", wantErr: true},
+		{source: "?vc=01", wantErr: true},
+		{source: "#vc-01", wantErr: true},
+		{source: "//vcenter.example.com", wantErr: true},
+		{source: "%zz", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			if err := ValidateCESource(test.source); (err != nil) != test.wantErr {
+				t.Errorf("ValidateCESource(%q) error = %v, wantErr %v", test.source, err, test.wantErr)
+			}
+		})
+	}
+}
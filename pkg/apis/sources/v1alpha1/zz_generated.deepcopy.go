@@ -12,6 +12,7 @@ package v1alpha1
 
 import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	apis "knative.dev/pkg/apis"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -319,12 +320,62 @@ func (in *VSphereSourceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereSourceRoute) DeepCopyInto(out *VSphereSourceRoute) {
+	*out = *in
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Sink.DeepCopyInto(&out.Sink)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSourceRoute.
+func (in *VSphereSourceRoute) DeepCopy() *VSphereSourceRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereSourceRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereSourceRouteStatus) DeepCopyInto(out *VSphereSourceRouteStatus) {
+	*out = *in
+	if in.SinkURI != nil {
+		in, out := &in.SinkURI, &out.SinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSourceRouteStatus.
+func (in *VSphereSourceRouteStatus) DeepCopy() *VSphereSourceRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereSourceRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereSourceSpec) DeepCopyInto(out *VSphereSourceSpec) {
 	*out = *in
 	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
 	in.VAuthSpec.DeepCopyInto(&out.VAuthSpec)
 	out.CheckpointConfig = in.CheckpointConfig
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]VSphereSourceRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -342,6 +393,13 @@ func (in *VSphereSourceSpec) DeepCopy() *VSphereSourceSpec {
 func (in *VSphereSourceStatus) DeepCopyInto(out *VSphereSourceStatus) {
 	*out = *in
 	in.SourceStatus.DeepCopyInto(&out.SourceStatus)
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]VSphereSourceRouteStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -26,3 +26,16 @@ func MakeConfigMap(ctx context.Context, vms *v1alpha1.VSphereSource) *corev1.Con
 		},
 	}
 }
+
+// MakeRouteConfigMap creates the checkpoint ConfigMap of the given route owned
+// by the VSphereSource
+func MakeRouteConfigMap(ctx context.Context, vms *v1alpha1.VSphereSource, route string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.RouteConfigMap(vms, route),
+			Namespace:       vms.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
+			Labels:          RouteLabels(vms, route),
+		},
+	}
+}
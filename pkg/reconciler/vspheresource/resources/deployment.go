@@ -15,6 +15,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/ptr"
@@ -30,11 +32,59 @@ type AdapterArgs struct {
 	MetricsConfig string
 }
 
+const (
+	// labels of route adapter deployments, distinct from the labels of the
+	// source adapter deployment to avoid overlapping selectors
+	routeSourceLabel = "vspheresources.sources.tanzu.vmware.com/route-source"
+	routeNameLabel   = "vspheresources.sources.tanzu.vmware.com/route"
+)
+
 func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, args AdapterArgs) (*appsv1.Deployment, error) {
 	labels := map[string]string{
 		"vspheresources.sources.tanzu.vmware.com/name": vms.Name,
 	}
 
+	return makeDeployment(ctx, vms, args, names.Deployment(vms), labels, vms.Status.SinkURI.String(), names.ConfigMap(vms), nil)
+}
+
+// MakeRouteDeployment creates the adapter deployment of the given route
+// delivering the events matching the route filter to sinkURI. The adapter
+// uses a dedicated checkpoint ConfigMap so delivery progress of each route is
+// independent.
+func MakeRouteDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, route v1alpha1.VSphereSourceRoute, sinkURI *apis.URL, args AdapterArgs) (*appsv1.Deployment, error) {
+	var env []corev1.EnvVar
+	if len(route.EventTypes) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "VSPHERE_EVENT_TYPES",
+			Value: strings.Join(route.EventTypes, ","),
+		})
+	}
+
+	return makeDeployment(ctx, vms, args, names.RouteDeployment(vms, route.Name), RouteLabels(vms, route.Name),
+		sinkURI.String(), names.RouteConfigMap(vms, route.Name), env)
+}
+
+// RouteLabels returns the labels of the resources created for the given route
+func RouteLabels(vms *v1alpha1.VSphereSource, route string) map[string]string {
+	return map[string]string{
+		routeSourceLabel: vms.Name,
+		routeNameLabel:   route,
+	}
+}
+
+// RouteSelector returns the selector matching the resources created for all
+// routes of the given source
+func RouteSelector(vms *v1alpha1.VSphereSource) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{routeSourceLabel: vms.Name})
+}
+
+// RouteName returns the name of the route of a resource created for a route
+func RouteName(obj metav1.Object) string {
+	return obj.GetLabels()[routeNameLabel]
+}
+
+func makeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, args AdapterArgs, name string,
+	labels map[string]string, sinkURI, configMap string, extraEnv []corev1.EnvVar) (*appsv1.Deployment, error) {
 	var ceOverrides string
 	if vms.Spec.CloudEventOverrides != nil {
 		if co, err := json.Marshal(vms.Spec.SourceSpec.CloudEventOverrides); err != nil {
@@ -55,9 +105,60 @@ func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, args Adapt
 		return nil, fmt.Errorf("marshal checkpoint config: %w", err)
 	}
 
+	env := []corev1.EnvVar{{
+		Name: "NAMESPACE",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "metadata.namespace",
+			},
+		},
+	}, {
+		Name: "NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "metadata.name",
+			},
+		},
+	}, {
+		Name:  "VSPHERE_SOURCE_NAME",
+		Value: vms.Name,
+	}, {
+		Name:  "VSPHERE_SOURCE_UID",
+		Value: string(vms.UID),
+	}, {
+		Name:  "K_METRICS_CONFIG",
+		Value: args.MetricsConfig,
+	}, {
+		Name:  "K_LOGGING_CONFIG",
+		Value: args.LoggingConfig,
+	}, {
+		Name:  "VSPHERE_KVSTORE_CONFIGMAP",
+		Value: configMap,
+	}, {
+		Name:  "VSPHERE_CHECKPOINT_CONFIG",
+		Value: string(jsonBytes),
+	}, {
+		Name:  "VSPHERE_PAYLOAD_ENCODING",
+		Value: strings.ToLower(vms.Spec.PayloadEncoding),
+	}, {
+		Name:  "K_CE_OVERRIDES",
+		Value: ceOverrides,
+	}, {
+		Name:  "K_SINK",
+		Value: sinkURI,
+	}}
+
+	if vms.Spec.CESource != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "VSPHERE_SOURCE_OVERRIDE",
+			Value: vms.Spec.CESource,
+		})
+	}
+	env = append(env, extraEnv...)
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            names.Deployment(vms),
+			Name:            name,
 			Namespace:       vms.Namespace,
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
 			Labels:          labels,
@@ -76,42 +177,7 @@ func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, args Adapt
 					Containers: []corev1.Container{{
 						Name:  "adapter",
 						Image: args.Image,
-						Env: []corev1.EnvVar{{
-							Name: "NAMESPACE",
-							ValueFrom: &corev1.EnvVarSource{
-								FieldRef: &corev1.ObjectFieldSelector{
-									FieldPath: "metadata.namespace",
-								},
-							},
-						}, {
-							Name: "NAME",
-							ValueFrom: &corev1.EnvVarSource{
-								FieldRef: &corev1.ObjectFieldSelector{
-									FieldPath: "metadata.name",
-								},
-							},
-						}, {
-							Name:  "K_METRICS_CONFIG",
-							Value: args.MetricsConfig,
-						}, {
-							Name:  "K_LOGGING_CONFIG",
-							Value: args.LoggingConfig,
-						}, {
-							Name:  "VSPHERE_KVSTORE_CONFIGMAP",
-							Value: names.ConfigMap(vms),
-						}, {
-							Name:  "VSPHERE_CHECKPOINT_CONFIG",
-							Value: string(jsonBytes),
-						}, {
-							Name:  "VSPHERE_PAYLOAD_ENCODING",
-							Value: strings.ToLower(vms.Spec.PayloadEncoding),
-						}, {
-							Name:  "K_CE_OVERRIDES",
-							Value: ceOverrides,
-						}, {
-							Name:  "K_SINK",
-							Value: vms.Status.SinkURI.String(),
-						}},
+						Env:   env,
 					}},
 				},
 			},
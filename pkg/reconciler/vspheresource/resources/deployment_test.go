@@ -0,0 +1,104 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/pkg/apis"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+)
+
+func TestMakeRouteDeployment(t *testing.T) {
+	vms := &v1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo", UID: "uid"},
+		Spec: v1alpha1.VSphereSourceSpec{
+			PayloadEncoding: "application/json",
+			CESource:        "urn:vcenter:vc-01",
+		},
+	}
+	sourceSink, _ := apis.ParseURL("http://source.example.com")
+	vms.Status.SinkURI = sourceSink
+	routeSink, _ := apis.ParseURL("http://route.example.com")
+	route := v1alpha1.VSphereSourceRoute{
+		Name:       "vm-power",
+		EventTypes: []string{"VmPoweredOnEvent", "VmPoweredOffEvent"},
+	}
+
+	d, err := MakeRouteDeployment(context.Background(), vms, route, routeSink, AdapterArgs{Image: "adapter-image"})
+	if err != nil {
+		t.Fatalf("MakeRouteDeployment() error = %v", err)
+	}
+
+	if d.Name != names.RouteDeployment(vms, route.Name) || d.Namespace != vms.Namespace {
+		t.Errorf("MakeRouteDeployment() = %s/%s, wanted %s/%s", d.Namespace, d.Name, vms.Namespace, names.RouteDeployment(vms, route.Name))
+	}
+	if !metav1.IsControlledBy(d, vms) {
+		t.Errorf("MakeRouteDeployment() owner references = %+v, wanted controlled by source", d.OwnerReferences)
+	}
+	if RouteName(d) != route.Name {
+		t.Errorf("RouteName() = %q, wanted %q", RouteName(d), route.Name)
+	}
+	if !RouteSelector(vms).Matches(labels.Set(d.Labels)) {
+		t.Errorf("RouteSelector() does not match labels %v", d.Labels)
+	}
+
+	// the selector of the source adapter must not select route adapter pods
+	main, err := MakeDeployment(context.Background(), vms, AdapterArgs{Image: "adapter-image"})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+	if selects(main, d) || selects(d, main) {
+		t.Errorf("selectors of source adapter %v and route adapter %v overlap", main.Spec.Selector, d.Spec.Selector)
+	}
+
+	wantEnv := map[string]string{
+		"K_SINK":                    "http://route.example.com",
+		"VSPHERE_KVSTORE_CONFIGMAP": names.RouteConfigMap(vms, route.Name),
+		"VSPHERE_EVENT_TYPES":       "VmPoweredOnEvent,VmPoweredOffEvent",
+		"VSPHERE_SOURCE_OVERRIDE":   "urn:vcenter:vc-01",
+		"VSPHERE_PAYLOAD_ENCODING":  "application/json",
+	}
+	env := map[string]string{}
+	for _, e := range d.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	for name, want := range wantEnv {
+		if got := env[name]; got != want {
+			t.Errorf("MakeRouteDeployment() env %s = %q, wanted %q", name, got, want)
+		}
+	}
+}
+
+func TestMakeRouteDeployment_allEventTypes(t *testing.T) {
+	vms := &v1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"}}
+	sink, _ := apis.ParseURL("http://route.example.com")
+
+	d, err := MakeRouteDeployment(context.Background(), vms, v1alpha1.VSphereSourceRoute{Name: "all"}, sink, AdapterArgs{})
+	if err != nil {
+		t.Fatalf("MakeRouteDeployment() error = %v", err)
+	}
+	for _, e := range d.Spec.Template.Spec.Containers[0].Env {
+		if e.Name == "VSPHERE_EVENT_TYPES" {
+			t.Errorf("MakeRouteDeployment() env VSPHERE_EVENT_TYPES = %q, wanted unset", e.Value)
+		}
+	}
+}
+
+// selects returns true if the selector of a matches the pods of b
+func selects(a, b *appsv1.Deployment) bool {
+	selector, err := metav1.LabelSelectorAsSelector(a.Spec.Selector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(b.Spec.Template.Labels))
+}
@@ -6,6 +6,9 @@ SPDX-License-Identifier: Apache-2.0
 package names
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"knative.dev/pkg/kmeta"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
@@ -15,14 +18,40 @@ func Deployment(vms *v1alpha1.VSphereSource) string {
 	return kmeta.ChildName(vms.Name, "-adapter")
 }
 
+// RouteDeployment returns the name of the adapter deployment of the given
+// route. Route resource names contain a hash of the source and route name so
+// they do not match the resources of another source, e.g. of source
+// <source>-<route>.
+func RouteDeployment(vms *v1alpha1.VSphereSource, route string) string {
+	return kmeta.ChildName(vms.Name+"-route-"+route+"-"+routeHash(vms, route), "-adapter")
+}
+
 func VSphereBinding(vms *v1alpha1.VSphereSource) string {
 	return kmeta.ChildName(vms.Name, "-vspherebinding")
 }
 
+// RouteVSphereBinding returns the name of the VSphereBinding of the adapter
+// deployment of the given route
+func RouteVSphereBinding(vms *v1alpha1.VSphereSource, route string) string {
+	return kmeta.ChildName(vms.Name+"-route-"+route+"-"+routeHash(vms, route), "-vspherebinding")
+}
+
 func ConfigMap(vms *v1alpha1.VSphereSource) string {
 	return kmeta.ChildName(vms.Name, "-configmap")
 }
 
+// RouteConfigMap returns the name of the checkpoint ConfigMap of the given
+// route
+func RouteConfigMap(vms *v1alpha1.VSphereSource, route string) string {
+	return kmeta.ChildName(vms.Name+"-route-"+route+"-"+routeHash(vms, route), "-configmap")
+}
+
+// routeHash returns a short hash identifying the given route of the source
+func routeHash(vms *v1alpha1.VSphereSource, route string) string {
+	sum := sha256.Sum256([]byte(vms.Name + "/" + route))
+	return hex.EncodeToString(sum[:4])
+}
+
 func RoleBinding(vms *v1alpha1.VSphereSource) string {
 	return kmeta.ChildName(vms.Name, "-rolebinding")
 }
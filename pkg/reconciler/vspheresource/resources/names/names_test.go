@@ -103,3 +103,76 @@ func TestNames(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		vss   *v1alpha1.VSphereSource
+		route string
+		f     func(*v1alpha1.VSphereSource, string) string
+		want  string
+	}{{
+		name: "route deployment",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		route: "vm-power",
+		f:     RouteDeployment,
+		want:  "foo-route-vm-power-62b5bb05-adapter",
+	}, {
+		name: "route deployment too long",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: strings.Repeat("f", 52),
+			},
+		},
+		route: "vm-power",
+		f:     RouteDeployment,
+		want:  "fffffffffffffffffffffffa9847e39944a08a656b722db19b63ad4-adapter",
+	}, {
+		name: "route configmap",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "baz",
+			},
+		},
+		route: "vm-power",
+		f:     RouteConfigMap,
+		want:  "baz-route-vm-power-2f806124-configmap",
+	}, {
+		name: "route vspherebinding",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		route: "vm-power",
+		f:     RouteVSphereBinding,
+		want:  "foo-route-vm-power-62b5bb05-vspherebinding",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.f(test.vss, test.route)
+			if got != test.want {
+				t.Errorf("%s() = %v, wanted %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRouteNamesDoNotCollide(t *testing.T) {
+	source := &v1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	other := &v1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "foo-bar"}}
+
+	if got := RouteDeployment(source, "bar"); got == Deployment(other) {
+		t.Errorf("RouteDeployment() = %v, collides with deployment of source %s", got, other.Name)
+	}
+	if got := RouteConfigMap(source, "bar"); got == ConfigMap(other) {
+		t.Errorf("RouteConfigMap() = %v, collides with configmap of source %s", got, other.Name)
+	}
+	if got := RouteVSphereBinding(source, "bar"); got == VSphereBinding(other) {
+		t.Errorf("RouteVSphereBinding() = %v, collides with vspherebinding of source %s", got, other.Name)
+	}
+}
@@ -18,10 +18,22 @@ import (
 )
 
 func MakeVSphereBinding(ctx context.Context, vms *v1alpha1.VSphereSource) *v1alpha1.VSphereBinding {
+	return makeVSphereBinding(vms, names.VSphereBinding(vms), names.Deployment(vms), nil)
+}
+
+// MakeRouteVSphereBinding creates the VSphereBinding injecting the vCenter
+// address and credentials into the adapter deployment of the given route
+func MakeRouteVSphereBinding(ctx context.Context, vms *v1alpha1.VSphereSource, route string) *v1alpha1.VSphereBinding {
+	return makeVSphereBinding(vms, names.RouteVSphereBinding(vms, route), names.RouteDeployment(vms, route),
+		RouteLabels(vms, route))
+}
+
+func makeVSphereBinding(vms *v1alpha1.VSphereSource, name, deployment string, labels map[string]string) *v1alpha1.VSphereBinding {
 	return &v1alpha1.VSphereBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            names.VSphereBinding(vms),
+			Name:            name,
 			Namespace:       vms.Namespace,
+			Labels:          labels,
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
 		},
 		Spec: v1alpha1.VSphereBindingSpec{
@@ -33,7 +45,7 @@ func MakeVSphereBinding(ctx context.Context, vms *v1alpha1.VSphereSource) *v1alp
 					APIVersion: "apps/v1",
 					Kind:       "Deployment",
 					Namespace:  vms.Namespace,
-					Name:       names.Deployment(vms),
+					Name:       deployment,
 				},
 			},
 		},
@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -87,6 +88,10 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, vms *sourcesv1alpha1.VSp
 	if err = r.reconcileDeployment(ctx, vms); err != nil {
 		return err
 	}
+
+	if err = r.reconcileRoutes(ctx, vms); err != nil {
+		return err
+	}
 	logging.FromContext(ctx).Infof("Reconciled vspheresource %q", vms.Name)
 
 	return nil
@@ -173,59 +178,214 @@ func (r *Reconciler) reconcileRoleBinding(ctx context.Context, vms *sourcesv1alp
 }
 
 func (r *Reconciler) reconcileDeployment(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	args, err := r.adapterArgs()
+	if err != nil {
+		return err
+	}
+
+	desiredDeployment, err := resources.MakeDeployment(ctx, vms, args)
+	if err != nil {
+		return fmt.Errorf("failed to create deployment %q: %w", names.Deployment(vms), err)
+	}
+
+	deployment, err := r.applyDeployment(ctx, vms, desiredDeployment)
+	if err != nil {
+		return err
+	}
+
+	// Reflect the state of the Adapter Deployment in the VSphereSource
+	vms.Status.PropagateAdapterStatus(deployment.Status)
+
+	return nil
+}
+
+// reconcileRoutes creates or updates a checkpoint ConfigMap, a VSphereBinding
+// and an adapter deployment for each route and deletes the resources of
+// removed routes.
+// Resources of routes not controlled by the source are never modified.
+func (r *Reconciler) reconcileRoutes(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
 	ns := vms.Namespace
-	deploymentName := names.Deployment(vms)
 
+	args, err := r.adapterArgs()
+	if err != nil {
+		return err
+	}
+
+	routes := make(map[string]struct{}, len(vms.Spec.Routes))
+	statuses := make([]sourcesv1alpha1.VSphereSourceRouteStatus, 0, len(vms.Spec.Routes))
+	for _, route := range vms.Spec.Routes {
+		routes[route.Name] = struct{}{}
+
+		cmName := names.RouteConfigMap(vms, route.Name)
+		if cm, err := r.cmLister.ConfigMaps(ns).Get(cmName); apierrs.IsNotFound(err) {
+			cm = resources.MakeRouteConfigMap(ctx, vms, route.Name)
+			if _, err = r.kubeclient.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create configmap %q: %w", cmName, err)
+			}
+			logging.FromContext(ctx).Infof("Created configmap %q", cmName)
+		} else if err != nil {
+			return fmt.Errorf("failed to get configmap %q: %w", cmName, err)
+		} else if !metav1.IsControlledBy(cm, vms) {
+			return fmt.Errorf("configmap %q of route %q is not owned by source %q", cmName, route.Name, vms.Name)
+		}
+
+		if err := r.applyVSphereBinding(ctx, vms, resources.MakeRouteVSphereBinding(ctx, vms, route.Name)); err != nil {
+			return err
+		}
+
+		uri, err := r.resolver.URIFromDestinationV1(ctx, route.Sink, vms)
+		if err != nil {
+			return fmt.Errorf("failed to resolve sink of route %q: %w", route.Name, err)
+		}
+
+		desiredDeployment, err := resources.MakeRouteDeployment(ctx, vms, route, uri, args)
+		if err != nil {
+			return fmt.Errorf("failed to create deployment %q: %w", names.RouteDeployment(vms, route.Name), err)
+		}
+		deployment, err := r.applyDeployment(ctx, vms, desiredDeployment)
+		if err != nil {
+			return err
+		}
+
+		status := sourcesv1alpha1.VSphereSourceRouteStatus{
+			Name:    route.Name,
+			SinkURI: uri,
+		}
+		status.PropagateAdapterStatus(deployment.Status)
+		statuses = append(statuses, status)
+	}
+	vms.Status.Routes = statuses
+	if len(statuses) == 0 {
+		vms.Status.Routes = nil
+	}
+
+	// delete resources of removed routes and resources named differently,
+	// e.g. created by an earlier version
+	deployments, err := r.deploymentLister.Deployments(ns).List(resources.RouteSelector(vms))
+	if err != nil {
+		return fmt.Errorf("failed to list route deployments: %w", err)
+	}
+	for _, d := range deployments {
+		route := resources.RouteName(d)
+		if _, ok := routes[route]; (ok && d.Name == names.RouteDeployment(vms, route)) || !metav1.IsControlledBy(d, vms) {
+			continue
+		}
+		if err = r.kubeclient.AppsV1().Deployments(ns).Delete(ctx, d.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete deployment %q: %w", d.Name, err)
+		}
+		logging.FromContext(ctx).Infof("Deleted deployment %q of removed route", d.Name)
+	}
+
+	cms, err := r.cmLister.ConfigMaps(ns).List(resources.RouteSelector(vms))
+	if err != nil {
+		return fmt.Errorf("failed to list route configmaps: %w", err)
+	}
+	for _, cm := range cms {
+		route := resources.RouteName(cm)
+		if _, ok := routes[route]; (ok && cm.Name == names.RouteConfigMap(vms, route)) || !metav1.IsControlledBy(cm, vms) {
+			continue
+		}
+		if err = r.kubeclient.CoreV1().ConfigMaps(ns).Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete configmap %q: %w", cm.Name, err)
+		}
+		logging.FromContext(ctx).Infof("Deleted configmap %q of removed route", cm.Name)
+	}
+
+	bindings, err := r.vspherebindingLister.VSphereBindings(ns).List(resources.RouteSelector(vms))
+	if err != nil {
+		return fmt.Errorf("failed to list route vspherebindings: %w", err)
+	}
+	for _, b := range bindings {
+		route := resources.RouteName(b)
+		if _, ok := routes[route]; (ok && b.Name == names.RouteVSphereBinding(vms, route)) || !metav1.IsControlledBy(b, vms) {
+			continue
+		}
+		if err = r.client.SourcesV1alpha1().VSphereBindings(ns).Delete(ctx, b.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete vspherebinding %q: %w", b.Name, err)
+		}
+		logging.FromContext(ctx).Infof("Deleted vspherebinding %q of removed route", b.Name)
+	}
+
+	return nil
+}
+
+// applyVSphereBinding creates the desired vspherebinding or updates the spec
+// of the existing vspherebinding controlled by the given source
+func (r *Reconciler) applyVSphereBinding(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, desired *sourcesv1alpha1.VSphereBinding) error {
+	ns := desired.Namespace
+	vspherebindingName := desired.Name
+
+	vspherebinding, err := r.vspherebindingLister.VSphereBindings(ns).Get(vspherebindingName)
+	if apierrs.IsNotFound(err) {
+		if _, err = r.client.SourcesV1alpha1().VSphereBindings(ns).Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create vspherebinding %q: %w", vspherebindingName, err)
+		}
+		logging.FromContext(ctx).Infof("Created vspherebinding %q", vspherebindingName)
+	} else if err != nil {
+		return fmt.Errorf("failed to get vspherebinding %q: %w", vspherebindingName, err)
+	} else if !metav1.IsControlledBy(vspherebinding, vms) {
+		return fmt.Errorf("vspherebinding %q is not owned by source %q", vspherebindingName, vms.Name)
+	} else {
+		// The vspherebinding exists, but make sure that it has the shape that we expect.
+		vspherebinding = vspherebinding.DeepCopy()
+		vspherebinding.Spec = desired.Spec
+		if _, err = r.client.SourcesV1alpha1().VSphereBindings(ns).Update(ctx, vspherebinding, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update vspherebinding %q: %w", vspherebindingName, err)
+		}
+		logging.FromContext(ctx).Infof("Updated vspherebinding %q", vspherebindingName)
+	}
+
+	return nil
+}
+
+// adapterArgs returns the arguments of the adapter deployments
+func (r *Reconciler) adapterArgs() (resources.AdapterArgs, error) {
 	loggingConfig, err := logging.ConfigToJSON(r.loggingConfig)
 	if err != nil {
-		return fmt.Errorf("marshal logging config to JSON: %w", err)
+		return resources.AdapterArgs{}, fmt.Errorf("marshal logging config to JSON: %w", err)
 	}
 
 	metricsConfig, err := metrics.OptionsToJSON(r.metricsConfig)
 	if err != nil {
-		return fmt.Errorf("marshal metrics config to JSON: %w", err)
+		return resources.AdapterArgs{}, fmt.Errorf("marshal metrics config to JSON: %w", err)
 	}
 
-	args := resources.AdapterArgs{
+	return resources.AdapterArgs{
 		Image:         r.adapterImage,
 		LoggingConfig: loggingConfig,
 		MetricsConfig: metricsConfig,
-	}
+	}, nil
+}
+
+// applyDeployment creates the desired deployment or updates the spec of the
+// existing deployment controlled by the given source
+func (r *Reconciler) applyDeployment(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	ns := desired.Namespace
+	deploymentName := desired.Name
 
 	deployment, err := r.deploymentLister.Deployments(ns).Get(deploymentName)
 	if apierrs.IsNotFound(err) {
-		deployment, err = resources.MakeDeployment(ctx, vms, args)
-		if err != nil {
-			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
-		}
-
-		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Create(ctx, deployment, metav1.CreateOptions{})
+		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Create(ctx, desired, metav1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
+			return nil, fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
 		}
 		logging.FromContext(ctx).Infof("Created deployment %q", deploymentName)
 	} else if err != nil {
-		return fmt.Errorf("failed to get deployment %q: %w", deploymentName, err)
+		return nil, fmt.Errorf("failed to get deployment %q: %w", deploymentName, err)
+	} else if !metav1.IsControlledBy(deployment, vms) {
+		return nil, fmt.Errorf("deployment %q is not owned by source %q", deploymentName, vms.Name)
 	} else {
 		// The deployment exists, but make sure that it has the shape that we expect.
-		desiredDeployment, err := resources.MakeDeployment(ctx, vms, args)
-		if err != nil {
-			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
-		}
-
 		deployment = deployment.DeepCopy()
-		deployment.Spec = desiredDeployment.Spec
+		deployment.Spec = desired.Spec
 		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Update(ctx, deployment, metav1.UpdateOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
+			return nil, fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
 		}
 		logging.FromContext(ctx).Infof("Updated deployment %q", deploymentName)
 	}
 
-	// Reflect the state of the Adapter Deployment in the VSphereSource
-	vms.Status.PropagateAdapterStatus(deployment.Status)
-
-	return nil
+	return deployment, nil
 }
 
 func (r *Reconciler) UpdateFromLoggingConfigMap(cfg *corev1.ConfigMap) {
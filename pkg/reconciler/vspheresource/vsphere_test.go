@@ -0,0 +1,260 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/resolver"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	sourcesfake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+)
+
+const testNamespace = "ns"
+
+func newTestSource(name string, routes ...string) *sourcesv1alpha1.VSphereSource {
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name, UID: types.UID("uid-" + name)},
+	}
+	for _, route := range routes {
+		uri, _ := apis.ParseURL("http://" + route + ".example.com")
+		vms.Spec.Routes = append(vms.Spec.Routes, sourcesv1alpha1.VSphereSourceRoute{
+			Name: route,
+			Sink: duckv1.Destination{URI: uri},
+		})
+	}
+	return vms
+}
+
+// newTestReconciler returns a reconciler with listers and clients seeded
+// with the given objects
+func newTestReconciler(t *testing.T, objects ...runtime.Object) (*Reconciler, *k8sfake.Clientset) {
+	t.Helper()
+
+	deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	cms := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	bindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	var kubeObjects, sourcesObjects []runtime.Object
+	for _, obj := range objects {
+		var err error
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			err = deployments.Add(o)
+			kubeObjects = append(kubeObjects, o)
+		case *corev1.ConfigMap:
+			err = cms.Add(o)
+			kubeObjects = append(kubeObjects, o)
+		case *sourcesv1alpha1.VSphereBinding:
+			err = bindings.Add(o)
+			sourcesObjects = append(sourcesObjects, o)
+		}
+		if err != nil {
+			t.Fatalf("add %T to indexer: %v", obj, err)
+		}
+	}
+
+	client := k8sfake.NewSimpleClientset(kubeObjects...)
+	return &Reconciler{
+		resolver:             &resolver.URIResolver{},
+		kubeclient:           client,
+		client:               sourcesfake.NewSimpleClientset(sourcesObjects...),
+		deploymentLister:     appsv1listers.NewDeploymentLister(deployments),
+		vspherebindingLister: v1alpha1lister.NewVSphereBindingLister(bindings),
+		cmLister:             corev1Listers.NewConfigMapLister(cms),
+		adapterImage:         "adapter-image",
+	}, client
+}
+
+func routeDeployment(t *testing.T, vms *sourcesv1alpha1.VSphereSource, route string) *appsv1.Deployment {
+	t.Helper()
+	uri, _ := apis.ParseURL("http://" + route + ".example.com")
+	d, err := resources.MakeRouteDeployment(context.Background(), vms, sourcesv1alpha1.VSphereSourceRoute{Name: route}, uri, resources.AdapterArgs{})
+	if err != nil {
+		t.Fatalf("MakeRouteDeployment() error = %v", err)
+	}
+	return d
+}
+
+func TestReconcileRoutes(t *testing.T) {
+	ctx := context.Background()
+	vms := newTestSource("foo", "vm-power", "host")
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.SecretRef = corev1.LocalObjectReference{Name: "vsphere-credentials"}
+
+	// deployment of a route that is ready
+	available := routeDeployment(t, vms, "vm-power")
+	available.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+
+	r, client := newTestReconciler(t, available)
+	if err := r.reconcileRoutes(ctx, vms); err != nil {
+		t.Fatalf("reconcileRoutes() error = %v", err)
+	}
+
+	for _, route := range []string{"vm-power", "host"} {
+		d, err := client.AppsV1().Deployments(testNamespace).Get(ctx, names.RouteDeployment(vms, route), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get deployment of route %s: %v", route, err)
+		}
+		if !metav1.IsControlledBy(d, vms) {
+			t.Errorf("deployment of route %s is not controlled by the source", route)
+		}
+		if got := envValue(d, "K_SINK"); got != "http://"+route+".example.com" {
+			t.Errorf("deployment of route %s K_SINK = %q, wanted route sink", route, got)
+		}
+		if got := envValue(d, "VSPHERE_KVSTORE_CONFIGMAP"); got != names.RouteConfigMap(vms, route) {
+			t.Errorf("deployment of route %s VSPHERE_KVSTORE_CONFIGMAP = %q, wanted %q", route, got, names.RouteConfigMap(vms, route))
+		}
+		if _, err = client.CoreV1().ConfigMaps(testNamespace).Get(ctx, names.RouteConfigMap(vms, route), metav1.GetOptions{}); err != nil {
+			t.Errorf("get configmap of route %s: %v", route, err)
+		}
+
+		b, err := r.client.SourcesV1alpha1().VSphereBindings(testNamespace).Get(ctx, names.RouteVSphereBinding(vms, route), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get vspherebinding of route %s: %v", route, err)
+		}
+		if got := b.Spec.Subject; got.Kind != "Deployment" || got.Namespace != testNamespace || got.Name != d.Name {
+			t.Errorf("vspherebinding of route %s subject = %+v, wanted deployment %q", route, got, d.Name)
+		}
+		if b.Spec.VAuthSpec != vms.Spec.VAuthSpec {
+			t.Errorf("vspherebinding of route %s VAuthSpec = %+v, wanted %+v", route, b.Spec.VAuthSpec, vms.Spec.VAuthSpec)
+		}
+	}
+
+	if len(vms.Status.Routes) != 2 {
+		t.Fatalf("reconcileRoutes() route statuses = %+v, wanted 2", vms.Status.Routes)
+	}
+	if got := vms.Status.Routes[0]; got.Name != "vm-power" || got.Ready != corev1.ConditionTrue || got.SinkURI.String() != "http://vm-power.example.com" {
+		t.Errorf("reconcileRoutes() status = %+v, wanted ready route vm-power", got)
+	}
+	if got := vms.Status.Routes[1]; got.Name != "host" || got.Ready != corev1.ConditionUnknown {
+		t.Errorf("reconcileRoutes() status = %+v, wanted route host with unknown readiness", got)
+	}
+}
+
+func TestReconcileRoutes_deletesRemovedRoutes(t *testing.T) {
+	ctx := context.Background()
+	vms := newTestSource("foo", "vm-power")
+	removed := newTestSource("foo", "host")
+
+	// route resources named by an earlier version
+	legacy := routeDeployment(t, vms, "vm-power")
+	legacy.Name = "foo-vm-power-adapter"
+
+	// route resources of the same source name not controlled by the source
+	foreign := routeDeployment(t, removed, "host")
+	foreign.Name = "foreign-adapter"
+	foreign.OwnerReferences = nil
+
+	objects := []runtime.Object{
+		routeDeployment(t, vms, "vm-power"),
+		resources.MakeRouteConfigMap(ctx, vms, "vm-power"),
+		routeDeployment(t, removed, "host"),
+		resources.MakeRouteConfigMap(ctx, removed, "host"),
+		resources.MakeRouteVSphereBinding(ctx, vms, "vm-power"),
+		resources.MakeRouteVSphereBinding(ctx, removed, "host"),
+		legacy,
+		foreign,
+	}
+	r, client := newTestReconciler(t, objects...)
+	if err := r.reconcileRoutes(ctx, vms); err != nil {
+		t.Fatalf("reconcileRoutes() error = %v", err)
+	}
+
+	deployments, err := client.AppsV1().Deployments(testNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list deployments: %v", err)
+	}
+	var got []string
+	for _, d := range deployments.Items {
+		got = append(got, d.Name)
+	}
+	sort.Strings(got)
+	want := []string{names.RouteDeployment(vms, "vm-power"), "foreign-adapter"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("reconcileRoutes() kept deployments %v, wanted %v", got, want)
+	}
+
+	if _, err = client.CoreV1().ConfigMaps(testNamespace).Get(ctx, names.RouteConfigMap(removed, "host"), metav1.GetOptions{}); err == nil {
+		t.Errorf("reconcileRoutes() kept configmap of removed route")
+	}
+	if _, err = client.CoreV1().ConfigMaps(testNamespace).Get(ctx, names.RouteConfigMap(vms, "vm-power"), metav1.GetOptions{}); err != nil {
+		t.Errorf("reconcileRoutes() deleted configmap of route: %v", err)
+	}
+
+	if _, err = r.client.SourcesV1alpha1().VSphereBindings(testNamespace).Get(ctx, names.RouteVSphereBinding(removed, "host"), metav1.GetOptions{}); err == nil {
+		t.Errorf("reconcileRoutes() kept vspherebinding of removed route")
+	}
+	if _, err = r.client.SourcesV1alpha1().VSphereBindings(testNamespace).Get(ctx, names.RouteVSphereBinding(vms, "vm-power"), metav1.GetOptions{}); err != nil {
+		t.Errorf("reconcileRoutes() deleted vspherebinding of route: %v", err)
+	}
+}
+
+func TestReconcileRoutes_refusesForeignResources(t *testing.T) {
+	ctx := context.Background()
+	vms := newTestSource("foo", "bar")
+	other := newTestSource("other")
+
+	foreignDeployment := routeDeployment(t, vms, "bar")
+	foreignDeployment.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(other)}
+
+	foreignConfigMap := resources.MakeRouteConfigMap(ctx, vms, "bar")
+	foreignConfigMap.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(other)}
+
+	foreignBinding := resources.MakeRouteVSphereBinding(ctx, vms, "bar")
+	foreignBinding.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(other)}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantErr string
+	}{
+		{name: "deployment", objects: []runtime.Object{foreignDeployment}, wantErr: "is not owned by source"},
+		{name: "configmap", objects: []runtime.Object{foreignConfigMap}, wantErr: "is not owned by source"},
+		{name: "vspherebinding", objects: []runtime.Object{foreignBinding}, wantErr: "is not owned by source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, client := newTestReconciler(t, tt.objects...)
+			err := r.reconcileRoutes(ctx, vms)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("reconcileRoutes() error = %v, wanted containing %q", err, tt.wantErr)
+			}
+
+			d, err := client.AppsV1().Deployments(testNamespace).Get(ctx, names.RouteDeployment(vms, "bar"), metav1.GetOptions{})
+			if err == nil && metav1.IsControlledBy(d, vms) {
+				t.Errorf("reconcileRoutes() took over deployment %q", d.Name)
+			}
+		})
+	}
+}
+
+func envValue(d *appsv1.Deployment, name string) string {
+	for _, env := range d.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// activeEventTypes tracks the distinct event types seen within a rolling
+// window to detect new vCenter behavior, e.g. after an upgrade
+type activeEventTypes struct {
+	window time.Duration
+
+	mu sync.Mutex
+	// last time an event type was seen within the window
+	lastSeen map[string]time.Time
+	// all event types seen since start
+	known map[string]struct{}
+}
+
+// newActiveEventTypes returns a tracker for the distinct event types seen
+// within the given rolling window. It returns nil if window is 0, i.e.
+// tracking is disabled.
+func newActiveEventTypes(window time.Duration) *activeEventTypes {
+	if window <= 0 {
+		return nil
+	}
+
+	return &activeEventTypes{
+		window:   window,
+		lastSeen: make(map[string]time.Time),
+		known:    make(map[string]struct{}),
+	}
+}
+
+// observe records that an event of the given type was seen at now. The first
+// occurrence of a type since start is logged. It returns true if the type was
+// not seen before.
+func (t *activeEventTypes) observe(ctx context.Context, eventType string, now time.Time) bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSeen[eventType] = now
+	if _, ok := t.known[eventType]; ok {
+		return false
+	}
+
+	t.known[eventType] = struct{}{}
+	logging.FromContext(ctx).Infow("observed new event type", zap.String("type", eventType),
+		zap.Int("knownTypes", len(t.known)))
+	return true
+}
+
+// count removes the event types not seen within the window before now and
+// returns the number of remaining active types
+func (t *activeEventTypes) count(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for eventType, seen := range t.lastSeen {
+		if now.Sub(seen) > t.window {
+			delete(t.lastSeen, eventType)
+		}
+	}
+	return len(t.lastSeen)
+}
+
+// report records the number of active event types at now
+func (t *activeEventTypes) report(ctx context.Context, now time.Time) {
+	if t == nil {
+		return
+	}
+	reportActiveEventTypes(ctx, t.count(now))
+}
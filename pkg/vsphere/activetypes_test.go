@@ -0,0 +1,59 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_newActiveEventTypes(t *testing.T) {
+	if got := newActiveEventTypes(0); got != nil {
+		t.Errorf("newActiveEventTypes(0) = %v, want nil", got)
+	}
+	if got := newActiveEventTypes(time.Hour); got == nil {
+		t.Error("newActiveEventTypes(1h) = nil, want tracker")
+	}
+}
+
+func Test_activeEventTypes(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newActiveEventTypes(time.Minute)
+
+	steps := []struct {
+		eventType string
+		at        time.Duration
+		wantNew   bool
+		wantCount int
+	}{
+		{eventType: "VmPoweredOnEvent", at: 0, wantNew: true, wantCount: 1},
+		{eventType: "VmPoweredOnEvent", at: 10 * time.Second, wantNew: false, wantCount: 1},
+		{eventType: "VmPoweredOffEvent", at: 20 * time.Second, wantNew: true, wantCount: 2},
+		// VmPoweredOnEvent expired
+		{eventType: "VmPoweredOffEvent", at: 75 * time.Second, wantNew: false, wantCount: 1},
+		// expired types are not new again
+		{eventType: "VmPoweredOnEvent", at: 80 * time.Second, wantNew: false, wantCount: 2},
+	}
+	for _, s := range steps {
+		now := start.Add(s.at)
+		if got := tracker.observe(ctx, s.eventType, now); got != s.wantNew {
+			t.Errorf("observe(%s, +%v) = %v, want %v", s.eventType, s.at, got, s.wantNew)
+		}
+		if got := tracker.count(now); got != s.wantCount {
+			t.Errorf("count(+%v) = %d, want %d", s.at, got, s.wantCount)
+		}
+	}
+}
+
+func Test_activeEventTypes_nil(t *testing.T) {
+	var tracker *activeEventTypes
+	if tracker.observe(context.Background(), "VmPoweredOnEvent", time.Now()) {
+		t.Error("observe() = true on disabled tracker, want false")
+	}
+	tracker.report(context.Background(), time.Now())
+}
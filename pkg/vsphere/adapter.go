@@ -8,12 +8,12 @@ package vsphere
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/jpillora/backoff"
 	"github.com/vmware/govmomi"
-	"github.com/vmware/govmomi/event"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/types"
 	"go.uber.org/zap"
@@ -30,8 +30,10 @@ const (
 	// extended attribute to filter on vSphere API version/class
 	ceVSphereAPIKey     = "vsphereapiversion"
 	ceVSphereEventClass = "eventclass"
-	// read up to max events per iteration
+	// read up to max events per iteration unless configured otherwise
 	maxEventsBatch = 100
+	// maximum number of events returned by a single vCenter read
+	maxEventsBatchLimit = 1000
 )
 
 type envConfig struct {
@@ -40,11 +42,533 @@ type envConfig struct {
 	// KVConfigMap is the name of the configmap to use as our kvstore.
 	KVConfigMap string `envconfig:"VSPHERE_KVSTORE_CONFIGMAP" required:"true"`
 
+	// KVNamespace configures the namespace of the kvstore configmap, e.g. a
+	// shared operations namespace. Defaults to the adapter namespace. The
+	// adapter service account requires the configmap permissions described
+	// for KVPrecreated in this namespace, e.g. a RoleBinding to the
+	// vsphere-receive-adapter-cm ClusterRole, which is not created by the
+	// controller.
+	KVNamespace string `envconfig:"VSPHERE_KVSTORE_NAMESPACE"`
+
+	// KVPrecreated configures the kvstore configmap to be pre-created, e.g.
+	// by an administrator, instead of created by the adapter if missing. The
+	// adapter fails to start if the configmap does not exist. The adapter
+	// service account requires get and update permissions on the configmap
+	// if enabled and get, create and update permissions on configmaps
+	// otherwise.
+	KVPrecreated bool `envconfig:"VSPHERE_KVSTORE_PRECREATED" default:"false"`
+
+	// InitRetry configures how often initializing the kvstore is retried
+	// before the adapter fails, e.g. while the API server is briefly
+	// unavailable during a cluster upgrade. Retries are delayed with
+	// exponential backoff starting at InitRetryBackoff (at most 30s).
+	InitRetry        int           `envconfig:"VSPHERE_INIT_RETRY" default:"5"`
+	InitRetryBackoff time.Duration `envconfig:"VSPHERE_INIT_RETRY_BACKOFF" default:"1s"`
+
 	// CheckpointConfig configures the checkpoint behavior of this controller
 	CheckpointConfig string `envconfig:"VSPHERE_CHECKPOINT_CONFIG" default:"{}"`
 
 	// PayloadEncoding configures the encoding format for the cloud event payload
+	// (application/xml, application/json with the event fields wrapped in a
+	// JSONEvent or the stable, versioned JSON representation
+	// application/vnd.vsphere.event.v1+json)
 	PayloadEncoding string `envconfig:"VSPHERE_PAYLOAD_ENCODING" default:"application/xml"`
+
+	// ContentMode configures the cloud event content mode (binary, structured
+	// or auto) used when sending events to the sink
+	ContentMode string `envconfig:"VSPHERE_CE_CONTENT_MODE" default:"binary"`
+
+	// XMLFields restricts the XML payload to a comma-separated list of
+	// dot-separated event field paths, e.g. Key,CreatedTime,Vm.Name to reduce
+	// the payload size. Nested fields of a listed field are included. Requires
+	// the application/xml payload encoding. All fields are sent if empty.
+	XMLFields []string `envconfig:"VSPHERE_XML_FIELDS"`
+
+	// DataContentEncoding configures the encoding of the serialized cloud
+	// event payload declared in the datacontentencoding attribute, e.g. for
+	// transports mangling raw XML. Only base64 in binary content mode is
+	// supported. The payload is not encoded if empty.
+	DataContentEncoding string `envconfig:"VSPHERE_CE_DATA_CONTENT_ENCODING"`
+
+	// ReplayMinRate and ReplayMaxRate configure the range of events per second
+	// sent to the sink depending on the lag of the event stream. Throttling is
+	// disabled if ReplayMaxRate is 0.
+	ReplayMinRate float64 `envconfig:"VSPHERE_REPLAY_MIN_RATE" default:"0"`
+	ReplayMaxRate float64 `envconfig:"VSPHERE_REPLAY_MAX_RATE" default:"0"`
+
+	// ReplayLagScale configures how fast the send rate decays with increasing
+	// lag
+	ReplayLagScale time.Duration `envconfig:"VSPHERE_REPLAY_LAG_SCALE" default:"5m"`
+
+	// SinkRampDuration and SinkRampInitialRate configure a ramp-up after
+	// startup giving a cold sink time to scale. Events are sent at the initial
+	// rate per second, increased by the initial rate every second until the
+	// ramp duration has elapsed. The ramp-up is disabled if the duration is 0.
+	SinkRampDuration    time.Duration `envconfig:"VSPHERE_SINK_RAMP_DURATION" default:"0s"`
+	SinkRampInitialRate float64       `envconfig:"VSPHERE_SINK_RAMP_INITIAL_RATE" default:"1"`
+
+	// EventsBatchSize configures the number of events requested per read from
+	// vCenter (1-1000). Smaller batches re-send fewer events after a failed
+	// send, larger batches increase throughput with a fast sink.
+	EventsBatchSize int32 `envconfig:"VSPHERE_EVENTS_BATCH_SIZE" default:"100"`
+
+	// MaxBatchBytes limits the estimated serialized size of events sent per
+	// batch. The number of events requested per read from vCenter is reduced
+	// based on the average size of recent events to bound memory, unless
+	// reads are widened to catch up. 0 means no limit.
+	MaxBatchBytes int `envconfig:"VSPHERE_MAX_BATCH_BYTES" default:"0"`
+
+	// SinkProtocol configures the protocol used to deliver events (http,
+	// sqs or pubsub)
+	SinkProtocol string `envconfig:"VSPHERE_SINK_PROTOCOL" default:"http"`
+
+	// SQSQueueURL and SQSRegion configure the AWS SQS queue used when
+	// SinkProtocol is sqs
+	SQSQueueURL string `envconfig:"VSPHERE_SQS_QUEUE_URL"`
+	SQSRegion   string `envconfig:"VSPHERE_SQS_REGION"`
+
+	// PubSubProject and PubSubTopic configure the Google Cloud Pub/Sub topic
+	// used when SinkProtocol is pubsub. Google application default
+	// credentials are used for authentication. PubSubEndpoint overrides the
+	// Pub/Sub API endpoint, e.g. to use the Pub/Sub emulator.
+	PubSubProject  string `envconfig:"VSPHERE_PUBSUB_PROJECT"`
+	PubSubTopic    string `envconfig:"VSPHERE_PUBSUB_TOPIC"`
+	PubSubEndpoint string `envconfig:"VSPHERE_PUBSUB_ENDPOINT" default:"https://pubsub.googleapis.com"`
+
+	// SinkLocalAddr configures the local IP address or network interface
+	// used to connect to the sink
+	SinkLocalAddr string `envconfig:"VSPHERE_SINK_LOCAL_ADDR"`
+
+	// SinkCompression configures the compression (gzip) of request bodies
+	// sent to the sink. Compression is disabled by default.
+	SinkCompression string `envconfig:"VSPHERE_SINK_COMPRESSION"`
+
+	// SinkFollowRedirects enables following 3xx redirects returned by the
+	// sink, re-sending the event with the same method and body to the
+	// redirect location up to SinkMaxRedirects hops
+	SinkFollowRedirects bool `envconfig:"VSPHERE_SINK_FOLLOW_REDIRECTS" default:"false"`
+	SinkMaxRedirects    int  `envconfig:"VSPHERE_SINK_MAX_REDIRECTS" default:"10"`
+
+	// SinkHeaders configures static HTTP headers added to every request sent
+	// to the sink as a comma-separated list of key=value pairs, e.g.
+	// X-Api-Key=secret,X-Tenant=team-a. CloudEvents headers (ce-*) and
+	// content headers cannot be overridden. Like the other sink transport
+	// options, the headers also apply to the dead letter, fallback and tee
+	// sinks.
+	SinkHeaders string `envconfig:"VSPHERE_SINK_HEADERS"`
+
+	// ClockSkewWarn configures the clock skew between adapter and vCenter
+	// above which a warning is logged at startup
+	ClockSkewWarn time.Duration `envconfig:"VSPHERE_CLOCK_SKEW_WARN" default:"30s"`
+
+	// MaxClockSkew configures the clock skew between adapter and vCenter
+	// above which the adapter refuses to start. 0 disables the check.
+	MaxClockSkew time.Duration `envconfig:"VSPHERE_MAX_CLOCK_SKEW" default:"0"`
+
+	// TimeBackward configures the handling of vCenter time moved backward
+	// behind the checkpoint at startup, e.g. after an NTP correction or HA
+	// failover to a skewed node: warn begins at the checkpoint, i.e. events
+	// are read once vCenter time catches up, resume begins at the current
+	// vCenter time skipping checkpointed events and fail refuses to start.
+	TimeBackward string `envconfig:"VSPHERE_TIME_BACKWARD" default:"warn"`
+
+	// CheckpointFormat configures how the checkpoint is stored in the
+	// checkpoint ConfigMap: json stores a JSON object under the checkpoint
+	// key, flat stores each field under a separate key, e.g. lastEventKey,
+	// for external tooling like kubectl get configmap -o jsonpath. A
+	// checkpoint in the other format is read if none is stored in the
+	// configured format.
+	CheckpointFormat string `envconfig:"VSPHERE_CHECKPOINT_FORMAT" default:"json"`
+
+	// CheckpointKeys configures additional (comma-separated) keys in the
+	// kvstore to read checkpoints from, e.g. when migrating between source
+	// configurations. The event stream resumes from the newest checkpoint.
+	// New checkpoints are always stored under the default key.
+	CheckpointKeys []string `envconfig:"VSPHERE_CHECKPOINT_KEYS"`
+
+	// ExtensionMap configures additional CloudEvent extensions set from
+	// vSphere event fields as a JSON object of extension names to
+	// dot-separated field paths, e.g. {"vmname":"Vm.Name"}
+	ExtensionMap string `envconfig:"VSPHERE_EXTENSION_MAP"`
+
+	// TruncateFields configures the truncation of large string fields before
+	// serialization as a JSON object of dot-separated event field paths to
+	// maximum lengths in bytes, e.g. {"FullFormattedMessage":1024}. Events
+	// with truncated fields carry the vspheretruncated extension.
+	TruncateFields string `envconfig:"VSPHERE_TRUNCATE_FIELDS"`
+
+	// CheckpointAnnotations enables annotating the checkpoint ConfigMap with
+	// progress metadata (last event type, timestamp and lag) on each
+	// checkpoint
+	CheckpointAnnotations bool `envconfig:"VSPHERE_CHECKPOINT_ANNOTATIONS" default:"false"`
+
+	// CheckpointMirror configures a secondary ConfigMap ([namespace/]name)
+	// receiving a best-effort copy of each checkpoint for disaster recovery.
+	// The mirror is never read by the adapter.
+	CheckpointMirror string `envconfig:"VSPHERE_CHECKPOINT_MIRROR"`
+
+	// CEExtensions configures an allowlist (comma-separated) of CloudEvent
+	// extensions set on emitted events. Empty means all extensions.
+	CEExtensions []string `envconfig:"VSPHERE_CE_EXTENSIONS"`
+
+	// BackfillLagThreshold configures the lag of the event stream above which
+	// replay progress is reported. 0 disables reporting.
+	BackfillLagThreshold time.Duration `envconfig:"VSPHERE_BACKFILL_LAG_THRESHOLD" default:"5m"`
+
+	// BackfillReportInterval configures the interval of replay progress
+	// reports
+	BackfillReportInterval time.Duration `envconfig:"VSPHERE_BACKFILL_REPORT_INTERVAL" default:"30s"`
+
+	// SortEvents enables sorting each batch by event creation time and key
+	// before sending. This is only safe if vCenter event creation times are
+	// consistent, e.g. not affected by clock changes on the vCenter server,
+	// since checkpoints are created from the last event in the sorted order.
+	SortEvents bool `envconfig:"VSPHERE_SORT_EVENTS" default:"false"`
+
+	// EntityPath enables setting the inventory path of the event's primary
+	// entity as CloudEvent extension
+	EntityPath bool `envconfig:"VSPHERE_ENTITY_PATH" default:"false"`
+
+	// EntityPathCacheSize and EntityPathCacheTTL configure the number of
+	// cached inventory paths and the interval after which the cache is
+	// invalidated
+	EntityPathCacheSize int           `envconfig:"VSPHERE_ENTITY_PATH_CACHE_SIZE" default:"1000"`
+	EntityPathCacheTTL  time.Duration `envconfig:"VSPHERE_ENTITY_PATH_CACHE_TTL" default:"10m"`
+
+	// VMMetadata enables enriching events referencing a VM with metadata of
+	// the VM as CloudEvent extensions. It is a comma-separated list of the
+	// properties guest (guest OS full name), host and cluster (name of the
+	// runtime host and its cluster). Lookups are cached per VM for
+	// VMMetadataCacheTTL. Events are sent without metadata if a lookup fails.
+	VMMetadata          string        `envconfig:"VSPHERE_VM_METADATA"`
+	VMMetadataCacheSize int           `envconfig:"VSPHERE_VM_METADATA_CACHE_SIZE" default:"1000"`
+	VMMetadataCacheTTL  time.Duration `envconfig:"VSPHERE_VM_METADATA_CACHE_TTL" default:"5m"`
+
+	// JSONOmitEmpty enables omitting null and empty fields from JSON encoded
+	// payloads to reduce the payload size
+	JSONOmitEmpty bool `envconfig:"VSPHERE_JSON_OMITEMPTY" default:"false"`
+
+	// CheckpointHistory configures the number of recent checkpoints retained
+	// in the kvstore for auditing and restoring a prior position. 0 disables
+	// the history.
+	CheckpointHistory int `envconfig:"VSPHERE_CHECKPOINT_HISTORY" default:"0"`
+
+	// ConfirmHook configures an HTTP endpoint notified with the event key and
+	// sink result after each event successfully delivered to the sink
+	ConfirmHook string `envconfig:"VSPHERE_CONFIRM_HOOK"`
+
+	// ConfirmHookTimeout configures the request timeout of the confirmation
+	// hook
+	ConfirmHookTimeout time.Duration `envconfig:"VSPHERE_CONFIRM_HOOK_TIMEOUT" default:"5s"`
+
+	// CompositeIDs enables using a composite CloudEvent ID of host, chain ID
+	// and creation time for events with a zero key or a key equal to the
+	// previous event
+	CompositeIDs bool `envconfig:"VSPHERE_COMPOSITE_IDS" default:"true"`
+
+	// TeeSink configures a secondary sink receiving a best-effort copy of the
+	// events ACK-ed by the primary sink. Events not delivered to the tee sink
+	// do not affect checkpointing.
+	TeeSink string `envconfig:"VSPHERE_TEE_SINK"`
+
+	// TeeQueueSize configures the number of events queued for the tee sink
+	// before events are dropped
+	TeeQueueSize int `envconfig:"VSPHERE_TEE_QUEUE_SIZE" default:"100"`
+
+	// SinkProbe enables verifying at startup that the sink is reachable and
+	// responds with a 2xx status code to an OPTIONS request
+	SinkProbe bool `envconfig:"VSPHERE_SINK_PROBE" default:"false"`
+
+	// SinkProbeTimeout configures the request timeout of the sink probe
+	SinkProbeTimeout time.Duration `envconfig:"VSPHERE_SINK_PROBE_TIMEOUT" default:"10s"`
+
+	// DataSchema configures the URL set as CloudEvent dataschema attribute.
+	// DataSchemaMap optionally configures schema URLs per vSphere event type
+	// as a JSON object, e.g. {"VmPoweredOnEvent":"https://example.com/vm.json"}.
+	// The dataschema attribute is not set by default.
+	DataSchema    string `envconfig:"VSPHERE_DATA_SCHEMA"`
+	DataSchemaMap string `envconfig:"VSPHERE_DATA_SCHEMA_MAP"`
+
+	// FailOnDataLoss configures the adapter to fail at startup instead of
+	// clamping the begin of the event stream to the maximum replay window if
+	// the checkpoint is older than the window. Operators must then explicitly
+	// acknowledge the gap, e.g. by increasing the window or removing the
+	// checkpoint.
+	FailOnDataLoss bool `envconfig:"VSPHERE_FAIL_ON_DATA_LOSS" default:"false"`
+
+	// CheckpointMinWriteInterval configures the minimum interval between
+	// checkpoint writes to the Kubernetes API server, independent of the
+	// checkpoint period and the number of processed batches. The latest
+	// checkpoint is buffered in memory in between. 0 writes on every
+	// checkpoint period with new events.
+	CheckpointMinWriteInterval time.Duration `envconfig:"VSPHERE_CHECKPOINT_MIN_WRITE_INTERVAL" default:"0s"`
+
+	// MaintenanceWindows configures recurring windows as a JSON list of cron
+	// schedules, durations and time zones, e.g.
+	// [{"schedule":"0 2 * * SAT","duration":"2h","timezone":"Europe/Berlin"}].
+	// Events created inside a window are dropped and checkpointed.
+	MaintenanceWindows string `envconfig:"VSPHERE_MAINTENANCE_WINDOWS"`
+
+	// ReplayKeyFrom and ReplayKeyTo enable a replay-only mode which sends the
+	// events with keys in [from, to] from the retained vCenter event history
+	// and exits without reading or writing checkpoints. Replay-only mode is
+	// disabled if ReplayKeyTo is 0.
+	ReplayKeyFrom int32 `envconfig:"VSPHERE_REPLAY_KEY_FROM" default:"0"`
+	ReplayKeyTo   int32 `envconfig:"VSPHERE_REPLAY_KEY_TO" default:"0"`
+
+	// ReplaySpeed paces replay-only mode relative to the original intervals
+	// between events, e.g. 10 replays events ten times faster than they were
+	// created. Events are replayed as fast as possible if 0. Replayed events
+	// carry the vspherereplay extension.
+	ReplaySpeed float64 `envconfig:"VSPHERE_REPLAY_SPEED" default:"0"`
+
+	// EventTypes restricts the sent events to a comma-separated list of
+	// vSphere event types, e.g. VmPoweredOnEvent,VmPoweredOffEvent. Other
+	// events are dropped and checkpointed. All events are sent if empty.
+	EventTypes []string `envconfig:"VSPHERE_EVENT_TYPES"`
+
+	// EventFilter restricts the events read from vCenter to a comma-separated
+	// list of vSphere event types or EventEx and ExtendedEvent type IDs, e.g.
+	// VmPoweredOnEvent,com.vmware.vc.HA.ClusterFailoverActionCompletedEvent.
+	// Unlike EventTypes, other events are filtered by vCenter and never
+	// transferred to the adapter. All events are read if empty.
+	EventFilter []string `envconfig:"VSPHERE_EVENT_FILTER"`
+
+	// ContentDedupWindow enables suppressing events with content identical
+	// to an event sent within the window, excluding key, chain ID and
+	// creation time, e.g. events re-emitted with new keys after a reconnect.
+	// Up to ContentDedupCacheSize sent events are tracked. 0 disables content
+	// deduplication.
+	ContentDedupWindow    time.Duration `envconfig:"VSPHERE_CONTENT_DEDUP_WINDOW" default:"0"`
+	ContentDedupCacheSize int           `envconfig:"VSPHERE_CONTENT_DEDUP_CACHE_SIZE" default:"10000"`
+
+	// SourceSuffix configures a suffix appended to the CloudEvent source
+	// with {class} replaced by the vSphere event class (event, eventex or
+	// extendedevent), e.g. /{class}, to route events by source. The source
+	// is not modified if empty.
+	SourceSuffix string `envconfig:"VSPHERE_CE_SOURCE_SUFFIX"`
+
+	// SourceOverride replaces the vCenter host as the CloudEvent source and
+	// the vCenter stored in checkpoints with a stable logical name, e.g.
+	// vcenter-prod-east. It must be a valid URI-reference. The host is used
+	// if empty.
+	SourceOverride string `envconfig:"VSPHERE_SOURCE_OVERRIDE"`
+
+	// EventTypePresets extends EventTypes with a comma-separated list of
+	// named sets of event types, e.g. vm-power for events changing the power
+	// state of a VM. VMPowerEventsOnly is a shorthand for the vm-power preset.
+	EventTypePresets  []string `envconfig:"VSPHERE_EVENT_TYPE_PRESETS"`
+	VMPowerEventsOnly bool     `envconfig:"VSPHERE_VM_POWER_EVENTS_ONLY" default:"false"`
+
+	// CollectorPageCapacity configures the number of unread events the vCenter
+	// event collector retains before overwriting them. When the estimated
+	// backlog reaches CollectorPageThreshold (fraction of the capacity),
+	// reads are widened up to the capacity and replay throttling is lifted.
+	// 0 disables widening reads.
+	CollectorPageCapacity  int     `envconfig:"VSPHERE_COLLECTOR_PAGE_CAPACITY" default:"0"`
+	CollectorPageThreshold float64 `envconfig:"VSPHERE_COLLECTOR_PAGE_THRESHOLD" default:"0.8"`
+
+	// EmitBacklogNotice sends a CloudEvent of type
+	// com.vmware.vsphere.adapter.backlognotice.v0 on startup describing the
+	// begin of the event stream, the estimated backlog window and whether
+	// the begin was clamped to the maximum replay window.
+	EmitBacklogNotice bool `envconfig:"VSPHERE_EMIT_BACKLOG_NOTICE" default:"false"`
+
+	// LogLevelAddr configures the address, e.g. :8081, of an HTTP endpoint
+	// at /loglevel to get (GET) and change (PUT) the log level at runtime.
+	// The endpoint is not authenticated and therefore only listens on the
+	// loopback interface, e.g. for kubectl port-forward or exec, unless
+	// LogLevelExpose is set. The endpoint is disabled if empty.
+	LogLevelAddr string `envconfig:"VSPHERE_LOG_LEVEL_ADDR"`
+	// LogLevelExpose allows the log level endpoint to listen on all or
+	// non-loopback interfaces, i.e. anyone reaching the pod can change the
+	// log level. Restrict access with a network policy if set.
+	LogLevelExpose bool `envconfig:"VSPHERE_LOG_LEVEL_EXPOSE" default:"false"`
+
+	// StartupJitter configures the maximum random delay before the adapter
+	// starts reading events to spread the load on vCenter when many adapters
+	// restart simultaneously. 0 disables the delay.
+	StartupJitter time.Duration `envconfig:"VSPHERE_STARTUP_JITTER" default:"0s"`
+
+	// MaxLifetime configures the maximum lifetime of the adapter after which
+	// it writes a final checkpoint between batches and exits with status 0
+	// to be restarted by Kubernetes, e.g. to clear memory and refresh the
+	// vCenter session. 0 disables periodic recycling.
+	MaxLifetime time.Duration `envconfig:"VSPHERE_MAX_LIFETIME" default:"0"`
+
+	// SendRetries configures how often an event not ACK-ed by the sink is
+	// retried with exponential backoff starting at SendRetryBackoff before
+	// the batch fails.
+	SendRetries      int           `envconfig:"VSPHERE_SEND_RETRIES" default:"0"`
+	SendRetryBackoff time.Duration `envconfig:"VSPHERE_SEND_RETRY_BACKOFF" default:"1s"`
+
+	// DeadLetterSink configures a sink receiving the events which still fail
+	// after all retries. Dead-lettered events are checkpointed and the batch
+	// continues with the next event. If empty, a failing event stops the
+	// batch.
+	DeadLetterSink string `envconfig:"VSPHERE_DEAD_LETTER_SINK"`
+
+	// DeadLetterAttempts configures how often the same event must fail
+	// before it is sent to the dead letter sink, counting each time the batch
+	// is read again after a failure. If set, only events which the sink
+	// rejects or which cannot be encoded are dead-lettered, whereas events
+	// failing because the sink is unreachable or returns a server error are
+	// retried until the sink recovers. If 0, every event failing after all
+	// retries is dead-lettered.
+	DeadLetterAttempts int `envconfig:"VSPHERE_DEAD_LETTER_ATTEMPTS" default:"0"`
+
+	// StandbyPromotionFile enables a warm standby which keeps the vCenter
+	// session alive and tracks the checkpoint in the shared KV store without
+	// sending events until the file contains "true", e.g. a key of a mounted
+	// ConfigMap flipped on failover. The file is checked every
+	// StandbyPollInterval.
+	StandbyPromotionFile string        `envconfig:"VSPHERE_STANDBY_PROMOTION_FILE"`
+	StandbyPollInterval  time.Duration `envconfig:"VSPHERE_STANDBY_POLL_INTERVAL" default:"5s"`
+
+	// ReplayLastOnStart enables re-sending the last checkpointed event on
+	// startup, marked with the vspherereplay extension, to prime consumers
+	// with the current state without a full backlog replay. Events up to the
+	// checkpoint are skipped afterwards as with QuietStart.
+	ReplayLastOnStart bool `envconfig:"VSPHERE_REPLAY_LAST_ON_START" default:"false"`
+
+	// QuietStart skips (without sending) the events replayed on startup up
+	// to and including the last event key of the checkpoint to reduce
+	// duplicates after a restart.
+	QuietStart bool `envconfig:"VSPHERE_QUIET_START" default:"false"`
+
+	// SendAggregateWindow configures how long to keep reading events after a
+	// non-empty read before sending all accumulated events as a single batch
+	// in batched content mode (application/cloudevents-batch+json). Requires
+	// the http sink protocol. Disabled if 0.
+	SendAggregateWindow time.Duration `envconfig:"VSPHERE_SEND_AGGREGATE_WINDOW" default:"0s"`
+
+	// ActiveTypesWindow configures the rolling window for the
+	// active_event_types metric, i.e. the number of distinct event types
+	// seen. The first occurrence of an event type is logged. Disabled if 0.
+	ActiveTypesWindow time.Duration `envconfig:"VSPHERE_ACTIVE_TYPES_WINDOW" default:"0s"`
+
+	// IdempotencyKey enables setting a stable idempotency key derived from
+	// the vCenter instance UUID and the event key on each event as extension
+	// and, if IdempotencyHeader is set, as HTTP request header, e.g.
+	// Idempotency-Key. Deduplication of events sent again after a restart is
+	// thereby left to the sink, which can reliably reject duplicates across
+	// adapter restarts. The header requires the http sink protocol without
+	// aggregation.
+	IdempotencyKey    bool   `envconfig:"VSPHERE_IDEMPOTENCY_KEY" default:"false"`
+	IdempotencyHeader string `envconfig:"VSPHERE_IDEMPOTENCY_HEADER"`
+
+	// EmitK8sEvents enables emitting Kubernetes events (Warning) on the
+	// source object for significant adapter conditions: unreachable sink,
+	// vCenter reconnect, data loss due to an expired checkpoint and failed
+	// checkpoint writes. Events of the same reason are emitted at most once
+	// per K8sEventsInterval. Requires the source name (VSPHERE_SOURCE_NAME) and
+	// permission to create events.
+	EmitK8sEvents     bool          `envconfig:"VSPHERE_EMIT_K8S_EVENTS" default:"false"`
+	K8sEventsInterval time.Duration `envconfig:"VSPHERE_K8S_EVENTS_INTERVAL" default:"5m"`
+	SourceName        string        `envconfig:"VSPHERE_SOURCE_NAME"`
+	SourceUID         string        `envconfig:"VSPHERE_SOURCE_UID"`
+
+	// TypeRateLimits configures rate limits (events per second) per event
+	// type as a JSON object, e.g. {"TaskEvent":0.5}, to throttle noisy event
+	// types without affecting others. Event types without limit are not
+	// throttled. TypeRateLimitMode configures whether events exceeding the
+	// limit are dropped (and checkpointed) or deferred until the limit
+	// permits sending them.
+	TypeRateLimits    string `envconfig:"VSPHERE_TYPE_RATE_LIMITS"`
+	TypeRateLimitMode string `envconfig:"VSPHERE_TYPE_RATE_LIMIT_MODE" default:"drop"`
+
+	// SeverityRoutes routes events by severity (info, warning, error or
+	// user) as reported by vCenter to different sinks as a JSON object of
+	// severities to sink URIs, e.g. {"error":"http://incidents"}. Each event
+	// is delivered to exactly one sink and events of severities without route
+	// are sent to the default sink. Requires the http sink protocol without
+	// aggregation.
+	SeverityRoutes string `envconfig:"VSPHERE_SEVERITY_ROUTES"`
+
+	// FallbackSink configures a sink receiving the events while the primary
+	// sink is unreachable or failing with 5xx for at least FallbackThreshold.
+	// Events ACK-ed by the fallback sink are checkpointed. The primary sink is
+	// retried once per FallbackThreshold and used again when it recovers.
+	FallbackSink      string        `envconfig:"VSPHERE_FALLBACK_SINK"`
+	FallbackThreshold time.Duration `envconfig:"VSPHERE_FALLBACK_THRESHOLD" default:"1m"`
+
+	// CETimePrecision truncates the CloudEvent time attribute to seconds,
+	// milliseconds or microseconds for sinks not supporting nanosecond
+	// precision. Defaults to full (nanosecond) precision.
+	CETimePrecision string `envconfig:"VSPHERE_CE_TIME_PRECISION"`
+
+	// EntityAllowlist restricts the sent events to events referencing one of
+	// the given entities by inventory path, e.g. /DC1/host/Cluster1. Paths are
+	// resolved when events arrive and cached for EntityAllowlistTTL, so
+	// entities not existing at startup are picked up after the TTL.
+	EntityAllowlist    []string      `envconfig:"VSPHERE_ENTITY_ALLOWLIST"`
+	EntityAllowlistTTL time.Duration `envconfig:"VSPHERE_ENTITY_ALLOWLIST_TTL" default:"5m"`
+
+	// FilterExpr restricts the sent events to events matching a boolean
+	// expression over the event attributes type, class, datacenter, username,
+	// severity and entity, e.g. type.startsWith("Vm") && datacenter == "DC1"
+	// && username != "admin". The expression syntax is not CEL, see
+	// filterExpr for the grammar. Other events are dropped and checkpointed.
+	FilterExpr string `envconfig:"VSPHERE_FILTER_EXPR"`
+
+	// ArchiveSink configures an object store bucket and prefix, e.g.
+	// s3://bucket/prefix or gs://bucket/prefix, events are additionally
+	// written to as gzip-compressed NDJSON objects. An object is written when
+	// the buffered events reach ArchiveMaxBytes (uncompressed) or the oldest
+	// buffered event is older than ArchiveMaxInterval. Events are only
+	// checkpointed once written. S3 uses AWS credentials from the environment
+	// and ArchiveRegion, GCS uses Google application default credentials.
+	// ArchiveEndpoint overrides the object store endpoint.
+	ArchiveSink        string        `envconfig:"VSPHERE_ARCHIVE_SINK"`
+	ArchiveRegion      string        `envconfig:"VSPHERE_ARCHIVE_REGION"`
+	ArchiveEndpoint    string        `envconfig:"VSPHERE_ARCHIVE_ENDPOINT"`
+	ArchiveMaxBytes    int           `envconfig:"VSPHERE_ARCHIVE_MAX_BYTES" default:"5242880"`
+	ArchiveMaxInterval time.Duration `envconfig:"VSPHERE_ARCHIVE_MAX_INTERVAL" default:"5m"`
+
+	// ArchiveOnly writes events only to the archive sink instead of sending
+	// them to the sink
+	ArchiveOnly bool `envconfig:"VSPHERE_ARCHIVE_ONLY" default:"false"`
+
+	// DiskBufferDir enables buffering events in a local directory, e.g. an
+	// emptyDir volume, while the sink is unreachable instead of stalling the
+	// event stream. Buffered events are delivered in order once the sink
+	// recovers, retried every DiskBufferRetryInterval, and only checkpointed
+	// after delivery, i.e. delivery stays at-least-once. The event stream
+	// stalls if the buffer exceeds DiskBufferMaxBytes. Not supported with an
+	// aggregation window or archive only mode.
+	DiskBufferDir           string        `envconfig:"VSPHERE_DISK_BUFFER_DIR"`
+	DiskBufferMaxBytes      int64         `envconfig:"VSPHERE_DISK_BUFFER_MAX_BYTES" default:"67108864"`
+	DiskBufferRetryInterval time.Duration `envconfig:"VSPHERE_DISK_BUFFER_RETRY_INTERVAL" default:"10s"`
+
+	// CreatedTimeStrategy configures the time substituted for events without
+	// CreatedTime: the time the event was read (read) or the CreatedTime of
+	// the preceding event (previous).
+	CreatedTimeStrategy string `envconfig:"VSPHERE_CREATED_TIME_STRATEGY" default:"read"`
+
+	// ChainSequence enables the vspherechainseq extension holding the
+	// position of an event within its event chain (ChainId). Sequences are
+	// best-effort: counters are kept in memory for up to
+	// ChainSequenceCacheSize chains and reset on restart.
+	ChainSequence          bool `envconfig:"VSPHERE_CHAIN_SEQUENCE" default:"false"`
+	ChainSequenceCacheSize int  `envconfig:"VSPHERE_CHAIN_SEQUENCE_CACHE_SIZE" default:"10000"`
+
+	// PollBackoffMin, PollBackoffMax and PollBackoffFactor configure the
+	// backoff between polls returning no events, growing by the factor from
+	// the minimum up to the maximum and reset when events are received
+	PollBackoffMin    time.Duration `envconfig:"VSPHERE_POLL_BACKOFF_MIN" default:"1s"`
+	PollBackoffMax    time.Duration `envconfig:"VSPHERE_POLL_BACKOFF_MAX" default:"5s"`
+	PollBackoffFactor float64       `envconfig:"VSPHERE_POLL_BACKOFF_FACTOR" default:"2"`
+
+	// PollBackoffAdaptiveMax enables adapting the maximum backoff between
+	// polls returning no events to the event rate. The maximum backoff
+	// doubles after each PollBackoffQuietPeriod without events up to
+	// PollBackoffAdaptiveMax and is reset when events are received. 0
+	// disables adaptation, i.e. the maximum backoff is PollBackoffMax.
+	PollBackoffAdaptiveMax time.Duration `envconfig:"VSPHERE_POLL_BACKOFF_ADAPTIVE_MAX" default:"0"`
+	PollBackoffQuietPeriod time.Duration `envconfig:"VSPHERE_POLL_BACKOFF_QUIET_PERIOD" default:"15m"`
+
+	logger   *zap.SugaredLogger
+	logLevel zap.AtomicLevel
 }
 
 func NewEnvConfig() adapter.EnvConfigAccessor {
@@ -62,31 +586,518 @@ type vAdapter struct {
 	KVStore         kvstore.Interface
 	CpConfig        CheckpointConfig
 	PayloadEncoding string
+	ContentMode     string
+	Throttle        *replayThrottle
+	SinkRamp        *sinkRamp
+	TypeRateLimits  *typeRateLimits
+	MaxBatchBytes   int
+	ReadBatchSize   int32
+	ClockSkewWarn   time.Duration
+	MaxClockSkew    time.Duration
+	TimeBackward    string
+	CheckpointKeys  []string
+	CpFormat        string
+	Extensions      extensionMap
+	AllowedExts     extensionAllowlist
+	Annotator       *checkpointAnnotator
+	Backfill        *backfillTracker
+	SortEvents      bool
+	EntityPaths     *entityPathResolver
+	VMMetadata      *vmMetadataEnricher
+	JSONOmitEmpty   bool
+	HistorySize     int
+	Confirm         *confirmHook
+	CompositeIDs    bool
+	Tee             *teeSink
+	DataSchemas     *dataSchemas
+	FailOnDataLoss  bool
+	CpMinWrite      time.Duration
+	Maintenance     maintenanceWindows
+	Mirror          *checkpointMirror
+	Truncator       truncator
+	Replay          *keyRange
+	ReplayPacer     *replayPacer
+	EventTypes      eventTypeFilter
+	ServerTypes     []string
+	Window          *collectorWindow
+	BacklogNotice   bool
+	LogLevelServer  *http.Server
+	StartupJitter   time.Duration
+	MaxLifetime     time.Duration
+	SendRetries     int
+	RetryBackoff    time.Duration
+	DeadLetter      *deadLetterSink
+	DeadLetterTries *failedAttempts
+	QuietStart      bool
+	Batch           *batchSender
+	AggregateWindow time.Duration
+	ActiveTypes     *activeEventTypes
+	Fallback        *fallbackSink
+	TimePrecision   time.Duration
+	Entities        *entityAllowlist
+	Filter          *filterExpr
+	Archive         *archiver
+	ArchiveOnly     bool
+	DiskBuffer      *diskBuffer
+	ChainSeq        *chainSequencer
+	PollBackoff     *adaptiveBackoff
+	PollBackoffBase backoff.Backoff
+	ClassSources    classSources
+	ContentDedup    *contentDedup
+	DataEncoding    string
+	XMLFields       *xmlProjection
+	Severity        *severityRouter
+	Idempotency     *idempotencyKeys
+	Standby         *standby
+	K8sEvents       *k8sEventEmitter
+	ReplayLast      eventLookupFunc
+	// CreatedTimeStrategy is the strategy for events without CreatedTime.
+	// The read time is used if not set.
+	CreatedTimeStrategy string
+	// IDGenerator generates CloudEvent IDs. The event key is used if not set.
+	IDGenerator IDGenerator
+
+	// content mode negotiated with the sink when ContentMode is auto
+	negotiatedContentMode string
+	// skips replayed events on startup if QuietStart is set
+	fastForward *fastForward
+	// skips checkpointed events after vCenter time moved backward
+	checkpointed *checkpointedFilter
+	// recreates the event collector on recoverable read errors
+	newCollector collectorFactory
+	// logs in to vCenter again after the session expired
+	renewSession func(ctx context.Context) error
+	// sends the events read from vCenter, sendEvents if not set
+	sendBaseEvents func(ctx context.Context, events []types.BaseEvent) (int, error)
+	// begin of the event stream read by the current collector
+	collectorBegin time.Time
+	// last event timestamp of the checkpoint restored on startup
+	restored time.Time
+	// CreatedTime of the last event read
+	lastCreatedTime time.Time
+	// time the event stream was started
+	started time.Time
 }
 
-func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+// NewAdapter returns the vSphere adapter. A *StartupError is returned if the
+// adapter cannot be started, classifying invalid configuration and failures
+// to connect. The configuration is validated before connecting, i.e. an
+// invalid configuration is reported even if vCenter is unreachable.
+func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) (_ adapter.Adapter, err error) {
 	env := processed.(*envConfig)
 	logger := logging.FromContext(ctx)
 
+	if _, err := overrideSource("", env.SourceOverride); err != nil {
+		return nil, configError("invalid source override: %w", err)
+	}
+
+	var mirrorNS, mirrorName string
+	if env.CheckpointMirror != "" {
+		ns, name, err := ParseConfigMapRef(env.CheckpointMirror, env.Namespace)
+		if err != nil {
+			return nil, configError("invalid checkpoint mirror: %w", err)
+		}
+		mirrorNS, mirrorName = ns, name
+	}
+
+	cpconf, err := newCheckpointConfig(env.CheckpointConfig)
+	if err != nil {
+		return nil, configError("could not not read checkpoint config: %w", err)
+	}
+
+	if err = validatePayloadEncoding(env.PayloadEncoding); err != nil {
+		return nil, configError("invalid payload encoding: %w", err)
+	}
+
+	if err = validateContentMode(env.ContentMode); err != nil {
+		return nil, configError("invalid cloud event content mode: %w", err)
+	}
+
+	projection, err := newXMLProjection(ctx, env.XMLFields)
+	if err != nil {
+		return nil, configError("invalid XML field projection: %w", err)
+	}
+	if projection != nil && env.PayloadEncoding != cloudevents.ApplicationXML {
+		return nil, configError("invalid XML field projection: requires payload encoding %s", cloudevents.ApplicationXML)
+	}
+
+	if err = validateDataContentEncoding(env.DataContentEncoding, env.ContentMode, env.SendAggregateWindow != 0); err != nil {
+		return nil, configError("invalid cloud event data content encoding: %w", err)
+	}
+
+	if err = validateCheckpointFormat(env.CheckpointFormat); err != nil {
+		return nil, configError("invalid checkpoint format: %w", err)
+	}
+
+	if err = validateTimeBackwardPolicy(env.TimeBackward); err != nil {
+		return nil, configError("invalid vCenter time backward policy: %w", err)
+	}
+
+	if env.CheckpointHistory < 0 || env.CheckpointHistory > maxCheckpointHistory {
+		return nil, configError("invalid checkpoint history size %d: must be between 0 and %d", env.CheckpointHistory, maxCheckpointHistory)
+	}
+
+	typeLimits, err := newTypeRateLimits(env.TypeRateLimits, env.TypeRateLimitMode)
+	if err != nil {
+		return nil, configError("invalid event type rate limits: %w", err)
+	}
+
+	ramp, err := newSinkRamp(env.SinkRampDuration, env.SinkRampInitialRate)
+	if err != nil {
+		return nil, configError("invalid sink ramp-up configuration: %w", err)
+	}
+
+	throttle, err := newReplayThrottle(env.ReplayMinRate, env.ReplayMaxRate, env.ReplayLagScale)
+	if err != nil {
+		return nil, configError("invalid replay throttle configuration: %w", err)
+	}
+
+	extensions, err := newExtensionMap(ctx, env.ExtensionMap)
+	if err != nil {
+		return nil, configError("invalid extension map: %w", err)
+	}
+
+	allowedExts, err := newExtensionAllowlist(env.CEExtensions)
+	if err != nil {
+		return nil, configError("invalid extension allowlist: %w", err)
+	}
+
+	truncator, err := newTruncator(env.TruncateFields)
+	if err != nil {
+		return nil, configError("invalid field truncation: %w", err)
+	}
+
+	replay, err := newKeyRange(env.ReplayKeyFrom, env.ReplayKeyTo)
+	if err != nil {
+		return nil, configError("invalid replay configuration: %w", err)
+	}
+
+	pacer, err := newReplayPacer(env.ReplaySpeed)
+	if err != nil {
+		return nil, configError("invalid replay configuration: %w", err)
+	}
+	if pacer != nil && replay == nil {
+		return nil, configError("invalid replay configuration: replay speed requires replay-only mode")
+	}
+
+	var entityPaths *entityPathResolver
+	if env.EntityPath {
+		entityPaths, err = newEntityPathResolver(nil, env.EntityPathCacheSize, env.EntityPathCacheTTL)
+		if err != nil {
+			return nil, configError("invalid entity path configuration: %w", err)
+		}
+	}
+
+	vmMetadata, err := newVMMetadataEnricher(env.VMMetadata, nil, env.VMMetadataCacheSize, env.VMMetadataCacheTTL)
+	if err != nil {
+		return nil, configError("invalid VM metadata configuration: %w", err)
+	}
+
+	confirm, err := newConfirmHook(env.ConfirmHook, env.ConfirmHookTimeout)
+	if err != nil {
+		return nil, configError("invalid confirmation hook: %w", err)
+	}
+
+	schemas, err := newDataSchemas(env.DataSchema, env.DataSchemaMap)
+	if err != nil {
+		return nil, configError("invalid data schema configuration: %w", err)
+	}
+
+	maintenance, err := newMaintenanceWindows(env.MaintenanceWindows)
+	if err != nil {
+		return nil, configError("invalid maintenance windows: %w", err)
+	}
+
+	if err = validateReadBatchSize(env.EventsBatchSize); err != nil {
+		return nil, configError("invalid events batch size: %w", err)
+	}
+
+	window, err := newCollectorWindow(env.CollectorPageCapacity, env.CollectorPageThreshold, env.EventsBatchSize, nil)
+	if err != nil {
+		return nil, configError("invalid collector page configuration: %w", err)
+	}
+
+	logLevelAddr, err := logLevelListenAddr(env.LogLevelAddr, env.LogLevelExpose)
+	if err != nil {
+		return nil, configError("invalid log level endpoint: %w", err)
+	}
+	if logLevelAddr != "" && env.logger == nil {
+		logger.Warn("disabling log level endpoint: adapter logger not created from environment")
+		logLevelAddr = ""
+	}
+	logLevelServer := newLogLevelServer(logLevelAddr, env.logLevel)
+
+	if env.SendRetries < 0 {
+		return nil, configError("invalid send retries %d: must not be negative", env.SendRetries)
+	}
+
+	// dead letter, fallback and tee sinks share the transport and client
+	// configuration of the primary HTTP sink
+	transport := http.DefaultTransport
+	if customSinkTransport(env) {
+		transport, err = newSinkTransport(env)
+		if err != nil {
+			return nil, configError("unable to create sink transport: %w", err)
+		}
+	}
+	newClient := func(target string) (cloudevents.Client, error) {
+		return newSinkClient(env, transport, target)
+	}
+
+	deadLetter, err := newDeadLetterSink(env.DeadLetterSink, newClient)
+	if err != nil {
+		return nil, configError("invalid dead letter sink configuration: %w", err)
+	}
+
+	deadLetterTries, err := newFailedAttempts(env.DeadLetterAttempts)
+	if err != nil {
+		return nil, configError("invalid dead letter sink configuration: %w", err)
+	}
+	if deadLetterTries != nil && deadLetter == nil {
+		return nil, configError("invalid dead letter sink configuration: dead letter attempts require a dead letter sink")
+	}
+
+	timePrecision, err := newTimePrecision(env.CETimePrecision)
+	if err != nil {
+		return nil, configError("invalid cloud event time precision: %w", err)
+	}
+
+	if err = validateCreatedTimeStrategy(env.CreatedTimeStrategy); err != nil {
+		return nil, configError("invalid created time configuration: %w", err)
+	}
+
+	var chainSeq *chainSequencer
+	if env.ChainSequence {
+		chainSeq, err = newChainSequencer(env.ChainSequenceCacheSize)
+		if err != nil {
+			return nil, configError("invalid chain sequence configuration: %w", err)
+		}
+	}
+
+	dedup, err := newContentDedup(env.ContentDedupWindow, env.ContentDedupCacheSize)
+	if err != nil {
+		return nil, configError("invalid content deduplication configuration: %w", err)
+	}
+
+	if _, err = newClassSources(env.SourceOverride, env.SourceSuffix); err != nil {
+		return nil, configError("invalid cloud event source suffix: %w", err)
+	}
+
+	presets := env.EventTypePresets
+	if env.VMPowerEventsOnly {
+		presets = append(presets, presetVMPower)
+	}
+	eventTypes, err := presetEventTypes(env.EventTypes, presets)
+	if err != nil {
+		return nil, configError("invalid event type presets: %w", err)
+	}
+
+	serverTypes, err := newServerEventFilter(env.EventFilter)
+	if err != nil {
+		return nil, configError("invalid event filter: %w", err)
+	}
+
+	pollBackoffBase, err := newPollBackoff(env.PollBackoffMin, env.PollBackoffMax, env.PollBackoffFactor)
+	if err != nil {
+		return nil, configError("invalid poll backoff: %w", err)
+	}
+
+	pollBackoff, err := newAdaptiveBackoff(env.PollBackoffAdaptiveMax, env.PollBackoffMax, env.PollBackoffQuietPeriod)
+	if err != nil {
+		return nil, configError("invalid adaptive poll backoff: %w", err)
+	}
+
+	archive, err := newArchiver(ctx, env)
+	if err != nil {
+		return nil, configError("invalid archive sink configuration: %w", err)
+	}
+
+	diskBuffer, err := newDiskBuffer(env.DiskBufferDir, env.DiskBufferMaxBytes, env.DiskBufferRetryInterval)
+	if err != nil {
+		return nil, configError("invalid disk buffer configuration: %w", err)
+	}
+	if diskBuffer != nil && (env.SendAggregateWindow != 0 || env.ArchiveOnly) {
+		return nil, configError("invalid disk buffer configuration: not supported with an aggregation window or archive only mode")
+	}
+
+	filter, err := newFilterExpr(env.FilterExpr, nil)
+	if err != nil {
+		return nil, configError("invalid filter expression: %w", err)
+	}
+
+	severity, err := newSeverityRouter(env.SeverityRoutes, nil)
+	if err != nil {
+		return nil, configError("invalid severity routes: %w", err)
+	}
+	if severity != nil && (env.SinkProtocol != sinkProtocolHTTP || env.SendAggregateWindow != 0) {
+		return nil, configError("invalid severity routes: require sink protocol %q without aggregation", sinkProtocolHTTP)
+	}
+
+	if err = validateIdempotencyHeader(env.IdempotencyKey, env.IdempotencyHeader); err != nil {
+		return nil, configError("invalid idempotency key configuration: %w", err)
+	}
+	if env.IdempotencyHeader != "" && (env.SinkProtocol != sinkProtocolHTTP || env.SendAggregateWindow != 0) {
+		return nil, configError("invalid idempotency header: requires sink protocol %q without aggregation", sinkProtocolHTTP)
+	}
+
+	k8sEvents, err := newK8sEventEmitter(env.EmitK8sEvents, kubeclient.Get(ctx).CoreV1(), env.Namespace, env.SourceName,
+		env.SourceUID, env.K8sEventsInterval)
+	if err != nil {
+		return nil, configError("invalid Kubernetes events configuration: %w", err)
+	}
+
+	stdby, err := newStandby(env.StandbyPromotionFile, env.StandbyPollInterval)
+	if err != nil {
+		return nil, configError("invalid standby configuration: %w", err)
+	}
+
+	fallback, err := newFallbackSink(env.FallbackSink, env.FallbackThreshold, newClient)
+	if err != nil {
+		return nil, configError("invalid fallback sink configuration: %w", err)
+	}
+
+	tee, err := newTeeSink(env.TeeSink, env.TeeQueueSize, newClient)
+	if err != nil {
+		return nil, configError("invalid tee sink configuration: %w", err)
+	}
+
+	var batch *batchSender
+	if env.SinkProtocol != sinkProtocolHTTP && env.SendAggregateWindow != 0 {
+		return nil, configError("invalid aggregation window configuration: not supported with sink protocol %q", env.SinkProtocol)
+	}
+
+	switch env.SinkProtocol {
+	case sinkProtocolHTTP:
+		// fail fast instead of failing to send every event
+		if err = validateSink(env); err != nil {
+			return nil, configError("invalid sink configuration: %w", err)
+		}
+
+		if customSinkTransport(env) {
+			ceClient, err = newSinkClient(env, transport, env.GetSink())
+			if err != nil {
+				return nil, configError("unable to create sink client: %w", err)
+			}
+		}
+
+		batch, err = newBatchSender(env, transport)
+		if err != nil {
+			return nil, configError("invalid aggregation window configuration: %w", err)
+		}
+	case sinkProtocolSQS:
+		ceClient, err = newSQSClient(env.SQSQueueURL, env.SQSRegion)
+		if err != nil {
+			return nil, configError("unable to create SQS client: %w", err)
+		}
+		logger.Infow("sending events to SQS", zap.String("queueURL", env.SQSQueueURL))
+	case sinkProtocolPubSub:
+		ceClient, err = newPubSubClient(ctx, env.PubSubEndpoint, env.PubSubProject, env.PubSubTopic)
+		if err != nil {
+			return nil, configError("unable to create Pub/Sub client: %w", err)
+		}
+		logger.Infow("sending events to Pub/Sub", zap.String("project", env.PubSubProject),
+			zap.String("topic", env.PubSubTopic))
+	default:
+		return nil, configError("unsupported sink protocol %q", env.SinkProtocol)
+	}
+
+	// the configuration is valid, connect to the sink, vCenter and the
+	// Kubernetes API
+	if env.SinkProtocol == sinkProtocolHTTP && env.SinkProbe {
+		headers, err := parseSinkHeaders(env.SinkHeaders)
+		if err != nil {
+			return nil, configError("invalid sink headers: %w", err)
+		}
+		if err = probeSink(ctx, env.GetSink(), transport, headers, env.SinkProbeTimeout); err != nil {
+			return nil, connectivityError("sink probe failed: %w", err)
+		}
+		logger.Infow("sink probe succeeded", zap.String("sink", env.GetSink()))
+	}
+
 	vClient, err := NewSOAPClient(ctx)
 	if err != nil {
-		logger.Fatalf("unable to create vSphere client: %v", err)
+		return nil, connectivityError("unable to create vSphere client: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			// best effort, the adapter does not start
+			if lerr := vClient.Logout(context.Background()); lerr != nil {
+				logger.Warnw("could not log out of vCenter", zap.Error(lerr))
+			}
+		}
+	}()
 
-	source := vClient.URL().Host
+	source, err := overrideSource(vClient.URL().Host, env.SourceOverride)
+	if err != nil {
+		return nil, configError("invalid source override: %w", err)
+	}
 	if source == "" {
-		logger.Fatal("unable to determine vSphere client source: empty host")
+		return nil, configError("unable to determine vSphere client source: empty host")
 	}
 
-	// setup checkpointing
-	store := kvstore.NewConfigMapKVStore(ctx, env.KVConfigMap, env.Namespace, kubeclient.Get(ctx).CoreV1())
-	if err = store.Init(ctx); err != nil {
-		logger.Fatalf("could not initialize kv store: %v", err)
+	sources, err := newClassSources(source, env.SourceSuffix)
+	if err != nil {
+		return nil, configError("invalid cloud event source suffix: %w", err)
 	}
 
-	cpconf, err := newCheckpointConfig(env.CheckpointConfig)
+	idempotency, err := newIdempotencyKeys(env.IdempotencyKey, vClient.ServiceContent.About.InstanceUuid, env.IdempotencyHeader)
 	if err != nil {
-		logger.Fatalf("could not not read checkpoint config: %v", err)
+		return nil, configError("invalid idempotency key configuration: %w", err)
+	}
+
+	// setup checkpointing
+	kvNamespace := kvStoreNamespace(env)
+	if env.KVPrecreated {
+		cms := kubeclient.Get(ctx).CoreV1().ConfigMaps(kvNamespace)
+		err = retryInit(ctx, "check checkpoint configmap", env.InitRetry, env.InitRetryBackoff, func(ctx context.Context) error {
+			return checkPrecreated(ctx, cms, kvNamespace, env.KVConfigMap)
+		})
+		if err != nil {
+			return nil, kvStoreInitError(err, kvNamespace, env.KVConfigMap, true)
+		}
+	}
+
+	store := kvstore.NewConfigMapKVStore(ctx, env.KVConfigMap, kvNamespace, kubeclient.Get(ctx).CoreV1())
+	if err = retryInit(ctx, "initialize checkpoint store", env.InitRetry, env.InitRetryBackoff, store.Init); err != nil {
+		return nil, kvStoreInitError(err, kvNamespace, env.KVConfigMap, env.KVPrecreated)
+	}
+
+	var annotator *checkpointAnnotator
+	if env.CheckpointAnnotations {
+		annotator = newCheckpointAnnotator(kubeclient.Get(ctx).CoreV1().ConfigMaps(kvNamespace), env.KVConfigMap)
+	}
+
+	var mirror *checkpointMirror
+	if env.CheckpointMirror != "" {
+		// mirroring is best-effort and must not prevent the adapter from starting
+		mirrorStore := kvstore.NewConfigMapKVStore(ctx, mirrorName, mirrorNS, kubeclient.Get(ctx).CoreV1())
+		if merr := mirrorStore.Init(ctx); merr != nil {
+			logger.Warnw("disabling checkpoint mirror: could not initialize kv store", zap.String("mirror", env.CheckpointMirror), zap.Error(merr))
+		} else {
+			mirror = newCheckpointMirror(mirrorStore, env.CheckpointMirror)
+		}
+	}
+
+	// bind the components configured above to the vCenter client
+	if entityPaths != nil {
+		entityPaths.resolve = inventoryPathFunc(vClient.Client)
+	}
+	if vmMetadata != nil {
+		vmMetadata.lookup = vmPropertyFunc(vClient.Client)
+	}
+	if window != nil {
+		window.latest = newLatestEventKeyFunc(vClient.Client)
+	}
+	if filter != nil {
+		filter.severity = eventManagerSeverity(vClient.Client)
+	}
+	if severity != nil {
+		severity.severity = eventManagerSeverity(vClient.Client)
+	}
+
+	var replayLast eventLookupFunc
+	if env.ReplayLastOnStart {
+		replayLast = eventAtFunc(vClient.Client)
 	}
 
 	logger.Infow("configuring checkpointing", zap.String("ReplayWindow", cpconf.MaxAge.String()),
@@ -106,7 +1117,75 @@ func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClie
 		KVStore:         store,
 		CpConfig:        *cpconf,
 		PayloadEncoding: env.PayloadEncoding,
-	}
+		ContentMode:     env.ContentMode,
+		Throttle:        throttle,
+		SinkRamp:        ramp,
+		TypeRateLimits:  typeLimits,
+		MaxBatchBytes:   env.MaxBatchBytes,
+		ReadBatchSize:   env.EventsBatchSize,
+		ClockSkewWarn:   env.ClockSkewWarn,
+		MaxClockSkew:    env.MaxClockSkew,
+		TimeBackward:    env.TimeBackward,
+		CheckpointKeys:  env.CheckpointKeys,
+		CpFormat:        env.CheckpointFormat,
+		Extensions:      extensions,
+		AllowedExts:     allowedExts,
+		Annotator:       annotator,
+		Backfill:        newBackfillTracker(env.BackfillLagThreshold, env.BackfillReportInterval),
+		SortEvents:      env.SortEvents,
+		EntityPaths:     entityPaths,
+		VMMetadata:      vmMetadata,
+		JSONOmitEmpty:   env.JSONOmitEmpty,
+		HistorySize:     env.CheckpointHistory,
+		Confirm:         confirm,
+		CompositeIDs:    env.CompositeIDs,
+		Tee:             tee,
+		DataSchemas:     schemas,
+		FailOnDataLoss:  env.FailOnDataLoss,
+		IDGenerator:     newKeyIDGenerator(source, env.CompositeIDs),
+		CpMinWrite:      env.CheckpointMinWriteInterval,
+		Maintenance:     maintenance,
+		Mirror:          mirror,
+		Truncator:       truncator,
+		Replay:          replay,
+		ReplayPacer:     pacer,
+		EventTypes:      newEventTypeFilter(eventTypes),
+		ServerTypes:     serverTypes,
+		Window:          window,
+		BacklogNotice:   env.EmitBacklogNotice,
+		LogLevelServer:  logLevelServer,
+		StartupJitter:   env.StartupJitter,
+		MaxLifetime:     env.MaxLifetime,
+		SendRetries:     env.SendRetries,
+		RetryBackoff:    env.SendRetryBackoff,
+		DeadLetter:      deadLetter,
+		DeadLetterTries: deadLetterTries,
+		QuietStart:      env.QuietStart,
+		Batch:           batch,
+		AggregateWindow: env.SendAggregateWindow,
+		ActiveTypes:     newActiveEventTypes(env.ActiveTypesWindow),
+		Fallback:        fallback,
+		TimePrecision:   timePrecision,
+		Entities:        newEntityAllowlist(env.EntityAllowlist, inventoryRefFunc(vClient.Client), env.EntityAllowlistTTL),
+		Filter:          filter,
+		Archive:         archive,
+		ArchiveOnly:     env.ArchiveOnly,
+		DiskBuffer:      diskBuffer,
+		ChainSeq:        chainSeq,
+		PollBackoff:     pollBackoff,
+		PollBackoffBase: pollBackoffBase,
+		ClassSources:    sources,
+		ContentDedup:    dedup,
+		DataEncoding:    env.DataContentEncoding,
+		XMLFields:       projection,
+		Severity:        severity,
+		Idempotency:     idempotency,
+		Standby:         stdby,
+		ReplayLast:      replayLast,
+		K8sEvents:       k8sEvents,
+
+		CreatedTimeStrategy: env.CreatedTimeStrategy,
+	}, nil
 }
 
 // Start implements adapter.Adapter
@@ -116,6 +1195,10 @@ func (a *vAdapter) Start(ctx context.Context) error {
 		_ = a.VClient.Logout(context.Background()) // best effort, ignoring error
 	}()
 
+	if a.LogLevelServer != nil {
+		go serveLogLevel(ctx, a.LogLevelServer)
+	}
+
 	return a.run(ctx)
 }
 
@@ -125,22 +1208,83 @@ func (a *vAdapter) Start(ctx context.Context) error {
 // checkpoint with additional validation logic to avoid unbounded event replay.
 // A checkpoint will be created periodically to track the position in the
 // vCenter event stream. This allows to implement at-least-once semantics.
+// In replay-only mode, only the events in the configured key range are sent.
+// In standby, events are only read and sent once promoted.
 func (a *vAdapter) run(ctx context.Context) error {
-	var cp checkpoint
-	if err := a.KVStore.Get(ctx, checkpointKey, &cp); err != nil {
-		logging.FromContext(ctx).Warnw("could not retrieve checkpoint configuration", zap.Error(err))
+	if err := waitStartupJitter(ctx, a.StartupJitter); err != nil {
+		return err
+	}
+	a.started = time.Now()
+
+	if a.Replay != nil {
+		return a.runReplay(ctx)
 	}
+
+	if a.Standby != nil {
+		if err := a.waitPromotion(ctx); err != nil {
+			return err
+		}
+	}
+
+	cp := a.newestCheckpoint(ctx)
+	a.restored = cp.LastEventKeyTimestamp
+
 	// begin of event stream defaults to current vCenter time (UTC)
 	vcTime, err := methods.GetCurrentTime(ctx, a.VClient)
 	if err != nil {
 		return fmt.Errorf("get current time from vCenter: %w", err)
 	}
 
+	if err = a.checkClockSkew(ctx, time.Now().Sub(*vcTime)); err != nil {
+		return err
+	}
+
+	if a.FailOnDataLoss && checkpointExpired(*vcTime, cp, a.CpConfig.MaxAge) {
+		return fmt.Errorf("%w: last event timestamp %s in checkpoint is older than configured maximum %s",
+			ErrDataLoss, cp.LastEventKeyTimestamp, a.CpConfig.MaxAge)
+	}
+
+	if checkpointExpired(*vcTime, cp, a.CpConfig.MaxAge) {
+		a.K8sEvents.warn(ctx, reasonDataLoss, "Last event timestamp %s in checkpoint is older than configured maximum %s: "+
+			"events in between are not sent", cp.LastEventKeyTimestamp.Format(time.RFC3339), a.CpConfig.MaxAge)
+	}
+
 	begin := getBeginFromCheckpoint(ctx, *vcTime, cp, a.CpConfig.MaxAge)
-	coll, err := newHistoryCollector(ctx, a.VClient.Client, begin)
+	if begin, err = a.checkTimeBackward(ctx, *vcTime, cp, begin); err != nil {
+		return err
+	}
+	if a.BacklogNotice {
+		a.sendBacklogNotice(ctx, newBacklogNotice(begin, *vcTime, cp, a.CpConfig.MaxAge))
+	}
+
+	if a.Backfill != nil {
+		a.Backfill.start(begin, *vcTime)
+	}
+
+	coll, err := newHistoryCollector(ctx, a.VClient.Client, begin, a.ServerTypes)
 	if err != nil {
 		return fmt.Errorf("create event collector: %w", err)
 	}
+	a.collectorBegin = begin
+	a.newCollector = func(ctx context.Context, begin time.Time) (eventCollector, error) {
+		return newHistoryCollector(ctx, a.VClient.Client, begin, a.ServerTypes)
+	}
+	a.renewSession = func(ctx context.Context) error {
+		return renewSOAPSession(ctx, a.VClient)
+	}
+
+	if a.Tee != nil {
+		go a.Tee.run(ctx)
+	}
+
+	if a.ReplayLast != nil {
+		a.sendLastCheckpointed(ctx, cp)
+	}
+
+	// the last checkpointed event was re-sent already when priming
+	if a.QuietStart || a.ReplayLast != nil {
+		a.fastForward = newFastForward(cp)
+	}
 
 	return a.readEvents(ctx, coll)
 }
@@ -149,19 +1293,62 @@ func (a *vAdapter) run(ctx context.Context) error {
 // in the provided event history collector. A checkpoint will be periodically
 // created and stored in Kubernetes to track successfully processed events
 // (ACK-ed by sink).
-func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) error {
+func (a *vAdapter) readEvents(ctx context.Context, c eventCollector) error {
 	logger := logging.FromContext(ctx)
 
 	var (
 		lastEvent              types.BaseEvent
 		lastCheckpointEventKey int32
+		lastCheckpointSave     time.Time
+		// events read from vCenter exceeding the batch byte budget
+		pending []types.BaseEvent
+		// estimated sizes of events not yet sent and average size of the
+		// last batch to bound reads by the batch byte budget
+		sizes    = newEventSizes(a.PayloadEncoding)
+		avgBytes int
+		// consecutive collector recreations without a successful read
+		recreations int
 	)
 
-	bOff := backoff.Backoff{
-		Factor: 2,
-		Jitter: false,
-		Min:    time.Second,
-		Max:    5 * time.Second,
+	bOff := a.pollBackoff()
+
+	send := a.sendBaseEvents
+	if send == nil {
+		send = a.sendEvents
+	}
+
+	// flush writes the pending archive and checkpoint before exiting
+	flush := func() {
+		// using fresh ctx to avoid canceled error
+		flushCtx, cancel := context.WithTimeout(logging.WithLogger(context.Background(), logger), checkpointFlushTimeout)
+		defer cancel()
+
+		if a.Archive != nil {
+			if archived := a.archiveCheckpoint(flushCtx, nil, true); archived != nil {
+				if _, err := a.setCheckpoint(flushCtx, archived); err != nil {
+					logger.Errorw("could not set checkpoint on shutdown", zap.Error(err))
+				} else {
+					lastEvent = archived
+				}
+			}
+		}
+
+		if a.DiskBuffer != nil {
+			if delivered := a.DiskBuffer.checkpointEvent(nil); delivered != nil && delivered != lastEvent {
+				if _, err := a.setCheckpoint(flushCtx, delivered); err != nil {
+					logger.Errorw("could not set checkpoint on shutdown", zap.Error(err))
+				} else {
+					lastEvent = delivered
+				}
+			}
+		}
+
+		if lastEvent != nil && lastCheckpointEventKey != lastEvent.GetEvent().Key {
+			if err := a.saveCheckpoint(flushCtx); err != nil {
+				logger.Errorw("could not flush checkpoint on shutdown", zap.Error(err))
+				a.K8sEvents.warn(flushCtx, reasonCheckpointWriteFailed, "Failed to write checkpoint on shutdown: %v", err)
+			}
+		}
 	}
 
 	cpTicker := time.NewTicker(a.CpConfig.Period)
@@ -170,69 +1357,230 @@ func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) er
 	for {
 		select {
 		case <-ctx.Done():
+			flush()
 			return ctx.Err()
 
 		// checkpoints
 		case <-cpTicker.C:
 			// avoid unnecessary K8s API calls
 			skip := lastEvent == nil || lastCheckpointEventKey == lastEvent.GetEvent().Key
-			if !skip {
-				var current checkpoint
-				if err := a.KVStore.Get(ctx, checkpointKey, &current); err != nil {
-					return fmt.Errorf("retrieve current checkpoint: %w", err)
-				}
-
-				logger.Debugw("creating checkpoint", zap.Any("checkpoint", current))
-				if err := a.KVStore.Save(ctx); err != nil {
-					return fmt.Errorf("save checkpoint: %w", err)
-				}
-				lastCheckpointEventKey = lastEvent.GetEvent().Key
-			} else {
+			if skip {
 				logger.Debug("skipping checkpoint: no new events since last checkpoint")
+				continue
 			}
 
+			// coalesce checkpoint writes under high event rates
+			if since := time.Since(lastCheckpointSave); since < a.CpMinWrite {
+				logger.Debugw("deferring checkpoint: minimum write interval not elapsed", zap.Duration("sinceLastWrite", since))
+				continue
+			}
+
+			if err := a.saveCheckpoint(ctx); err != nil {
+				a.K8sEvents.warn(ctx, reasonCheckpointWriteFailed, "Failed to write checkpoint: %v", err)
+				return err
+			}
+			lastCheckpointEventKey = lastEvent.GetEvent().Key
+			lastCheckpointSave = time.Now()
+
 		// poll vCenter events
 		default:
-			events, err := c.ReadNextEvents(ctx, maxEventsBatch)
-			if err != nil {
-				return fmt.Errorf("read events from vcenter: %w", err)
+			// exit between batches, i.e. at a clean checkpoint boundary
+			if len(pending) == 0 && a.lifetimeExpired(time.Now()) {
+				logger.Infow("exiting: maximum lifetime reached", zap.Duration("maxLifetime", a.MaxLifetime))
+				flush()
+				return nil
 			}
 
+			if a.DiskBuffer.active() {
+				// checkpoint events delivered from the buffer
+				if delivered := a.drainDiskBuffer(ctx, int(a.readBatchSize())); delivered != nil && delivered != lastEvent {
+					if _, err := a.setCheckpoint(ctx, delivered); err != nil {
+						return err
+					}
+					lastEvent = delivered
+				}
+			}
+
+			events := pending
 			if len(events) == 0 {
+				var err error
+				size := a.readBatchSize()
+				if !a.Window.widened() {
+					size = budgetedReadSize(size, a.MaxBatchBytes, avgBytes)
+				}
+				events, err = c.ReadNextEvents(ctx, size)
+				if err != nil {
+					if ctx.Err() != nil {
+						// read interrupted by shutdown
+						flush()
+						return ctx.Err()
+					}
+					if a.newCollector == nil || recreations >= maxCollectorRecreations {
+						return fmt.Errorf("read events from vcenter: %w", err)
+					}
+
+					switch {
+					case collectorRecoverable(err):
+						// keep the session and only rebuild the collector
+						if c, err = a.recreateCollector(ctx, lastEvent, err); err != nil {
+							return err
+						}
+					case a.renewSession != nil && sessionExpired(err):
+						renewed, renewErr := a.renewCollector(ctx, lastEvent, err)
+						if renewErr != nil {
+							// e.g. rotated credentials not mounted yet
+							delay := bOff.Duration()
+							logger.Warnw("could not renew vCenter session, retrying", zap.Duration("backoffSeconds", delay), zap.Error(renewErr))
+							select {
+							case <-ctx.Done():
+							case <-time.After(delay):
+							}
+						} else {
+							c = renewed
+						}
+					default:
+						return fmt.Errorf("read events from vcenter: %w", err)
+					}
+					recreations++
+					continue
+				}
+				recreations = 0
+				a.fillCreatedTime(ctx, events, time.Now().UTC())
+				reportBatchSize(ctx, len(events))
+				reportEventsRead(ctx, len(events))
+				logger.Debugw("read events from vcenter", zap.Int("batchSize", len(events)), zap.Int32("maxBatchSize", size))
+
+				if a.Window != nil && len(events) > 0 {
+					a.Window.update(ctx, events, size)
+				}
+
+				if read := len(events); a.fastForward != nil || a.checkpointed != nil {
+					events = a.checkpointed.filter(ctx, a.fastForward.skip(ctx, events))
+					if len(events) == 0 && read > 0 {
+						// continue reading without backoff
+						continue
+					}
+				}
+
+				if a.Batch != nil && len(events) > 0 {
+					if events, err = a.accumulate(ctx, c, events); err != nil {
+						if ctx.Err() != nil {
+							flush()
+							return ctx.Err()
+						}
+						return err
+					}
+				}
+			}
+			events, pending = splitBatch(events, a.MaxBatchBytes, sizes)
+			if a.MaxBatchBytes > 0 && len(events) > 0 {
+				avgBytes = sizes.average(events)
+			}
+
+			if len(events) == 0 {
+				if a.Archive != nil {
+					// checkpoint events written while idle
+					if archived := a.archiveCheckpoint(ctx, nil, false); archived != nil && archived != lastEvent {
+						if _, err := a.setCheckpoint(ctx, archived); err != nil {
+							return err
+						}
+						lastEvent = archived
+					}
+				}
+
+				if a.PollBackoff != nil {
+					bOff.Max = a.PollBackoff.quiet(ctx, time.Now())
+				}
 				delay := bOff.Duration()
+				reportPollBackoff(ctx, delay)
 				logger.Debugw("backing off retrieving events: no new events received", zap.Duration("backoffSeconds", delay))
-				time.Sleep(delay)
+				// flushed on the next iteration if canceled
+				select {
+				case <-ctx.Done():
+				case <-time.After(delay):
+				}
 				continue
 			}
 
 			logger.Debugf("got %d events", len(events))
+			a.PollBackoff.active(ctx)
+			// no longer backing off
+			reportPollBackoff(ctx, 0)
+
+			if a.SortEvents {
+				sortEvents(events)
+			}
 
-			n, err := a.sendEvents(ctx, events)
+			if a.Throttle != nil {
+				lag := eventLag(events[0].GetEvent().CreatedTime, time.Now().UTC())
+				if a.Window.widened() {
+					// catch up before unread events are overwritten
+					lag = 0
+				}
+				r := a.Throttle.adjust(lag)
+				reportReplayRate(ctx, r)
+				logger.Debugw("adjusted replay rate", zap.Duration("lag", lag), zap.Float64("eventsPerSecond", r))
+			}
+
+			n, err := send(ctx, events)
+			// validate before slicing the batch with n
+			if verr := checkSendResult(events, n, err); verr != nil {
+				return verr
+			}
+			sizes.forget(events[:n])
+			a.ActiveTypes.report(ctx, time.Now())
+			// report before checkpointing to expose a growing lag while sending fails
+			reportCheckpointLag(ctx, checkpointLag(lastEvent, a.restored, a.collectorBegin, time.Now().UTC()))
 			if err != nil {
-				// TODO: return and fail instead?
 				logger.Errorf("send events: success %d (total %d): %v", n, len(events), err)
 
+				// the collector already advanced past the batch, so redeliver
+				// unsent events before reading new ones
+				pending = requeueEvents(events[n:], pending)
+
 				// 	special case: all events failed so skipping checkpoint
 				if n == 0 {
+					delay := bOff.Duration()
+					logger.Debugw("backing off redelivering events", zap.Int("events", len(pending)), zap.Duration("backoffSeconds", delay))
+					select {
+					case <-ctx.Done():
+					case <-time.After(delay):
+					}
 					continue
 				}
 			}
 
-			if n == 0 && err == nil {
-				panic("we should never get here")
+			// last successfully sent event from batch
+			lastSent, err := lastSentEvent(events, n)
+			if err != nil {
+				return err
 			}
 
-			// last successfully sent event from batch
-			lastEvent = events[n-1]
-			cp := checkpoint{
-				VCenter:               a.Source,
-				LastEventKey:          lastEvent.GetEvent().Key,
-				LastEventType:         getEventDetails(lastEvent).Type,
-				LastEventKeyTimestamp: lastEvent.GetEvent().CreatedTime,
-				CreatedTimestamp:      time.Now().UTC(),
+			if a.Archive != nil {
+				// only checkpoint events written to the archive
+				if lastSent = a.archiveCheckpoint(ctx, lastSent, false); lastSent == nil {
+					bOff.Reset()
+					continue
+				}
 			}
-			if err = a.KVStore.Set(ctx, checkpointKey, cp); err != nil {
-				return fmt.Errorf("set checkpoint: %w", err)
+
+			if a.DiskBuffer != nil {
+				// only checkpoint events delivered to the sink
+				if lastSent = a.DiskBuffer.checkpointEvent(lastSent); lastSent == nil || lastSent == lastEvent {
+					bOff.Reset()
+					continue
+				}
+			}
+
+			lastEvent = lastSent
+			cp, err := a.setCheckpoint(ctx, lastEvent)
+			if err != nil {
+				return err
+			}
+			reportCheckpointLag(ctx, eventLag(lastEvent.GetEvent().CreatedTime, time.Now().UTC()))
+
+			if a.Backfill != nil {
+				a.Backfill.update(ctx, n, cp.LastEventKeyTimestamp, time.Now().UTC())
 			}
 
 			bOff.Reset()
@@ -240,48 +1588,357 @@ func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) er
 	}
 }
 
+// saveCheckpoint persists the current checkpoint set in the kvstore, records
+// it in the checkpoint history and annotates the checkpoint ConfigMap if
+// configured
+func (a *vAdapter) saveCheckpoint(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
+	current, err := a.loadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve current checkpoint: %w", err)
+	}
+
+	if a.HistorySize > 0 {
+		if err := a.recordCheckpointHistory(ctx, current); err != nil {
+			return err
+		}
+	}
+
+	logger.Debugw("creating checkpoint", zap.Any("checkpoint", current))
+	if err := a.KVStore.Save(ctx); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	if a.Annotator != nil {
+		if err := a.Annotator.annotate(ctx, current, time.Now().UTC()); err != nil {
+			logger.Warnw("could not annotate checkpoint configmap", zap.Error(err))
+		}
+	}
+
+	if a.Mirror != nil {
+		if err := a.Mirror.mirror(ctx, current); err != nil {
+			logger.Warnw("could not mirror checkpoint", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// recordCheckpointHistory adds the given checkpoint to the bounded history of
+// recent checkpoints in the kvstore. The history is persisted with the next
+// save of the kvstore.
+func (a *vAdapter) recordCheckpointHistory(ctx context.Context, cp checkpoint) error {
+	var history []checkpoint
+	if err := a.KVStore.Get(ctx, CheckpointHistoryKey, &history); err != nil {
+		logging.FromContext(ctx).Debugw("starting new checkpoint history", zap.Error(err))
+	}
+
+	history = appendCheckpointHistory(history, cp, a.HistorySize)
+	if err := a.KVStore.Set(ctx, CheckpointHistoryKey, history); err != nil {
+		return fmt.Errorf("set checkpoint history: %w", err)
+	}
+	return nil
+}
+
+// setCheckpoint sets the checkpoint for the given last processed event in the
+// kv store. The checkpoint is persisted with the next saveCheckpoint.
+func (a *vAdapter) setCheckpoint(ctx context.Context, be types.BaseEvent) (checkpoint, error) {
+	cp := checkpoint{
+		VCenter:               a.Source,
+		LastEventKey:          be.GetEvent().Key,
+		LastEventType:         getEventDetails(be).Type,
+		LastEventKeyTimestamp: be.GetEvent().CreatedTime,
+		CreatedTimestamp:      time.Now().UTC(),
+	}
+	if err := a.storeCheckpoint(ctx, cp); err != nil {
+		return cp, fmt.Errorf("set checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// checkSendResult returns an error if the number of sent events n reported by
+// sendEvents is outside of the batch boundaries or if no event was sent
+// without an error, i.e. the send result is inconsistent.
+func checkSendResult(events []types.BaseEvent, n int, err error) error {
+	if n < 0 || n > len(events) || (n == 0 && err == nil) {
+		return fmt.Errorf("invalid send result: %d events sent from batch of %d events", n, len(events))
+	}
+	return nil
+}
+
+// lastSentEvent returns the last successfully sent event from the given batch
+// for the number of sent events n reported by sendEvents. An error is returned
+// if n is outside of the batch boundaries, i.e. the send result is
+// inconsistent.
+func lastSentEvent(events []types.BaseEvent, n int) (types.BaseEvent, error) {
+	if n <= 0 || n > len(events) {
+		return nil, fmt.Errorf("invalid send result: %d events sent from batch of %d events", n, len(events))
+	}
+	return events[n-1], nil
+}
+
 // sendEvents converts all events to cloud events and sends them to the
-// configured sink. It returns the number of successfully processed events,
-// which might 0, partial or all events. sendEvents returns when all events are
-// processed or on the first error.
+// configured sink. Events created during a maintenance window and events not
+// matching the event type filter or entity allowlist are dropped. It
+// returns the number of successfully processed (sent or dropped) events, which
+// might 0, partial or all events. sendEvents returns when all events are
+// processed or on the first error. If an aggregation window is configured, the
+// events are sent as a single batch and either none or all events are
+// processed.
 func (a *vAdapter) sendEvents(ctx context.Context, baseEvents []types.BaseEvent) (int, error) {
-	var success int
+	var (
+		success int
+		// cloud events and their vCenter events and content hashes sent as
+		// batch
+		batch         []cloudevents.Event
+		batched       []types.BaseEvent
+		batchedHashes []string
+	)
+
+	if a.IDGenerator == nil {
+		a.IDGenerator = newKeyIDGenerator(a.Source, a.CompositeIDs)
+	}
 
 	for _, be := range baseEvents {
-		ev := cloudevents.NewEvent(cloudevents.VersionV1)
-		ev.SetSource(a.Source)
+		if a.Maintenance.active(be.GetEvent().CreatedTime) {
+			logging.FromContext(ctx).Debugw("dropping event created during maintenance window",
+				zap.Int32("eventKey", be.GetEvent().Key))
+			success++
+			continue
+		}
 
 		details := getEventDetails(be)
+		a.ActiveTypes.observe(ctx, details.Type, time.Now())
+		if !a.EventTypes.allows(details.Type) {
+			logging.FromContext(ctx).Debugw("dropping event not matching event type filter",
+				zap.Int32("eventKey", be.GetEvent().Key), zap.String("type", details.Type))
+			success++
+			continue
+		}
+
+		allowed, err := a.TypeRateLimits.allow(ctx, details.Type)
+		if err != nil {
+			return success, err
+		}
+		if !allowed {
+			logging.FromContext(ctx).Debugw("dropping event exceeding rate limit of event type",
+				zap.Int32("eventKey", be.GetEvent().Key), zap.String("type", details.Type))
+			success++
+			continue
+		}
+
+		if !a.Entities.allows(ctx, be) {
+			logging.FromContext(ctx).Debugw("dropping event not referencing an allowlisted entity",
+				zap.Int32("eventKey", be.GetEvent().Key))
+			success++
+			continue
+		}
+
+		matches, err := a.Filter.allows(ctx, be, details)
+		if err != nil {
+			return success, err
+		}
+		if !matches {
+			logging.FromContext(ctx).Debugw("dropping event not matching filter expression",
+				zap.Int32("eventKey", be.GetEvent().Key))
+			success++
+			continue
+		}
+
+		hash, err := a.ContentDedup.hash(be, details.Type)
+		if err != nil {
+			return success, fmt.Errorf("hash event content: %w", err)
+		}
+		if a.ContentDedup.duplicate(hash) {
+			logging.FromContext(ctx).Debugw("dropping event identical to recently sent event",
+				zap.Int32("eventKey", be.GetEvent().Key))
+			reportContentDuplicate(ctx)
+			success++
+			continue
+		}
+
+		ev := cloudevents.NewEvent(cloudevents.VersionV1)
+		ev.SetSource(a.ClassSources.source(details.Class, a.Source))
 
 		// CE envelop
-		ev.SetID(fmt.Sprintf("%d", be.GetEvent().Key))
+		ev.SetID(a.IDGenerator.ID(ctx, be))
 		ev.SetType(fmt.Sprintf(eventTypeFormat, details.Type))
-		ev.SetTime(be.GetEvent().CreatedTime)
-		ev.SetExtension(ceVSphereEventClass, details.Class)
-		ev.SetExtension(ceVSphereAPIKey, a.VAPIVersion)
+		ev.SetTime(be.GetEvent().CreatedTime.Truncate(a.TimePrecision))
+		if a.DataSchemas != nil {
+			if schema, ok := a.DataSchemas.schemaFor(details.Type); ok {
+				ev.SetDataSchema(schema)
+			}
+		}
+		a.AllowedExts.set(&ev, ceVSphereEventClass, details.Class)
+		a.AllowedExts.set(&ev, ceVSphereAPIKey, a.VAPIVersion)
+		setMoRefExtensions(&ev, be, a.AllowedExts)
+		a.Extensions.apply(ctx, &ev, be, a.AllowedExts)
+
+		if a.EntityPaths != nil && a.AllowedExts.allows(ceVSphereEntityPath) {
+			if path, ok := a.EntityPaths.path(ctx, be); ok {
+				ev.SetExtension(ceVSphereEntityPath, path)
+			}
+		}
+		a.VMMetadata.apply(ctx, &ev, be, a.AllowedExts)
+
+		if a.Truncator.truncate(be) {
+			a.AllowedExts.set(&ev, ceVSphereTruncated, true)
+		}
+
+		if a.ChainSeq != nil && a.AllowedExts.allows(ceVSphereChainSeq) {
+			ev.SetExtension(ceVSphereChainSeq, a.ChainSeq.next(be))
+		}
+		if isPriming(ctx) || a.Replay != nil {
+			a.AllowedExts.set(&ev, ceVSphereReplay, true)
+		}
+		sendCtx := a.Idempotency.apply(ctx, &ev, be, a.AllowedExts)
+
+		data, err := eventData(be, a.PayloadEncoding, a.JSONOmitEmpty)
+		if err != nil {
+			if err = fmt.Errorf("encode event data: %w", err); a.deadLetterMalformed(ctx, ev, err) {
+				success++
+				continue
+			}
+			return success, err
+		}
+		if a.XMLFields != nil {
+			data = a.XMLFields.project(be)
+		}
 
-		if err := ev.SetData(a.PayloadEncoding, be); err != nil {
-			return success, fmt.Errorf("set data on event: %w", err)
+		if err = ev.SetData(a.PayloadEncoding, data); err != nil {
+			if err = fmt.Errorf("set data on event: %w", err); a.deadLetterMalformed(ctx, ev, err) {
+				success++
+				continue
+			}
+			return success, err
+		}
+		if a.DataEncoding == dataEncodingBase64 {
+			encodeDataBase64(&ev)
 		}
 
-		// TODO: better partial batch failure handling here?
 		logging.FromContext(ctx).Debugw("sending event",
 			zap.String("ID", ev.ID()),
 			zap.String("type", ev.Type()),
 			zap.Any("data", be),
 		)
 
-		result := a.CEClient.Send(ctx, ev)
+		if a.Throttle != nil {
+			if err := a.Throttle.wait(ctx); err != nil {
+				return success, fmt.Errorf("throttle sending event: %w", err)
+			}
+		}
+
+		if a.ArchiveOnly {
+			if err := a.Archive.add(ctx, ev, be); err != nil {
+				return success, err
+			}
+			a.ContentDedup.record(hash)
+			success++
+			continue
+		}
+
+		if err := a.SinkRamp.wait(ctx); err != nil {
+			return success, fmt.Errorf("ramp up sending event: %w", err)
+		}
+
+		if a.Batch != nil {
+			batch = append(batch, ev)
+			batched = append(batched, be)
+			batchedHashes = append(batchedHashes, hash)
+			continue
+		}
+
+		// preserve ordering while events are buffered
+		if a.DiskBuffer.active() {
+			if err := a.DiskBuffer.add(ev, be); err != nil {
+				return success, err
+			}
+			a.ContentDedup.record(hash)
+			success++
+			continue
+		}
+
+		deadLettered, result := a.deliver(a.Severity.route(sendCtx, be), ev)
+		if deadLettered {
+			a.ContentDedup.record(hash)
+			success++
+			continue
+		}
+
 		if !cloudevents.IsACK(result) {
+			reportSendFailure(ctx)
 			logging.FromContext(ctx).Errorw("failed to send cloudevent", zap.Error(result))
-			return success, result
+			a.K8sEvents.warn(ctx, reasonSinkUnreachable, "Failed to send event to sink: %v", result)
+			if a.DiskBuffer == nil || !sinkUnavailable(result) || ctx.Err() != nil {
+				return success, result
+			}
+			if err := a.bufferUndelivered(ctx, ev, be, result); err != nil {
+				return success, err
+			}
+			a.ContentDedup.record(hash)
+			success++
+			continue
+		}
+
+		reportEventsSent(ctx, 1)
+		if a.Confirm != nil {
+			if err := a.Confirm.confirm(ctx, be.GetEvent().Key, ev.ID(), ev.Type(), result); err != nil {
+				logging.FromContext(ctx).Warnw("could not confirm event delivery", zap.String("ID", ev.ID()), zap.Error(err))
+			}
+		}
+		if a.Tee != nil && !a.Tee.enqueue(ctx, ev) {
+			logging.FromContext(ctx).Debugw("dropping event for tee sink: queue full", zap.String("ID", ev.ID()))
+		}
+		if a.Archive != nil {
+			if err := a.Archive.add(ctx, ev, be); err != nil {
+				return success, err
+			}
 		}
+		a.ContentDedup.record(hash)
 		success++
 	}
 
+	if a.Batch != nil {
+		if err := a.sendBatch(ctx, batch, batched); err != nil {
+			return 0, err
+		}
+		for _, hash := range batchedHashes {
+			a.ContentDedup.record(hash)
+		}
+		return len(baseEvents), nil
+	}
+
 	return success, nil
 }
 
+// newestCheckpoint returns the checkpoint with the latest event timestamp
+// stored under the default checkpoint key or any of the configured additional
+// checkpoint keys. An empty checkpoint is returned if none could be retrieved.
+func (a *vAdapter) newestCheckpoint(ctx context.Context) checkpoint {
+	logger := logging.FromContext(ctx)
+
+	var newest checkpoint
+	for _, key := range append([]string{CheckpointKey}, a.CheckpointKeys...) {
+		var cp checkpoint
+		var err error
+		if key == CheckpointKey {
+			cp, err = a.loadCheckpoint(ctx)
+		} else {
+			err = a.KVStore.Get(ctx, key, &cp)
+		}
+		if err != nil {
+			logger.Warnw("could not retrieve checkpoint configuration", zap.String("key", key), zap.Error(err))
+			continue
+		}
+
+		if cp.LastEventKeyTimestamp.After(newest.LastEventKeyTimestamp) {
+			logger.Debugw("found newer checkpoint", zap.String("key", key), zap.Any("checkpoint", cp))
+			newest = cp
+		}
+	}
+	return newest
+}
+
 // getBeginFromCheckpoint returns the valid begin time to start replaying
 // vCenter events. If the checkpoint is empty the current vCenter time (UTC) is
 // used. If the last checkpoint event timestamp is larger than maxAge, replay
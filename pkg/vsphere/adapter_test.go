@@ -23,8 +23,10 @@ import (
 	"github.com/cloudevents/sdk-go/v2/client"
 	cecontext "github.com/cloudevents/sdk-go/v2/context"
 	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
 	"github.com/google/go-cmp/cmp"
+	"github.com/jpillora/backoff"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/simulator"
@@ -44,6 +46,7 @@ type roundTripperTest struct {
 	statusCodes  []int
 	requestCount int
 	events       []*event.Event
+	contentTypes []string
 }
 
 func (r *roundTripperTest) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -54,6 +57,7 @@ func (r *roundTripperTest) RoundTrip(req *http.Request) (*http.Response, error)
 		return nil, err
 	}
 	r.events = append(r.events, e)
+	r.contentTypes = append(r.contentTypes, req.Header.Get("Content-Type"))
 	r.requestCount++
 	return &http.Response{StatusCode: code}, nil
 }
@@ -285,6 +289,61 @@ func Test_getBeginFromCheckpoint(t *testing.T) {
 	}
 }
 
+func Test_vAdapter_newestCheckpoint(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name string
+		keys []string
+		data map[string]string
+		want time.Time
+	}{
+		{
+			name: "no checkpoint",
+			want: time.Time{},
+		},
+		{
+			name: "default checkpoint only",
+			data: map[string]string{
+				CheckpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
+			},
+			want: now.Add(time.Hour * -1),
+		},
+		{
+			name: "additional checkpoint is newer",
+			keys: []string{"checkpoint-old", "checkpoint-new"},
+			data: map[string]string{
+				CheckpointKey:    createCheckpoint(t, now.Add(time.Hour*-2)),
+				"checkpoint-old": createCheckpoint(t, now.Add(time.Hour*-3)),
+				"checkpoint-new": createCheckpoint(t, now.Add(time.Hour*-1)),
+			},
+			want: now.Add(time.Hour * -1),
+		},
+		{
+			name: "default checkpoint is newer and missing additional checkpoint",
+			keys: []string{"checkpoint-old", "does-not-exist"},
+			data: map[string]string{
+				CheckpointKey:    createCheckpoint(t, now.Add(time.Hour*-1)),
+				"checkpoint-old": createCheckpoint(t, now.Add(time.Hour*-3)),
+			},
+			want: now.Add(time.Hour * -1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			a := &vAdapter{
+				KVStore:        &fakeKVStore{data: tt.data},
+				CheckpointKeys: tt.keys,
+			}
+
+			if got := a.newestCheckpoint(ctx); !got.LastEventKeyTimestamp.Equal(tt.want) {
+				t.Errorf("newestCheckpoint() LastEventKeyTimestamp = %v, want %v", got.LastEventKeyTimestamp, tt.want)
+			}
+		})
+	}
+}
+
 func Test_vAdapter_run(t *testing.T) {
 	const (
 		// number of vcsim events emitted for default VPX model
@@ -326,7 +385,7 @@ func Test_vAdapter_run(t *testing.T) {
 				Source:      source,
 				KVStore: &fakeKVStore{
 					data: map[string]string{
-						checkpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
+						CheckpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
 					},
 					dataChan: make(chan string, 1),
 				},
@@ -345,7 +404,7 @@ func Test_vAdapter_run(t *testing.T) {
 				Source:      source,
 				KVStore: &fakeKVStore{
 					data: map[string]string{
-						checkpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
+						CheckpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
 					},
 					dataChan: make(chan string, 1),
 				},
@@ -452,6 +511,616 @@ func Test_vAdapter_run(t *testing.T) {
 	}
 }
 
+func Test_vAdapter_run_failOnDataLoss(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vim *vim25.Client) error {
+		a := &vAdapter{
+			Logger:  zaptest.NewLogger(t).Sugar(),
+			Source:  source,
+			VClient: &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)},
+			KVStore: &fakeKVStore{
+				data: map[string]string{
+					CheckpointKey: createCheckpoint(t, time.Now().UTC().Add(-2*time.Hour)),
+				},
+			},
+			CpConfig: CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: time.Millisecond,
+			},
+			FailOnDataLoss: true,
+		}
+
+		if err := a.run(ctx); !errors.Is(err, ErrDataLoss) {
+			t.Errorf("run() error = %v, want %v", err, ErrDataLoss)
+		}
+		return nil
+	})
+}
+
+func Test_lastSentEvent(t *testing.T) {
+	events := createTestEvents(3, source, time.Now().UTC()).vEvents
+
+	tests := []struct {
+		name    string
+		n       int
+		wantKey int32
+		wantErr bool
+	}{
+		{
+			name:    "first event sent",
+			n:       1,
+			wantKey: 1000,
+		},
+		{
+			name:    "all events sent",
+			n:       3,
+			wantKey: 1002,
+		},
+		{
+			name:    "no events sent without error",
+			n:       0,
+			wantErr: true,
+		},
+		{
+			name:    "more events sent than in batch",
+			n:       4,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lastSentEvent(events, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lastSentEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got.GetEvent().Key != tt.wantKey {
+				t.Errorf("lastSentEvent() key = %d, want %d", got.GetEvent().Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents(t *testing.T) {
+	now := time.Now().UTC()
+	events := createTestEvents(3, source, now).vEvents
+	unsorted := []types.BaseEvent{
+		createBaseEvent(1001, now.Add(time.Second)),
+		createBaseEvent(1002, now.Add(-time.Second)),
+		createBaseEvent(1000, now),
+	}
+
+	tests := []struct {
+		name              string
+		batches           [][]types.BaseEvent
+		sendResults       []error
+		sortEvents        bool
+		wantCheckpointKey int32
+	}{
+		{
+			name:              "all sends succeed",
+			batches:           [][]types.BaseEvent{events},
+			sendResults:       []error{nil, nil, nil},
+			wantCheckpointKey: 1002,
+		},
+		{
+			name:              "all sends of first batch fail",
+			batches:           [][]types.BaseEvent{events[:1], events[1:]},
+			sendResults:       []error{errors.New("fail"), nil, nil},
+			wantCheckpointKey: 1002,
+		},
+		{
+			name:              "partial batch failure redelivers unsent events",
+			batches:           [][]types.BaseEvent{events},
+			sendResults:       []error{nil, errors.New("fail")},
+			wantCheckpointKey: 1002,
+		},
+		{
+			name:              "unsorted batch checkpoints last event by creation time",
+			batches:           [][]types.BaseEvent{unsorted},
+			sendResults:       []error{nil, nil, nil},
+			sortEvents:        true,
+			wantCheckpointKey: 1001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			kv := &fakeKVStore{dataChan: make(chan string, 1)}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        &fakeCEClient{results: tt.sendResults},
+				KVStore:         kv,
+				CpConfig:        CheckpointConfig{Period: time.Millisecond},
+				PayloadEncoding: cloudevents.ApplicationXML,
+				SortEvents:      tt.sortEvents,
+			}
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- a.readEvents(ctx, &fakeCollector{batches: tt.batches})
+			}()
+
+			var cp checkpoint
+			for cp.LastEventKey != tt.wantCheckpointKey {
+				select {
+				case data := <-kv.dataChan:
+					if err := json.Unmarshal([]byte(data), &cp); err != nil {
+						t.Fatalf("unmarshal data from KV store: %v", err)
+					}
+				case err := <-errCh:
+					t.Fatalf("readEvents() returned unexpectedly: %v", err)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for checkpoint key %d, got %d", tt.wantCheckpointKey, cp.LastEventKey)
+				}
+			}
+
+			cancel()
+			if err := <-errCh; !errors.Is(err, context.Canceled) {
+				t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_partialBatchFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := createTestEvents(5, source, time.Now().UTC()).vEvents
+	// sink ACKs the first two events and then fails once
+	ce := &fakeCEClient{results: []error{nil, nil, errors.New("fail")}}
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		KVStore:         kv,
+		CpConfig:        CheckpointConfig{Period: time.Millisecond},
+		PayloadEncoding: cloudevents.ApplicationXML,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, &fakeCollector{batches: [][]types.BaseEvent{events}})
+	}()
+
+	var cp checkpoint
+	for cp.LastEventKey != 1004 {
+		select {
+		case data := <-kv.dataChan:
+			if err := json.Unmarshal([]byte(data), &cp); err != nil {
+				t.Fatalf("unmarshal data from KV store: %v", err)
+			}
+		case err := <-errCh:
+			t.Fatalf("readEvents() returned unexpectedly: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for checkpoint key %d, got %d", 1004, cp.LastEventKey)
+		}
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	ce.Lock()
+	defer ce.Unlock()
+	var got []string
+	for _, ev := range ce.sent {
+		got = append(got, ev.ID())
+	}
+	// unsent events are redelivered although the collector advanced
+	want := []string{"1000", "1001", "1002", "1002", "1003", "1004"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("readEvents() sent events (-want +got): %s", diff)
+	}
+}
+
+func Test_vAdapter_readEvents_invalidSendResult(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		err  error
+	}{
+		{name: "nothing sent without error", n: 0},
+		{name: "more than batch sent", n: 4},
+		{name: "more than batch sent with error", n: 4, err: errors.New("send failed")},
+		{name: "negative sent", n: -1},
+		{name: "negative sent with error", n: -1, err: errors.New("send failed")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := createTestEvents(3, source, time.Now().UTC()).vEvents
+			ce := &fakeCEClient{}
+			kv := &fakeKVStore{dataChan: make(chan string, 1)}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				KVStore:         kv,
+				CpConfig:        CheckpointConfig{Period: time.Millisecond},
+				PayloadEncoding: cloudevents.ApplicationXML,
+			}
+			// the sink receives the events but the send result is inconsistent
+			a.sendBaseEvents = func(ctx context.Context, events []types.BaseEvent) (int, error) {
+				if _, err := a.sendEvents(ctx, events); err != nil {
+					return 0, err
+				}
+				return tt.n, tt.err
+			}
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- a.readEvents(context.Background(), &fakeCollector{batches: [][]types.BaseEvent{events}})
+			}()
+
+			select {
+			case err := <-errCh:
+				if err == nil || !strings.Contains(err.Error(), "invalid send result") {
+					t.Errorf("readEvents() error = %v, want invalid send result", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for readEvents() to return")
+			}
+
+			if len(ce.sent) != len(events) {
+				t.Errorf("readEvents() sent %d events, want %d", len(ce.sent), len(events))
+			}
+			if cp, ok := kv.data[CheckpointKey]; ok {
+				t.Errorf("readEvents() advanced checkpoint to %s", cp)
+			}
+		})
+	}
+}
+
+// recordingCollector returns the configured batches and records the
+// requested batch sizes
+type recordingCollector struct {
+	sync.Mutex
+	fakeCollector
+	requested []int32
+}
+
+func (f *recordingCollector) ReadNextEvents(ctx context.Context, size int32) ([]types.BaseEvent, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.requested = append(f.requested, size)
+	return f.fakeCollector.ReadNextEvents(ctx, size)
+}
+
+func Test_vAdapter_readEvents_maxBatchBytes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := createTestEvents(5, source, time.Now().UTC()).vEvents
+	ce := &fakeCEClient{}
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		KVStore:         kv,
+		CpConfig:        CheckpointConfig{Period: time.Millisecond},
+		PayloadEncoding: cloudevents.ApplicationXML,
+		// budget fits two events
+		MaxBatchBytes:   estimateSize(events[0], cloudevents.ApplicationXML)*2 + 1,
+		PollBackoffBase: backoff.Backoff{Factor: 1, Min: time.Millisecond, Max: time.Millisecond},
+	}
+
+	c := &recordingCollector{fakeCollector: fakeCollector{batches: [][]types.BaseEvent{events}}}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, c)
+	}()
+
+	var cp checkpoint
+	for cp.LastEventKey != 1004 {
+		select {
+		case data := <-kv.dataChan:
+			if err := json.Unmarshal([]byte(data), &cp); err != nil {
+				t.Fatalf("unmarshal data from KV store: %v", err)
+			}
+		case err := <-errCh:
+			t.Fatalf("readEvents() returned unexpectedly: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for checkpoint key %d, got %d", 1004, cp.LastEventKey)
+		}
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	if len(ce.sent) != 5 {
+		t.Errorf("readEvents() sent %d events, want 5", len(ce.sent))
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if len(c.requested) < 2 || c.requested[0] != maxEventsBatch {
+		t.Fatalf("readEvents() requested batch sizes %v, want %d followed by reads bound by the budget", c.requested, maxEventsBatch)
+	}
+	for _, size := range c.requested[1:] {
+		if size != 2 {
+			t.Errorf("readEvents() requested batch sizes %v, want 2 after the first read", c.requested)
+			break
+		}
+	}
+}
+
+func Test_vAdapter_readEvents_flushOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := createTestEvents(3, source, time.Now().UTC()).vEvents
+	ce := &fakeCEClient{}
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:   zaptest.NewLogger(t).Sugar(),
+		Source:   source,
+		CEClient: ce,
+		KVStore:  kv,
+		// checkpoint period and write interval never elapse during the test
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		CpMinWrite:      time.Hour,
+		PayloadEncoding: cloudevents.ApplicationXML,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, &fakeCollector{batches: [][]types.BaseEvent{events}})
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		ce.Lock()
+		sent := len(ce.sent)
+		ce.Unlock()
+		if sent == len(events) {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for events to be sent, got %d", sent)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	select {
+	case <-kv.dataChan:
+		t.Fatal("checkpoint saved before shutdown")
+	default:
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	select {
+	case data := <-kv.dataChan:
+		var cp checkpoint
+		if err := json.Unmarshal([]byte(data), &cp); err != nil {
+			t.Fatalf("unmarshal data from KV store: %v", err)
+		}
+		if cp.LastEventKey != 1002 {
+			t.Errorf("flushed checkpoint key = %d, want %d", cp.LastEventKey, 1002)
+		}
+	default:
+		t.Error("checkpoint not flushed on shutdown")
+	}
+}
+
+// blockingCollector returns the configured batch and then blocks reads until
+// the context is canceled
+type blockingCollector struct {
+	batch []types.BaseEvent
+	reads chan struct{}
+}
+
+func (f *blockingCollector) ReadNextEvents(ctx context.Context, _ int32) ([]types.BaseEvent, error) {
+	if f.batch != nil {
+		batch := f.batch
+		f.batch = nil
+		return batch, nil
+	}
+	close(f.reads)
+	<-ctx.Done()
+	return nil, fmt.Errorf("read next events: %w", ctx.Err())
+}
+
+func Test_vAdapter_readEvents_flushOnShutdownDuringRead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:   zaptest.NewLogger(t).Sugar(),
+		Source:   source,
+		CEClient: &fakeCEClient{},
+		KVStore:  kv,
+		// checkpoint period and write interval never elapse during the test
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		CpMinWrite:      time.Hour,
+		PayloadEncoding: cloudevents.ApplicationXML,
+	}
+
+	c := &blockingCollector{batch: createTestEvents(3, source, time.Now().UTC()).vEvents, reads: make(chan struct{})}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, c)
+	}()
+
+	// events sent and blocked in subsequent read
+	select {
+	case <-c.reads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for read")
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	select {
+	case data := <-kv.dataChan:
+		var cp checkpoint
+		if err := json.Unmarshal([]byte(data), &cp); err != nil {
+			t.Fatalf("unmarshal data from KV store: %v", err)
+		}
+		if cp.LastEventKey != 1002 {
+			t.Errorf("flushed checkpoint key = %d, want %d", cp.LastEventKey, 1002)
+		}
+	default:
+		t.Error("checkpoint not flushed on shutdown")
+	}
+}
+
+// idleCollector returns the batch on the first read followed by no events,
+// closing reads on the first read returning no events
+type idleCollector struct {
+	batch []types.BaseEvent
+	reads chan struct{}
+	once  sync.Once
+}
+
+func (f *idleCollector) ReadNextEvents(_ context.Context, _ int32) ([]types.BaseEvent, error) {
+	if f.batch != nil {
+		batch := f.batch
+		f.batch = nil
+		return batch, nil
+	}
+	f.once.Do(func() { close(f.reads) })
+	return nil, nil
+}
+
+func Test_vAdapter_readEvents_flushOnShutdownDuringPollBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:   zaptest.NewLogger(t).Sugar(),
+		Source:   source,
+		CEClient: &fakeCEClient{},
+		KVStore:  kv,
+		// checkpoint period and write interval never elapse during the test
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		CpMinWrite:      time.Hour,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		PollBackoffBase: backoff.Backoff{Factor: 2, Min: time.Hour, Max: time.Hour},
+	}
+
+	c := &idleCollector{batch: createTestEvents(3, source, time.Now().UTC()).vEvents, reads: make(chan struct{})}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, c)
+	}()
+
+	// events sent and backing off after a read returning no events
+	select {
+	case <-c.reads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for read")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readEvents() did not return while backing off after cancellation")
+	}
+
+	select {
+	case data := <-kv.dataChan:
+		var cp checkpoint
+		if err := json.Unmarshal([]byte(data), &cp); err != nil {
+			t.Fatalf("unmarshal data from KV store: %v", err)
+		}
+		if cp.LastEventKey != 1002 {
+			t.Errorf("flushed checkpoint key = %d, want %d", cp.LastEventKey, 1002)
+		}
+	default:
+		t.Error("checkpoint not flushed on shutdown")
+	}
+}
+
+func Test_vAdapter_recordCheckpointHistory(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKVStore{}
+	a := &vAdapter{
+		KVStore:     kv,
+		HistorySize: 2,
+	}
+
+	for i := int32(1); i <= 3; i++ {
+		if err := a.recordCheckpointHistory(ctx, checkpoint{LastEventKey: i}); err != nil {
+			t.Fatalf("recordCheckpointHistory() error = %v", err)
+		}
+	}
+
+	var history []checkpoint
+	if err := kv.Get(ctx, CheckpointHistoryKey, &history); err != nil {
+		t.Fatalf("get checkpoint history: %v", err)
+	}
+	if len(history) != 2 || history[0].LastEventKey != 3 || history[1].LastEventKey != 2 {
+		t.Errorf("recordCheckpointHistory() history = %+v, want keys [3 2]", history)
+	}
+}
+
+// fakeCollector returns the configured batches of events, one per call to
+// ReadNextEvents, followed by empty batches
+type fakeCollector struct {
+	batches [][]types.BaseEvent
+}
+
+func (f *fakeCollector) ReadNextEvents(_ context.Context, _ int32) ([]types.BaseEvent, error) {
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+// fakeCEClient returns the configured results, one per call to Send,
+// followed by ACKs
+type fakeCEClient struct {
+	sync.Mutex
+	results []error
+	sent    []cloudevents.Event
+}
+
+func (f *fakeCEClient) Send(_ context.Context, ev cloudevents.Event) protocol.Result {
+	f.Lock()
+	defer f.Unlock()
+
+	f.sent = append(f.sent, ev)
+	if len(f.results) == 0 {
+		return nil
+	}
+	result := f.results[0]
+	f.results = f.results[1:]
+	return result
+}
+
+func (f *fakeCEClient) Request(_ context.Context, _ cloudevents.Event) (*cloudevents.Event, protocol.Result) {
+	panic("implement me")
+}
+
+func (f *fakeCEClient) StartReceiver(_ context.Context, _ interface{}) error {
+	panic("implement me")
+}
+
 func createCheckpoint(t *testing.T, lastEventTS time.Time) string {
 	t.Helper()
 	cp := checkpoint{
@@ -490,6 +1159,7 @@ type fakeKVStore struct {
 	sync.Mutex
 	data  map[string]string
 	saved bool
+	loads int
 
 	// send last checkpoint saved over this channel (should be buffered)
 	// can be used so sync between read/write goroutines in tests
@@ -501,7 +1171,10 @@ func (f *fakeKVStore) Init(ctx context.Context) error {
 }
 
 func (f *fakeKVStore) Load(ctx context.Context) error {
-	panic("implement me")
+	f.Lock()
+	defer f.Unlock()
+	f.loads++
+	return nil
 }
 
 func (f *fakeKVStore) Save(ctx context.Context) error {
@@ -511,7 +1184,7 @@ func (f *fakeKVStore) Save(ctx context.Context) error {
 		return nil
 	}
 	f.saved = true
-	f.dataChan <- f.data[checkpointKey]
+	f.dataChan <- f.data[CheckpointKey]
 	return nil
 }
 
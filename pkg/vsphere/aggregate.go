@@ -0,0 +1,200 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// content type of CloudEvents sent in batched content mode
+	contentTypeBatch = "application/cloudevents-batch+json"
+
+	// upper bound of events accumulated during an aggregation window
+	maxAggregateEvents = 10 * maxEventsBatch
+
+	// delay between reads when vCenter returns no new events during an
+	// aggregation window
+	aggregatePollInterval = 200 * time.Millisecond
+)
+
+// batchSender sends CloudEvents to the sink in batched content mode, i.e. as
+// a JSON array of structured events in a single HTTP request
+type batchSender struct {
+	target  string
+	headers http.Header
+	client  *http.Client
+}
+
+// newBatchSender returns a batch sender for the sink configured in env using
+// the given HTTP transport. It returns nil if no aggregation window is
+// configured.
+func newBatchSender(env *envConfig, rt http.RoundTripper) (*batchSender, error) {
+	if env.SendAggregateWindow == 0 {
+		return nil, nil
+	}
+
+	if env.SendAggregateWindow < 0 {
+		return nil, fmt.Errorf("invalid aggregation window %s: must not be negative", env.SendAggregateWindow)
+	}
+
+	target := env.GetSink()
+	if target == "" {
+		return nil, fmt.Errorf("aggregation window requires a sink")
+	}
+
+	headers, err := parseSinkHeaders(env.SinkHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	// use dedicated http client to not modify http.DefaultClient
+	client := &http.Client{Transport: rt}
+	if timeout := env.GetSinktimeout(); timeout > 0 {
+		client.Timeout = time.Duration(timeout) * time.Second
+	}
+	if env.SinkFollowRedirects {
+		// redirects are followed by redirectTransport
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return &batchSender{target: target, headers: headers, client: client}, nil
+}
+
+// send sends the given events to the sink in a single request. The batch is
+// ACK-ed or NACK-ed as a whole.
+func (b *batchSender) send(ctx context.Context, events []cloudevents.Event) protocol.Result {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("encode event batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create batch request: %w", err)
+	}
+	for key, values := range b.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentTypeBatch)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send event batch: %w", err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return cehttp.NewResult(resp.StatusCode, "%w", protocol.ResultNACK)
+	}
+	return protocol.ResultACK
+}
+
+// accumulate reads more events after events were read until the aggregation
+// window elapses, ctx is cancelled or maxAggregateEvents are accumulated. On
+// cancellation the events accumulated so far are returned without error so
+// that the caller can decide how to handle them.
+func (a *vAdapter) accumulate(ctx context.Context, c eventCollector, events []types.BaseEvent) ([]types.BaseEvent, error) {
+	logger := logging.FromContext(ctx)
+
+	timer := time.NewTimer(a.AggregateWindow)
+	defer timer.Stop()
+
+	for len(events) < maxAggregateEvents {
+		select {
+		case <-ctx.Done():
+			return events, nil
+		case <-timer.C:
+			return events, nil
+		default:
+		}
+
+		size := a.readBatchSize()
+		if remaining := int32(maxAggregateEvents - len(events)); remaining < size {
+			size = remaining
+		}
+
+		more, err := c.ReadNextEvents(ctx, size)
+		if err != nil {
+			return events, fmt.Errorf("read events from vcenter: %w", err)
+		}
+		a.fillCreatedTime(ctx, more, time.Now().UTC())
+		reportBatchSize(ctx, len(more))
+
+		if a.fastForward != nil {
+			more = a.fastForward.skip(ctx, more)
+		}
+
+		if len(more) > 0 {
+			events = append(events, more...)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return events, nil
+		case <-timer.C:
+			return events, nil
+		case <-time.After(aggregatePollInterval):
+		}
+	}
+
+	logger.Debugw("stopping aggregation: maximum number of events reached", zap.Int("events", len(events)))
+	return events, nil
+}
+
+// sendBatch sends the given cloud events to the sink as a single batch.
+// baseEvents holds the vCenter event of each cloud event.
+func (a *vAdapter) sendBatch(ctx context.Context, events []cloudevents.Event, baseEvents []types.BaseEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	logging.FromContext(ctx).Debugw("sending event batch", zap.Int("events", len(events)))
+
+	result := a.Batch.send(ctx, events)
+	if !cloudevents.IsACK(result) {
+		reportSendFailure(ctx)
+		logging.FromContext(ctx).Errorw("failed to send cloudevent batch", zap.Error(result))
+		a.K8sEvents.warn(ctx, reasonSinkUnreachable, "Failed to send event batch to sink: %v", result)
+		return result
+	}
+	reportEventsSent(ctx, len(events))
+
+	for i, ev := range events {
+		if a.Confirm != nil {
+			if err := a.Confirm.confirm(ctx, baseEvents[i].GetEvent().Key, ev.ID(), ev.Type(), result); err != nil {
+				logging.FromContext(ctx).Warnw("could not confirm event delivery", zap.String("ID", ev.ID()), zap.Error(err))
+			}
+		}
+		if a.Tee != nil && !a.Tee.enqueue(ctx, ev) {
+			logging.FromContext(ctx).Debugw("dropping event for tee sink: queue full", zap.String("ID", ev.ID()))
+		}
+		if a.Archive != nil {
+			if err := a.Archive.add(ctx, ev, baseEvents[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+func Test_newBatchSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  time.Duration
+		sink    string
+		headers string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", window: 0, sink: "http://sink", wantNil: true},
+		{name: "enabled", window: time.Second, sink: "http://sink"},
+		{name: "negative window", window: -time.Second, sink: "http://sink", wantErr: true},
+		{name: "no sink", window: time.Second, wantErr: true},
+		{name: "invalid headers", window: time.Second, sink: "http://sink", headers: "ce-id=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &envConfig{
+				EnvConfig:           adapter.EnvConfig{Sink: tt.sink},
+				SendAggregateWindow: tt.window,
+				SinkHeaders:         tt.headers,
+			}
+
+			got, err := newBatchSender(env, http.DefaultTransport)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newBatchSender() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newBatchSender() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_accumulate(t *testing.T) {
+	first := createTestEvents(2, source, time.Now().UTC()).vEvents
+	second := createTestEvents(3, source, time.Now().UTC()).vEvents
+
+	t.Run("accumulates events until window elapses", func(t *testing.T) {
+		c := &fakeCollector{batches: [][]types.BaseEvent{second}}
+		a := &vAdapter{AggregateWindow: 50 * time.Millisecond}
+
+		start := time.Now()
+		got, err := a.accumulate(context.Background(), c, first)
+		if err != nil {
+			t.Fatalf("accumulate() error = %v", err)
+		}
+		if len(got) != len(first)+len(second) {
+			t.Errorf("accumulate() = %d events, want %d", len(got), len(first)+len(second))
+		}
+		if elapsed := time.Since(start); elapsed < a.AggregateWindow {
+			t.Errorf("accumulate() returned after %v, want at least %v", elapsed, a.AggregateWindow)
+		}
+	})
+
+	t.Run("returns accumulated events on shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		c := &fakeCollector{batches: [][]types.BaseEvent{second}}
+		a := &vAdapter{AggregateWindow: time.Hour}
+
+		got, err := a.accumulate(ctx, c, first)
+		if err != nil {
+			t.Fatalf("accumulate() error = %v", err)
+		}
+		if len(got) != len(first) {
+			t.Errorf("accumulate() = %d events, want %d", len(got), len(first))
+		}
+	})
+}
+
+func Test_vAdapter_sendEvents_batch(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantN   int
+		wantErr bool
+	}{
+		{name: "batch ACK-ed", status: http.StatusAccepted, wantN: 3},
+		{name: "batch rejected", status: http.StatusServiceUnavailable, wantN: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				contentType string
+				received    []cloudevents.Event
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				body, _ := ioutil.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &received); err != nil {
+					t.Errorf("unmarshal batch: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			env := &envConfig{
+				EnvConfig:           adapter.EnvConfig{Sink: srv.URL},
+				SendAggregateWindow: time.Second,
+			}
+			batch, err := newBatchSender(env, http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("newBatchSender() error = %v", err)
+			}
+
+			ce := &fakeCEClient{}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationJSON,
+				Batch:           batch,
+			}
+
+			events := createTestEvents(3, source, time.Now().UTC()).vEvents
+			n, err := a.sendEvents(context.Background(), events)
+			if n != tt.wantN || (err != nil) != tt.wantErr {
+				t.Errorf("sendEvents() = %d, %v, want %d, wantErr %v", n, err, tt.wantN, tt.wantErr)
+			}
+
+			if contentType != contentTypeBatch {
+				t.Errorf("sendEvents() content type = %q, want %q", contentType, contentTypeBatch)
+			}
+			if len(received) != len(events) {
+				t.Errorf("sendEvents() sent batch of %d events, want %d", len(received), len(events))
+			}
+			if len(ce.sent) != 0 {
+				t.Errorf("sendEvents() sent %d single events, want 0", len(ce.sent))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	checkpointAnnotationPrefix = "vspheresources.sources.tanzu.vmware.com/"
+	// last event type in checkpoint
+	annotationLastEventType = checkpointAnnotationPrefix + "last-event-type"
+	// last event timestamp in checkpoint
+	annotationLastEventTimestamp = checkpointAnnotationPrefix + "last-event-timestamp"
+	// lag between last event timestamp and checkpoint creation
+	annotationLag = checkpointAnnotationPrefix + "lag"
+)
+
+// checkpointAnnotator annotates the checkpoint ConfigMap with progress metadata
+// of the event stream
+type checkpointAnnotator struct {
+	client corev1client.ConfigMapInterface
+	name   string
+}
+
+// newCheckpointAnnotator returns an annotator for the given checkpoint
+// ConfigMap
+func newCheckpointAnnotator(client corev1client.ConfigMapInterface, name string) *checkpointAnnotator {
+	return &checkpointAnnotator{
+		client: client,
+		name:   name,
+	}
+}
+
+// annotate sets the progress annotations for the given checkpoint on the
+// checkpoint ConfigMap
+func (c *checkpointAnnotator) annotate(ctx context.Context, cp checkpoint, now time.Time) error {
+	cm, err := c.client.Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get configmap %q: %w", c.name, err)
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[annotationLastEventType] = cp.LastEventType
+	cm.Annotations[annotationLastEventTimestamp] = cp.LastEventKeyTimestamp.Format(time.RFC3339)
+	cm.Annotations[annotationLag] = eventLag(cp.LastEventKeyTimestamp, now).Truncate(time.Second).String()
+
+	if _, err = c.client.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update configmap %q: %w", c.name, err)
+	}
+	return nil
+}
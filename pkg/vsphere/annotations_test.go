@@ -0,0 +1,70 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_checkpointAnnotator_annotate(t *testing.T) {
+	const (
+		namespace = "default"
+		name      = "vsphere-source-checkpoint"
+	)
+
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Annotations: map[string]string{
+				"existing": "annotation",
+			},
+		},
+		Data: map[string]string{
+			CheckpointKey: "{}",
+		},
+	})
+
+	a := newCheckpointAnnotator(client.CoreV1().ConfigMaps(namespace), name)
+	cp := checkpoint{
+		LastEventKey:          1234,
+		LastEventType:         "VmPoweredOnEvent",
+		LastEventKeyTimestamp: now.Add(-90 * time.Second),
+	}
+
+	ctx := context.TODO()
+	if err := a.annotate(ctx, cp, now); err != nil {
+		t.Fatalf("annotate() error = %v", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"existing":                   "annotation",
+		annotationLastEventType:      "VmPoweredOnEvent",
+		annotationLastEventTimestamp: "2020-10-01T11:58:30Z",
+		annotationLag:                "1m30s",
+	}
+	if diff := cmp.Diff(want, cm.Annotations); diff != "" {
+		t.Errorf("annotate() annotations (-want +got): %s", diff)
+	}
+
+	if cm.Data[CheckpointKey] != "{}" {
+		t.Errorf("annotate() modified checkpoint data: %v", cm.Data)
+	}
+}
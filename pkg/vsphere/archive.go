@@ -0,0 +1,322 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// archive to an AWS S3 bucket, e.g. s3://bucket/prefix
+	archiveSchemeS3 = "s3"
+	// archive to a Google Cloud Storage bucket, e.g. gs://bucket/prefix
+	archiveSchemeGCS = "gs"
+
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+
+	// content type and encoding of archived objects
+	archiveContentType     = "application/x-ndjson"
+	archiveContentEncoding = "gzip"
+)
+
+// objectWriter durably writes objects to an object store
+type objectWriter interface {
+	put(ctx context.Context, key string, body []byte) error
+}
+
+// archiver writes events as gzip-compressed newline-delimited JSON (NDJSON)
+// objects of structured CloudEvents to an object store. Events are buffered
+// until the uncompressed buffer reaches maxBytes or the oldest buffered event
+// is older than maxInterval. Events are only checkpointed once written.
+type archiver struct {
+	writer      objectWriter
+	prefix      string
+	maxBytes    int
+	maxInterval time.Duration
+	now         func() time.Time
+
+	buf bytes.Buffer
+	// first and last buffered event
+	first, last types.BaseEvent
+	// time the first event was buffered
+	opened time.Time
+	// last event durably written to the object store
+	written types.BaseEvent
+}
+
+// newArchiver returns an archiver for the archive sink configured in env. It
+// returns nil if no archive sink is configured.
+func newArchiver(ctx context.Context, env *envConfig) (*archiver, error) {
+	if env.ArchiveSink == "" {
+		if env.ArchiveOnly {
+			return nil, errors.New("archive only mode requires an archive sink")
+		}
+		return nil, nil
+	}
+
+	u, err := url.Parse(env.ArchiveSink)
+	if err != nil {
+		return nil, fmt.Errorf("parse archive sink: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid archive sink %q: bucket must be set", env.ArchiveSink)
+	}
+
+	if env.ArchiveMaxBytes <= 0 || env.ArchiveMaxInterval <= 0 {
+		return nil, errors.New("archive maximum bytes and interval must be greater than 0")
+	}
+
+	var writer objectWriter
+	switch u.Scheme {
+	case archiveSchemeS3:
+		creds, err := awsCredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if writer, err = newS3Writer(env.ArchiveEndpoint, u.Host, env.ArchiveRegion, creds); err != nil {
+			return nil, err
+		}
+	case archiveSchemeGCS:
+		ts, err := google.DefaultTokenSource(ctx, gcsScope)
+		if err != nil {
+			return nil, fmt.Errorf("get Google credentials: %w", err)
+		}
+		client := &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &oauth2.Transport{
+				Source: ts,
+				Base:   http.DefaultTransport,
+			},
+		}
+		writer = newGCSWriter(env.ArchiveEndpoint, u.Host, client)
+	default:
+		return nil, fmt.Errorf("unsupported archive sink scheme %q: must be %s or %s", u.Scheme, archiveSchemeS3, archiveSchemeGCS)
+	}
+
+	prefix := strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &archiver{
+		writer:      writer,
+		prefix:      prefix,
+		maxBytes:    env.ArchiveMaxBytes,
+		maxInterval: env.ArchiveMaxInterval,
+		now:         time.Now,
+	}, nil
+}
+
+// add buffers the given cloud event created from be and writes the buffer if
+// it reaches the maximum size. Write failures are logged and the events are
+// kept buffered to be written with the next object.
+func (a *archiver) add(ctx context.Context, ev cloudevents.Event, be types.BaseEvent) error {
+	line, err := ev.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("encode archived event: %w", err)
+	}
+
+	if a.first == nil {
+		a.first = be
+		a.opened = a.now()
+	}
+	a.last = be
+	a.buf.Write(line)
+	a.buf.WriteByte('\n')
+
+	if a.buf.Len() >= a.maxBytes {
+		if err = a.flush(ctx); err != nil {
+			logging.FromContext(ctx).Errorw("could not write event archive", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// due returns true if the oldest buffered event exceeds the maximum interval
+func (a *archiver) due() bool {
+	return a.first != nil && a.now().Sub(a.opened) >= a.maxInterval
+}
+
+// flush writes the buffered events as a single object
+func (a *archiver) flush(ctx context.Context) error {
+	if a.first == nil {
+		return nil
+	}
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if _, err := zw.Write(a.buf.Bytes()); err != nil {
+		return fmt.Errorf("compress event archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress event archive: %w", err)
+	}
+
+	key := a.objectKey()
+	if err := a.writer.put(ctx, key, body.Bytes()); err != nil {
+		return fmt.Errorf("write event archive %q: %w", key, err)
+	}
+
+	logging.FromContext(ctx).Debugw("wrote event archive", zap.String("key", key), zap.Int("bytes", body.Len()))
+	a.written = a.last
+	a.first, a.last = nil, nil
+	a.buf.Reset()
+	return nil
+}
+
+// objectKey returns the object key of the buffered events, e.g.
+// prefix/2020/10/01/120000-1000-1099.ndjson.gz
+func (a *archiver) objectKey() string {
+	first := a.first.GetEvent()
+	return fmt.Sprintf("%s%s-%d-%d.ndjson.gz", a.prefix, first.CreatedTime.UTC().Format("2006/01/02/150405"),
+		first.Key, a.last.GetEvent().Key)
+}
+
+// checkpointEvent returns the event to checkpoint given the last processed
+// event, i.e. the last written event while events are buffered. If nothing is
+// buffered all processed events are durable and processed is returned, or the
+// last written event if processed is nil.
+func (a *archiver) checkpointEvent(processed types.BaseEvent) types.BaseEvent {
+	if a.first != nil || processed == nil {
+		return a.written
+	}
+	return processed
+}
+
+// archiveCheckpoint writes the archive if due or force is true and returns
+// the event to checkpoint given the last processed event. It returns nil if
+// no event can be checkpointed.
+func (a *vAdapter) archiveCheckpoint(ctx context.Context, processed types.BaseEvent, force bool) types.BaseEvent {
+	if force || a.Archive.due() {
+		if err := a.Archive.flush(ctx); err != nil {
+			logging.FromContext(ctx).Errorw("could not write event archive", zap.Error(err))
+		}
+	}
+	return a.Archive.checkpointEvent(processed)
+}
+
+// s3Writer writes objects to an AWS S3 bucket using path-style requests
+type s3Writer struct {
+	endpoint    string
+	bucket      string
+	region      string
+	credentials awsCredentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+// newS3Writer returns a writer for the given S3 bucket. If endpoint is empty
+// the regional AWS endpoint is used.
+func newS3Writer(endpoint, bucket, region string, creds awsCredentials) (*s3Writer, error) {
+	if region == "" {
+		return nil, errors.New("archive region must be set for S3")
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	return &s3Writer{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		bucket:      bucket,
+		region:      region,
+		credentials: creds,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		now:         time.Now,
+	}, nil
+}
+
+func (w *s3Writer) put(ctx context.Context, key string, body []byte) error {
+	target := fmt.Sprintf("%s/%s/%s", w.endpoint, url.PathEscape(w.bucket), escapeObjectKey(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create S3 request: %w", err)
+	}
+
+	hash := sha256.Sum256(body)
+	req.Header.Set("Content-Type", archiveContentType)
+	req.Header.Set("Content-Encoding", archiveContentEncoding)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+	signAWSRequest(req, body, "s3", w.region, w.credentials, w.now().UTC())
+
+	return doObjectRequest(w.client, req)
+}
+
+// gcsWriter writes objects to a Google Cloud Storage bucket using the JSON
+// API media upload
+type gcsWriter struct {
+	endpoint string
+	bucket   string
+	client   *http.Client
+}
+
+// newGCSWriter returns a writer for the given GCS bucket. If endpoint is empty
+// the public Google Cloud Storage endpoint is used.
+func newGCSWriter(endpoint, bucket string, client *http.Client) *gcsWriter {
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+	return &gcsWriter{endpoint: strings.TrimSuffix(endpoint, "/"), bucket: bucket, client: client}
+}
+
+func (w *gcsWriter) put(ctx context.Context, key string, body []byte) error {
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", w.endpoint,
+		url.PathEscape(w.bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create GCS request: %w", err)
+	}
+	req.Header.Set("Content-Type", archiveContentType)
+	req.Header.Set("Content-Encoding", archiveContentEncoding)
+
+	return doObjectRequest(w.client, req)
+}
+
+// escapeObjectKey escapes each segment of the given object key
+func escapeObjectKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// doObjectRequest sends the given object store request and returns an error if
+// the object was not written
+func doObjectRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
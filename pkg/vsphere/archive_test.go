@@ -0,0 +1,222 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// fakeObjectWriter records written objects and fails if err is set
+type fakeObjectWriter struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (f *fakeObjectWriter) put(_ context.Context, key string, body []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.objects == nil {
+		f.objects = make(map[string][]byte)
+	}
+	f.objects[key] = body
+	return nil
+}
+
+func newTestCloudEvent(t *testing.T, be types.BaseEvent) cloudevents.Event {
+	t.Helper()
+	ev := cloudevents.NewEvent()
+	ev.SetID(be.GetEvent().CreatedTime.String())
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.VmPoweredOnEvent.v0")
+	if err := ev.SetData(cloudevents.ApplicationJSON, map[string]int32{"key": be.GetEvent().Key}); err != nil {
+		t.Fatalf("set data: %v", err)
+	}
+	return ev
+}
+
+func Test_newArchiver(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     envConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "archive only without sink", env: envConfig{ArchiveOnly: true}, wantErr: true},
+		{name: "unsupported scheme", env: envConfig{ArchiveSink: "ftp://bucket", ArchiveMaxBytes: 1, ArchiveMaxInterval: time.Minute}, wantErr: true},
+		{name: "missing bucket", env: envConfig{ArchiveSink: "s3:///prefix", ArchiveMaxBytes: 1, ArchiveMaxInterval: time.Minute}, wantErr: true},
+		{name: "invalid thresholds", env: envConfig{ArchiveSink: "s3://bucket/prefix"}, wantErr: true},
+		{name: "S3 without region", env: envConfig{ArchiveSink: "s3://bucket/prefix", ArchiveMaxBytes: 1, ArchiveMaxInterval: time.Minute}, wantErr: true},
+		{name: "S3", env: envConfig{ArchiveSink: "s3://bucket/prefix", ArchiveRegion: "us-west-2", ArchiveMaxBytes: 1, ArchiveMaxInterval: time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+			got, err := newArchiver(context.Background(), &tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newArchiver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("newArchiver() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got != nil && got.prefix != "prefix/" {
+				t.Errorf("newArchiver() prefix = %q, want %q", got.prefix, "prefix/")
+			}
+		})
+	}
+}
+
+func Test_archiver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := createTestEvents(3, source, now).vEvents
+
+	writer := &fakeObjectWriter{}
+	a := &archiver{
+		writer:      writer,
+		prefix:      "vc-01/",
+		maxBytes:    1 << 20,
+		maxInterval: time.Minute,
+		now:         func() time.Time { return now },
+	}
+
+	for _, be := range events[:2] {
+		if err := a.add(ctx, newTestCloudEvent(t, be), be); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+
+	if got := a.checkpointEvent(events[1]); got != nil {
+		t.Errorf("checkpointEvent() = %v while buffered, want nil", got)
+	}
+	if a.due() {
+		t.Error("due() = true before maximum interval, want false")
+	}
+
+	// write fails: events kept buffered
+	writer.err = errors.New("bucket unavailable")
+	if err := a.flush(ctx); err == nil {
+		t.Fatal("flush() error = nil, want error")
+	}
+	writer.err = nil
+
+	now = now.Add(time.Minute)
+	if !a.due() {
+		t.Fatal("due() = false after maximum interval, want true")
+	}
+	if err := a.flush(ctx); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	const key = "vc-01/2020/10/01/120000-1000-1001.ndjson.gz"
+	body, ok := writer.objects[key]
+	if !ok {
+		t.Fatalf("flush() wrote objects %v, want %q", writer.objects, key)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("decompress archive: %v", err)
+	}
+	var lines int
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var ev cloudevents.Event
+		if err = ev.UnmarshalJSON(scanner.Bytes()); err != nil {
+			t.Fatalf("archived line %d is not a structured cloud event: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("archive contains %d events, want 2", lines)
+	}
+
+	// nothing buffered: processed events are durable
+	if got := a.checkpointEvent(events[2]); got != events[2] {
+		t.Errorf("checkpointEvent() = %v, want processed event", got)
+	}
+	if got := a.checkpointEvent(nil); got != events[1] {
+		t.Errorf("checkpointEvent(nil) = %v, want last written event", got)
+	}
+
+	// maximum size reached
+	a.maxBytes = 1
+	if err = a.add(ctx, newTestCloudEvent(t, events[2]), events[2]); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if len(writer.objects) != 2 || a.written != events[2] {
+		t.Errorf("add() wrote %d objects, want 2 after reaching maximum size", len(writer.objects))
+	}
+}
+
+func Test_s3Writer_put(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		_, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	w, err := newS3Writer(srv.URL, "bucket", "us-west-2", awsCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("newS3Writer() error = %v", err)
+	}
+
+	if err = w.put(context.Background(), "vc-01/2020/10/01/120000-1-2.ndjson.gz", []byte("body")); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+
+	if req.Method != http.MethodPut || req.URL.Path != "/bucket/vc-01/2020/10/01/120000-1-2.ndjson.gz" {
+		t.Errorf("put() request = %s %s, want PUT to bucket object", req.Method, req.URL.Path)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("put() Authorization = %q, want AWS signature", req.Header.Get("Authorization"))
+	}
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		t.Error("put() X-Amz-Content-Sha256 not set")
+	}
+}
+
+func Test_gcsWriter_put(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		_, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	w := newGCSWriter(srv.URL, "bucket", srv.Client())
+	if err := w.put(context.Background(), "vc-01/a.ndjson.gz", []byte("body")); err == nil {
+		t.Error("put() error = nil for rejected upload, want error")
+	}
+
+	if req.URL.Path != "/upload/storage/v1/b/bucket/o" || req.URL.Query().Get("name") != "vc-01/a.ndjson.gz" {
+		t.Errorf("put() request URL = %s, want media upload of object", req.URL)
+	}
+	if req.Header.Get("Content-Encoding") != archiveContentEncoding {
+		t.Errorf("put() Content-Encoding = %q, want %q", req.Header.Get("Content-Encoding"), archiveContentEncoding)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// backfillTracker reports the progress of replaying events while the event
+// stream lags behind vCenter by more than a threshold, e.g. when catching up
+// from an old checkpoint. Progress is estimated by the timestamp of the last
+// processed event relative to the begin and target (vCenter time at start) of
+// the replay.
+type backfillTracker struct {
+	threshold time.Duration
+	interval  time.Duration
+
+	begin      time.Time
+	target     time.Time
+	processed  int64
+	active     bool
+	lastReport time.Time
+}
+
+// newBackfillTracker returns a tracker reporting progress at the given interval
+// while the lag exceeds threshold. It returns nil if threshold is 0, i.e.
+// reporting is disabled.
+func newBackfillTracker(threshold, interval time.Duration) *backfillTracker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &backfillTracker{
+		threshold: threshold,
+		interval:  interval,
+	}
+}
+
+// start sets the begin and target time of the event stream
+func (b *backfillTracker) start(begin, target time.Time) {
+	b.begin = begin
+	b.target = target
+}
+
+// progress returns the estimated fraction [0,1] of the replay window processed
+// for the given event timestamp
+func (b *backfillTracker) progress(ts time.Time) float64 {
+	window := b.target.Sub(b.begin)
+	if window <= 0 {
+		return 1
+	}
+
+	p := float64(ts.Sub(b.begin)) / float64(window)
+	switch {
+	case p < 0:
+		return 0
+	case p > 1:
+		return 1
+	default:
+		return p
+	}
+}
+
+// update records n processed events with ts being the creation time of the
+// last processed event and reports progress if the stream is catching up and
+// the report interval has elapsed
+func (b *backfillTracker) update(ctx context.Context, n int, ts, now time.Time) {
+	b.processed += int64(n)
+	lag := eventLag(ts, now)
+	logger := logging.FromContext(ctx)
+
+	if lag < b.threshold {
+		if b.active {
+			logger.Infow("backfill completed", zap.Int64("processedEvents", b.processed),
+				zap.Duration("lag", lag))
+			reportBackfill(ctx, b.processed, 1)
+			b.active = false
+		}
+		return
+	}
+
+	if b.active && now.Sub(b.lastReport) < b.interval {
+		return
+	}
+
+	b.active = true
+	b.lastReport = now
+
+	p := b.progress(ts)
+	logger.Infow("backfill in progress", zap.Int64("processedEvents", b.processed),
+		zap.String("eventTimestamp", ts.String()), zap.String("targetTimestamp", b.target.String()),
+		zap.Duration("lag", lag), zap.Float64("progress", p))
+	reportBackfill(ctx, b.processed, p)
+}
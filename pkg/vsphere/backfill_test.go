@@ -0,0 +1,75 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_backfillTracker_progress(t *testing.T) {
+	begin := time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC)
+	target := begin.Add(2 * time.Hour)
+
+	b := newBackfillTracker(5*time.Minute, 30*time.Second)
+	b.start(begin, target)
+
+	tests := []struct {
+		name string
+		ts   time.Time
+		want float64
+	}{
+		{name: "begin", ts: begin, want: 0},
+		{name: "half way", ts: begin.Add(time.Hour), want: 0.5},
+		{name: "target", ts: target, want: 1},
+		{name: "before begin", ts: begin.Add(-time.Hour), want: 0},
+		{name: "after target", ts: target.Add(time.Hour), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.progress(tt.ts); got != tt.want {
+				t.Errorf("progress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_backfillTracker_update(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	b := newBackfillTracker(5*time.Minute, 30*time.Second)
+	b.start(now.Add(-2*time.Hour), now)
+
+	// catching up: first update reports
+	b.update(ctx, 10, now.Add(-time.Hour), now)
+	if !b.active || !b.lastReport.Equal(now) {
+		t.Fatalf("update() expected backfill report, active = %v, lastReport = %v", b.active, b.lastReport)
+	}
+
+	// within report interval: no report
+	b.update(ctx, 10, now.Add(-50*time.Minute), now.Add(10*time.Second))
+	if !b.lastReport.Equal(now) {
+		t.Errorf("update() unexpected report within interval, lastReport = %v", b.lastReport)
+	}
+
+	// caught up
+	b.update(ctx, 5, now.Add(time.Minute), now.Add(2*time.Minute))
+	if b.active {
+		t.Error("update() expected backfill to be completed")
+	}
+
+	if b.processed != 25 {
+		t.Errorf("update() processed = %d, want %d", b.processed, 25)
+	}
+}
+
+func Test_newBackfillTracker(t *testing.T) {
+	if b := newBackfillTracker(0, time.Minute); b != nil {
+		t.Errorf("newBackfillTracker() = %v, want nil for disabled threshold", b)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// backlogNoticeEventType is the CloudEvent type of the notice sent on
+	// startup before the event stream is replayed
+	backlogNoticeEventType = "com.vmware.vsphere.adapter.backlognotice.v0"
+)
+
+// backlogNotice describes the backlog of events the adapter is about to
+// replay from vCenter
+type backlogNotice struct {
+	// Begin is the computed begin of the event stream
+	Begin time.Time `json:"begin"`
+	// VCenterTime is the current vCenter time, i.e. the end of the backlog
+	VCenterTime time.Time `json:"vcenterTime"`
+	// WindowSeconds is the estimated backlog window in seconds
+	WindowSeconds float64 `json:"windowSeconds"`
+	// Clamped is true if the checkpoint was older than the maximum replay
+	// window and the begin was clamped to it
+	Clamped bool `json:"clamped"`
+	// CheckpointEventKey is the last event key of the checkpoint, if any
+	CheckpointEventKey int32 `json:"checkpointEventKey,omitempty"`
+	// CheckpointTimestamp is the last event timestamp of the checkpoint, if
+	// any
+	CheckpointTimestamp *time.Time `json:"checkpointTimestamp,omitempty"`
+}
+
+// newBacklogNotice returns the notice for replaying events starting at begin
+// from the given checkpoint
+func newBacklogNotice(begin, vcTime time.Time, cp checkpoint, maxAge time.Duration) backlogNotice {
+	window := vcTime.Sub(begin)
+	if window < 0 {
+		window = 0
+	}
+
+	notice := backlogNotice{
+		Begin:         begin,
+		VCenterTime:   vcTime,
+		WindowSeconds: window.Seconds(),
+		Clamped:       checkpointExpired(vcTime, cp, maxAge),
+	}
+
+	if !cp.LastEventKeyTimestamp.IsZero() {
+		ts := cp.LastEventKeyTimestamp
+		notice.CheckpointEventKey = cp.LastEventKey
+		notice.CheckpointTimestamp = &ts
+	}
+	return notice
+}
+
+// sendBacklogNotice sends a backlog notice CloudEvent to the sink. Failures
+// are logged and do not prevent the adapter from starting.
+func (a *vAdapter) sendBacklogNotice(ctx context.Context, notice backlogNotice) {
+	logger := logging.FromContext(ctx)
+
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetID(fmt.Sprintf("backlog-%d", notice.VCenterTime.UnixNano()))
+	ev.SetSource(a.Source)
+	ev.SetType(backlogNoticeEventType)
+	ev.SetTime(notice.VCenterTime)
+	if err := ev.SetData(cloudevents.ApplicationJSON, notice); err != nil {
+		logger.Warnw("could not encode backlog notice", zap.Error(err))
+		return
+	}
+
+	if result := a.CEClient.Send(ctx, ev); !cloudevents.IsACK(result) {
+		logger.Warnw("could not send backlog notice", zap.Error(result))
+		return
+	}
+	logger.Infow("sent backlog notice", zap.Time("begin", notice.Begin),
+		zap.Float64("windowSeconds", notice.WindowSeconds), zap.Bool("clamped", notice.Clamped))
+}
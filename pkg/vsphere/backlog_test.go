@@ -0,0 +1,119 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newBacklogNotice(t *testing.T) {
+	vcTime := time.Date(2020, 7, 2, 12, 0, 0, 0, time.UTC)
+	maxAge := time.Hour
+
+	tests := []struct {
+		name        string
+		cp          checkpoint
+		wantWindow  float64
+		wantClamped bool
+		wantKey     int32
+	}{
+		{
+			name:       "no checkpoint",
+			cp:         checkpoint{},
+			wantWindow: 0,
+		},
+		{
+			name: "checkpoint within replay window",
+			cp: checkpoint{
+				LastEventKey:          1000,
+				LastEventKeyTimestamp: vcTime.Add(-30 * time.Minute),
+			},
+			wantWindow: 1800,
+			wantKey:    1000,
+		},
+		{
+			name: "checkpoint older than replay window",
+			cp: checkpoint{
+				LastEventKey:          1000,
+				LastEventKeyTimestamp: vcTime.Add(-3 * time.Hour),
+			},
+			wantWindow:  3600,
+			wantClamped: true,
+			wantKey:     1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			begin := getBeginFromCheckpoint(context.Background(), vcTime, tt.cp, maxAge)
+			got := newBacklogNotice(begin, vcTime, tt.cp, maxAge)
+
+			if got.WindowSeconds != tt.wantWindow {
+				t.Errorf("newBacklogNotice() WindowSeconds = %v, want %v", got.WindowSeconds, tt.wantWindow)
+			}
+			if got.Clamped != tt.wantClamped {
+				t.Errorf("newBacklogNotice() Clamped = %v, want %v", got.Clamped, tt.wantClamped)
+			}
+			if got.CheckpointEventKey != tt.wantKey {
+				t.Errorf("newBacklogNotice() CheckpointEventKey = %d, want %d", got.CheckpointEventKey, tt.wantKey)
+			}
+			if (got.CheckpointTimestamp == nil) != tt.cp.LastEventKeyTimestamp.IsZero() {
+				t.Errorf("newBacklogNotice() CheckpointTimestamp = %v, want %v", got.CheckpointTimestamp, tt.cp.LastEventKeyTimestamp)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendBacklogNotice(t *testing.T) {
+	vcTime := time.Date(2020, 7, 2, 12, 0, 0, 0, time.UTC)
+	notice := newBacklogNotice(vcTime.Add(-time.Hour), vcTime, checkpoint{}, 0)
+
+	t.Run("notice sent", func(t *testing.T) {
+		ce := &fakeCEClient{}
+		a := &vAdapter{
+			Logger:   zaptest.NewLogger(t).Sugar(),
+			Source:   source,
+			CEClient: ce,
+		}
+		a.sendBacklogNotice(context.Background(), notice)
+
+		if len(ce.sent) != 1 {
+			t.Fatalf("sendBacklogNotice() sent %d events, want 1", len(ce.sent))
+		}
+
+		ev := ce.sent[0]
+		if ev.Type() != backlogNoticeEventType || ev.Source() != source {
+			t.Errorf("sendBacklogNotice() type, source = %q, %q, want %q, %q", ev.Type(), ev.Source(), backlogNoticeEventType, source)
+		}
+
+		var got backlogNotice
+		if err := json.Unmarshal(ev.Data(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.WindowSeconds != 3600 || !got.Begin.Equal(notice.Begin) {
+			t.Errorf("sendBacklogNotice() data = %+v, want %+v", got, notice)
+		}
+	})
+
+	t.Run("send failure is ignored", func(t *testing.T) {
+		ce := &fakeCEClient{results: []error{errors.New("sink unavailable")}}
+		a := &vAdapter{
+			Logger:   zaptest.NewLogger(t).Sugar(),
+			Source:   source,
+			CEClient: ce,
+		}
+		a.sendBacklogNotice(context.Background(), notice)
+
+		if len(ce.sent) != 1 {
+			t.Errorf("sendBacklogNotice() sent %d events, want 1", len(ce.sent))
+		}
+	})
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// validateReadBatchSize returns an error if the given number of events to
+// request per vCenter read is out of range
+func validateReadBatchSize(size int32) error {
+	if size < 1 || size > maxEventsBatchLimit {
+		return fmt.Errorf("batch size %d must be between 1 and %d", size, maxEventsBatchLimit)
+	}
+	return nil
+}
+
+// readBatchSize returns the number of events to request with the next vCenter
+// read, i.e. the configured batch size unless reads are widened
+func (a *vAdapter) readBatchSize() int32 {
+	if a.Window != nil {
+		return a.Window.batchSize()
+	}
+	if a.ReadBatchSize == 0 {
+		return maxEventsBatch
+	}
+	return a.ReadBatchSize
+}
+
+// splitBatch returns the leading events of the given events which fit into
+// the byte budget maxBytes, estimated by the serialized size cached in sizes,
+// and the remaining events. The returned batch always contains at least one
+// event, even if it exceeds the budget. If maxBytes is 0 all events are
+// returned.
+func splitBatch(events []types.BaseEvent, maxBytes int, sizes *eventSizes) (batch, rest []types.BaseEvent) {
+	if maxBytes <= 0 {
+		return events, nil
+	}
+
+	var size int
+	for i, be := range events {
+		size += sizes.size(be)
+		if size > maxBytes && i > 0 {
+			return events[:i], events[i:]
+		}
+	}
+
+	return events, nil
+}
+
+// budgetedReadSize returns the number of events to request from vCenter, up
+// to size, so the events read fit into the byte budget maxBytes given the
+// average serialized size of recent events avgBytes. This bounds the memory
+// used by a read instead of only splitting large reads into batches. size is
+// returned if maxBytes or avgBytes is 0.
+func budgetedReadSize(size int32, maxBytes, avgBytes int) int32 {
+	if maxBytes <= 0 || avgBytes <= 0 {
+		return size
+	}
+
+	n := maxBytes / avgBytes
+	if n < 1 {
+		return 1
+	}
+	if n < int(size) {
+		return int32(n)
+	}
+	return size
+}
+
+// eventSizes caches the estimated serialized size of events until they are
+// sent, so events requeued after a failed send are not serialized again on
+// every iteration
+type eventSizes struct {
+	encoding string
+	sizes    map[types.BaseEvent]int
+}
+
+func newEventSizes(encoding string) *eventSizes {
+	return &eventSizes{encoding: encoding, sizes: make(map[types.BaseEvent]int)}
+}
+
+// size returns the estimated size in bytes of the given event
+func (s *eventSizes) size(be types.BaseEvent) int {
+	if n, ok := s.sizes[be]; ok {
+		return n
+	}
+	n := estimateSize(be, s.encoding)
+	s.sizes[be] = n
+	return n
+}
+
+// average returns the average estimated size in bytes of the given events
+func (s *eventSizes) average(events []types.BaseEvent) int {
+	if len(events) == 0 {
+		return 0
+	}
+	var total int
+	for _, be := range events {
+		total += s.size(be)
+	}
+	return total / len(events)
+}
+
+// forget removes the cached sizes of the given events, e.g. once sent
+func (s *eventSizes) forget(events []types.BaseEvent) {
+	for _, be := range events {
+		delete(s.sizes, be)
+	}
+}
+
+// requeueEvents returns the given unsent events followed by the pending events
+// not yet sent, i.e. the events to send with the next iterations
+func requeueEvents(unsent, pending []types.BaseEvent) []types.BaseEvent {
+	events := make([]types.BaseEvent, 0, len(unsent)+len(pending))
+	events = append(events, unsent...)
+	return append(events, pending...)
+}
+
+// estimateSize returns the estimated size in bytes of the given event when
+// serialized with the given payload encoding
+func estimateSize(be types.BaseEvent, encoding string) int {
+	var (
+		b   []byte
+		err error
+	)
+
+	switch encoding {
+	case cloudevents.ApplicationJSON:
+		b, err = marshalJSONEvent(be, false)
+	case PayloadEncodingEventV1:
+		b, err = marshalEventV1(be)
+	default:
+		b, err = xml.Marshal(be)
+	}
+
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// sortEvents sorts the given events in place by creation time, using the event
+// key as secondary key for events with the same creation time
+func sortEvents(events []types.BaseEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		ei, ej := events[i].GetEvent(), events[j].GetEvent()
+		if !ei.CreatedTime.Equal(ej.CreatedTime) {
+			return ei.CreatedTime.Before(ej.CreatedTime)
+		}
+		return ei.Key < ej.Key
+	})
+}
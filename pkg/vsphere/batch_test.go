@@ -0,0 +1,190 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_splitBatch(t *testing.T) {
+	events := make([]types.BaseEvent, 3)
+	for i := range events {
+		events[i] = &types.VmPoweredOnEvent{
+			VmEvent: types.VmEvent{
+				Event: types.Event{
+					Key:                  int32(i),
+					FullFormattedMessage: "vm powered on",
+				},
+			},
+		}
+	}
+
+	size := estimateSize(events[0], cloudevents.ApplicationXML)
+	if size == 0 {
+		t.Fatal("estimateSize() returned 0")
+	}
+
+	tests := []struct {
+		name      string
+		maxBytes  int
+		wantBatch int
+		wantRest  int
+	}{
+		{
+			name:      "no budget",
+			maxBytes:  0,
+			wantBatch: 3,
+			wantRest:  0,
+		},
+		{
+			name:      "budget fits all events",
+			maxBytes:  size * 3,
+			wantBatch: 3,
+			wantRest:  0,
+		},
+		{
+			name:      "budget fits two events",
+			maxBytes:  size*2 + 1,
+			wantBatch: 2,
+			wantRest:  1,
+		},
+		{
+			name:      "budget smaller than first event",
+			maxBytes:  1,
+			wantBatch: 1,
+			wantRest:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch, rest := splitBatch(events, tt.maxBytes, newEventSizes(cloudevents.ApplicationXML))
+			if len(batch) != tt.wantBatch {
+				t.Errorf("splitBatch() batch = %d events, want %d", len(batch), tt.wantBatch)
+			}
+			if len(rest) != tt.wantRest {
+				t.Errorf("splitBatch() rest = %d events, want %d", len(rest), tt.wantRest)
+			}
+			if len(batch) > 0 && batch[0].GetEvent().Key != 0 {
+				t.Errorf("splitBatch() did not preserve event order")
+			}
+		})
+	}
+}
+
+func Test_sortEvents(t *testing.T) {
+	now := time.Now().UTC()
+
+	events := []types.BaseEvent{
+		createBaseEvent(1003, now.Add(time.Second)),
+		createBaseEvent(1002, now),
+		createBaseEvent(1000, now.Add(2*time.Second)),
+		createBaseEvent(1001, now),
+	}
+
+	sortEvents(events)
+
+	want := []int32{1001, 1002, 1003, 1000}
+	for i, be := range events {
+		if got := be.GetEvent().Key; got != want[i] {
+			t.Errorf("sortEvents() key at %d = %d, want %d", i, got, want[i])
+		}
+	}
+}
+
+func Test_validateReadBatchSize(t *testing.T) {
+	tests := []struct {
+		size    int32
+		wantErr bool
+	}{
+		{size: 0, wantErr: true},
+		{size: 1},
+		{size: maxEventsBatch},
+		{size: maxEventsBatchLimit},
+		{size: maxEventsBatchLimit + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateReadBatchSize(tt.size); (err != nil) != tt.wantErr {
+			t.Errorf("validateReadBatchSize(%d) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+		}
+	}
+}
+
+func Test_vAdapter_readBatchSize(t *testing.T) {
+	window, err := newCollectorWindow(1000, 0.8, 20, nil)
+	if err != nil {
+		t.Fatalf("newCollectorWindow() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		a    *vAdapter
+		want int32
+	}{
+		{name: "default", a: &vAdapter{}, want: maxEventsBatch},
+		{name: "configured", a: &vAdapter{ReadBatchSize: 10}, want: 10},
+		{name: "collector window", a: &vAdapter{ReadBatchSize: 20, Window: window}, want: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.readBatchSize(); got != tt.want {
+				t.Errorf("readBatchSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_budgetedReadSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		avgBytes int
+		want     int32
+	}{
+		{name: "no budget", avgBytes: 1000, want: 100},
+		{name: "no average yet", maxBytes: 10000, want: 100},
+		{name: "budget fits fewer events", maxBytes: 10000, avgBytes: 1000, want: 10},
+		{name: "budget fits more events", maxBytes: 1000000, avgBytes: 1000, want: 100},
+		{name: "budget smaller than one event", maxBytes: 100, avgBytes: 1000, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetedReadSize(100, tt.maxBytes, tt.avgBytes); got != tt.want {
+				t.Errorf("budgetedReadSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventSizes(t *testing.T) {
+	be := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1, FullFormattedMessage: "vm powered on"}}}
+	sizes := newEventSizes(cloudevents.ApplicationXML)
+
+	size := sizes.size(be)
+	if size != estimateSize(be, cloudevents.ApplicationXML) {
+		t.Fatalf("size() = %d, want %d", size, estimateSize(be, cloudevents.ApplicationXML))
+	}
+
+	// cached sizes are not estimated again
+	be.FullFormattedMessage = "vm powered on after a much longer message"
+	if got := sizes.size(be); got != size {
+		t.Errorf("size() = %d after change, want cached %d", got, size)
+	}
+	if got := sizes.average([]types.BaseEvent{be, be}); got != size {
+		t.Errorf("average() = %d, want %d", got, size)
+	}
+
+	sizes.forget([]types.BaseEvent{be})
+	if len(sizes.sizes) != 0 {
+		t.Errorf("forget() kept %d sizes, want 0", len(sizes.sizes))
+	}
+	if got := sizes.size(be); got <= size {
+		t.Errorf("size() = %d after forget, want estimate of changed event above %d", got, size)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ceVSphereChainSeq is the CloudEvent extension holding the position (starting
+// at 1) of an event within its event chain (ChainId)
+const ceVSphereChainSeq = "vspherechainseq"
+
+// chainSequencer tracks the number of events sent per event chain. Sequences
+// are best-effort: counters are kept in memory and reset on restart, and the
+// least recently used chains are evicted when the cache is full, so a chain
+// spanning a restart or eviction restarts at 1.
+type chainSequencer struct {
+	counters *lru.Cache
+}
+
+// newChainSequencer returns a sequencer tracking up to size chains
+func newChainSequencer(size int) (*chainSequencer, error) {
+	counters, err := lru.New(size)
+	if err != nil {
+		return nil, fmt.Errorf("create chain sequence cache: %w", err)
+	}
+	return &chainSequencer{counters: counters}, nil
+}
+
+// next returns the position of the given event within its chain
+func (s *chainSequencer) next(be types.BaseEvent) int {
+	chainID := be.GetEvent().ChainId
+
+	seq := 1
+	if v, ok := s.counters.Get(chainID); ok {
+		seq = v.(int) + 1
+	}
+	s.counters.Add(chainID, seq)
+	return seq
+}
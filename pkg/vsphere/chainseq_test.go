@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func chainEvent(key, chainID int32) types.BaseEvent {
+	be := createBaseEvent(int(key), time.Now().UTC())
+	be.GetEvent().ChainId = chainID
+	return be
+}
+
+func Test_newChainSequencer(t *testing.T) {
+	if _, err := newChainSequencer(0); err == nil {
+		t.Error("newChainSequencer(0) error = nil, want error")
+	}
+}
+
+func Test_chainSequencer_next(t *testing.T) {
+	s, err := newChainSequencer(2)
+	if err != nil {
+		t.Fatalf("newChainSequencer() error = %v", err)
+	}
+
+	steps := []struct {
+		event types.BaseEvent
+		want  int
+	}{
+		{event: chainEvent(1000, 1000), want: 1},
+		{event: chainEvent(1001, 1000), want: 2},
+		{event: chainEvent(1002, 1002), want: 1},
+		{event: chainEvent(1003, 1000), want: 3},
+		// evicts least recently used chain 1002
+		{event: chainEvent(1004, 1004), want: 1},
+		{event: chainEvent(1005, 1002), want: 1},
+	}
+	for _, step := range steps {
+		if got := s.next(step.event); got != step.want {
+			t.Errorf("next(key %d, chain %d) = %d, want %d", step.event.GetEvent().Key,
+				step.event.GetEvent().ChainId, got, step.want)
+		}
+	}
+}
+
+func Test_vAdapter_sendEvents_chainSeq(t *testing.T) {
+	s, err := newChainSequencer(10)
+	if err != nil {
+		t.Fatalf("newChainSequencer() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		ChainSeq:        s,
+	}
+
+	events := []types.BaseEvent{chainEvent(1000, 1000), chainEvent(1001, 1000)}
+	if _, err = a.sendEvents(context.Background(), events); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+
+	for i, ev := range ce.sent {
+		if got, want := ev.Extensions()[ceVSphereChainSeq], int32(i+1); got != want {
+			t.Errorf("sendEvents() event %s %s = %v (%T), want %d", ev.ID(), ceVSphereChainSeq, got, got, want)
+		}
+	}
+}
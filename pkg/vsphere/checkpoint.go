@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 const (
@@ -16,12 +18,20 @@ const (
 	CheckpointDefaultAge = 5 * time.Minute
 	// create checkpoint every frequency but only on changes
 	CheckpointDefaultPeriod = 10 * time.Second
+	// timeout for flushing the last checkpoint on shutdown
+	checkpointFlushTimeout = 5 * time.Second
 	// key name used in KV store for storing the latest checkpoint
-	checkpointKey = "checkpoint"
+	CheckpointKey = "checkpoint"
+	// key name used in KV store for storing the recent checkpoints
+	CheckpointHistoryKey = "checkpointHistory"
+	// maximum number of retained checkpoints to stay within ConfigMap size
+	// limits
+	maxCheckpointHistory = 100
 )
 
 var (
 	ErrInvalidInterval = errors.New("invalid checkpoint time interval")
+	ErrDataLoss        = errors.New("potential data loss")
 )
 
 // checkpoint represents a vCenter checkpoint object
@@ -38,6 +48,48 @@ type checkpoint struct {
 	CreatedTimestamp time.Time `json:"createdTimestamp"`
 }
 
+// appendCheckpointHistory prepends cp to the given history of checkpoints,
+// newest first, retaining at most size entries
+func appendCheckpointHistory(history []checkpoint, cp checkpoint, size int) []checkpoint {
+	history = append([]checkpoint{cp}, history...)
+	if len(history) > size {
+		history = history[:size]
+	}
+	return history
+}
+
+// checkpointExpired returns true if the last event timestamp in the given
+// checkpoint is older than maxAge relative to vcTime, i.e. events between the
+// checkpoint and the begin of the replay window will not be replayed. An empty
+// checkpoint or a maxAge of 0 (replay disabled) never expires.
+func checkpointExpired(vcTime time.Time, cp checkpoint, maxAge time.Duration) bool {
+	cpTime := cp.LastEventKeyTimestamp
+	if cpTime.IsZero() || maxAge == 0 {
+		return false
+	}
+	return vcTime.Add(maxAge*-1).Unix() > cpTime.Unix()
+}
+
+// eventLag returns the lag of an event created at ts relative to now
+func eventLag(ts, now time.Time) time.Duration {
+	return now.Sub(ts)
+}
+
+// checkpointLag returns the lag of the last checkpointed event relative to
+// now. Until an event is sent the lag of the restored checkpoint is returned
+// or, without a checkpoint, the lag of the begin of the event stream, i.e. the
+// lag is also reported if sending fails from the start.
+func checkpointLag(lastEvent types.BaseEvent, restored, begin, now time.Time) time.Duration {
+	switch {
+	case lastEvent != nil:
+		return eventLag(lastEvent.GetEvent().CreatedTime, now)
+	case !restored.IsZero():
+		return eventLag(restored, now)
+	default:
+		return eventLag(begin, now)
+	}
+}
+
 // CheckpointConfig influences the checkpoint behavior. It configures the
 // maximum age of the replay (look-back) window when starting the event stream
 // and the period of saving the checkpoint
@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func Test_checkpointConfig_UnmarshalJSON(t *testing.T) {
@@ -197,3 +199,98 @@ func Test_newCheckpointConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_appendCheckpointHistory(t *testing.T) {
+	var history []checkpoint
+	for i := int32(1); i <= 4; i++ {
+		history = appendCheckpointHistory(history, checkpoint{LastEventKey: i}, 3)
+	}
+
+	var got []int32
+	for _, cp := range history {
+		got = append(got, cp.LastEventKey)
+	}
+
+	want := []int32{4, 3, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("appendCheckpointHistory() keys = %v, want %v", got, want)
+	}
+}
+
+func Test_checkpointExpired(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name   string
+		cp     checkpoint
+		maxAge time.Duration
+		want   bool
+	}{
+		{
+			name:   "empty checkpoint",
+			maxAge: time.Hour,
+			want:   false,
+		},
+		{
+			name:   "checkpoint within replay window",
+			cp:     checkpoint{LastEventKeyTimestamp: now.Add(-time.Minute)},
+			maxAge: time.Hour,
+			want:   false,
+		},
+		{
+			name:   "checkpoint older than replay window",
+			cp:     checkpoint{LastEventKeyTimestamp: now.Add(-2 * time.Hour)},
+			maxAge: time.Hour,
+			want:   true,
+		},
+		{
+			name:   "replay disabled",
+			cp:     checkpoint{LastEventKeyTimestamp: now.Add(-2 * time.Hour)},
+			maxAge: 0,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkpointExpired(now, tt.cp, tt.maxAge); got != tt.want {
+				t.Errorf("checkpointExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkpointLag(t *testing.T) {
+	now := time.Now().UTC()
+	restored := now.Add(-time.Hour)
+	begin := now.Add(-5 * time.Minute)
+
+	tests := []struct {
+		name      string
+		lastEvent types.BaseEvent
+		restored  time.Time
+		want      time.Duration
+	}{
+		{
+			name:      "sent event",
+			lastEvent: createBaseEvent(1, now.Add(-time.Minute)),
+			restored:  restored,
+			want:      time.Minute,
+		},
+		{
+			name:     "nothing sent with restored checkpoint",
+			restored: restored,
+			want:     time.Hour,
+		},
+		{
+			name: "nothing sent without checkpoint",
+			want: 5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkpointLag(tt.lastEvent, tt.restored, begin, now); got != tt.want {
+				t.Errorf("checkpointLag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
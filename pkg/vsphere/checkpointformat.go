@@ -0,0 +1,114 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// checkpoint stored as JSON object under CheckpointKey
+	checkpointFormatJSON = "json"
+	// checkpoint stored as flat set of keys, one per checkpoint field
+	checkpointFormatFlat = "flat"
+)
+
+// ConfigMap keys of the flat checkpoint format. Values are JSON encoded by
+// the KV store, i.e. numbers are stored verbatim and strings quoted.
+const (
+	FlatCheckpointVCenterKey    = "vCenter"
+	FlatCheckpointLastEventKey  = "lastEventKey"
+	FlatCheckpointLastEventType = "lastEventType"
+	FlatCheckpointTimestampKey  = "lastEventKeyTimestamp"
+	FlatCheckpointCreatedAtKey  = "createdTimestamp"
+)
+
+// flatCheckpointKeys returns the ConfigMap keys of the flat checkpoint format
+// and pointers to the respective fields of cp
+func flatCheckpointKeys(cp *checkpoint) map[string]interface{} {
+	return map[string]interface{}{
+		FlatCheckpointVCenterKey:    &cp.VCenter,
+		FlatCheckpointLastEventKey:  &cp.LastEventKey,
+		FlatCheckpointLastEventType: &cp.LastEventType,
+		FlatCheckpointTimestampKey:  &cp.LastEventKeyTimestamp,
+		FlatCheckpointCreatedAtKey:  &cp.CreatedTimestamp,
+	}
+}
+
+// RemoveFlatCheckpoint removes a checkpoint stored in the flat format from
+// the given ConfigMap data, e.g. before restoring a checkpoint in the JSON
+// format which would otherwise be shadowed by the flat checkpoint
+func RemoveFlatCheckpoint(data map[string]string) {
+	for key := range flatCheckpointKeys(&checkpoint{}) {
+		delete(data, key)
+	}
+}
+
+// validateCheckpointFormat returns an error if the given checkpoint format is
+// not supported
+func validateCheckpointFormat(format string) error {
+	switch format {
+	case checkpointFormatJSON, checkpointFormatFlat:
+		return nil
+	default:
+		return fmt.Errorf("unsupported checkpoint format %q: must be %s or %s", format, checkpointFormatJSON, checkpointFormatFlat)
+	}
+}
+
+// storeCheckpoint sets cp in the KV store in the configured format
+func (a *vAdapter) storeCheckpoint(ctx context.Context, cp checkpoint) error {
+	if a.CpFormat != checkpointFormatFlat {
+		return a.KVStore.Set(ctx, CheckpointKey, cp)
+	}
+
+	for key, field := range flatCheckpointKeys(&cp) {
+		if err := a.KVStore.Set(ctx, key, field); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// loadCheckpoint returns the checkpoint from the KV store in the configured
+// format. For backward compatibility the other format is read if no
+// checkpoint is stored in the configured format, e.g. after changing the
+// format.
+func (a *vAdapter) loadCheckpoint(ctx context.Context) (checkpoint, error) {
+	if a.CpFormat == checkpointFormatFlat {
+		cp, err := a.loadFlatCheckpoint(ctx)
+		if err == nil {
+			return cp, nil
+		}
+		if jsonErr := a.KVStore.Get(ctx, CheckpointKey, &cp); jsonErr != nil {
+			return checkpoint{}, err
+		}
+		return cp, nil
+	}
+
+	var cp checkpoint
+	err := a.KVStore.Get(ctx, CheckpointKey, &cp)
+	if err == nil {
+		return cp, nil
+	}
+	if cp, flatErr := a.loadFlatCheckpoint(ctx); flatErr == nil {
+		return cp, nil
+	}
+	return checkpoint{}, err
+}
+
+// loadFlatCheckpoint returns the checkpoint stored in the flat format. The
+// event key and timestamp are required, the remaining fields are optional.
+func (a *vAdapter) loadFlatCheckpoint(ctx context.Context) (checkpoint, error) {
+	var cp checkpoint
+	for key, field := range flatCheckpointKeys(&cp) {
+		err := a.KVStore.Get(ctx, key, field)
+		if err != nil && (key == FlatCheckpointLastEventKey || key == FlatCheckpointTimestampKey) {
+			return checkpoint{}, fmt.Errorf("get flat checkpoint: %w", err)
+		}
+	}
+	return cp, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_validateCheckpointFormat(t *testing.T) {
+	for _, format := range []string{checkpointFormatJSON, checkpointFormatFlat} {
+		if err := validateCheckpointFormat(format); err != nil {
+			t.Errorf("validateCheckpointFormat(%q) error = %v", format, err)
+		}
+	}
+	if err := validateCheckpointFormat("yaml"); err == nil {
+		t.Error("validateCheckpointFormat() error = nil for unsupported format, want error")
+	}
+}
+
+func Test_vAdapter_storeCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	cp := checkpoint{
+		VCenter:               source,
+		LastEventKey:          1042,
+		LastEventType:         "VmPoweredOnEvent",
+		LastEventKeyTimestamp: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+		CreatedTimestamp:      time.Date(2020, 10, 1, 12, 0, 1, 0, time.UTC),
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		kv := &fakeKVStore{data: make(map[string]string)}
+		a := &vAdapter{KVStore: kv, CpFormat: checkpointFormatFlat}
+
+		if err := a.storeCheckpoint(ctx, cp); err != nil {
+			t.Fatalf("storeCheckpoint() error = %v", err)
+		}
+		if got := kv.data[FlatCheckpointLastEventKey]; got != "1042" {
+			t.Errorf("storeCheckpoint() %s = %q, want %q", FlatCheckpointLastEventKey, got, "1042")
+		}
+		if _, ok := kv.data[CheckpointKey]; ok {
+			t.Errorf("storeCheckpoint() set %s in flat format", CheckpointKey)
+		}
+
+		got, err := a.loadCheckpoint(ctx)
+		if err != nil {
+			t.Fatalf("loadCheckpoint() error = %v", err)
+		}
+		if got != cp {
+			t.Errorf("loadCheckpoint() = %+v, want %+v", got, cp)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		kv := &fakeKVStore{data: make(map[string]string)}
+		a := &vAdapter{KVStore: kv, CpFormat: checkpointFormatJSON}
+
+		if err := a.storeCheckpoint(ctx, cp); err != nil {
+			t.Fatalf("storeCheckpoint() error = %v", err)
+		}
+		if _, ok := kv.data[FlatCheckpointLastEventKey]; ok {
+			t.Errorf("storeCheckpoint() set %s in json format", FlatCheckpointLastEventKey)
+		}
+
+		got, err := a.loadCheckpoint(ctx)
+		if err != nil {
+			t.Fatalf("loadCheckpoint() error = %v", err)
+		}
+		if got != cp {
+			t.Errorf("loadCheckpoint() = %+v, want %+v", got, cp)
+		}
+	})
+}
+
+func Test_vAdapter_loadCheckpoint_compatibility(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	jsonData := map[string]string{CheckpointKey: `{"lastEventKey":1000,"lastEventKeyTimestamp":"2020-10-01T12:00:00Z"}`}
+	flatData := map[string]string{
+		FlatCheckpointLastEventKey: "1001",
+		FlatCheckpointTimestampKey: `"2020-10-01T12:00:00Z"`,
+	}
+	both := map[string]string{}
+	for k, v := range jsonData {
+		both[k] = v
+	}
+	for k, v := range flatData {
+		both[k] = v
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		data    map[string]string
+		wantKey int32
+		wantErr bool
+	}{
+		{name: "json reads flat", format: checkpointFormatJSON, data: flatData, wantKey: 1001},
+		{name: "flat reads json", format: checkpointFormatFlat, data: jsonData, wantKey: 1000},
+		{name: "json preferred", format: checkpointFormatJSON, data: both, wantKey: 1000},
+		{name: "flat preferred", format: checkpointFormatFlat, data: both, wantKey: 1001},
+		{name: "incomplete flat", format: checkpointFormatFlat, data: map[string]string{FlatCheckpointLastEventKey: "1001"}, wantErr: true},
+		{name: "no checkpoint", format: checkpointFormatFlat, data: map[string]string{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &vAdapter{KVStore: &fakeKVStore{data: tt.data}, CpFormat: tt.format}
+			got, err := a.loadCheckpoint(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadCheckpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.LastEventKey != tt.wantKey || !got.LastEventKeyTimestamp.Equal(ts) {
+				t.Errorf("loadCheckpoint() = %+v, want event key %d", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestRemoveFlatCheckpoint(t *testing.T) {
+	data := map[string]string{
+		CheckpointKey:              "{}",
+		FlatCheckpointLastEventKey: "1001",
+		FlatCheckpointTimestampKey: `"2020-10-01T12:00:00Z"`,
+	}
+	RemoveFlatCheckpoint(data)
+	if len(data) != 1 {
+		t.Errorf("RemoveFlatCheckpoint() left %v, want only %s", data, CheckpointKey)
+	}
+}
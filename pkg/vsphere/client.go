@@ -8,16 +8,20 @@ package vsphere
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/session/keepalive"
+	"github.com/vmware/govmomi/sts"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
@@ -31,12 +35,32 @@ const (
 	VolumeName        = "vsphere-binding"
 	DefaultMountPath  = "/var/bindings/vsphere" // filepath.Join isn't const.
 	keepaliveInterval = 5 * time.Minute         // vCenter APIs keep-alive
+
+	// AuthModePassword authenticates with the username and password keys of
+	// the secret (default)
+	AuthModePassword = "password"
+	// AuthModeToken authenticates with the SAML bearer token in the TokenKey
+	// key of the secret, e.g. issued by the vCenter SSO token exchange
+	AuthModeToken = "token"
+	// TokenKey is the secret key holding the token used with AuthModeToken
+	TokenKey = "token"
 )
 
 type EnvConfig struct {
-	Insecure   bool   `envconfig:"VC_INSECURE" default:"false"`
+	Insecure bool `envconfig:"VC_INSECURE" default:"false"`
+	// Address is the vCenter URL. The unix scheme, e.g.
+	// unix:///var/run/vcenter.sock, connects over a Unix domain socket proxy.
 	Address    string `envconfig:"VC_URL" required:"true"`
 	SecretPath string `envconfig:"VC_SECRET_PATH" default:""`
+	// AddressSRV optionally names a DNS SRV record which is resolved to the
+	// vCenter host and port at connect time, overriding the host in Address
+	AddressSRV string `envconfig:"VSPHERE_ADDR_SRV" default:""`
+	// ReconnectMinInterval is the minimum interval enforced between
+	// successive login attempts to vCenter
+	ReconnectMinInterval time.Duration `envconfig:"VSPHERE_RECONNECT_MIN_INTERVAL" default:"0s"`
+	// AuthMode selects the vCenter authentication (password or token). The
+	// token is read from the secret on every login to support rotation.
+	AuthMode string `envconfig:"VSPHERE_AUTH_MODE" default:"password"`
 }
 
 // ReadKey reads the key from the secret.
@@ -60,33 +84,120 @@ func ReadKey(key string) (string, error) {
 
 // NewSOAPClient returns a vCenter SOAP API client with active keep-alive. Use
 // Logout() to release resources and perform a clean logout from vCenter.
+// Invalid configuration and credentials are returned as a *StartupError of
+// kind ConfigError.
 func NewSOAPClient(ctx context.Context) (*govmomi.Client, error) {
 	var env EnvConfig
 	if err := envconfig.Process("", &env); err != nil {
-		return nil, err
+		return nil, configError("%w", err)
 	}
 
 	parsedURL, err := soap.ParseURL(env.Address)
 	if err != nil {
-		return nil, err
+		return nil, configError("invalid vCenter address: %w", err)
 	}
 
-	// Read the username and password from the filesystem.
-	username, err := ReadKey(corev1.BasicAuthUsernameKey)
+	creds, err := readCredentials(env.AuthMode)
 	if err != nil {
-		return nil, err
+		return nil, configError("%w", err)
+	}
+	parsedURL.User = creds.user
+
+	return connectSOAP(ctx, parsedURL, env, creds.token)
+}
+
+// credentials holds either the user or the token used to log in to vCenter
+type credentials struct {
+	user  *url.Userinfo
+	token string
+}
+
+// readCredentials reads the credentials for the given authentication mode
+// from the filesystem
+func readCredentials(mode string) (credentials, error) {
+	switch mode {
+	case AuthModePassword, "":
+		username, err := ReadKey(corev1.BasicAuthUsernameKey)
+		if err != nil {
+			return credentials{}, err
+		}
+		password, err := ReadKey(corev1.BasicAuthPasswordKey)
+		if err != nil {
+			return credentials{}, err
+		}
+		return credentials{user: url.UserPassword(username, password)}, nil
+	case AuthModeToken:
+		token, err := ReadKey(TokenKey)
+		if err != nil {
+			return credentials{}, err
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return credentials{}, errors.New("vCenter token must not be empty")
+		}
+		return credentials{token: token}, nil
+	default:
+		return credentials{}, fmt.Errorf("unsupported vCenter authentication mode %q", mode)
 	}
-	password, err := ReadKey(corev1.BasicAuthPasswordKey)
+}
+
+// connectSOAP returns a SOAP client with active keep-alive connected to the
+// given URL. If an SRV record is configured it is resolved on every call and
+// the discovered targets are tried in order until a connection succeeds. The
+// session is created with token if not empty, otherwise with the user of u.
+func connectSOAP(ctx context.Context, u *url.URL, env EnvConfig, token string) (*govmomi.Client, error) {
+	u, socket := unixSocketURL(u)
+	if env.AddressSRV == "" {
+		if err := waitLogin(ctx, env.ReconnectMinInterval); err != nil {
+			return nil, err
+		}
+		return soapWithKeepalive(ctx, u, env.Insecure, socket, token)
+	}
+
+	urls, err := resolveSRV(u, env.AddressSRV)
 	if err != nil {
 		return nil, err
 	}
-	parsedURL.User = url.UserPassword(username, password)
 
-	return soapWithKeepalive(ctx, parsedURL, env.Insecure)
+	logger := logging.FromContext(ctx)
+	for _, target := range urls {
+		if err = waitLogin(ctx, env.ReconnectMinInterval); err != nil {
+			return nil, err
+		}
+
+		var c *govmomi.Client
+		c, err = soapWithKeepalive(ctx, target, env.Insecure, socket, token)
+		if err == nil {
+			logger.Infow("connected to discovered vCenter", "srv", env.AddressSRV, "host", target.Host)
+			return c, nil
+		}
+		logger.Warnw("could not connect to discovered vCenter", "srv", env.AddressSRV, "host", target.Host, "error", err)
+	}
+	return nil, err
 }
 
-func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmomi.Client, error) {
-	soapClient := soap.NewClient(url, insecure)
+// waitLogin waits until the minimum interval since the previous login attempt
+// has passed
+func waitLogin(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+
+	delay, err := soapLogins.wait(ctx, interval)
+	if err != nil {
+		return err
+	}
+
+	reportReconnectWait(ctx, delay)
+	if delay > 0 {
+		logging.FromContext(ctx).Infow("delayed vCenter login to enforce minimum reconnect interval",
+			"delay", delay.String(), "minInterval", interval.String())
+	}
+	return nil
+}
+
+func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool, socket, token string) (*govmomi.Client, error) {
+	soapClient := newSOAPClient(url, insecure, socket)
 	vimClient, err := vim25.NewClient(ctx, soapClient)
 	if err != nil {
 		return nil, err
@@ -95,7 +206,12 @@ func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmo
 
 	// explicitly create session to activate keep-alive handler via Login
 	m := session.NewManager(vimClient)
-	err = m.Login(ctx, url.User)
+	if token != "" {
+		signer := &sts.Signer{Token: token}
+		err = m.LoginByToken(vimClient.WithHeader(ctx, soap.Header{Security: signer}))
+	} else {
+		err = m.Login(ctx, url.User)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +224,44 @@ func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmo
 	return &c, nil
 }
 
+// newSOAPClient returns a SOAP client for the given URL. If socket is not empty
+// connections are established over the given Unix domain socket instead of
+// TCP.
+func newSOAPClient(u *url.URL, insecure bool, socket string) *soap.Client {
+	soapClient := soap.NewClient(u, insecure)
+	if socket != "" {
+		dialer := net.Dialer{}
+		soapClient.DefaultTransport().DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+	return soapClient
+}
+
+// unixSocketURL returns the HTTP URL and socket path for a vCenter address
+// using the unix scheme, e.g. unix:///var/run/vcenter.sock?host=vc.local. The
+// (mesh) proxy listening on the socket is expected to originate TLS to
+// vCenter, i.e. plain HTTP is used over the socket. The optional host query
+// parameter sets the HTTP host (default localhost). For other schemes u is
+// returned unmodified and socket is empty.
+func unixSocketURL(u *url.URL) (*url.URL, string) {
+	if u.Scheme != "unix" {
+		return u, ""
+	}
+
+	host := u.Query().Get("host")
+	if host == "" {
+		host = "localhost"
+	}
+
+	return &url.URL{
+		Scheme: "http",
+		User:   u.User,
+		Host:   host,
+		Path:   "/sdk",
+	}, u.Path
+}
+
 func soapKeepAliveHandler(ctx context.Context, c *vim25.Client) func() error {
 	logger := logging.FromContext(ctx).With("rpc", "keepalive")
 
@@ -136,18 +290,13 @@ func NewRESTClient(ctx context.Context) (*rest.Client, error) {
 		return nil, err
 	}
 
-	// Read the username and password from the filesystem.
-	username, err := ReadKey(corev1.BasicAuthUsernameKey)
-	if err != nil {
-		return nil, err
-	}
-	password, err := ReadKey(corev1.BasicAuthPasswordKey)
+	creds, err := readCredentials(env.AuthMode)
 	if err != nil {
 		return nil, err
 	}
-	parsedURL.User = url.UserPassword(username, password)
+	parsedURL.User = creds.user
 
-	soapclient, err := soapWithKeepalive(ctx, parsedURL, env.Insecure)
+	soapclient, err := connectSOAP(ctx, parsedURL, env, creds.token)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +305,12 @@ func NewRESTClient(ctx context.Context) (*rest.Client, error) {
 	restclient.Transport = keepalive.NewHandlerREST(restclient, keepaliveInterval, restKeepAliveHandler(ctx, restclient))
 
 	// Login activates the keep-alive handler
-	if err := restclient.Login(ctx, parsedURL.User); err != nil {
+	if creds.token != "" {
+		err = restclient.LoginByToken(restclient.WithSigner(ctx, &sts.Signer{Token: creds.token}))
+	} else {
+		err = restclient.Login(ctx, parsedURL.User)
+	}
+	if err != nil {
 		return nil, err
 	}
 	return restclient, nil
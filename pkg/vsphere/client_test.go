@@ -0,0 +1,206 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func Test_unixSocketURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    string
+		wantURL    string
+		wantSocket string
+	}{
+		{
+			name:    "TCP address",
+			address: "https://vcenter.example.com/sdk",
+			wantURL: "https://vcenter.example.com/sdk",
+		},
+		{
+			name:       "unix socket",
+			address:    "unix:///var/run/vcenter.sock",
+			wantURL:    "http://localhost/sdk",
+			wantSocket: "/var/run/vcenter.sock",
+		},
+		{
+			name:       "unix socket with host",
+			address:    "unix:///var/run/vcenter.sock?host=vcenter.example.com",
+			wantURL:    "http://vcenter.example.com/sdk",
+			wantSocket: "/var/run/vcenter.sock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := soap.ParseURL(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u.User = nil
+
+			gotURL, gotSocket := unixSocketURL(u)
+			if gotURL.String() != tt.wantURL {
+				t.Errorf("unixSocketURL() url = %v, want %v", gotURL, tt.wantURL)
+			}
+			if gotSocket != tt.wantSocket {
+				t.Errorf("unixSocketURL() socket = %v, want %v", gotSocket, tt.wantSocket)
+			}
+		})
+	}
+}
+
+func Test_newSOAPClient_unixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "vcenter.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host string
+	srv := http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.WriteHeader(http.StatusOK)
+	})}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	u, err := soap.ParseURL("unix://" + socket + "?host=vcenter.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, path := unixSocketURL(u)
+
+	c := newSOAPClient(u, false, path)
+	resp, err := c.Client.Get(u.String())
+	if err != nil {
+		t.Fatalf("request over unix socket: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if host != "vcenter.example.com" {
+		t.Errorf("request host = %q, want %q", host, "vcenter.example.com")
+	}
+}
+
+func writeSecret(t *testing.T, data map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for k, v := range data {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), []byte(v), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_readCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		secret    map[string]string
+		wantUser  string
+		wantToken string
+		wantErr   bool
+	}{
+		{
+			name:     "password",
+			mode:     AuthModePassword,
+			secret:   map[string]string{"username": "user", "password": "pass"},
+			wantUser: "user",
+		},
+		{
+			name:     "default mode",
+			mode:     "",
+			secret:   map[string]string{"username": "user", "password": "pass"},
+			wantUser: "user",
+		},
+		{
+			name:    "missing password",
+			mode:    AuthModePassword,
+			secret:  map[string]string{"username": "user"},
+			wantErr: true,
+		},
+		{
+			name:      "token",
+			mode:      AuthModeToken,
+			secret:    map[string]string{TokenKey: "<saml2:Assertion/>\n"},
+			wantToken: "<saml2:Assertion/>",
+		},
+		{
+			name:    "empty token",
+			mode:    AuthModeToken,
+			secret:  map[string]string{TokenKey: " \n"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported mode",
+			mode:    "kerberos",
+			secret:  map[string]string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VC_URL", "https://vcenter.example.com")
+			t.Setenv("VC_SECRET_PATH", writeSecret(t, tt.secret))
+
+			got, err := readCredentials(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.user.Username() != tt.wantUser {
+				t.Errorf("readCredentials() user = %q, want %q", got.user.Username(), tt.wantUser)
+			}
+			if got.token != tt.wantToken {
+				t.Errorf("readCredentials() token = %q, want %q", got.token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func Test_NewSOAPClient_token(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		u := *vim.URL()
+		u.User = nil
+
+		token := `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion">` +
+			`<saml2:Subject><saml2:NameID>adapter@vsphere.local</saml2:NameID></saml2:Subject>` +
+			`</saml2:Assertion>`
+
+		t.Setenv("VC_URL", u.String())
+		t.Setenv("VC_INSECURE", "true")
+		t.Setenv("VSPHERE_AUTH_MODE", AuthModeToken)
+		t.Setenv("VC_SECRET_PATH", writeSecret(t, map[string]string{TokenKey: token}))
+
+		c, err := NewSOAPClient(ctx)
+		if err != nil {
+			t.Fatalf("NewSOAPClient() error = %v", err)
+		}
+		defer func() { _ = c.Logout(context.Background()) }()
+
+		s, err := c.SessionManager.UserSession(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s == nil || s.UserName != "adapter@vsphere.local" {
+			t.Errorf("NewSOAPClient() session = %+v, want user adapter@vsphere.local", s)
+		}
+	})
+}
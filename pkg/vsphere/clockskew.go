@@ -0,0 +1,39 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// checkClockSkew reports the clock skew between adapter and vCenter. A warning
+// is logged if the skew exceeds ClockSkewWarn and an error is returned if the
+// skew exceeds MaxClockSkew (if set) since replaying events from a checkpoint
+// relies on comparing adapter and vCenter time.
+func (a *vAdapter) checkClockSkew(ctx context.Context, skew time.Duration) error {
+	reportClockSkew(ctx, skew)
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+
+	if a.MaxClockSkew > 0 && abs > a.MaxClockSkew {
+		return fmt.Errorf("clock skew between adapter and vCenter %v exceeds maximum %v", skew, a.MaxClockSkew)
+	}
+
+	if a.ClockSkewWarn > 0 && abs > a.ClockSkewWarn {
+		logging.FromContext(ctx).Warnw("clock skew between adapter and vCenter exceeds threshold, "+
+			"event replay from checkpoint might be inaccurate", zap.String("skew", skew.String()),
+			zap.String("threshold", a.ClockSkewWarn.String()))
+	}
+	return nil
+}
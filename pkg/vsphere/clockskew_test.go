@@ -0,0 +1,80 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"knative.dev/pkg/logging"
+)
+
+func Test_vAdapter_checkClockSkew(t *testing.T) {
+	tests := []struct {
+		name        string
+		skew        time.Duration
+		warn        time.Duration
+		max         time.Duration
+		wantWarning bool
+		wantErr     bool
+	}{
+		{
+			name: "skew below threshold",
+			skew: time.Second,
+			warn: 30 * time.Second,
+		},
+		{
+			name:        "skew above warning threshold",
+			skew:        time.Minute,
+			warn:        30 * time.Second,
+			wantWarning: true,
+		},
+		{
+			name:        "negative skew above warning threshold",
+			skew:        -time.Minute,
+			warn:        30 * time.Second,
+			wantWarning: true,
+		},
+		{
+			name:    "skew above maximum",
+			skew:    -time.Hour,
+			warn:    30 * time.Second,
+			max:     10 * time.Minute,
+			wantErr: true,
+		},
+		{
+			name:        "maximum disabled",
+			skew:        time.Hour,
+			warn:        30 * time.Second,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logs), zap.WarnLevel)
+			ctx := logging.WithLogger(context.Background(), zap.New(core).Sugar())
+
+			a := &vAdapter{
+				ClockSkewWarn: tt.warn,
+				MaxClockSkew:  tt.max,
+			}
+
+			err := a.checkClockSkew(ctx, tt.skew)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkClockSkew() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotWarning := logs.Len() > 0; gotWarning != tt.wantWarning {
+				t.Errorf("checkClockSkew() logged warning = %v, want %v", gotWarning, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// maximum number of consecutive collector recreations without a successful
+// read before readEvents fails
+const maxCollectorRecreations = 3
+
+// collectorFactory creates an event collector starting at begin
+type collectorFactory func(ctx context.Context, begin time.Time) (eventCollector, error)
+
+// collectorRecoverable returns true if the given ReadNextEvents error is
+// caused by the state of the event collector, e.g. the collector was destroyed
+// by vCenter, and can be recovered by recreating the collector within the
+// existing session. Session failures, e.g. NotAuthenticated, require a new
+// session, see sessionExpired.
+func collectorRecoverable(err error) bool {
+	var fault interface{}
+	switch {
+	case soap.IsSoapFault(err):
+		fault = soap.ToSoapFault(err).VimFault()
+	case soap.IsVimFault(err):
+		fault = soap.ToVimFault(err)
+	default:
+		return false
+	}
+
+	switch fault.(type) {
+	case types.ManagedObjectNotFound, *types.ManagedObjectNotFound:
+		return true
+	default:
+		return false
+	}
+}
+
+// recreateCollector returns a new event collector resuming after the given
+// last successfully sent event or, if nil, at the begin of the event stream.
+// Events up to and including the last event are skipped.
+func (a *vAdapter) recreateCollector(ctx context.Context, last types.BaseEvent, cause error) (eventCollector, error) {
+	begin := a.collectorBegin
+	if last != nil {
+		begin = last.GetEvent().CreatedTime
+		a.fastForward = newFastForward(checkpoint{
+			LastEventKey:          last.GetEvent().Key,
+			LastEventKeyTimestamp: begin,
+		})
+	}
+
+	logging.FromContext(ctx).Warnw("recreating event collector after recoverable read error",
+		zap.Time("begin", begin), zap.Error(cause))
+	a.K8sEvents.warn(ctx, reasonVCenterReconnect, "Recreating vCenter event collector after read error: %v", cause)
+
+	c, err := a.newCollector(ctx, begin)
+	if err != nil {
+		return nil, fmt.Errorf("recreate event collector: %w", err)
+	}
+	return c, nil
+}
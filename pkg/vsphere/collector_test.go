@@ -0,0 +1,171 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// failingCollector returns the configured batches followed by err
+type failingCollector struct {
+	batches [][]types.BaseEvent
+	err     error
+}
+
+func (f *failingCollector) ReadNextEvents(_ context.Context, _ int32) ([]types.BaseEvent, error) {
+	if len(f.batches) == 0 {
+		return nil, f.err
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func collectorNotFound() error {
+	fault := &soap.Fault{Code: "ServerFaultCode", String: "collector not found"}
+	fault.Detail.Fault = types.ManagedObjectNotFound{}
+	return soap.WrapSoapFault(fault)
+}
+
+func Test_collectorRecoverable(t *testing.T) {
+	notAuthenticated := &soap.Fault{Code: "ServerFaultCode", String: "not authenticated"}
+	notAuthenticated.Detail.Fault = types.NotAuthenticated{}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "collector not found", err: collectorNotFound(), want: true},
+		{name: "vim fault collector not found", err: soap.WrapVimFault(&types.ManagedObjectNotFound{}), want: true},
+		{name: "session expired", err: soap.WrapSoapFault(notAuthenticated), want: false},
+		{name: "regular error", err: errors.New("connection reset"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collectorRecoverable(tt.err); got != tt.want {
+				t.Errorf("collectorRecoverable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_recreateCollector(t *testing.T) {
+	now := time.Now().UTC()
+
+	newAdapter := func(ce *fakeCEClient, factory collectorFactory) *vAdapter {
+		return &vAdapter{
+			Logger:          zaptest.NewLogger(t).Sugar(),
+			Source:          source,
+			CEClient:        ce,
+			KVStore:         &fakeKVStore{dataChan: make(chan string, 10)},
+			CpConfig:        CheckpointConfig{Period: time.Hour},
+			PayloadEncoding: cloudevents.ApplicationXML,
+			newCollector:    factory,
+			collectorBegin:  now,
+		}
+	}
+
+	t.Run("resumes after last sent event", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var begins []time.Time
+		factory := func(_ context.Context, begin time.Time) (eventCollector, error) {
+			begins = append(begins, begin)
+			// events since begin are read again
+			return &fakeCollector{batches: [][]types.BaseEvent{createTestEvents(3, source, now).vEvents}}, nil
+		}
+
+		ce := &fakeCEClient{}
+		a := newAdapter(ce, factory)
+		first := &failingCollector{
+			batches: [][]types.BaseEvent{createTestEvents(2, source, now).vEvents},
+			err:     collectorNotFound(),
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- a.readEvents(ctx, first)
+		}()
+
+		deadline := time.After(5 * time.Second)
+		for {
+			ce.Lock()
+			sent := len(ce.sent)
+			ce.Unlock()
+			if sent >= 3 {
+				break
+			}
+
+			select {
+			case <-deadline:
+				t.Fatal("timed out waiting for events to be sent")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+
+		cancel()
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+		}
+
+		ce.Lock()
+		defer ce.Unlock()
+		var ids []string
+		for _, ev := range ce.sent {
+			ids = append(ids, ev.ID())
+		}
+		if len(ids) != 3 || ids[2] != "1002" {
+			t.Errorf("readEvents() sent events %v, want [1000 1001 1002]", ids)
+		}
+		if len(begins) != 1 || !begins[0].Equal(now) {
+			t.Errorf("recreated collector with begin %v, want [%v]", begins, now)
+		}
+	})
+
+	t.Run("fails on session errors", func(t *testing.T) {
+		var recreated bool
+		factory := func(context.Context, time.Time) (eventCollector, error) {
+			recreated = true
+			return &fakeCollector{}, nil
+		}
+
+		a := newAdapter(&fakeCEClient{}, factory)
+		err := a.readEvents(context.Background(), &failingCollector{err: errors.New("connection reset")})
+		if err == nil {
+			t.Error("readEvents() error = nil, want error")
+		}
+		if recreated {
+			t.Error("readEvents() recreated collector on non-recoverable error")
+		}
+	})
+
+	t.Run("fails after maximum recreations", func(t *testing.T) {
+		var recreations int
+		factory := func(context.Context, time.Time) (eventCollector, error) {
+			recreations++
+			return &failingCollector{err: collectorNotFound()}, nil
+		}
+
+		a := newAdapter(&fakeCEClient{}, factory)
+		err := a.readEvents(context.Background(), &failingCollector{err: collectorNotFound()})
+		if err == nil {
+			t.Error("readEvents() error = nil, want error")
+		}
+		if recreations != maxCollectorRecreations {
+			t.Errorf("readEvents() recreated collector %d times, want %d", recreations, maxCollectorRecreations)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/protocol"
+)
+
+// confirmation is the payload sent to the confirmation hook for each event
+// delivered to the sink
+type confirmation struct {
+	EventKey int32  `json:"eventKey"`
+	EventID  string `json:"eventID"`
+	Type     string `json:"type"`
+	Result   string `json:"result"`
+}
+
+// confirmHook notifies an external HTTP endpoint about events successfully
+// delivered to the sink
+type confirmHook struct {
+	url    string
+	client *http.Client
+}
+
+// newConfirmHook returns a hook posting confirmations to the given URL with
+// the given request timeout. It returns nil if the URL is empty, i.e. the hook
+// is disabled.
+func newConfirmHook(hookURL string, timeout time.Duration) (*confirmHook, error) {
+	if hookURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(hookURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse confirmation hook URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid confirmation hook URL %q: scheme must be http or https", hookURL)
+	}
+
+	return &confirmHook{
+		url:    hookURL,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// confirm posts the delivery confirmation for the event with the given key, ID
+// and type and the result returned by the sink
+func (h *confirmHook) confirm(ctx context.Context, key int32, id, eventType string, result protocol.Result) error {
+	c := confirmation{
+		EventKey: key,
+		EventID:  id,
+		Type:     eventType,
+		Result:   "ACK",
+	}
+	if result != nil {
+		c.Result = result.Error()
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal confirmation: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create confirmation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send confirmation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("confirmation hook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
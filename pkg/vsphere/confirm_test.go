@@ -0,0 +1,79 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_newConfirmHook(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", url: "", wantNil: true},
+		{name: "valid URL", url: "http://hook.example.com/confirm"},
+		{name: "invalid scheme", url: "ftp://hook.example.com", wantErr: true},
+		{name: "invalid URL", url: "http://[::1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConfirmHook(tt.url, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfirmHook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newConfirmHook() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_confirmHook_confirm(t *testing.T) {
+	var got confirmation
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode confirmation: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	h, err := newConfirmHook(srv.URL, time.Second)
+	if err != nil {
+		t.Fatalf("newConfirmHook() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err = h.confirm(ctx, 1000, "1000", "com.vmware.vsphere.VmPoweredOnEvent.v0", nil); err != nil {
+		t.Fatalf("confirm() error = %v", err)
+	}
+
+	want := confirmation{
+		EventKey: 1000,
+		EventID:  "1000",
+		Type:     "com.vmware.vsphere.VmPoweredOnEvent.v0",
+		Result:   "ACK",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("confirm() mismatch (-want +got):\n%s", diff)
+	}
+
+	status = http.StatusInternalServerError
+	if err = h.confirm(ctx, 1001, "1001", "com.vmware.vsphere.VmPoweredOnEvent.v0", nil); err == nil {
+		t.Error("confirm() expected error for failing hook")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// contentVolatileFields are the event fields excluded from the content hash
+// since they differ between re-emitted copies of the same event
+var contentVolatileFields = []string{"Key", "ChainId", "CreatedTime"}
+
+// contentDedup suppresses events with content identical to an event sent
+// within a window, e.g. events re-emitted by vCenter with new keys after a
+// reconnect. Hashes of sent events are kept in a bounded LRU cache, i.e.
+// duplicates of evicted events are not detected.
+type contentDedup struct {
+	window time.Duration
+	now    func() time.Time
+	// content hash to time the event was sent
+	sent *lru.Cache
+}
+
+// newContentDedup returns a deduplicator suppressing identical events within
+// window, tracking up to size events. It returns nil if window is 0, i.e.
+// content deduplication is disabled.
+func newContentDedup(window time.Duration, size int) (*contentDedup, error) {
+	if window == 0 {
+		return nil, nil
+	}
+
+	if window < 0 {
+		return nil, errors.New("content deduplication window must not be negative")
+	}
+
+	sent, err := lru.New(size)
+	if err != nil {
+		return nil, fmt.Errorf("create content deduplication cache: %w", err)
+	}
+
+	return &contentDedup{window: window, now: time.Now, sent: sent}, nil
+}
+
+// hash returns the content hash of the given event of eventType excluding
+// volatile fields. It returns an empty hash if d is nil.
+func (d *contentDedup) hash(be types.BaseEvent, eventType string) (string, error) {
+	if d == nil {
+		return "", nil
+	}
+
+	b, err := json.Marshal(be)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return "", err
+	}
+	for _, f := range contentVolatileFields {
+		delete(fields, f)
+	}
+
+	// map keys are sorted when encoded
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(eventType))
+	h.Write([]byte{0})
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// duplicate returns whether an event with the given content hash was sent
+// within the window
+func (d *contentDedup) duplicate(hash string) bool {
+	if d == nil {
+		return false
+	}
+
+	v, ok := d.sent.Get(hash)
+	return ok && d.now().Sub(v.(time.Time)) < d.window
+}
+
+// record records that an event with the given content hash was sent
+func (d *contentDedup) record(hash string) {
+	if d == nil {
+		return
+	}
+	d.sent.Add(hash, d.now())
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func powerOnEvent(key int32, vm string, created time.Time) types.BaseEvent {
+	return &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+		Key:                  key,
+		ChainId:              key,
+		CreatedTime:          created,
+		FullFormattedMessage: vm + " on host-1 is powered on",
+		Vm:                   &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: vm}},
+	}}}
+}
+
+func Test_newContentDedup(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  time.Duration
+		size    int
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "negative window", window: -time.Minute, size: 10, wantErr: true},
+		{name: "invalid cache size", window: time.Minute, wantErr: true},
+		{name: "valid", window: time.Minute, size: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newContentDedup(tt.window, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newContentDedup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newContentDedup() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_contentDedup(t *testing.T) {
+	d, err := newContentDedup(time.Minute, 10)
+	if err != nil {
+		t.Fatalf("newContentDedup() error = %v", err)
+	}
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	hash := func(be types.BaseEvent, eventType string) string {
+		t.Helper()
+		h, err := d.hash(be, eventType)
+		if err != nil {
+			t.Fatalf("hash() error = %v", err)
+		}
+		return h
+	}
+
+	sent := hash(powerOnEvent(1000, "vm-1", now), "VmPoweredOnEvent")
+	d.record(sent)
+
+	if got := hash(powerOnEvent(1005, "vm-1", now.Add(time.Second)), "VmPoweredOnEvent"); got != sent {
+		t.Error("hash() differs for event re-emitted with new key and time")
+	}
+	if got := hash(powerOnEvent(1006, "vm-2", now), "VmPoweredOnEvent"); got == sent {
+		t.Error("hash() equal for events with different content")
+	}
+	if got := hash(powerOnEvent(1000, "vm-1", now), "VmPoweredOffEvent"); got == sent {
+		t.Error("hash() equal for events of different types")
+	}
+
+	if !d.duplicate(sent) {
+		t.Error("duplicate() = false within window, want true")
+	}
+	now = now.Add(time.Minute)
+	if d.duplicate(sent) {
+		t.Error("duplicate() = true after window, want false")
+	}
+
+	var disabled *contentDedup
+	if h, err := disabled.hash(powerOnEvent(1000, "vm-1", now), "VmPoweredOnEvent"); h != "" || err != nil {
+		t.Errorf("hash() on disabled deduplicator = %q, %v, want empty hash", h, err)
+	}
+	if disabled.duplicate(sent) {
+		t.Error("duplicate() on disabled deduplicator = true, want false")
+	}
+}
+
+func Test_vAdapter_sendEvents_contentDedup(t *testing.T) {
+	dedup, err := newContentDedup(time.Minute, 10)
+	if err != nil {
+		t.Fatalf("newContentDedup() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		ContentDedup:    dedup,
+	}
+
+	now := time.Now().UTC()
+	events := []types.BaseEvent{
+		powerOnEvent(1000, "vm-1", now),
+		powerOnEvent(1001, "vm-2", now),
+		// re-emitted with new key
+		powerOnEvent(1002, "vm-1", now.Add(time.Second)),
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if n != len(events) {
+		t.Errorf("sendEvents() = %d, want %d processed events", n, len(events))
+	}
+	if len(ce.sent) != 2 {
+		t.Errorf("sendEvents() sent %d events, want 2", len(ce.sent))
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// send cloud events in binary content mode (default)
+	contentModeBinary = "binary"
+	// send cloud events in structured content mode
+	contentModeStructured = "structured"
+	// try binary content mode first and fall back to structured content mode
+	// if the sink rejects the request
+	contentModeAuto = "auto"
+)
+
+// validateContentMode returns an error if the given cloud event content mode
+// is not supported
+func validateContentMode(mode string) error {
+	switch mode {
+	case contentModeBinary, contentModeStructured, contentModeAuto:
+		return nil
+	default:
+		return fmt.Errorf("unsupported cloud event content mode %q", mode)
+	}
+}
+
+// send delivers the given event to the sink using the configured content
+// mode. In auto mode, binary content mode is tried first and the same event is
+// retried in structured content mode if the sink rejects the binary request.
+// The negotiated mode is cached for the lifetime of the adapter to avoid
+// repeated probing.
+func (a *vAdapter) send(ctx context.Context, ev cloudevents.Event) protocol.Result {
+	mode := a.ContentMode
+	if mode == contentModeAuto && a.negotiatedContentMode != "" {
+		mode = a.negotiatedContentMode
+	}
+
+	switch mode {
+	case contentModeStructured:
+		return a.CEClient.Send(cloudevents.WithEncodingStructured(ctx), ev)
+
+	case contentModeAuto:
+		result := a.CEClient.Send(cloudevents.WithEncodingBinary(ctx), ev)
+		if isContentModeRejection(result) {
+			logging.FromContext(ctx).Infow("sink rejected binary content mode, retrying in structured content mode")
+			result = a.CEClient.Send(cloudevents.WithEncodingStructured(ctx), ev)
+			if cloudevents.IsACK(result) {
+				a.negotiatedContentMode = contentModeStructured
+			}
+			return result
+		}
+
+		if cloudevents.IsACK(result) {
+			a.negotiatedContentMode = contentModeBinary
+		}
+		return result
+
+	default:
+		return a.CEClient.Send(ctx, ev)
+	}
+}
+
+// isContentModeRejection returns true if the sink rejected the request because
+// of the used content mode
+func isContentModeRejection(result protocol.Result) bool {
+	var httpResult *cehttp.Result
+	if !cloudevents.ResultAs(result, &httpResult) {
+		return false
+	}
+	return httpResult.StatusCode == http.StatusUnsupportedMediaType
+}
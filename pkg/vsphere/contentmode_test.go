@@ -0,0 +1,125 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_vAdapter_send_contentMode(t *testing.T) {
+	const structuredContentType = "application/cloudevents+json"
+
+	now := time.Now().UTC()
+	events := createTestEvents(2, source, now)
+
+	tests := []struct {
+		name             string
+		contentMode      string
+		statusCodes      []int
+		wantCount        int
+		wantContentTypes []string
+		wantNegotiated   string
+	}{
+		{
+			name:             "binary mode",
+			contentMode:      contentModeBinary,
+			statusCodes:      createStatusCodes(2, failNever),
+			wantCount:        2,
+			wantContentTypes: []string{cloudevents.ApplicationXML, cloudevents.ApplicationXML},
+		},
+		{
+			name:             "structured mode",
+			contentMode:      contentModeStructured,
+			statusCodes:      createStatusCodes(2, failNever),
+			wantCount:        2,
+			wantContentTypes: []string{structuredContentType, structuredContentType},
+		},
+		{
+			name:             "auto mode, sink accepts binary",
+			contentMode:      contentModeAuto,
+			statusCodes:      createStatusCodes(2, failNever),
+			wantCount:        2,
+			wantContentTypes: []string{cloudevents.ApplicationXML, cloudevents.ApplicationXML},
+			wantNegotiated:   contentModeBinary,
+		},
+		{
+			name:             "auto mode, sink rejects binary",
+			contentMode:      contentModeAuto,
+			statusCodes:      []int{http.StatusUnsupportedMediaType, http.StatusOK, http.StatusOK},
+			wantCount:        2,
+			wantContentTypes: []string{cloudevents.ApplicationXML, structuredContentType, structuredContentType},
+			wantNegotiated:   contentModeStructured,
+		},
+		{
+			name:             "auto mode, sink rejects binary and structured",
+			contentMode:      contentModeAuto,
+			statusCodes:      []int{http.StatusUnsupportedMediaType, http.StatusUnsupportedMediaType},
+			wantCount:        0,
+			wantContentTypes: []string{cloudevents.ApplicationXML, structuredContentType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+
+			roundTripper := &roundTripperTest{statusCodes: tt.statusCodes}
+			p, err := cehttp.New(cehttp.WithRoundTripper(roundTripper))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p, client.WithTimeNow(), client.WithUUIDs())
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller()))
+
+			a := vAdapter{
+				Logger:          logger.Sugar(),
+				CEClient:        c,
+				Source:          source,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				VAPIVersion:     "6.7.0",
+				ContentMode:     tt.contentMode,
+			}
+
+			count, _ := a.sendEvents(ctx, events.vEvents)
+			if count != tt.wantCount {
+				t.Errorf("sendEvents() count = %d, want %d", count, tt.wantCount)
+			}
+
+			if diff := cmp.Diff(tt.wantContentTypes, roundTripper.contentTypes); diff != "" {
+				t.Errorf("unexpected diff in content types (-want +got): %s", diff)
+			}
+
+			if a.negotiatedContentMode != tt.wantNegotiated {
+				t.Errorf("negotiated content mode = %q, want %q", a.negotiatedContentMode, tt.wantNegotiated)
+			}
+		})
+	}
+}
+
+func Test_validateContentMode(t *testing.T) {
+	for _, mode := range []string{contentModeBinary, contentModeStructured, contentModeAuto} {
+		if err := validateContentMode(mode); err != nil {
+			t.Errorf("validateContentMode(%q) unexpected error: %v", mode, err)
+		}
+	}
+
+	if err := validateContentMode("invalid"); err == nil {
+		t.Error("validateContentMode(\"invalid\") expected error")
+	}
+}
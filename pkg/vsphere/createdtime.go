@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// substitute a missing CreatedTime with the time the event was read
+	createdTimeRead = "read"
+	// substitute a missing CreatedTime with the CreatedTime of the preceding
+	// event, keeping timestamps monotonic for checkpoints and replay
+	createdTimePrevious = "previous"
+)
+
+// validateCreatedTimeStrategy returns an error if the given strategy for
+// events without CreatedTime is not supported
+func validateCreatedTimeStrategy(strategy string) error {
+	switch strategy {
+	case createdTimeRead, createdTimePrevious:
+		return nil
+	default:
+		return fmt.Errorf("unsupported created time strategy %q: must be %s or %s", strategy, createdTimeRead, createdTimePrevious)
+	}
+}
+
+// fillCreatedTime substitutes the zero CreatedTime of the given events read at
+// readTime according to the configured strategy, so that the event time,
+// checkpoint timestamps and replay window are valid. With the previous
+// strategy, the first event of the batch falls back to the CreatedTime of the
+// last event of the previous batch and then to readTime.
+func (a *vAdapter) fillCreatedTime(ctx context.Context, events []types.BaseEvent, readTime time.Time) {
+	for _, be := range events {
+		e := be.GetEvent()
+		if !e.CreatedTime.IsZero() {
+			a.lastCreatedTime = e.CreatedTime
+			continue
+		}
+
+		substitute := readTime
+		if a.CreatedTimeStrategy == createdTimePrevious && !a.lastCreatedTime.IsZero() {
+			substitute = a.lastCreatedTime
+		}
+
+		logging.FromContext(ctx).Warnw("substituting missing created time of event",
+			zap.Int32("eventKey", e.Key), zap.String("strategy", a.CreatedTimeStrategy), zap.Time("createdTime", substitute))
+		reportMissingCreatedTime(ctx)
+
+		e.CreatedTime = substitute
+		a.lastCreatedTime = substitute
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_validateCreatedTimeStrategy(t *testing.T) {
+	for _, strategy := range []string{createdTimeRead, createdTimePrevious} {
+		if err := validateCreatedTimeStrategy(strategy); err != nil {
+			t.Errorf("validateCreatedTimeStrategy(%q) error = %v", strategy, err)
+		}
+	}
+	if err := validateCreatedTimeStrategy("now"); err == nil {
+		t.Error("validateCreatedTimeStrategy(now) error = nil, want error")
+	}
+}
+
+func Test_vAdapter_fillCreatedTime(t *testing.T) {
+	readTime := time.Date(2020, 10, 1, 12, 5, 0, 0, time.UTC)
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		strategy string
+		last     time.Time
+		want     []time.Time
+	}{
+		{
+			name:     "read time",
+			strategy: createdTimeRead,
+			want:     []time.Time{readTime, created, readTime},
+		},
+		{
+			name:     "previous event",
+			strategy: createdTimePrevious,
+			want:     []time.Time{readTime, created, created},
+		},
+		{
+			name:     "previous event from previous batch",
+			strategy: createdTimePrevious,
+			last:     created.Add(-time.Minute),
+			want:     []time.Time{created.Add(-time.Minute), created, created},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []types.BaseEvent{
+				createBaseEvent(1000, time.Time{}),
+				createBaseEvent(1001, created),
+				createBaseEvent(1002, time.Time{}),
+			}
+
+			a := &vAdapter{CreatedTimeStrategy: tt.strategy, lastCreatedTime: tt.last}
+			a.fillCreatedTime(context.Background(), events, readTime)
+
+			for i, be := range events {
+				if got := be.GetEvent().CreatedTime; !got.Equal(tt.want[i]) {
+					t.Errorf("fillCreatedTime() event %d created time = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_zeroCreatedTime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ce := &fakeCEClient{}
+	kv := &fakeKVStore{dataChan: make(chan string, 10)}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		KVStore:         kv,
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		PayloadEncoding: cloudevents.ApplicationXML,
+	}
+
+	start := time.Now().UTC()
+	c := &fakeCollector{batches: [][]types.BaseEvent{{createBaseEvent(1000, time.Time{})}}}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, c)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		ce.Lock()
+		sent := len(ce.sent)
+		ce.Unlock()
+		if sent > 0 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for events to be sent")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-errCh
+
+	ce.Lock()
+	defer ce.Unlock()
+	if got := ce.sent[0].Time(); got.Before(start) {
+		t.Errorf("readEvents() event time = %v, want read time after %v", got, start)
+	}
+
+	var cp checkpoint
+	if err := kv.Get(context.Background(), CheckpointKey, &cp); err != nil {
+		t.Fatalf("get checkpoint: %v", err)
+	}
+	if cp.LastEventKeyTimestamp.Before(start) {
+		t.Errorf("checkpoint timestamp = %v, want read time after %v", cp.LastEventKeyTimestamp, start)
+	}
+}
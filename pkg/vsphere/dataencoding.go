@@ -0,0 +1,51 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// ceDataContentEncoding is the CloudEvent attribute declaring the encoding of
+// the event data. It was removed in CloudEvents 1.0 and is set as extension
+// for consumers relying on it.
+const ceDataContentEncoding = "datacontentencoding"
+
+// base64-encode the serialized event data
+const dataEncodingBase64 = event.Base64
+
+// validateDataContentEncoding returns an error if the given data content
+// encoding is not supported with the given content mode and aggregation. In
+// structured content mode binary data is already base64-encoded
+// (data_base64).
+func validateDataContentEncoding(encoding, contentMode string, aggregate bool) error {
+	switch encoding {
+	case "":
+		return nil
+	case dataEncodingBase64:
+		if contentMode != contentModeBinary || aggregate {
+			return fmt.Errorf("data content encoding %s requires %s content mode without aggregation", encoding, contentModeBinary)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported data content encoding %q: must be %s", encoding, dataEncodingBase64)
+	}
+}
+
+// encodeDataBase64 replaces the serialized data of ev with its base64 encoding
+// and declares the encoding in the datacontentencoding attribute. The data
+// content type is kept and describes the decoded data.
+func encodeDataBase64(ev *cloudevents.Event) {
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(ev.DataEncoded)))
+	base64.StdEncoding.Encode(encoded, ev.DataEncoded)
+	ev.DataEncoded = encoded
+	ev.DataBase64 = false
+	ev.SetExtension(ceDataContentEncoding, dataEncodingBase64)
+}
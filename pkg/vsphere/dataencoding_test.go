@@ -0,0 +1,91 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_validateDataContentEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    string
+		contentMode string
+		aggregate   bool
+		wantErr     bool
+	}{
+		{name: "disabled", contentMode: contentModeStructured},
+		{name: "base64 in binary mode", encoding: event.Base64, contentMode: contentModeBinary},
+		{name: "base64 in structured mode", encoding: event.Base64, contentMode: contentModeStructured, wantErr: true},
+		{name: "base64 in auto mode", encoding: event.Base64, contentMode: contentModeAuto, wantErr: true},
+		{name: "base64 with aggregation", encoding: event.Base64, contentMode: contentModeBinary, aggregate: true, wantErr: true},
+		{name: "unsupported encoding", encoding: "gzip", contentMode: contentModeBinary, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDataContentEncoding(tt.encoding, tt.contentMode, tt.aggregate); (err != nil) != tt.wantErr {
+				t.Errorf("validateDataContentEncoding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_dataContentEncoding(t *testing.T) {
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationJSON,
+		ContentMode:     contentModeBinary,
+		DataEncoding:    event.Base64,
+	}
+
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	sent := powerOnEvent(1000, "vm-1", created)
+	if _, err := a.sendEvents(context.Background(), []types.BaseEvent{sent}); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if len(ce.sent) != 1 {
+		t.Fatalf("sendEvents() sent %d events, want 1", len(ce.sent))
+	}
+
+	ev := ce.sent[0]
+	if got := ev.Extensions()[ceDataContentEncoding]; got != event.Base64 {
+		t.Errorf("sendEvents() %s = %v, want %s", ceDataContentEncoding, got, event.Base64)
+	}
+	if got := ev.DataContentType(); got != cloudevents.ApplicationJSON {
+		t.Errorf("sendEvents() datacontenttype = %q, want %q", got, cloudevents.ApplicationJSON)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(ev.Data()))
+	if err != nil {
+		t.Fatalf("decode base64 data: %v", err)
+	}
+
+	var payload JSONEvent
+	if err = json.Unmarshal(decoded, &payload); err != nil {
+		t.Fatalf("unmarshal decoded data: %v", err)
+	}
+	var got types.VmPoweredOnEvent
+	if err = json.Unmarshal(payload.Fields, &got); err != nil {
+		t.Fatalf("unmarshal decoded event fields: %v", err)
+	}
+	want := sent.GetEvent()
+	if got.Key != want.Key || !got.CreatedTime.Equal(want.CreatedTime) || got.Vm.Name != want.Vm.Name ||
+		got.FullFormattedMessage != want.FullFormattedMessage {
+		t.Errorf("decoded event = %+v, want %+v", got.Event, *want)
+	}
+}
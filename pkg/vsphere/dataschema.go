@@ -0,0 +1,75 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// dataSchemas maps vSphere event types, e.g. VmPoweredOnEvent, to the URL of
+// the schema of the event payload set as CloudEvent dataschema attribute
+type dataSchemas struct {
+	// used for event types without a dedicated schema
+	defaultURL string
+	byType     map[string]string
+}
+
+// newDataSchemas returns the data schemas for the given default schema URL and
+// JSON-encoded object of event types to schema URLs, e.g.
+// {"VmPoweredOnEvent":"https://schemas.example.com/vm.json"}. It returns nil if
+// both are empty, i.e. the dataschema attribute is not set.
+func newDataSchemas(defaultURL, config string) (*dataSchemas, error) {
+	if defaultURL == "" && config == "" {
+		return nil, nil
+	}
+
+	if defaultURL != "" {
+		if err := validateSchemaURL(defaultURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var byType map[string]string
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), &byType); err != nil {
+			return nil, fmt.Errorf("unmarshal data schema map: %w", err)
+		}
+		for eventType, u := range byType {
+			if err := validateSchemaURL(u); err != nil {
+				return nil, fmt.Errorf("event type %q: %w", eventType, err)
+			}
+		}
+	}
+
+	return &dataSchemas{
+		defaultURL: defaultURL,
+		byType:     byType,
+	}, nil
+}
+
+// schemaFor returns the schema URL for the given vSphere event type. False is
+// returned if no schema is configured for the type.
+func (d *dataSchemas) schemaFor(eventType string) (string, bool) {
+	if u, ok := d.byType[eventType]; ok {
+		return u, true
+	}
+	return d.defaultURL, d.defaultURL != ""
+}
+
+// validateSchemaURL returns an error if u is not an absolute URI as required
+// by the dataschema attribute
+func validateSchemaURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid data schema URL %q: %w", u, err)
+	}
+	if !parsed.IsAbs() {
+		return fmt.Errorf("invalid data schema URL %q: must be an absolute URI", u)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+)
+
+func Test_newDataSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		config    string
+		wantNil   bool
+		wantErr   bool
+		eventType string
+		want      string
+		wantOK    bool
+	}{
+		{
+			name:    "disabled",
+			wantNil: true,
+		},
+		{
+			name:      "default schema",
+			url:       "https://schemas.example.com/event.json",
+			eventType: "VmPoweredOnEvent",
+			want:      "https://schemas.example.com/event.json",
+			wantOK:    true,
+		},
+		{
+			name:      "per type schema",
+			url:       "https://schemas.example.com/event.json",
+			config:    `{"VmPoweredOnEvent":"https://schemas.example.com/vm.json"}`,
+			eventType: "VmPoweredOnEvent",
+			want:      "https://schemas.example.com/vm.json",
+			wantOK:    true,
+		},
+		{
+			name:      "per type schema without default",
+			config:    `{"VmPoweredOnEvent":"https://schemas.example.com/vm.json"}`,
+			eventType: "VmPoweredOffEvent",
+			wantOK:    false,
+		},
+		{
+			name:    "relative default schema",
+			url:     "event.json",
+			wantErr: true,
+		},
+		{
+			name:    "relative per type schema",
+			config:  `{"VmPoweredOnEvent":"vm.json"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid map",
+			config:  `{"VmPoweredOnEvent":}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := newDataSchemas(tt.url, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDataSchemas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (d == nil) != tt.wantNil {
+				t.Fatalf("newDataSchemas() = %v, wantNil %v", d, tt.wantNil)
+			}
+			if tt.wantNil {
+				return
+			}
+
+			got, ok := d.schemaFor(tt.eventType)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("schemaFor() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// ceVSphereDeadLetterReason is the CloudEvent extension holding the last
+	// delivery error of events sent to the dead letter sink
+	ceVSphereDeadLetterReason = "vspheredeadletterreason"
+
+	// maximum length of the dead letter reason
+	maxDeadLetterReason = 1024
+)
+
+// deadLetterSink receives the events which the sink failed to ACK after all
+// retries so that a single poison event does not stall the event stream
+type deadLetterSink struct {
+	client cloudevents.Client
+}
+
+// newDeadLetterSink returns a dead letter sink sending to the given target
+// with a client created by newClient. It returns nil if target is empty, i.e.
+// dead-lettering is disabled.
+func newDeadLetterSink(target string, newClient sinkClientFunc) (*deadLetterSink, error) {
+	if target == "" {
+		return nil, nil
+	}
+
+	client, err := newClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("create dead letter sink client: %w", err)
+	}
+	return &deadLetterSink{client: client}, nil
+}
+
+// send sends the given event with the delivery error as reason to the dead
+// letter sink
+func (d *deadLetterSink) send(ctx context.Context, ev cloudevents.Event, reason error) error {
+	ev = ev.Clone()
+	ev.SetExtension(ceVSphereDeadLetterReason, truncateString(reason.Error(), maxDeadLetterReason))
+
+	if result := d.client.Send(ctx, ev); !cloudevents.IsACK(result) {
+		return result
+	}
+	return nil
+}
+
+// failedAttempts counts the consecutive attempts to process the failing event
+// at the head of the event stream. The batch is read again after a failure, so
+// only the first failing event of a batch is counted.
+type failedAttempts struct {
+	max   int
+	id    string
+	count int
+}
+
+// newFailedAttempts returns a counter of up to max attempts. It returns nil if
+// max is 0, i.e. events are dead-lettered after the first failed attempt.
+func newFailedAttempts(max int) (*failedAttempts, error) {
+	if max < 0 {
+		return nil, fmt.Errorf("invalid dead letter attempts %d: must not be negative", max)
+	}
+	if max == 0 {
+		return nil, nil
+	}
+	return &failedAttempts{max: max}, nil
+}
+
+// exhausted records a failed attempt of the event with the given ID and
+// returns true if the event failed max times
+func (f *failedAttempts) exhausted(id string) bool {
+	if f == nil {
+		return true
+	}
+	if f.id != id {
+		f.id, f.count = id, 0
+	}
+	f.count++
+	return f.count >= f.max
+}
+
+// transientFailure returns true if the given event is valid and failed because
+// the sink is unavailable, i.e. it will likely be delivered once the sink
+// recovers
+func transientFailure(ev cloudevents.Event, result protocol.Result) bool {
+	return ev.Validate() == nil && sinkUnavailable(result)
+}
+
+// retryBackoff returns the delay before the given (zero-based) retry
+func retryBackoff(base time.Duration, retry int) time.Duration {
+	return base * time.Duration(1<<retry)
+}
+
+// deliver sends the given event to the sink, retrying up to SendRetries times
+// if the event is not ACK-ed. An event still failing after all retries is sent
+// to the dead letter sink if configured, in which case deadLettered is true
+// and result is nil, i.e. the event is considered processed.
+func (a *vAdapter) deliver(ctx context.Context, ev cloudevents.Event) (deadLettered bool, result protocol.Result) {
+	logger := logging.FromContext(ctx)
+
+	result = a.sendOrFallback(ctx, ev)
+	for retry := 0; retry < a.SendRetries && !cloudevents.IsACK(result); retry++ {
+		delay := retryBackoff(a.RetryBackoff, retry)
+		logger.Warnw("retrying failed cloudevent", zap.String("ID", ev.ID()), zap.Int("retry", retry+1),
+			zap.Duration("delay", delay), zap.Error(result))
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(delay):
+		}
+		result = a.sendOrFallback(ctx, ev)
+	}
+
+	if cloudevents.IsACK(result) || a.DeadLetter == nil {
+		return false, result
+	}
+
+	if a.DeadLetterTries != nil {
+		// only dead-letter events the sink repeatedly rejects
+		if transientFailure(ev, result) {
+			return false, result
+		}
+		if !a.DeadLetterTries.exhausted(ev.ID()) {
+			logger.Warnw("sink rejected cloudevent, retrying before dead-lettering", zap.String("ID", ev.ID()),
+				zap.Int("attempt", a.DeadLetterTries.count), zap.Int("maxAttempts", a.DeadLetterTries.max), zap.Error(result))
+			return false, result
+		}
+	}
+
+	if err := a.DeadLetter.send(ctx, ev, result); err != nil {
+		logger.Errorw("failed to send cloudevent to dead letter sink", zap.String("ID", ev.ID()), zap.Error(err))
+		return false, result
+	}
+
+	logger.Warnw("sent failed cloudevent to dead letter sink", zap.String("ID", ev.ID()), zap.Error(result))
+	return true, nil
+}
+
+// deadLetterMalformed sends the given event which could not be encoded to the
+// dead letter sink once it failed the configured number of attempts, i.e.
+// immediately without configured attempts. It returns true if the event was
+// dead-lettered, i.e. it is considered processed.
+func (a *vAdapter) deadLetterMalformed(ctx context.Context, ev cloudevents.Event, reason error) bool {
+	if a.DeadLetter == nil || !a.DeadLetterTries.exhausted(ev.ID()) {
+		return false
+	}
+
+	logger := logging.FromContext(ctx)
+	if err := a.DeadLetter.send(ctx, ev, reason); err != nil {
+		logger.Errorw("failed to send malformed cloudevent to dead letter sink", zap.String("ID", ev.ID()), zap.Error(err))
+		return false
+	}
+
+	logger.Warnw("sent malformed cloudevent to dead letter sink", zap.String("ID", ev.ID()), zap.Error(reason))
+	return true
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_retryBackoff(t *testing.T) {
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 0, want: time.Second},
+		{retry: 1, want: 2 * time.Second},
+		{retry: 3, want: 8 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(time.Second, tt.retry); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func Test_vAdapter_sendEvents_deadLetter(t *testing.T) {
+	poison := errors.New("poison event")
+
+	tests := []struct {
+		name       string
+		retries    int
+		results    []error
+		dlsResults []error
+		deadLetter bool
+		wantN      int
+		wantErr    bool
+		wantSent   int
+		wantDLS    int
+	}{
+		{
+			name:     "failing event stops batch without dead letter sink",
+			results:  []error{nil, poison},
+			wantN:    1,
+			wantErr:  true,
+			wantSent: 2,
+		},
+		{
+			name:     "retry succeeds",
+			retries:  2,
+			results:  []error{nil, poison, nil},
+			wantN:    3,
+			wantSent: 4,
+		},
+		{
+			name:       "exhausted retries are dead-lettered",
+			retries:    1,
+			results:    []error{nil, poison, poison},
+			deadLetter: true,
+			wantN:      3,
+			wantSent:   4,
+			wantDLS:    1,
+		},
+		{
+			name:       "dead letter sink failure stops batch",
+			results:    []error{nil, poison},
+			dlsResults: []error{errors.New("dead letter sink unavailable")},
+			deadLetter: true,
+			wantN:      1,
+			wantErr:    true,
+			wantSent:   2,
+			wantDLS:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []types.BaseEvent{
+				createBaseEvent(1000, time.Now()),
+				createBaseEvent(1001, time.Now()),
+				createBaseEvent(1002, time.Now()),
+			}
+
+			ce := &fakeCEClient{results: tt.results}
+			dls := &fakeCEClient{results: tt.dlsResults}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				SendRetries:     tt.retries,
+				RetryBackoff:    time.Millisecond,
+			}
+			if tt.deadLetter {
+				a.DeadLetter = &deadLetterSink{client: dls}
+			}
+
+			n, err := a.sendEvents(context.Background(), events)
+			if n != tt.wantN || (err != nil) != tt.wantErr {
+				t.Errorf("sendEvents() = %d, %v, want %d, wantErr %v", n, err, tt.wantN, tt.wantErr)
+			}
+
+			if len(ce.sent) != tt.wantSent {
+				t.Errorf("sendEvents() sent %d events to sink, want %d", len(ce.sent), tt.wantSent)
+			}
+
+			if len(dls.sent) != tt.wantDLS {
+				t.Fatalf("sendEvents() sent %d events to dead letter sink, want %d", len(dls.sent), tt.wantDLS)
+			}
+
+			if tt.wantDLS > 0 {
+				ev := dls.sent[0]
+				if ev.ID() != "1001" {
+					t.Errorf("dead-lettered event ID = %q, want %q", ev.ID(), "1001")
+				}
+				if reason := ev.Extensions()[ceVSphereDeadLetterReason]; reason != poison.Error() {
+					t.Errorf("dead letter reason = %v, want %q", reason, poison.Error())
+				}
+			}
+		})
+	}
+}
+
+func Test_vAdapter_deliver_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ce := &fakeCEClient{results: []error{errors.New("sink unavailable")}}
+	a := &vAdapter{
+		Logger:       zaptest.NewLogger(t).Sugar(),
+		CEClient:     ce,
+		SendRetries:  3,
+		RetryBackoff: time.Hour,
+	}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+	deadLettered, result := a.deliver(ctx, ev)
+	if deadLettered || !errors.Is(result, context.Canceled) {
+		t.Errorf("deliver() = %v, %v, want false, %v", deadLettered, result, context.Canceled)
+	}
+}
+
+func Test_newFailedAttempts(t *testing.T) {
+	if got, err := newFailedAttempts(0); got != nil || err != nil {
+		t.Errorf("newFailedAttempts(0) = %v, %v, want nil", got, err)
+	}
+	if _, err := newFailedAttempts(-1); err == nil {
+		t.Error("newFailedAttempts(-1) error = nil, want error")
+	}
+
+	f, err := newFailedAttempts(2)
+	if err != nil {
+		t.Fatalf("newFailedAttempts(2) error = %v", err)
+	}
+	if f.exhausted("1000") {
+		t.Error("exhausted() = true after first attempt, want false")
+	}
+	// attempts of another event start over
+	if f.exhausted("1001") {
+		t.Error("exhausted() = true after first attempt of next event, want false")
+	}
+	if !f.exhausted("1001") {
+		t.Error("exhausted() = false after second attempt, want true")
+	}
+}
+
+func Test_vAdapter_sendEvents_deadLetterAttempts(t *testing.T) {
+	rejected := cehttp.NewResult(http.StatusBadRequest, "%w", protocol.ResultNACK)
+	unavailable := cehttp.NewResult(http.StatusServiceUnavailable, "%w", protocol.ResultNACK)
+
+	tests := []struct {
+		name     string
+		encoding string
+		results  []error
+		// number of times the batch is read
+		reads int
+		// events processed by the last read
+		wantN    int
+		wantErr  bool
+		wantDLS  int
+		wantData bool
+	}{
+		{
+			name:     "rejected event is dead-lettered after maximum attempts",
+			encoding: cloudevents.ApplicationXML,
+			results:  []error{nil, rejected, rejected},
+			reads:    2,
+			wantN:    1,
+			wantDLS:  1,
+			wantData: true,
+		},
+		{
+			name:     "rejected event is retried before maximum attempts",
+			encoding: cloudevents.ApplicationXML,
+			results:  []error{nil, rejected},
+			reads:    1,
+			wantN:    1,
+			wantErr:  true,
+		},
+		{
+			name:     "unavailable sink never dead-letters",
+			encoding: cloudevents.ApplicationXML,
+			results:  []error{unavailable, unavailable, unavailable},
+			reads:    3,
+			wantN:    0,
+			wantErr:  true,
+		},
+		{
+			name:     "transport error never dead-letters",
+			encoding: cloudevents.ApplicationXML,
+			results:  []error{errors.New("connection refused"), errors.New("connection refused")},
+			reads:    2,
+			wantN:    0,
+			wantErr:  true,
+		},
+		{
+			name:     "event which cannot be encoded is dead-lettered",
+			encoding: "text/plain",
+			reads:    2,
+			wantN:    1,
+			wantDLS:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := &fakeCEClient{results: tt.results}
+			dls := &fakeCEClient{}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: tt.encoding,
+				DeadLetter:      &deadLetterSink{client: dls},
+				DeadLetterTries: &failedAttempts{max: 2},
+			}
+
+			events := []types.BaseEvent{createBaseEvent(1000, time.Now())}
+			if tt.encoding == cloudevents.ApplicationXML {
+				events = append(events, createBaseEvent(1001, time.Now()))
+			}
+
+			var (
+				n   int
+				err error
+			)
+			for i := 0; i < tt.reads; i++ {
+				// the batch is read again from the first unprocessed event
+				if n, err = a.sendEvents(context.Background(), events); err == nil {
+					break
+				}
+				events = events[n:]
+			}
+
+			if len(dls.sent) != tt.wantDLS {
+				t.Fatalf("sendEvents() sent %d events to dead letter sink, want %d", len(dls.sent), tt.wantDLS)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sendEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("sendEvents() = %d, want %d", n, tt.wantN)
+			}
+			if tt.wantDLS > 0 && (len(dls.sent[0].Data()) > 0) != tt.wantData {
+				t.Errorf("dead-lettered event data = %q, want data %v", dls.sent[0].Data(), tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_deadLetterMalformedWithoutAttempts(t *testing.T) {
+	// 0 attempts, i.e. no failed attempts tracker
+	tries, err := newFailedAttempts(0)
+	if err != nil {
+		t.Fatalf("newFailedAttempts() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	dls := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: "text/plain",
+		DeadLetter:      &deadLetterSink{client: dls},
+		DeadLetterTries: tries,
+	}
+
+	n, err := a.sendEvents(context.Background(), []types.BaseEvent{createBaseEvent(1000, time.Now())})
+	if n != 1 || err != nil {
+		t.Errorf("sendEvents() = %d, %v, want 1, nil", n, err)
+	}
+	if len(dls.sent) != 1 {
+		t.Fatalf("sendEvents() sent %d events to dead letter sink, want 1", len(dls.sent))
+	}
+	if len(ce.sent) != 0 {
+		t.Errorf("sendEvents() sent %d events to sink, want 0", len(ce.sent))
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// lookupSRV resolves DNS SRV records (replaced in tests)
+var lookupSRV = net.LookupSRV
+
+// resolveSRV resolves the given SRV record name, e.g.
+// _vcenter._tcp.example.com, and returns a copy of u for each target with the
+// host and port replaced. Targets are returned in order of priority and
+// weight.
+func resolveSRV(u *url.URL, name string) ([]*url.URL, error) {
+	_, records, err := lookupSRV("", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("resolve SRV record %q: %w", name, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("resolve SRV record %q: no targets found", name)
+	}
+
+	urls := make([]*url.URL, 0, len(records))
+	for _, r := range records {
+		target := *u
+		host := strings.TrimSuffix(r.Target, ".")
+		target.Host = net.JoinHostPort(host, strconv.Itoa(int(r.Port)))
+		urls = append(urls, &target)
+	}
+	return urls, nil
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// suffix of buffered event files
+const diskBufferSuffix = ".json"
+
+// errDiskBufferFull is returned when an event exceeds the buffer size
+var errDiskBufferFull = errors.New("disk buffer full")
+
+// bufferedEvent is a cloud event persisted in the disk buffer together with
+// the vCenter event attributes needed to checkpoint it once delivered
+type bufferedEvent struct {
+	Event   json.RawMessage `json:"event"`
+	Key     int32           `json:"key"`
+	Class   string          `json:"class"`
+	Type    string          `json:"type"`
+	Created time.Time       `json:"created"`
+}
+
+// diskBufferEntry is a buffered event file
+type diskBufferEntry struct {
+	seq  uint64
+	size int64
+}
+
+// diskBuffer persists events to a local directory, e.g. an emptyDir volume,
+// while the sink is unreachable so reading vCenter events does not stall.
+// Once an event is buffered, all subsequent events are buffered as well until
+// the buffer is drained in order. Buffered events are only checkpointed after
+// delivery to the sink, so buffered events lost with the directory are
+// replayed from the checkpoint on restart, i.e. delivery stays at-least-once.
+// Events in the buffer when the adapter restarts are drained and also
+// replayed from the checkpoint, which may duplicate them.
+type diskBuffer struct {
+	dir           string
+	maxBytes      int64
+	retryInterval time.Duration
+	now           func() time.Time
+
+	// buffered event files, oldest first
+	entries []diskBufferEntry
+	bytes   int64
+	nextSeq uint64
+	// earliest time to retry draining the buffer after a failed delivery
+	nextDrain time.Time
+	// last event delivered from the buffer, nil if none was delivered since
+	// the buffer was last empty
+	delivered types.BaseEvent
+}
+
+// newDiskBuffer returns a disk buffer in the given directory restoring events
+// buffered before a restart. It returns nil if dir is empty, i.e. events are
+// not buffered.
+func newDiskBuffer(dir string, maxBytes int64, retryInterval time.Duration) (*diskBuffer, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	if maxBytes <= 0 || retryInterval <= 0 {
+		return nil, fmt.Errorf("invalid disk buffer: maximum bytes (%d) and retry interval (%s) must be greater than 0", maxBytes, retryInterval)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create disk buffer directory: %w", err)
+	}
+
+	b := &diskBuffer{dir: dir, maxBytes: maxBytes, retryInterval: retryInterval, now: time.Now}
+	if err := b.restore(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// restore lists the event files buffered in the directory
+func (b *diskBuffer) restore() error {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return fmt.Errorf("read disk buffer directory: %w", err)
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasSuffix(name, diskBufferSuffix+".tmp") {
+			// partially written before a restart
+			_ = os.Remove(filepath.Join(b.dir, name))
+			continue
+		}
+		if f.IsDir() || !strings.HasSuffix(name, diskBufferSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, diskBufferSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		b.entries = append(b.entries, diskBufferEntry{seq: seq, size: f.Size()})
+		b.bytes += f.Size()
+		if seq >= b.nextSeq {
+			b.nextSeq = seq + 1
+		}
+	}
+
+	sort.Slice(b.entries, func(i, j int) bool { return b.entries[i].seq < b.entries[j].seq })
+	return nil
+}
+
+// active returns true if events are buffered, i.e. new events must be
+// buffered as well to preserve ordering
+func (b *diskBuffer) active() bool {
+	return b != nil && len(b.entries) > 0
+}
+
+// path returns the file of the buffered event with the given sequence number
+func (b *diskBuffer) path(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", seq, diskBufferSuffix))
+}
+
+// add persists the given cloud event created from be. errDiskBufferFull is
+// returned if the event exceeds the maximum buffer size.
+func (b *diskBuffer) add(ev cloudevents.Event, be types.BaseEvent) error {
+	encoded, err := ev.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("encode buffered event: %w", err)
+	}
+
+	details := getEventDetails(be)
+	data, err := json.Marshal(bufferedEvent{
+		Event:   encoded,
+		Key:     be.GetEvent().Key,
+		Class:   details.Class,
+		Type:    details.Type,
+		Created: be.GetEvent().CreatedTime,
+	})
+	if err != nil {
+		return fmt.Errorf("encode buffered event: %w", err)
+	}
+
+	size := int64(len(data))
+	if b.bytes+size > b.maxBytes {
+		return fmt.Errorf("%w: %d of %d bytes used", errDiskBufferFull, b.bytes, b.maxBytes)
+	}
+
+	// write atomically to not restore partially written events
+	seq := b.nextSeq
+	tmp := b.path(seq) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write buffered event: %w", err)
+	}
+	if err = os.Rename(tmp, b.path(seq)); err != nil {
+		return fmt.Errorf("write buffered event: %w", err)
+	}
+
+	if len(b.entries) == 0 {
+		b.delivered = nil
+	}
+	b.entries = append(b.entries, diskBufferEntry{seq: seq, size: size})
+	b.bytes += size
+	b.nextSeq++
+	return nil
+}
+
+// peek reads the oldest buffered event
+func (b *diskBuffer) peek() (cloudevents.Event, types.BaseEvent, error) {
+	ev := cloudevents.NewEvent()
+
+	data, err := ioutil.ReadFile(b.path(b.entries[0].seq))
+	if err != nil {
+		return ev, nil, fmt.Errorf("read buffered event: %w", err)
+	}
+
+	var buffered bufferedEvent
+	if err = json.Unmarshal(data, &buffered); err != nil {
+		return ev, nil, fmt.Errorf("decode buffered event: %w", err)
+	}
+	if err = ev.UnmarshalJSON(buffered.Event); err != nil {
+		return ev, nil, fmt.Errorf("decode buffered event: %w", err)
+	}
+	return ev, buffered.baseEvent(), nil
+}
+
+// remove deletes the oldest buffered event after delivery
+func (b *diskBuffer) remove(be types.BaseEvent) error {
+	if err := os.Remove(b.path(b.entries[0].seq)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove buffered event: %w", err)
+	}
+	b.bytes -= b.entries[0].size
+	b.entries = b.entries[1:]
+	b.delivered = be
+	return nil
+}
+
+// baseEvent returns a vCenter event with the buffered key, type and creation
+// time, sufficient to checkpoint the event
+func (e bufferedEvent) baseEvent() types.BaseEvent {
+	var be types.BaseEvent
+	switch e.Class {
+	case "eventex":
+		be = &types.EventEx{EventTypeId: e.Type}
+	case "extendedevent":
+		be = &types.ExtendedEvent{EventTypeId: e.Type}
+	default:
+		be = &types.Event{}
+		if t, ok := types.TypeFunc()(e.Type); ok {
+			if v, ok := reflect.New(t).Interface().(types.BaseEvent); ok {
+				be = v
+			}
+		}
+	}
+	be.GetEvent().Key = e.Key
+	be.GetEvent().CreatedTime = e.Created
+	return be
+}
+
+// checkpointEvent returns the event to checkpoint given the last processed
+// event, i.e. the last event delivered from the buffer while events are
+// buffered. If nothing is buffered all processed events are delivered and
+// processed is returned, or the last delivered event if processed is nil.
+func (b *diskBuffer) checkpointEvent(processed types.BaseEvent) types.BaseEvent {
+	if len(b.entries) > 0 || processed == nil {
+		return b.delivered
+	}
+	return processed
+}
+
+// bufferUndelivered persists the given cloud event which could not be
+// delivered because the sink is unreachable. Draining the buffer is retried
+// after the retry interval.
+func (a *vAdapter) bufferUndelivered(ctx context.Context, ev cloudevents.Event, be types.BaseEvent, result error) error {
+	if err := a.DiskBuffer.add(ev, be); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Warnw("sink unreachable, buffering events to disk", zap.String("ID", ev.ID()), zap.Error(result))
+	a.DiskBuffer.nextDrain = a.DiskBuffer.now().Add(a.DiskBuffer.retryInterval)
+	return nil
+}
+
+// drainDiskBuffer delivers up to max buffered events in order and returns
+// the event to checkpoint, nil if none. Draining stops on the first failed
+// delivery and is retried after the retry interval.
+func (a *vAdapter) drainDiskBuffer(ctx context.Context, max int) types.BaseEvent {
+	b := a.DiskBuffer
+	if !b.active() || b.now().Before(b.nextDrain) {
+		return nil
+	}
+
+	logger := logging.FromContext(ctx)
+	for i := 0; i < max && b.active(); i++ {
+		ev, be, err := b.peek()
+		if err != nil {
+			logger.Errorw("could not read buffered event", zap.Error(err))
+			b.nextDrain = b.now().Add(b.retryInterval)
+			return b.checkpointEvent(nil)
+		}
+
+		deadLettered, result := a.deliver(a.Severity.route(ctx, be), ev)
+		if !deadLettered && !cloudevents.IsACK(result) {
+			reportSendFailure(ctx)
+			logger.Warnw("could not deliver buffered event, retrying later", zap.String("ID", ev.ID()),
+				zap.Int("buffered", len(b.entries)), zap.Duration("retryInterval", b.retryInterval), zap.Error(result))
+			b.nextDrain = b.now().Add(b.retryInterval)
+			return b.checkpointEvent(nil)
+		}
+		if !deadLettered {
+			reportEventsSent(ctx, 1)
+		}
+
+		if err = b.remove(be); err != nil {
+			logger.Errorw("could not remove delivered event from disk buffer", zap.Error(err))
+			b.nextDrain = b.now().Add(b.retryInterval)
+			return b.checkpointEvent(nil)
+		}
+	}
+
+	if !b.active() {
+		logger.Infow("drained disk buffer")
+	}
+	return b.checkpointEvent(nil)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/logging"
+)
+
+func Test_newDiskBuffer(t *testing.T) {
+	tests := []struct {
+		name          string
+		dir           string
+		maxBytes      int64
+		retryInterval time.Duration
+		wantNil       bool
+		wantErr       bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "valid", dir: t.TempDir(), maxBytes: 1024, retryInterval: time.Second},
+		{name: "creates directory", dir: filepath.Join(t.TempDir(), "buffer"), maxBytes: 1024, retryInterval: time.Second},
+		{name: "invalid size", dir: t.TempDir(), maxBytes: 0, retryInterval: time.Second, wantErr: true},
+		{name: "invalid retry interval", dir: t.TempDir(), maxBytes: 1024, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDiskBuffer(tt.dir, tt.maxBytes, tt.retryInterval)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDiskBuffer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newDiskBuffer() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_diskBuffer(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := []types.BaseEvent{
+		powerOnEvent(1000, "web-01", now),
+		&types.EventEx{Event: types.Event{Key: 1001, CreatedTime: now}, EventTypeId: "com.vmware.vc.HA.ClusterFailoverActionCompletedEvent"},
+	}
+
+	b, err := newDiskBuffer(dir, 1<<20, time.Second)
+	if err != nil {
+		t.Fatalf("newDiskBuffer() error = %v", err)
+	}
+	for _, be := range events {
+		if err = b.add(newTestCloudEvent(t, be), be); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+	if got := b.checkpointEvent(events[1]); got != nil {
+		t.Errorf("checkpointEvent() = %v while buffered, want nil", got)
+	}
+
+	// leftover of an interrupted write
+	if err = ioutil.WriteFile(filepath.Join(dir, "00000000000000000002.json.tmp"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	// restore after restart
+	b, err = newDiskBuffer(dir, 1<<20, time.Second)
+	if err != nil {
+		t.Fatalf("newDiskBuffer() error = %v", err)
+	}
+	if len(b.entries) != 2 || b.nextSeq != 2 {
+		t.Fatalf("newDiskBuffer() restored %d events with next sequence %d, want 2 events and next sequence 2", len(b.entries), b.nextSeq)
+	}
+
+	for i, want := range events {
+		ev, be, err := b.peek()
+		if err != nil {
+			t.Fatalf("peek() error = %v", err)
+		}
+		if ev.ID() != want.GetEvent().CreatedTime.String() {
+			t.Errorf("peek() event ID = %q, want buffered event %d", ev.ID(), i)
+		}
+		if be.GetEvent().Key != want.GetEvent().Key || !be.GetEvent().CreatedTime.Equal(want.GetEvent().CreatedTime) {
+			t.Errorf("peek() event = %+v, want key and creation time of %+v", be.GetEvent(), want.GetEvent())
+		}
+		if got, want := getEventDetails(be), getEventDetails(want); got != want {
+			t.Errorf("peek() event details = %+v, want %+v", got, want)
+		}
+
+		if err = b.remove(be); err != nil {
+			t.Fatalf("remove() error = %v", err)
+		}
+	}
+
+	if b.active() || b.bytes != 0 {
+		t.Errorf("active() = %v with %d bytes after removing all events, want false", b.active(), b.bytes)
+	}
+	if got := b.checkpointEvent(nil); got == nil || got.GetEvent().Key != 1001 {
+		t.Errorf("checkpointEvent(nil) = %v, want last delivered event", got)
+	}
+	if got := b.checkpointEvent(events[0]); got != events[0] {
+		t.Errorf("checkpointEvent() = %v, want processed event", got)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read directory: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("buffer directory contains %d files after removing all events, want 0", len(files))
+	}
+
+	// buffer full
+	b.maxBytes = 1
+	if err = b.add(newTestCloudEvent(t, events[0]), events[0]); !errors.Is(err, errDiskBufferFull) {
+		t.Errorf("add() error = %v, want %v", err, errDiskBufferFull)
+	}
+}
+
+func Test_vAdapter_diskBuffer(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	now := time.Now().UTC()
+	events := []types.BaseEvent{
+		powerOnEvent(1000, "web-01", now),
+		powerOnEvent(1001, "web-02", now),
+		powerOnEvent(1002, "web-03", now),
+		powerOnEvent(1003, "web-04", now),
+	}
+
+	b, err := newDiskBuffer(t.TempDir(), 1<<20, time.Minute)
+	if err != nil {
+		t.Fatalf("newDiskBuffer() error = %v", err)
+	}
+	clock := now
+	b.now = func() time.Time { return clock }
+
+	// sink unreachable on second event
+	ce := &fakeCEClient{results: []error{nil, errors.New("connection refused")}}
+	a := &vAdapter{
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationJSON,
+		DiskBuffer:      b,
+	}
+
+	n, err := a.sendEvents(ctx, events[:3])
+	if err != nil || n != 3 {
+		t.Fatalf("sendEvents() = %d, %v, want 3 processed events", n, err)
+	}
+	// subsequent events are buffered without sending to preserve ordering
+	if len(ce.sent) != 2 || len(b.entries) != 2 {
+		t.Errorf("sendEvents() sent %d events and buffered %d, want 2 sent and 2 buffered", len(ce.sent), len(b.entries))
+	}
+	if got := b.checkpointEvent(events[2]); got != nil {
+		t.Errorf("checkpointEvent() = %v while buffered, want nil", got)
+	}
+
+	// not retried before retry interval
+	if got := a.drainDiskBuffer(ctx, maxEventsBatch); got != nil || len(ce.sent) != 2 {
+		t.Errorf("drainDiskBuffer() = %v with %d sent events, want no delivery before retry interval", got, len(ce.sent))
+	}
+
+	// sink still unreachable
+	clock = clock.Add(time.Minute)
+	ce.results = []error{errors.New("connection refused")}
+	if got := a.drainDiskBuffer(ctx, maxEventsBatch); got != nil || len(b.entries) != 2 {
+		t.Errorf("drainDiskBuffer() = %v with %d buffered events, want 2 events kept", got, len(b.entries))
+	}
+
+	if n, err = a.sendEvents(ctx, events[3:]); err != nil || n != 1 || len(b.entries) != 3 {
+		t.Fatalf("sendEvents() = %d, %v with %d buffered events, want event appended to buffer", n, err, len(b.entries))
+	}
+
+	// sink recovered: delivered in order
+	clock = clock.Add(time.Minute)
+	ce.sent = nil
+	got := a.drainDiskBuffer(ctx, maxEventsBatch)
+	if got == nil || got.GetEvent().Key != 1003 {
+		t.Errorf("drainDiskBuffer() = %v, want checkpoint of last buffered event", got)
+	}
+	if b.active() {
+		t.Errorf("drainDiskBuffer() kept %d buffered events, want 0", len(b.entries))
+	}
+
+	var gotIDs []string
+	for _, ev := range ce.sent {
+		gotIDs = append(gotIDs, ev.ID())
+	}
+	if diff := cmp.Diff([]string{"1001", "1002", "1003"}, gotIDs); diff != "" {
+		t.Errorf("drainDiskBuffer() sent events (-want +got): %s", diff)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// entityRefFunc resolves an inventory path to a managed object reference. It
+// returns nil if no entity exists at the path.
+type entityRefFunc func(ctx context.Context, path string) (*types.ManagedObjectReference, error)
+
+// allowlistEntry is a cached resolution of an allowlisted inventory path
+type allowlistEntry struct {
+	// nil if the entity did not exist when resolved
+	ref      *types.ManagedObjectReference
+	resolved time.Time
+}
+
+// entityAllowlist restricts the sent events to events referencing one of a
+// set of entities, e.g. a datacenter, cluster, host or VM, identified by
+// inventory path. Paths are resolved lazily when events arrive and cached for
+// ttl, so entities created after startup are picked up once their cache entry
+// expires.
+type entityAllowlist struct {
+	paths   []string
+	resolve entityRefFunc
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]allowlistEntry
+}
+
+// newEntityAllowlist returns an allowlist of the given inventory paths. It
+// returns nil if paths is empty, i.e. all events are allowed.
+func newEntityAllowlist(paths []string, resolve entityRefFunc, ttl time.Duration) *entityAllowlist {
+	var allowed []string
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" {
+			allowed = append(allowed, p)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	return &entityAllowlist{
+		paths:   allowed,
+		resolve: resolve,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]allowlistEntry, len(allowed)),
+	}
+}
+
+// inventoryRefFunc returns an entityRefFunc using the given vSphere client
+func inventoryRefFunc(client *vim25.Client) entityRefFunc {
+	index := object.NewSearchIndex(client)
+	return func(ctx context.Context, path string) (*types.ManagedObjectReference, error) {
+		ref, err := index.FindByInventoryPath(ctx, path)
+		if err != nil || ref == nil {
+			return nil, err
+		}
+		moref := ref.Reference()
+		return &moref, nil
+	}
+}
+
+// refs returns the managed object references of the allowlisted entities,
+// resolving paths not cached or expired
+func (l *entityAllowlist) refs(ctx context.Context) map[types.ManagedObjectReference]struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	refs := make(map[types.ManagedObjectReference]struct{}, len(l.paths))
+	for _, path := range l.paths {
+		entry, ok := l.entries[path]
+		if !ok || now.Sub(entry.resolved) >= l.ttl {
+			ref, err := l.resolve(ctx, path)
+			if err != nil {
+				// keep previous resolution and retry after ttl
+				logging.FromContext(ctx).Warnw("could not resolve allowlisted entity", zap.String("path", path), zap.Error(err))
+				ref = entry.ref
+			} else if ref == nil {
+				logging.FromContext(ctx).Debugw("allowlisted entity not found", zap.String("path", path))
+			}
+			entry = allowlistEntry{ref: ref, resolved: now}
+			l.entries[path] = entry
+		}
+
+		if entry.ref != nil {
+			refs[*entry.ref] = struct{}{}
+		}
+	}
+	return refs
+}
+
+// allows returns whether the given event references an allowlisted entity
+func (l *entityAllowlist) allows(ctx context.Context, be types.BaseEvent) bool {
+	if l == nil {
+		return true
+	}
+
+	refs := l.refs(ctx)
+	for _, ref := range eventEntities(be.GetEvent()) {
+		if _, ok := refs[ref]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eventEntities returns all managed entities referenced by the given event
+func eventEntities(e *types.Event) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+	if e.Vm != nil {
+		refs = append(refs, e.Vm.Vm)
+	}
+	if e.Host != nil {
+		refs = append(refs, e.Host.Host)
+	}
+	if e.Ds != nil {
+		refs = append(refs, e.Ds.Datastore)
+	}
+	if e.Net != nil {
+		refs = append(refs, e.Net.Network)
+	}
+	if e.Dvs != nil {
+		refs = append(refs, e.Dvs.Dvs)
+	}
+	if e.ComputeResource != nil {
+		refs = append(refs, e.ComputeResource.ComputeResource)
+	}
+	if e.Datacenter != nil {
+		refs = append(refs, e.Datacenter.Datacenter)
+	}
+	return refs
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newEntityAllowlist(t *testing.T) {
+	if got := newEntityAllowlist([]string{"", " "}, nil, time.Minute); got != nil {
+		t.Errorf("newEntityAllowlist() = %v, want nil", got)
+	}
+	if got := newEntityAllowlist([]string{"/DC1/vm/web-01"}, nil, time.Minute); got == nil {
+		t.Error("newEntityAllowlist() = nil, want allowlist")
+	}
+}
+
+func Test_entityAllowlist_allows(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	cluster := types.ManagedObjectReference{Type: "ClusterComputeResource", Value: "domain-c1"}
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}
+
+	vmEvent := func(ref types.ManagedObjectReference) types.BaseEvent {
+		return &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+			Vm:              &types.VmEventArgument{Vm: ref},
+			ComputeResource: &types.ComputeResourceEventArgument{ComputeResource: cluster},
+		}}}
+	}
+
+	var (
+		resolves int
+		// VM does not exist yet
+		inventory = map[string]types.ManagedObjectReference{"/DC1/host/Cluster1": cluster}
+		failing   bool
+	)
+	resolve := func(_ context.Context, path string) (*types.ManagedObjectReference, error) {
+		resolves++
+		if failing {
+			return nil, errors.New("vcenter unavailable")
+		}
+		if ref, ok := inventory[path]; ok {
+			return &ref, nil
+		}
+		return nil, nil
+	}
+
+	l := newEntityAllowlist([]string{"/DC1/vm/web-01"}, resolve, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if l.allows(ctx, vmEvent(vm)) {
+		t.Error("allows() = true for entity not existing yet, want false")
+	}
+
+	// cached until ttl expires
+	inventory["/DC1/vm/web-01"] = vm
+	if l.allows(ctx, vmEvent(vm)) || resolves != 1 {
+		t.Errorf("allows() resolved %d times before ttl expired, want 1", resolves)
+	}
+
+	now = now.Add(time.Minute)
+	if !l.allows(ctx, vmEvent(vm)) {
+		t.Error("allows() = false for entity created after startup, want true")
+	}
+
+	// previous resolution kept on errors
+	failing = true
+	now = now.Add(time.Minute)
+	if !l.allows(ctx, vmEvent(vm)) {
+		t.Error("allows() = false after failed resolution, want true")
+	}
+
+	// parent entities match events of their children
+	parent := newEntityAllowlist([]string{"/DC1/host/Cluster1"}, func(context.Context, string) (*types.ManagedObjectReference, error) {
+		return &cluster, nil
+	}, time.Minute)
+	other := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-7"}
+	if !parent.allows(ctx, vmEvent(other)) {
+		t.Error("allows() = false for event of VM in allowlisted cluster, want true")
+	}
+	if parent.allows(ctx, &types.VmPoweredOnEvent{}) {
+		t.Error("allows() = true for event without entities, want false")
+	}
+
+	var disabled *entityAllowlist
+	if !disabled.allows(ctx, vmEvent(vm)) {
+		t.Error("allows() = false for disabled allowlist, want true")
+	}
+}
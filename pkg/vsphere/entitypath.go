@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// extended attribute carrying the inventory path of the event's primary entity
+const ceVSphereEntityPath = "vsphereentitypath"
+
+// entityPathFunc resolves a managed object reference to its inventory path
+type entityPathFunc func(ctx context.Context, ref types.ManagedObjectReference) (string, error)
+
+// entityPathResolver resolves the inventory path, e.g. /DC1/vm/folder/MyVM, of
+// the primary entity of vSphere events. Resolved paths are cached and the
+// cache is invalidated periodically to pick up renamed or moved entities.
+type entityPathResolver struct {
+	resolve entityPathFunc
+	ttl     time.Duration
+	cache   *lru.Cache
+
+	mu     sync.Mutex
+	purged time.Time
+	now    func() time.Time
+}
+
+// newEntityPathResolver returns a resolver caching up to size paths which are
+// invalidated after ttl. A ttl of 0 disables invalidation.
+func newEntityPathResolver(resolve entityPathFunc, size int, ttl time.Duration) (*entityPathResolver, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, fmt.Errorf("create entity path cache: %w", err)
+	}
+
+	return &entityPathResolver{
+		resolve: resolve,
+		ttl:     ttl,
+		cache:   cache,
+		purged:  time.Now(),
+		now:     time.Now,
+	}, nil
+}
+
+// inventoryPathFunc returns an entityPathFunc using the given vSphere client
+func inventoryPathFunc(client *vim25.Client) entityPathFunc {
+	return func(ctx context.Context, ref types.ManagedObjectReference) (string, error) {
+		return find.InventoryPath(ctx, client, ref)
+	}
+}
+
+// path returns the inventory path of the primary entity of the given event.
+// False is returned if the event does not reference an entity or the path
+// could not be resolved.
+func (r *entityPathResolver) path(ctx context.Context, be types.BaseEvent) (string, bool) {
+	ref := primaryEntity(be.GetEvent())
+	if ref == nil {
+		return "", false
+	}
+
+	r.mu.Lock()
+	if r.ttl > 0 && r.now().Sub(r.purged) >= r.ttl {
+		r.cache.Purge()
+		r.purged = r.now()
+	}
+	r.mu.Unlock()
+
+	if p, ok := r.cache.Get(*ref); ok {
+		return p.(string), true
+	}
+
+	p, err := r.resolve(ctx, *ref)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("could not resolve inventory path", zap.String("entity", ref.String()), zap.Error(err))
+		return "", false
+	}
+
+	r.cache.Add(*ref, p)
+	return p, true
+}
+
+// primaryEntity returns the most specific managed entity referenced by the
+// given event or nil if the event does not reference an entity
+func primaryEntity(e *types.Event) *types.ManagedObjectReference {
+	switch {
+	case e.Vm != nil:
+		return &e.Vm.Vm
+	case e.Host != nil:
+		return &e.Host.Host
+	case e.Ds != nil:
+		return &e.Ds.Datastore
+	case e.Net != nil:
+		return &e.Net.Network
+	case e.Dvs != nil:
+		return &e.Dvs.Dvs
+	case e.ComputeResource != nil:
+		return &e.ComputeResource.ComputeResource
+	case e.Datacenter != nil:
+		return &e.Datacenter.Datacenter
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_entityPathResolver_path(t *testing.T) {
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	vmEvent := &types.VmPoweredOnEvent{
+		VmEvent: types.VmEvent{
+			Event: types.Event{
+				Host: &types.HostEventArgument{Host: types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}},
+				Vm:   &types.VmEventArgument{Vm: vm},
+			},
+		},
+	}
+
+	var calls int
+	resolve := func(_ context.Context, ref types.ManagedObjectReference) (string, error) {
+		calls++
+		if ref != vm {
+			return "", errors.New("unknown entity")
+		}
+		return "/DC1/vm/folder/MyVM", nil
+	}
+
+	r, err := newEntityPathResolver(resolve, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("newEntityPathResolver() error = %v", err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		got, ok := r.path(ctx, vmEvent)
+		if !ok || got != "/DC1/vm/folder/MyVM" {
+			t.Errorf("path() = %q, %v, want %q, true", got, ok, "/DC1/vm/folder/MyVM")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("path() resolved %d times, want cached resolution", calls)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := r.path(ctx, vmEvent); !ok || calls != 2 {
+		t.Errorf("path() resolved %d times, want resolution after cache invalidation", calls)
+	}
+
+	if _, ok := r.path(ctx, &types.UserLoginSessionEvent{}); ok {
+		t.Error("path() for event without entity returned true")
+	}
+
+	hostEvent := &types.HostConnectedEvent{
+		HostEvent: types.HostEvent{
+			Event: types.Event{
+				Host: &types.HostEventArgument{Host: types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}},
+			},
+		},
+	}
+	if _, ok := r.path(ctx, hostEvent); ok {
+		t.Error("path() for unresolvable entity returned true")
+	}
+}
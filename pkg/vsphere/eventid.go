@@ -0,0 +1,72 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// IDGenerator generates the CloudEvent ID for a vSphere event. Implementations
+// are called sequentially for all events sent to the sink in event stream
+// order.
+type IDGenerator interface {
+	ID(ctx context.Context, be types.BaseEvent) string
+}
+
+// keyIDGenerator is the default IDGenerator using the event key as ID
+type keyIDGenerator struct {
+	source    string
+	composite bool
+	// key of the previous event
+	prevKey int32
+}
+
+var _ IDGenerator = (*keyIDGenerator)(nil)
+
+// newKeyIDGenerator returns an IDGenerator using the event key as ID. If
+// composite is set, a composite ID is used for events with a zero key or a key
+// equal to the previous event (see eventID).
+func newKeyIDGenerator(source string, composite bool) *keyIDGenerator {
+	return &keyIDGenerator{
+		source:    source,
+		composite: composite,
+	}
+}
+
+// ID implements IDGenerator
+func (g *keyIDGenerator) ID(ctx context.Context, be types.BaseEvent) string {
+	id, fallback := eventID(be, g.prevKey, g.source, g.composite)
+	if fallback {
+		logging.FromContext(ctx).Warnw("using composite event ID: zero or duplicate event key",
+			zap.Int32("eventKey", be.GetEvent().Key), zap.String("ID", id))
+	}
+	g.prevKey = be.GetEvent().Key
+	return id
+}
+
+// eventID returns the CloudEvent ID for the given vSphere event. The event key
+// is used unless composite is set and the key is zero or equal to the key of
+// the previous event prevKey, e.g. after a reset of the event collector. In
+// this case a composite ID of host, chain ID and creation time is returned and
+// fallback is true.
+func eventID(be types.BaseEvent, prevKey int32, source string, composite bool) (id string, fallback bool) {
+	e := be.GetEvent()
+	if !composite || (e.Key != 0 && e.Key != prevKey) {
+		return strconv.Itoa(int(e.Key)), false
+	}
+
+	host := source
+	if e.Host != nil && e.Host.Name != "" {
+		host = e.Host.Name
+	}
+	return fmt.Sprintf("%s-%d-%d", host, e.ChainId, e.CreatedTime.UnixNano()), true
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_eventID(t *testing.T) {
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(key int32, host string) types.BaseEvent {
+		e := types.Event{
+			Key:         key,
+			ChainId:     42,
+			CreatedTime: created,
+		}
+		if host != "" {
+			e.Host = &types.HostEventArgument{EntityEventArgument: types.EntityEventArgument{Name: host}}
+		}
+		return &e
+	}
+
+	tests := []struct {
+		name         string
+		event        types.BaseEvent
+		prevKey      int32
+		composite    bool
+		wantID       string
+		wantFallback bool
+	}{
+		{
+			name:      "unique key",
+			event:     newEvent(1000, ""),
+			prevKey:   999,
+			composite: true,
+			wantID:    "1000",
+		},
+		{
+			name:         "zero key",
+			event:        newEvent(0, "esx-01"),
+			prevKey:      999,
+			composite:    true,
+			wantID:       fmt.Sprintf("esx-01-42-%d", created.UnixNano()),
+			wantFallback: true,
+		},
+		{
+			name:         "duplicate key without host",
+			event:        newEvent(1000, ""),
+			prevKey:      1000,
+			composite:    true,
+			wantID:       fmt.Sprintf("%s-42-%d", source, created.UnixNano()),
+			wantFallback: true,
+		},
+		{
+			name:      "duplicate key with composite IDs disabled",
+			event:     newEvent(1000, ""),
+			prevKey:   1000,
+			composite: false,
+			wantID:    "1000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, fallback := eventID(tt.event, tt.prevKey, source, tt.composite)
+			if id != tt.wantID {
+				t.Errorf("eventID() id = %q, want %q", id, tt.wantID)
+			}
+			if fallback != tt.wantFallback {
+				t.Errorf("eventID() fallback = %v, want %v", fallback, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_duplicateKeys(t *testing.T) {
+	now := time.Now().UTC()
+	events := []types.BaseEvent{
+		createBaseEvent(1000, now),
+		createBaseEvent(1000, now.Add(time.Second)),
+		createBaseEvent(0, now.Add(2*time.Second)),
+		createBaseEvent(1001, now.Add(3*time.Second)),
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		CompositeIDs:    true,
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil || n != len(events) {
+		t.Fatalf("sendEvents() = %d, %v, want %d, nil", n, err, len(events))
+	}
+
+	seen := make(map[string]struct{})
+	for _, ev := range ce.sent {
+		if _, ok := seen[ev.ID()]; ok {
+			t.Errorf("sendEvents() sent duplicate ID %q", ev.ID())
+		}
+		seen[ev.ID()] = struct{}{}
+	}
+
+	if got := ce.sent[0].ID(); got != "1000" {
+		t.Errorf("sendEvents() first ID = %q, want %q", got, "1000")
+	}
+	if got := ce.sent[3].ID(); got != "1001" {
+		t.Errorf("sendEvents() last ID = %q, want %q", got, "1001")
+	}
+}
+
+// prefixIDGenerator is a custom IDGenerator prefixing the event key
+type prefixIDGenerator struct {
+	prefix string
+}
+
+func (g prefixIDGenerator) ID(_ context.Context, be types.BaseEvent) string {
+	return fmt.Sprintf("%s-%d", g.prefix, be.GetEvent().Key)
+}
+
+func Test_vAdapter_sendEvents_idGenerator(t *testing.T) {
+	events := createTestEvents(2, source, time.Now().UTC()).vEvents
+
+	tests := []struct {
+		name      string
+		generator IDGenerator
+		wantIDs   []string
+	}{
+		{
+			name:    "default generator",
+			wantIDs: []string{"1000", "1001"},
+		},
+		{
+			name:      "key generator",
+			generator: newKeyIDGenerator(source, true),
+			wantIDs:   []string{"1000", "1001"},
+		},
+		{
+			name:      "custom generator",
+			generator: prefixIDGenerator{prefix: "vc-01"},
+			wantIDs:   []string{"vc-01-1000", "vc-01-1001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := &fakeCEClient{}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				IDGenerator:     tt.generator,
+			}
+
+			if _, err := a.sendEvents(context.Background(), events); err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+
+			for i, ev := range ce.sent {
+				if ev.ID() != tt.wantIDs[i] {
+					t.Errorf("sendEvents() ID = %q, want %q", ev.ID(), tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// PayloadEncodingEventV1 selects the stable, versioned JSON representation
+	// EventV1 of vSphere events as CloudEvent payload
+	PayloadEncodingEventV1 = "application/vnd.vsphere.event.v1+json"
+)
+
+// EventV1 is a normalized JSON representation of a vSphere event which is
+// independent of the govmomi library version. Fields are only added, never
+// renamed or removed, within a version.
+type EventV1 struct {
+	// Key is the vCenter event key
+	Key int32 `json:"key"`
+	// ChainID is the key of the parent event of a chain of events
+	ChainID int32 `json:"chainId"`
+	// Type is the vSphere event type, e.g. VmPoweredOnEvent or the event type
+	// ID of EventEx and ExtendedEvent events
+	Type string `json:"type"`
+	// Class is the event class: event, eventex or extendedevent
+	Class string `json:"class"`
+	// CreatedTime is the time the event was created in vCenter
+	CreatedTime time.Time `json:"createdTime"`
+	// UserName is the user who caused the event
+	UserName string `json:"userName,omitempty"`
+	// Message is the formatted event message
+	Message string `json:"message,omitempty"`
+	// Severity is the severity of EventEx events, e.g. info or warning
+	Severity string `json:"severity,omitempty"`
+	// ChangeTag is the change tag of the event
+	ChangeTag string `json:"changeTag,omitempty"`
+
+	// Entities the event refers to
+	Datacenter      *EntityV1 `json:"datacenter,omitempty"`
+	ComputeResource *EntityV1 `json:"computeResource,omitempty"`
+	Host            *EntityV1 `json:"host,omitempty"`
+	VM              *EntityV1 `json:"vm,omitempty"`
+	Datastore       *EntityV1 `json:"datastore,omitempty"`
+	Network         *EntityV1 `json:"network,omitempty"`
+	DVS             *EntityV1 `json:"dvs,omitempty"`
+	// Object is the object of EventEx and ExtendedEvent events
+	Object *EntityV1 `json:"object,omitempty"`
+
+	// Arguments are the arguments of EventEx and the data of ExtendedEvent
+	// events
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// EntityV1 is a managed entity referenced by an EventV1
+type EntityV1 struct {
+	// Name is the name of the entity
+	Name string `json:"name,omitempty"`
+	// Type is the managed object type, e.g. VirtualMachine
+	Type string `json:"type,omitempty"`
+	// Value is the managed object ID, e.g. vm-42
+	Value string `json:"value,omitempty"`
+}
+
+// newEventV1 returns the EventV1 representation of the given vSphere event
+func newEventV1(be types.BaseEvent) EventV1 {
+	e := be.GetEvent()
+	details := getEventDetails(be)
+
+	ev := EventV1{
+		Key:         e.Key,
+		ChainID:     e.ChainId,
+		Type:        details.Type,
+		Class:       details.Class,
+		CreatedTime: e.CreatedTime,
+		UserName:    e.UserName,
+		Message:     e.FullFormattedMessage,
+		ChangeTag:   e.ChangeTag,
+	}
+
+	if e.Datacenter != nil {
+		ev.Datacenter = newEntityV1(e.Datacenter.Name, e.Datacenter.Datacenter)
+	}
+	if e.ComputeResource != nil {
+		ev.ComputeResource = newEntityV1(e.ComputeResource.Name, e.ComputeResource.ComputeResource)
+	}
+	if e.Host != nil {
+		ev.Host = newEntityV1(e.Host.Name, e.Host.Host)
+	}
+	if e.Vm != nil {
+		ev.VM = newEntityV1(e.Vm.Name, e.Vm.Vm)
+	}
+	if e.Ds != nil {
+		ev.Datastore = newEntityV1(e.Ds.Name, e.Ds.Datastore)
+	}
+	if e.Net != nil {
+		ev.Network = newEntityV1(e.Net.Name, e.Net.Network)
+	}
+	if e.Dvs != nil {
+		ev.DVS = newEntityV1(e.Dvs.Name, e.Dvs.Dvs)
+	}
+
+	switch t := be.(type) {
+	case *types.EventEx:
+		ev.Severity = t.Severity
+		if ev.Message == "" {
+			ev.Message = t.Message
+		}
+		if t.ObjectId != "" || t.ObjectName != "" {
+			ev.Object = &EntityV1{Name: t.ObjectName, Type: t.ObjectType, Value: t.ObjectId}
+		}
+		for _, arg := range t.Arguments {
+			ev.setArgument(arg.Key, fmt.Sprintf("%v", arg.Value))
+		}
+	case *types.ExtendedEvent:
+		if t.ManagedObject.Value != "" {
+			ev.Object = newEntityV1("", t.ManagedObject)
+		}
+		for _, pair := range t.Data {
+			ev.setArgument(pair.Key, pair.Value)
+		}
+	}
+
+	return ev
+}
+
+func (ev *EventV1) setArgument(key, value string) {
+	if ev.Arguments == nil {
+		ev.Arguments = make(map[string]string)
+	}
+	ev.Arguments[key] = value
+}
+
+func newEntityV1(name string, ref types.ManagedObjectReference) *EntityV1 {
+	return &EntityV1{
+		Name:  name,
+		Type:  ref.Type,
+		Value: ref.Value,
+	}
+}
+
+// marshalEventV1 returns the JSON encoding of the EventV1 representation of
+// the given vSphere event
+func marshalEventV1(be types.BaseEvent) ([]byte, error) {
+	return json.Marshal(newEventV1(be))
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newEventV1(t *testing.T) {
+	created := time.Date(2020, 7, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event types.BaseEvent
+		want  EventV1
+	}{
+		{
+			name: "VM event",
+			event: &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+				Key:                  1000,
+				ChainId:              1000,
+				CreatedTime:          created,
+				UserName:             "root",
+				FullFormattedMessage: "vm-1 on host-1 is powered on",
+				Datacenter: &types.DatacenterEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "dc-1"},
+					Datacenter:          types.ManagedObjectReference{Type: "Datacenter", Value: "datacenter-2"},
+				},
+				Vm: &types.VmEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "vm-1"},
+					Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"},
+				},
+			}}},
+			want: EventV1{
+				Key:         1000,
+				ChainID:     1000,
+				Type:        "VmPoweredOnEvent",
+				Class:       "event",
+				CreatedTime: created,
+				UserName:    "root",
+				Message:     "vm-1 on host-1 is powered on",
+				Datacenter:  &EntityV1{Name: "dc-1", Type: "Datacenter", Value: "datacenter-2"},
+				VM:          &EntityV1{Name: "vm-1", Type: "VirtualMachine", Value: "vm-42"},
+			},
+		},
+		{
+			name: "EventEx",
+			event: &types.EventEx{
+				Event:       types.Event{Key: 1001, CreatedTime: created},
+				EventTypeId: "com.vmware.cl.CreateLibraryEvent",
+				Severity:    "info",
+				Message:     "library created",
+				ObjectId:    "lib-1",
+				ObjectType:  "ContentLibrary",
+				ObjectName:  "my-library",
+				Arguments: []types.KeyAnyValue{
+					{Key: "libraryName", Value: "my-library"},
+				},
+			},
+			want: EventV1{
+				Key:         1001,
+				Type:        "com.vmware.cl.CreateLibraryEvent",
+				Class:       "eventex",
+				CreatedTime: created,
+				Message:     "library created",
+				Severity:    "info",
+				Object:      &EntityV1{Name: "my-library", Type: "ContentLibrary", Value: "lib-1"},
+				Arguments:   map[string]string{"libraryName": "my-library"},
+			},
+		},
+		{
+			name: "ExtendedEvent",
+			event: &types.ExtendedEvent{
+				GeneralEvent:  types.GeneralEvent{Event: types.Event{Key: 1002, CreatedTime: created}},
+				EventTypeId:   "com.vmware.vc.extension",
+				ManagedObject: types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"},
+				Data:          []types.ExtendedEventPair{{Key: "reason", Value: "maintenance"}},
+			},
+			want: EventV1{
+				Key:         1002,
+				Type:        "com.vmware.vc.extension",
+				Class:       "extendedevent",
+				CreatedTime: created,
+				Object:      &EntityV1{Type: "HostSystem", Value: "host-1"},
+				Arguments:   map[string]string{"reason": "maintenance"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, newEventV1(tt.event)); diff != "" {
+				t.Errorf("newEventV1() (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_eventV1(t *testing.T) {
+	events := []types.BaseEvent{
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000}}},
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: PayloadEncodingEventV1,
+	}
+
+	if n, err := a.sendEvents(context.Background(), events); err != nil || n != 1 {
+		t.Fatalf("sendEvents() = %d, %v, want 1, nil", n, err)
+	}
+
+	ev := ce.sent[0]
+	if ev.DataContentType() != PayloadEncodingEventV1 {
+		t.Errorf("sendEvents() datacontenttype = %q, want %q", ev.DataContentType(), PayloadEncodingEventV1)
+	}
+
+	var got EventV1
+	if err := json.Unmarshal(ev.Data(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Key != 1000 || got.Type != "VmPoweredOnEvent" {
+		t.Errorf("sendEvents() data key, type = %d, %q, want 1000, VmPoweredOnEvent", got.Key, got.Type)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+var (
+	// CloudEvent attribute names must consist of lower-case letters and digits
+	extensionNameRegex = regexp.MustCompile(`^[a-z0-9]+$`)
+	// segments of an event field path must be exported Go field names
+	fieldNameRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+	// attribute names which cannot be used for custom extensions
+	reservedAttributes = map[string]struct{}{
+		"specversion":             {},
+		"id":                      {},
+		"source":                  {},
+		"type":                    {},
+		"subject":                 {},
+		"time":                    {},
+		"datacontenttype":         {},
+		"dataschema":              {},
+		"data":                    {},
+		ceDataContentEncoding:     {},
+		ceVSphereAPIKey:           {},
+		ceVSphereEventClass:       {},
+		ceVSphereEntityPath:       {},
+		ceVSphereTruncated:        {},
+		ceVSphereDeadLetterReason: {},
+		ceVSphereChainSeq:         {},
+		ceVSphereVMGuest:          {},
+		ceVSphereVMHost:           {},
+		ceVSphereVMCluster:        {},
+		ceVSphereIdempotencyKey:   {},
+		ceVSphereReplay:           {},
+		ceVSphereMoRef:            {},
+		ceVSphereDatacenter:       {},
+	}
+
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// extensionMap maps CloudEvent extension names to field paths in a vSphere
+// event, e.g. "vmname" -> ["Vm", "Name"]
+type extensionMap map[string][]string
+
+// newExtensionMap returns an extensionMap for the given JSON-encoded object of
+// extension names to dot-separated event field paths, e.g.
+// {"vmname":"Vm.Name","user":"UserName"}. Field paths are resolved against
+// the concrete vSphere event type when sending events. Paths which cannot be
+// resolved on the base vSphere event type are logged as a warning.
+func newExtensionMap(ctx context.Context, config string) (extensionMap, error) {
+	if config == "" {
+		return nil, nil
+	}
+
+	var in map[string]string
+	if err := json.Unmarshal([]byte(config), &in); err != nil {
+		return nil, fmt.Errorf("unmarshal extension map: %w", err)
+	}
+
+	m := make(extensionMap, len(in))
+	for name, path := range in {
+		if !extensionNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid extension name %q: must consist of lower-case letters and digits", name)
+		}
+
+		if _, ok := reservedAttributes[name]; ok {
+			return nil, fmt.Errorf("invalid extension name %q: reserved attribute name", name)
+		}
+
+		fields := strings.Split(path, ".")
+		for _, f := range fields {
+			if !fieldNameRegex.MatchString(f) {
+				return nil, fmt.Errorf("invalid field path %q for extension %q", path, name)
+			}
+		}
+
+		if !resolvableType(reflect.TypeOf(types.Event{}), fields) {
+			logging.FromContext(ctx).Warnw("field path cannot be resolved on base vSphere event, "+
+				"extension will only be set for events providing this field",
+				zap.String("extension", name), zap.String("path", path))
+		}
+		m[name] = fields
+	}
+
+	return m, nil
+}
+
+// apply sets the mapped extensions permitted by allowed on the given
+// CloudEvent. Field paths which cannot be resolved for the given vSphere event
+// are skipped.
+func (m extensionMap) apply(ctx context.Context, ev *cloudevents.Event, be types.BaseEvent, allowed extensionAllowlist) {
+	for name, fields := range m {
+		if !allowed.allows(name) {
+			continue
+		}
+
+		v, ok := resolveField(reflect.ValueOf(be), fields)
+		if !ok {
+			logging.FromContext(ctx).Debugw("skipping extension: field path cannot be resolved",
+				zap.String("extension", name), zap.String("path", strings.Join(fields, ".")))
+			continue
+		}
+		ev.SetExtension(name, v)
+	}
+}
+
+// extensionAllowlist contains the CloudEvent extensions which are set on
+// emitted events. A nil allowlist permits all extensions.
+type extensionAllowlist map[string]struct{}
+
+// newExtensionAllowlist returns an allowlist for the given extension names. An
+// empty list of names permits all extensions.
+func newExtensionAllowlist(names []string) (extensionAllowlist, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	l := make(extensionAllowlist, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !extensionNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid extension name %q: must consist of lower-case letters and digits", name)
+		}
+		l[name] = struct{}{}
+	}
+	return l, nil
+}
+
+// allows returns true if the given extension is permitted
+func (l extensionAllowlist) allows(name string) bool {
+	if l == nil {
+		return true
+	}
+	_, ok := l[name]
+	return ok
+}
+
+// set sets the given extension on the CloudEvent if permitted
+func (l extensionAllowlist) set(ev *cloudevents.Event, name string, value interface{}) {
+	if l.allows(name) {
+		ev.SetExtension(name, value)
+	}
+}
+
+// resolvableType returns true if the field path exists on the given type
+func resolvableType(t reflect.Type, fields []string) bool {
+	for _, f := range fields {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+
+		sf, ok := t.FieldByName(f)
+		if !ok {
+			return false
+		}
+		t = sf.Type
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return isScalar(t)
+}
+
+// resolveField returns the value of the field path in v as a CloudEvent
+// extension value. False is returned if the path does not exist, traverses a
+// nil value or does not end in a scalar value.
+func resolveField(v reflect.Value, fields []string) (interface{}, bool) {
+	for _, f := range fields {
+		v = indirect(v)
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		v = v.FieldByName(f)
+		if !v.IsValid() {
+			return nil, false
+		}
+	}
+
+	v = indirect(v)
+	if !v.IsValid() || !isScalar(v.Type()) {
+		return nil, false
+	}
+
+	if v.Type() == timeType {
+		return v.Interface(), true
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+// indirect dereferences pointers and interfaces, returning an invalid value
+// for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isScalar returns true if values of the given type can be used as extension
+// value
+func isScalar(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Interface:
+		// resolved against the concrete value
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newExtensionMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    extensionMap
+		wantErr bool
+	}{
+		{
+			name:   "empty config",
+			config: "",
+			want:   nil,
+		},
+		{
+			name:   "valid config",
+			config: `{"vmname":"Vm.Name","user":"UserName","desthost":"DestHost.Name"}`,
+			want: extensionMap{
+				"vmname":   {"Vm", "Name"},
+				"user":     {"UserName"},
+				"desthost": {"DestHost", "Name"},
+			},
+		},
+		{
+			name:    "invalid JSON",
+			config:  `{"vmname":`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid extension name",
+			config:  `{"vm-name":"Vm.Name"}`,
+			wantErr: true,
+		},
+		{
+			name:    "reserved extension name",
+			config:  `{"source":"Vm.Name"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid field path",
+			config:  `{"vmname":"Vm..Name"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unexported field",
+			config:  `{"vmname":"vm.name"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newExtensionMap(context.TODO(), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newExtensionMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("newExtensionMap() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_extensionMap_apply(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	be := &types.VmPoweredOnEvent{
+		VmEvent: types.VmEvent{
+			Event: types.Event{
+				Key:         42,
+				UserName:    "jane-doe",
+				CreatedTime: now,
+				Vm: &types.VmEventArgument{
+					EntityEventArgument: types.EntityEventArgument{
+						Name: "vm-01",
+					},
+				},
+			},
+		},
+	}
+
+	m := extensionMap{
+		"vmname":   {"Vm", "Name"},
+		"user":     {"UserName"},
+		"eventkey": {"Key"},
+		"created":  {"CreatedTime"},
+		// nil pointer
+		"hostname": {"Host", "Name"},
+		// not a scalar value
+		"vm": {"Vm"},
+		// field does not exist
+		"desthost": {"DestHost", "Name"},
+	}
+
+	ev := cloudevents.NewEvent()
+	m.apply(context.TODO(), &ev, be, nil)
+
+	want := map[string]interface{}{
+		"vmname":   "vm-01",
+		"user":     "jane-doe",
+		"eventkey": "42",
+		"created":  cloudevents.Timestamp{Time: now},
+	}
+
+	if diff := cmp.Diff(want, ev.Extensions()); diff != "" {
+		t.Errorf("apply() extensions (-want +got): %s", diff)
+	}
+}
+
+func Test_extensionAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "empty allowlist permits all extensions",
+			names: nil,
+			want: map[string]interface{}{
+				ceVSphereEventClass: "event",
+				ceVSphereAPIKey:     "6.7.0",
+				"vmname":            "vm-01",
+			},
+		},
+		{
+			name:  "built-in extensions excluded",
+			names: []string{"vmname"},
+			want: map[string]interface{}{
+				"vmname": "vm-01",
+			},
+		},
+		{
+			name:  "mapped extensions excluded",
+			names: []string{ceVSphereEventClass, " " + ceVSphereAPIKey},
+			want: map[string]interface{}{
+				ceVSphereEventClass: "event",
+				ceVSphereAPIKey:     "6.7.0",
+			},
+		},
+		{
+			name:    "invalid extension name",
+			names:   []string{"vm_name"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := newExtensionAllowlist(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newExtensionAllowlist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			be := &types.VmPoweredOnEvent{
+				VmEvent: types.VmEvent{
+					Event: types.Event{
+						Vm: &types.VmEventArgument{
+							EntityEventArgument: types.EntityEventArgument{
+								Name: "vm-01",
+							},
+						},
+					},
+				},
+			}
+
+			ev := cloudevents.NewEvent()
+			l.set(&ev, ceVSphereEventClass, "event")
+			l.set(&ev, ceVSphereAPIKey, "6.7.0")
+			extensionMap{"vmname": {"Vm", "Name"}}.apply(context.TODO(), &ev, be, l)
+
+			if diff := cmp.Diff(tt.want, ev.Extensions()); diff != "" {
+				t.Errorf("extensions (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// fallbackSink receives events while the primary sink is unavailable for
+// longer than threshold. While the fallback sink is active, the primary sink
+// is retried at most once per threshold and used again as soon as it
+// recovers.
+type fallbackSink struct {
+	client    cloudevents.Client
+	threshold time.Duration
+
+	// first failure of the primary sink since its last successful delivery
+	downSince time.Time
+	// last time the primary sink was tried while the fallback sink is active
+	lastProbe time.Time
+}
+
+// newFallbackSink returns a fallback sink sending to the given target with a
+// client created by newClient. It returns nil if target is empty, i.e. the
+// fallback is disabled.
+func newFallbackSink(target string, threshold time.Duration, newClient sinkClientFunc) (*fallbackSink, error) {
+	if target == "" {
+		return nil, nil
+	}
+
+	if threshold <= 0 {
+		return nil, fmt.Errorf("invalid fallback threshold %s: must be greater than 0", threshold)
+	}
+
+	client, err := newClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("create fallback sink client: %w", err)
+	}
+	return &fallbackSink{client: client, threshold: threshold}, nil
+}
+
+// active returns true if the primary sink is unavailable for at least the
+// threshold at now
+func (f *fallbackSink) active(now time.Time) bool {
+	return !f.downSince.IsZero() && now.Sub(f.downSince) >= f.threshold
+}
+
+// sinkUnavailable returns true if the result indicates that the sink could not
+// be reached or failed to process the request, as opposed to rejecting the
+// event
+func sinkUnavailable(result protocol.Result) bool {
+	if cloudevents.IsACK(result) {
+		return false
+	}
+
+	var httpResult *cehttp.Result
+	if !cloudevents.ResultAs(result, &httpResult) {
+		// transport error
+		return true
+	}
+	return httpResult.StatusCode >= http.StatusInternalServerError
+}
+
+// sendWithLatency sends the given event to the sink and records the send
+// latency
+func (a *vAdapter) sendWithLatency(ctx context.Context, ev cloudevents.Event) protocol.Result {
+	start := time.Now()
+	result := a.send(withRoutedTarget(ctx), ev)
+	reportSendLatency(ctx, time.Since(start))
+	return result
+}
+
+// sendOrFallback sends the given event to the primary sink or, if the primary
+// sink is unavailable for longer than the fallback threshold, to the fallback
+// sink
+func (a *vAdapter) sendOrFallback(ctx context.Context, ev cloudevents.Event) protocol.Result {
+	f := a.Fallback
+	if f == nil {
+		return a.sendWithLatency(ctx, ev)
+	}
+
+	logger := logging.FromContext(ctx)
+	now := time.Now()
+	if f.active(now) && now.Sub(f.lastProbe) < f.threshold {
+		return f.client.Send(ctx, ev)
+	}
+
+	result := a.sendWithLatency(ctx, ev)
+	if !sinkUnavailable(result) {
+		if cloudevents.IsACK(result) {
+			if f.active(now) {
+				logger.Infow("primary sink recovered, switching back from fallback sink",
+					zap.Duration("downtime", now.Sub(f.downSince)))
+			}
+			f.downSince = time.Time{}
+		}
+		return result
+	}
+
+	if f.downSince.IsZero() {
+		f.downSince = now
+	}
+	if !f.active(now) {
+		return result
+	}
+
+	if f.lastProbe.Before(f.downSince) {
+		logger.Warnw("primary sink unavailable, switching to fallback sink",
+			zap.Duration("downtime", now.Sub(f.downSince)), zap.Error(result))
+	}
+	f.lastProbe = now
+	return f.client.Send(ctx, ev)
+}
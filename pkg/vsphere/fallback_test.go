@@ -0,0 +1,123 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newFallbackSink(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		threshold time.Duration
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "disabled", threshold: time.Minute, wantNil: true},
+		{name: "enabled", target: "http://fallback", threshold: time.Minute},
+		{name: "invalid threshold", target: "http://fallback", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newFallbackSink(tt.target, tt.threshold, testSinkClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFallbackSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newFallbackSink() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_sinkUnavailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		result protocol.Result
+		want   bool
+	}{
+		{name: "ACK", result: nil, want: false},
+		{name: "transport error", result: errors.New("connection refused"), want: true},
+		{name: "server error", result: cehttp.NewResult(http.StatusBadGateway, "%w", protocol.ResultNACK), want: true},
+		{name: "rejected event", result: cehttp.NewResult(http.StatusBadRequest, "%w", protocol.ResultNACK), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sinkUnavailable(tt.result); got != tt.want {
+				t.Errorf("sinkUnavailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendOrFallback(t *testing.T) {
+	down := errors.New("connection refused")
+	ctx := context.Background()
+
+	primary := &fakeCEClient{results: []error{down, down, down}}
+	fallback := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:   zaptest.NewLogger(t).Sugar(),
+		CEClient: primary,
+		Fallback: &fallbackSink{client: fallback, threshold: time.Hour},
+	}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+
+	// below threshold the failure is returned
+	if result := a.sendOrFallback(ctx, ev); cloudevents.IsACK(result) {
+		t.Fatalf("sendOrFallback() = ACK, want failure below threshold")
+	}
+	if len(fallback.sent) != 0 {
+		t.Fatalf("sendOrFallback() sent %d events to fallback sink, want 0", len(fallback.sent))
+	}
+
+	// primary down for longer than threshold
+	a.Fallback.downSince = time.Now().Add(-2 * time.Hour)
+	if result := a.sendOrFallback(ctx, ev); !cloudevents.IsACK(result) {
+		t.Fatalf("sendOrFallback() = %v, want ACK from fallback sink", result)
+	}
+
+	// fallback active: primary is not retried before threshold
+	if result := a.sendOrFallback(ctx, ev); !cloudevents.IsACK(result) {
+		t.Fatalf("sendOrFallback() = %v, want ACK from fallback sink", result)
+	}
+	if len(primary.sent) != 2 || len(fallback.sent) != 2 {
+		t.Fatalf("sendOrFallback() sent %d/%d events to primary/fallback sink, want 2/2", len(primary.sent), len(fallback.sent))
+	}
+
+	// primary retried after threshold and still down
+	a.Fallback.lastProbe = time.Now().Add(-2 * time.Hour)
+	if result := a.sendOrFallback(ctx, ev); !cloudevents.IsACK(result) {
+		t.Fatalf("sendOrFallback() = %v, want ACK from fallback sink", result)
+	}
+	if len(primary.sent) != 3 || len(fallback.sent) != 3 {
+		t.Fatalf("sendOrFallback() sent %d/%d events to primary/fallback sink, want 3/3", len(primary.sent), len(fallback.sent))
+	}
+
+	// primary recovered
+	a.Fallback.lastProbe = time.Now().Add(-2 * time.Hour)
+	if result := a.sendOrFallback(ctx, ev); !cloudevents.IsACK(result) {
+		t.Fatalf("sendOrFallback() = %v, want ACK from primary sink", result)
+	}
+	if len(primary.sent) != 4 || len(fallback.sent) != 3 {
+		t.Errorf("sendOrFallback() sent %d/%d events to primary/fallback sink, want 4/3", len(primary.sent), len(fallback.sent))
+	}
+	if a.Fallback.active(time.Now()) {
+		t.Error("fallback sink active after primary recovered, want inactive")
+	}
+}
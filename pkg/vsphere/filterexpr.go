@@ -0,0 +1,577 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// event attributes available in filter expressions
+const (
+	filterAttrType       = "type"
+	filterAttrClass      = "class"
+	filterAttrDatacenter = "datacenter"
+	filterAttrUsername   = "username"
+	filterAttrSeverity   = "severity"
+	filterAttrEntity     = "entity"
+)
+
+var filterAttributes = map[string]struct{}{
+	filterAttrType:       {},
+	filterAttrClass:      {},
+	filterAttrDatacenter: {},
+	filterAttrUsername:   {},
+	filterAttrSeverity:   {},
+	filterAttrEntity:     {},
+}
+
+// filterExpr is a boolean predicate over the attributes of an event, e.g.
+//
+//	class == "event" && datacenter == "DC1" && !(username in ["admin", "root"])
+//
+// The syntax is a strict subset of the Common Expression Language (CEL): every
+// accepted expression is a valid CEL expression with the same result when
+// evaluated over a map of all attributes, so the evaluator can be replaced by
+// a CEL implementation without changing configured expressions. The complete
+// grammar in EBNF is:
+//
+//	expr      = and { "||" and } .
+//	and       = unary { "&&" unary } .
+//	unary     = "!" negated | negated | predicate .
+//	negated   = "!" negated | "(" expr ")" | "true" | "false" | call .
+//	predicate = operand ( ( "==" | "!=" ) operand | "in" list ) | call .
+//	call      = operand "." function "(" string ")" .
+//	operand   = attribute | string .
+//	list      = "[" [ string { "," string } ] "]" .
+//	attribute = "type" | "class" | "datacenter" | "username" | "severity" | "entity" .
+//	function  = "startsWith" | "endsWith" | "contains" | "matches" .
+//
+// Strings are enclosed in double or single quotes and support the escape
+// sequences of Go string literals. All values are strings, an attribute not
+// set on an event is the empty string. matches uses RE2 syntax and is
+// unanchored. Whitespace between tokens is ignored. Events not matching the
+// expression are dropped.
+type filterExpr struct {
+	expr     string
+	root     exprNode
+	severity severityFunc
+}
+
+// newFilterExpr parses the given expression. It returns nil if expr is empty,
+// i.e. all events are allowed. severity resolves the severity attribute and is
+// only called for expressions referencing it.
+func newFilterExpr(expr string, severity severityFunc) (*filterExpr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeFilterExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", expr, err)
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", expr, err)
+	}
+
+	return &filterExpr{expr: expr, root: root, severity: severity}, nil
+}
+
+// allows returns whether the given event matches the expression
+func (f *filterExpr) allows(ctx context.Context, be types.BaseEvent, details eventDetails) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+
+	attrs := &eventAttributes{ctx: ctx, be: be, details: details, severity: f.severity}
+	ok, err := f.root.eval(attrs)
+	if err != nil {
+		return false, fmt.Errorf("evaluate filter expression: %w", err)
+	}
+	return ok, nil
+}
+
+// eventAttributes resolves filter attributes of an event on demand
+type eventAttributes struct {
+	ctx      context.Context
+	be       types.BaseEvent
+	details  eventDetails
+	severity severityFunc
+}
+
+func (a *eventAttributes) get(name string) (string, error) {
+	e := a.be.GetEvent()
+	switch name {
+	case filterAttrType:
+		return a.details.Type, nil
+	case filterAttrClass:
+		return a.details.Class, nil
+	case filterAttrDatacenter:
+		if e.Datacenter != nil {
+			return e.Datacenter.Name, nil
+		}
+		return "", nil
+	case filterAttrUsername:
+		return e.UserName, nil
+	case filterAttrSeverity:
+		if a.severity == nil {
+			return "", nil
+		}
+		return a.severity(a.ctx, a.be)
+	case filterAttrEntity:
+		return eventEntityName(e), nil
+	}
+	return "", fmt.Errorf("unknown attribute %q", name)
+}
+
+// eventEntityName returns the name of the most specific entity referenced by
+// the given event, e.g. the VM of a VM event
+func eventEntityName(e *types.Event) string {
+	switch {
+	case e.Vm != nil:
+		return e.Vm.Name
+	case e.Host != nil:
+		return e.Host.Name
+	case e.Ds != nil:
+		return e.Ds.Name
+	case e.Net != nil:
+		return e.Net.Name
+	case e.Dvs != nil:
+		return e.Dvs.Name
+	case e.ComputeResource != nil:
+		return e.ComputeResource.Name
+	case e.Datacenter != nil:
+		return e.Datacenter.Name
+	}
+	return ""
+}
+
+// exprNode is a node of a parsed filter expression
+type exprNode interface {
+	eval(attrs *eventAttributes) (bool, error)
+}
+
+// exprOperand is an attribute or string literal
+type exprOperand struct {
+	attr    string
+	literal string
+}
+
+func (o exprOperand) value(attrs *eventAttributes) (string, error) {
+	if o.attr == "" {
+		return o.literal, nil
+	}
+	return attrs.get(o.attr)
+}
+
+type exprLiteral bool
+
+func (l exprLiteral) eval(*eventAttributes) (bool, error) {
+	return bool(l), nil
+}
+
+type exprNot struct {
+	operand exprNode
+}
+
+func (n exprNot) eval(attrs *eventAttributes) (bool, error) {
+	ok, err := n.operand.eval(attrs)
+	return !ok, err
+}
+
+// exprLogical is a short-circuit && (and is true) or || expression
+type exprLogical struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n exprLogical) eval(attrs *eventAttributes) (bool, error) {
+	ok, err := n.left.eval(attrs)
+	if err != nil || ok != n.and {
+		return ok, err
+	}
+	return n.right.eval(attrs)
+}
+
+// exprCompare is a == or != comparison
+type exprCompare struct {
+	equal       bool
+	left, right exprOperand
+}
+
+func (n exprCompare) eval(attrs *eventAttributes) (bool, error) {
+	left, err := n.left.value(attrs)
+	if err != nil {
+		return false, err
+	}
+	right, err := n.right.value(attrs)
+	if err != nil {
+		return false, err
+	}
+	return (left == right) == n.equal, nil
+}
+
+type exprIn struct {
+	operand exprOperand
+	list    []string
+}
+
+func (n exprIn) eval(attrs *eventAttributes) (bool, error) {
+	v, err := n.operand.value(attrs)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range n.list {
+		if v == item {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// exprCall is a string function call, e.g. type.startsWith("Vm")
+type exprCall struct {
+	operand exprOperand
+	fn      func(s string) bool
+}
+
+func (n exprCall) eval(attrs *eventAttributes) (bool, error) {
+	v, err := n.operand.value(attrs)
+	if err != nil {
+		return false, err
+	}
+	return n.fn(v), nil
+}
+
+// stringFunction returns the named string function with the given argument
+func stringFunction(name, arg string) (func(s string) bool, error) {
+	switch name {
+	case "startsWith":
+		return func(s string) bool { return strings.HasPrefix(s, arg) }, nil
+	case "endsWith":
+		return func(s string) bool { return strings.HasSuffix(s, arg) }, nil
+	case "contains":
+		return func(s string) bool { return strings.Contains(s, arg) }, nil
+	case "matches":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", arg, err)
+		}
+		return re.MatchString, nil
+	}
+	return nil, fmt.Errorf("unknown function %q: must be one of contains, endsWith, matches, startsWith", name)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenOp
+)
+
+type exprToken struct {
+	kind  tokenKind
+	value string
+}
+
+// String implements fmt.Stringer
+func (t exprToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// tokenizeFilterExpr splits the given expression into identifiers, string
+// literals and operators
+func tokenizeFilterExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			raw := expr[i+1 : end]
+			if c == '\'' {
+				raw = strings.ReplaceAll(strings.ReplaceAll(raw, `\'`, `'`), `"`, `\"`)
+			}
+			s, err := strconv.Unquote(`"` + raw + `"`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, value: s})
+			i = end + 1
+		case isIdentRune(rune(c)) && !unicode.IsDigit(rune(c)):
+			end := i
+			for end < len(expr) && isIdentRune(rune(expr[end])) {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, value: expr[i:end]})
+			i = end
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "!", "(", ")", "[", "]", ",", "."} {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOp, value: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF}), nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// exprParser is a recursive descent parser of filter expressions
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given operator
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q, found %s", op, p.peek())
+	}
+	return nil
+}
+
+// parseOr parses or := and ('||' and)*
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses and := unary ('&&' unary)*
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses unary := '!' negated | negated | predicate
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseNegated()
+		if err != nil {
+			return nil, err
+		}
+		return exprNot{operand: operand}, nil
+	}
+
+	if t := p.peek(); t.kind == tokenString || t.kind == tokenIdent && t.value != "true" && t.value != "false" {
+		return p.parsePredicate()
+	}
+	return p.parseNegated()
+}
+
+// parseNegated parses negated := '!' negated | '(' or ')' | 'true' | 'false' |
+// call. As in CEL, '!' binds tighter than comparisons but looser than function
+// calls, so a negated comparison must be parenthesized.
+func (p *exprParser) parseNegated() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseNegated()
+		if err != nil {
+			return nil, err
+		}
+		return exprNot{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	}
+
+	if t := p.peek(); t.kind == tokenIdent && (t.value == "true" || t.value == "false") {
+		p.next()
+		return exprLiteral(t.value == "true"), nil
+	}
+
+	operand, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect("."); err != nil {
+		return nil, fmt.Errorf("negated comparison must be parenthesized: %w", err)
+	}
+	return p.parseCall(operand)
+}
+
+// parsePredicate parses predicate := operand ('==' operand | '!=' operand |
+// 'in' list | '.' function '(' string ')')
+func (p *exprParser) parsePredicate() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := p.next(); {
+	case t.kind == tokenOp && (t.value == "==" || t.value == "!="):
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return exprCompare{equal: t.value == "==", left: left, right: right}, nil
+	case t.kind == tokenIdent && t.value == "in":
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return exprIn{operand: left, list: list}, nil
+	case t.kind == tokenOp && t.value == ".":
+		return p.parseCall(left)
+	default:
+		return nil, fmt.Errorf("expected comparison, in or function call, found %s", t)
+	}
+}
+
+// parseCall parses the function call following operand '.', i.e.
+// function '(' string ')'
+func (p *exprParser) parseCall(operand exprOperand) (exprNode, error) {
+	name := p.next()
+	if name.kind != tokenIdent {
+		return nil, fmt.Errorf("expected function name, found %s", name)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	arg := p.next()
+	if arg.kind != tokenString {
+		return nil, fmt.Errorf("expected string argument of %s, found %s", name.value, arg)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	fn, err := stringFunction(name.value, arg.value)
+	if err != nil {
+		return nil, err
+	}
+	return exprCall{operand: operand, fn: fn}, nil
+}
+
+// parseOperand parses an attribute or string literal
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return exprOperand{literal: t.value}, nil
+	case tokenIdent:
+		if _, ok := filterAttributes[t.value]; !ok {
+			names := make([]string, 0, len(filterAttributes))
+			for name := range filterAttributes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return exprOperand{}, fmt.Errorf("unknown attribute %q: must be one of %s", t.value, strings.Join(names, ", "))
+		}
+		return exprOperand{attr: t.value}, nil
+	}
+	return exprOperand{}, fmt.Errorf("expected attribute or string, found %s", t)
+}
+
+// parseList parses list := '[' (string (',' string)*)? ']'
+func (p *exprParser) parseList() ([]string, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	var list []string
+	if p.accept("]") {
+		return list, nil
+	}
+	for {
+		t := p.next()
+		if t.kind != tokenString {
+			return nil, fmt.Errorf("expected string in list, found %s", t)
+		}
+		list = append(list, t.value)
+		if p.accept("]") {
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
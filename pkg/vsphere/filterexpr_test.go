@@ -0,0 +1,187 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newFilterExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", expr: " ", wantNil: true},
+		{name: "comparison", expr: `type == "VmPoweredOnEvent"`},
+		{name: "single quoted string", expr: `username != 'admin'`},
+		{name: "in list", expr: `severity in ["warning", "error"]`},
+		{name: "function", expr: `entity.matches("^web-[0-9]+$")`},
+		{name: "nested", expr: `!(class == "event" || type.startsWith("Vm")) && true`},
+		{name: "unknown attribute", expr: `vm == "web-01"`, wantErr: true},
+		{name: "unknown function", expr: `type.hasPrefix("Vm")`, wantErr: true},
+		{name: "invalid regular expression", expr: `type.matches("(")`, wantErr: true},
+		{name: "attribute without predicate", expr: `type`, wantErr: true},
+		{name: "unterminated string", expr: `type == "Vm`, wantErr: true},
+		{name: "unbalanced parentheses", expr: `(type == "Vm"`, wantErr: true},
+		{name: "trailing tokens", expr: `type == "Vm" "Host"`, wantErr: true},
+		{name: "invalid character", expr: `type = "Vm"`, wantErr: true},
+		{name: "non-string list", expr: `type in [type]`, wantErr: true},
+		{name: "negated parenthesized predicate", expr: `!(type == "Vm") && !!true`},
+		// CEL applies '!' before '==', i.e. to the string attribute
+		{name: "negated predicate", expr: `!type == "Vm"`, wantErr: true},
+		{name: "negated in", expr: `!severity in ["error"]`, wantErr: true},
+		// CEL applies '.' before '!', i.e. negates the call
+		{name: "negated function", expr: `!type.startsWith("Vm")`},
+		// CEL constructs outside the documented grammar
+		{name: "CEL macro", expr: `["Vm", "Host"].exists(p, type.startsWith(p))`, wantErr: true},
+		{name: "CEL ternary", expr: `type == "Vm" ? true : false`, wantErr: true},
+		{name: "CEL function call", expr: `size(type) > 0`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newFilterExpr(tt.expr, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFilterExpr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newFilterExpr() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_filterExpr_allows(t *testing.T) {
+	severity := func(_ context.Context, be types.BaseEvent) (string, error) {
+		if _, ok := be.(*types.VmFailedToPowerOnEvent); ok {
+			return "error", nil
+		}
+		return "info", nil
+	}
+
+	base := types.Event{
+		Key:        1000,
+		UserName:   "operator",
+		Datacenter: &types.DatacenterEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "DC1"}},
+		Vm:         &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "web-01"}},
+	}
+	admin := base
+	admin.UserName = "admin"
+	dc2 := base
+	dc2.Datacenter = &types.DatacenterEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "DC2"}}
+	host := types.Event{Key: 1001, Host: &types.HostEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "esx-01"}}}
+
+	powerOn := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: base}}
+	adminPowerOn := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: admin}}
+	dc2PowerOn := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: dc2}}
+	failed := &types.VmFailedToPowerOnEvent{VmEvent: types.VmEvent{Event: base}}
+	hostConnected := &types.HostConnectedEvent{HostEvent: types.HostEvent{Event: host}}
+	eventEx := &types.EventEx{Event: base, EventTypeId: "com.vmware.vc.HA.ClusterFailoverActionCompletedEvent"}
+
+	const vmInDC1ByNonAdmin = `class == "event" && type.startsWith("Vm") && datacenter == "DC1" && !(username in ["admin", "root"])`
+
+	tests := []struct {
+		name string
+		expr string
+		be   types.BaseEvent
+		want bool
+	}{
+		{name: "VM event in DC1 by non-admin", expr: vmInDC1ByNonAdmin, be: powerOn, want: true},
+		{name: "VM event in DC1 by admin", expr: vmInDC1ByNonAdmin, be: adminPowerOn, want: false},
+		{name: "VM event in DC2", expr: vmInDC1ByNonAdmin, be: dc2PowerOn, want: false},
+		{name: "host event", expr: vmInDC1ByNonAdmin, be: hostConnected, want: false},
+		{name: "severity matches", expr: `severity in ["warning", "error"]`, be: failed, want: true},
+		{name: "severity does not match", expr: `severity in ["warning", "error"]`, be: powerOn, want: false},
+		{name: "VM entity", expr: `entity.matches("^web-[0-9]+$")`, be: powerOn, want: true},
+		{name: "host entity", expr: `entity == "esx-01"`, be: hostConnected, want: true},
+		{name: "extended event type", expr: `class == "eventex" && type.contains(".HA.")`, be: eventEx, want: true},
+		{name: "or", expr: `type == "HostConnectedEvent" || datacenter == "DC2"`, be: dc2PowerOn, want: true},
+		{name: "missing datacenter", expr: `datacenter != ""`, be: hostConnected, want: false},
+		{name: "literal on left", expr: `"admin" != username && type.endsWith("Event")`, be: powerOn, want: true},
+		{name: "negated function", expr: `!type.startsWith("Vm") && !!true`, be: hostConnected, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFilterExpr(tt.expr, severity)
+			if err != nil {
+				t.Fatalf("newFilterExpr() error = %v", err)
+			}
+			got, err := f.allows(context.Background(), tt.be, getEventDetails(tt.be))
+			if err != nil {
+				t.Fatalf("allows() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterExpr_allows_severityError(t *testing.T) {
+	calls := 0
+	severity := func(context.Context, types.BaseEvent) (string, error) {
+		calls++
+		return "", errors.New("vcenter unavailable")
+	}
+	be := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000}}}
+
+	// severity is not resolved if short-circuited
+	f, err := newFilterExpr(`type == "HostConnectedEvent" && severity == "error"`, severity)
+	if err != nil {
+		t.Fatalf("newFilterExpr() error = %v", err)
+	}
+	if ok, err := f.allows(context.Background(), be, getEventDetails(be)); ok || err != nil || calls != 0 {
+		t.Errorf("allows() = %v, %v with %d severity calls, want false, nil with 0 calls", ok, err, calls)
+	}
+
+	f, err = newFilterExpr(`severity == "error"`, severity)
+	if err != nil {
+		t.Fatalf("newFilterExpr() error = %v", err)
+	}
+	if _, err = f.allows(context.Background(), be, getEventDetails(be)); err == nil {
+		t.Error("allows() error = nil, want severity error")
+	}
+}
+
+func Test_vAdapter_sendEvents_filterExpr(t *testing.T) {
+	filter, err := newFilterExpr(`type == "VmPoweredOnEvent" && entity != "db-01"`, nil)
+	if err != nil {
+		t.Fatalf("newFilterExpr() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationJSON,
+		Filter:          filter,
+	}
+
+	now := time.Now().UTC()
+	events := []types.BaseEvent{
+		powerOnEvent(1000, "web-01", now),
+		powerOnEvent(1001, "db-01", now),
+		&types.VmPoweredOffEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1002, CreatedTime: now}}},
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if n != len(events) {
+		t.Errorf("sendEvents() = %d, want %d (filtered events are checkpointed)", n, len(events))
+	}
+	if len(ce.sent) != 1 || ce.sent[0].ID() != "1000" {
+		t.Errorf("sendEvents() sent %v, want event 1000", ce.sent)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// extended attribute carrying the idempotency key of an event
+const ceVSphereIdempotencyKey = "vsphereidempotencykey"
+
+// idempotencyKeys derives a stable key from the vCenter instance UUID and the
+// event key for each event, allowing idempotent sinks to reject events sent
+// again after an adapter restart (at-least-once delivery). The key is set as
+// extension and optionally as HTTP request header.
+type idempotencyKeys struct {
+	instanceUUID string
+	header       string
+}
+
+// newIdempotencyKeys returns idempotency keys for the given vCenter instance
+// UUID, sent in the given HTTP header if not empty. It returns nil if not
+// enabled.
+func newIdempotencyKeys(enabled bool, instanceUUID, header string) (*idempotencyKeys, error) {
+	if err := validateIdempotencyHeader(enabled, header); err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	if instanceUUID == "" {
+		return nil, errors.New("vCenter instance UUID not available")
+	}
+	return &idempotencyKeys{instanceUUID: instanceUUID, header: http.CanonicalHeaderKey(header)}, nil
+}
+
+// validateIdempotencyHeader validates the idempotency key configuration
+// which does not depend on the vCenter instance
+func validateIdempotencyHeader(enabled bool, header string) error {
+	if !enabled && header != "" {
+		return errors.New("idempotency header requires idempotency keys to be enabled")
+	}
+	return nil
+}
+
+// key returns the idempotency key of the given event, e.g.
+// 2a6b0fd6-7d6c-4a5f-8a39-7b2d9c1e5f10-1042
+func (k *idempotencyKeys) key(be types.BaseEvent) string {
+	return fmt.Sprintf("%s-%d", k.instanceUUID, be.GetEvent().Key)
+}
+
+// apply sets the idempotency key of be on ev and returns ctx carrying the key
+// as request header if configured
+func (k *idempotencyKeys) apply(ctx context.Context, ev *cloudevents.Event, be types.BaseEvent, allowed extensionAllowlist) context.Context {
+	if k == nil {
+		return ctx
+	}
+
+	key := k.key(be)
+	allowed.set(ev, ceVSphereIdempotencyKey, key)
+	if k.header == "" {
+		return ctx
+	}
+
+	header := cehttp.HeaderFrom(ctx).Clone()
+	header.Set(k.header, key)
+	return cehttp.WithCustomHeader(ctx, header)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+const testInstanceUUID = "2a6b0fd6-7d6c-4a5f-8a39-7b2d9c1e5f10"
+
+func Test_newIdempotencyKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		uuid    string
+		header  string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", uuid: testInstanceUUID, wantNil: true},
+		{name: "header without keys", uuid: testInstanceUUID, header: "Idempotency-Key", wantErr: true},
+		{name: "missing instance UUID", enabled: true, wantErr: true},
+		{name: "extension only", enabled: true, uuid: testInstanceUUID},
+		{name: "header", enabled: true, uuid: testInstanceUUID, header: "idempotency-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newIdempotencyKeys(tt.enabled, tt.uuid, tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newIdempotencyKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newIdempotencyKeys() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_idempotencyKeys_apply(t *testing.T) {
+	be := createBaseEvent(1042, time.Now())
+	const want = testInstanceUUID + "-1042"
+
+	tests := []struct {
+		name       string
+		header     string
+		allowed    extensionAllowlist
+		wantExt    bool
+		wantHeader string
+	}{
+		{name: "extension", wantExt: true},
+		{name: "extension not allowed", allowed: extensionAllowlist{"vmname": {}}},
+		{name: "extension and header", header: "Idempotency-Key", wantExt: true, wantHeader: want},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := newIdempotencyKeys(true, testInstanceUUID, tt.header)
+			if err != nil {
+				t.Fatalf("newIdempotencyKeys() error = %v", err)
+			}
+
+			ev := cloudevents.NewEvent()
+			ctx := k.apply(context.Background(), &ev, be, tt.allowed)
+
+			got, ok := ev.Extensions()[ceVSphereIdempotencyKey]
+			if ok != tt.wantExt || (ok && got != want) {
+				t.Errorf("apply() %s = %v, want %q set %v", ceVSphereIdempotencyKey, got, want, tt.wantExt)
+			}
+			if got := cehttp.HeaderFrom(ctx).Get("Idempotency-Key"); got != tt.wantHeader {
+				t.Errorf("apply() header = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// maximum backoff between attempts to initialize the adapter
+const maxInitRetryBackoff = 30 * time.Second
+
+// retryInit calls init until it succeeds or retries are exhausted, waiting
+// with exponential backoff starting at minBackoff between attempts. This
+// avoids crash loops if the API server or RBAC is briefly unavailable at pod
+// startup, e.g. during cluster upgrades. The error of the last attempt is
+// returned.
+func retryInit(ctx context.Context, what string, retries int, minBackoff time.Duration, init func(ctx context.Context) error) error {
+	logger := logging.FromContext(ctx)
+	bOff := backoff.Backoff{
+		Factor: 2,
+		Jitter: false,
+		Min:    minBackoff,
+		Max:    maxInitRetryBackoff,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := init(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt > retries {
+			return err
+		}
+
+		delay := bOff.Duration()
+		logger.Warnw("initialization failed, retrying", zap.String("init", what), zap.Int("attempt", attempt),
+			zap.Int("retries", retries), zap.Duration("backoff", delay), zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
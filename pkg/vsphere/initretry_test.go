@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_retryInit(t *testing.T) {
+	errUnavailable := errors.New("the server is currently unable to handle the request")
+
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "succeeds", retries: 3, wantAttempts: 1},
+		{name: "succeeds after retries", retries: 3, failures: 2, wantAttempts: 3},
+		{name: "retries exhausted", retries: 2, failures: 5, wantAttempts: 3, wantErr: true},
+		{name: "retries disabled", failures: 1, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			err := retryInit(context.Background(), "test", tt.retries, time.Millisecond, func(context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errUnavailable
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryInit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("retryInit() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var attempts int
+		err := retryInit(ctx, "test", 5, time.Hour, func(context.Context) error {
+			attempts++
+			return errUnavailable
+		})
+		if !errors.Is(err, errUnavailable) || attempts != 1 {
+			t.Errorf("retryInit() = %v after %d attempts, want %v after 1 attempt", err, attempts, errUnavailable)
+		}
+	})
+}
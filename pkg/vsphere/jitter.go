@@ -0,0 +1,47 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// startupDelay returns a random delay in [0, max) using rnd to spread the
+// first reads of many simultaneously (re)started adapters. It returns 0 if max
+// is not positive.
+func startupDelay(max time.Duration, rnd func(n int64) int64) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rnd(int64(max)))
+}
+
+// waitStartupJitter blocks for a random delay up to max before the adapter
+// starts reading events. It returns early with the context error if ctx is
+// canceled.
+func waitStartupJitter(ctx context.Context, max time.Duration) error {
+	delay := startupDelay(max, rand.Int63n)
+	if delay == 0 {
+		return nil
+	}
+
+	logging.FromContext(ctx).Infow("delaying start of event stream", zap.Duration("delay", delay))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
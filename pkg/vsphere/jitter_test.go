@@ -0,0 +1,64 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_startupDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		max  time.Duration
+		rnd  func(n int64) int64
+		want time.Duration
+	}{
+		{
+			name: "disabled",
+			max:  0,
+			rnd:  func(n int64) int64 { return n - 1 },
+			want: 0,
+		},
+		{
+			name: "negative",
+			max:  -time.Second,
+			rnd:  func(n int64) int64 { return n - 1 },
+			want: 0,
+		},
+		{
+			name: "random delay",
+			max:  10 * time.Second,
+			rnd:  func(n int64) int64 { return n / 2 },
+			want: 5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startupDelay(tt.max, tt.rnd); got != tt.want {
+				t.Errorf("startupDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_waitStartupJitter(t *testing.T) {
+	if err := waitStartupJitter(context.Background(), 0); err != nil {
+		t.Errorf("waitStartupJitter() = %v, want nil", err)
+	}
+
+	if err := waitStartupJitter(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("waitStartupJitter() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := waitStartupJitter(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("waitStartupJitter() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// API version and kind of the source object Kubernetes events are
+	// emitted on
+	sourceAPIVersion = "sources.tanzu.vmware.com/v1alpha1"
+	sourceKind       = "VSphereSource"
+	// reporting component of emitted Kubernetes events
+	k8sEventComponent = "vsphere-source-adapter"
+
+	// reasons of emitted Kubernetes events
+	reasonSinkUnreachable       = "SinkUnreachable"
+	reasonVCenterReconnect      = "VCenterReconnect"
+	reasonDataLoss              = "DataLoss"
+	reasonCheckpointWriteFailed = "CheckpointWriteFailed"
+)
+
+// k8sEventEmitter emits Kubernetes events on the source object for significant
+// adapter conditions, e.g. an unreachable sink, so they show up in kubectl get
+// events. Events of the same reason are emitted at most once per interval to
+// avoid flooding the API server. Emission is best effort.
+type k8sEventEmitter struct {
+	client   corev1client.EventInterface
+	source   corev1.ObjectReference
+	interval time.Duration
+	now      func() time.Time
+
+	mu sync.Mutex
+	// last emission per reason
+	last map[string]time.Time
+}
+
+// newK8sEventEmitter returns an emitter for the source with the given
+// namespace, name and UID emitting each reason at most once per interval. It
+// returns nil if not enabled.
+func newK8sEventEmitter(enabled bool, client corev1client.EventsGetter, namespace, name, uid string, interval time.Duration) (*k8sEventEmitter, error) {
+	if !enabled {
+		return nil, nil
+	}
+	if name == "" {
+		return nil, errors.New("source name must be set to emit Kubernetes events")
+	}
+	if interval <= 0 {
+		return nil, errors.New("Kubernetes event interval must be greater than 0")
+	}
+
+	return &k8sEventEmitter{
+		client: client.Events(namespace),
+		source: corev1.ObjectReference{
+			APIVersion: sourceAPIVersion,
+			Kind:       sourceKind,
+			Namespace:  namespace,
+			Name:       name,
+			UID:        k8stypes.UID(uid),
+		},
+		interval: interval,
+		now:      time.Now,
+		last:     make(map[string]time.Time),
+	}, nil
+}
+
+// warn emits a warning event with the given reason and formatted message
+// unless an event with the same reason was emitted within the interval.
+// Failures are logged.
+func (e *k8sEventEmitter) warn(ctx context.Context, reason, format string, args ...interface{}) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	now := e.now()
+	if last, ok := e.last[reason]; ok && now.Sub(last) < e.interval {
+		e.mu.Unlock()
+		return
+	}
+	e.last[reason] = now
+	e.mu.Unlock()
+
+	ts := metav1.NewTime(now)
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// naming convention of client-go event recorders
+			Name:      fmt.Sprintf("%s.%x", e.source.Name, now.UnixNano()),
+			Namespace: e.source.Namespace,
+		},
+		InvolvedObject: e.source,
+		Reason:         reason,
+		Message:        fmt.Sprintf(format, args...),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: k8sEventComponent},
+		FirstTimestamp: ts,
+		LastTimestamp:  ts,
+		Count:          1,
+	}
+
+	if _, err := e.client.Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		logging.FromContext(ctx).Warnw("could not emit Kubernetes event", zap.String("reason", reason), zap.Error(err))
+	}
+}
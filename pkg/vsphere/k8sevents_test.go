@@ -0,0 +1,93 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_newK8sEventEmitter(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		source   string
+		interval time.Duration
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "missing source name", enabled: true, interval: time.Minute, wantErr: true},
+		{name: "invalid interval", enabled: true, source: "vc-01", wantErr: true},
+		{name: "enabled", enabled: true, source: "vc-01", interval: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newK8sEventEmitter(tt.enabled, client, "default", tt.source, "uid-1", tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newK8sEventEmitter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newK8sEventEmitter() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_k8sEventEmitter_warn(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	client := fake.NewSimpleClientset()
+	e, err := newK8sEventEmitter(true, client.CoreV1(), "default", "vc-01", "uid-1", time.Minute)
+	if err != nil {
+		t.Fatalf("newK8sEventEmitter() error = %v", err)
+	}
+	e.now = func() time.Time { return now }
+
+	e.warn(ctx, reasonSinkUnreachable, "Failed to send event to sink: %v", errors.New("connection refused"))
+	// rate-limited
+	e.warn(ctx, reasonSinkUnreachable, "Failed to send event to sink: %v", errors.New("connection refused"))
+	now = now.Add(time.Second)
+	e.warn(ctx, reasonCheckpointWriteFailed, "Failed to write checkpoint: %v", errors.New("forbidden"))
+
+	events, err := client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events.Items) != 2 {
+		t.Fatalf("warn() emitted %d events, want 2", len(events.Items))
+	}
+
+	got := events.Items[0]
+	if got.Type != corev1.EventTypeWarning || got.InvolvedObject.Kind != sourceKind ||
+		got.InvolvedObject.Name != "vc-01" || got.InvolvedObject.UID != "uid-1" {
+		t.Errorf("warn() emitted %s event on %v, want warning on source", got.Type, got.InvolvedObject)
+	}
+
+	// interval elapsed
+	now = now.Add(time.Minute)
+	e.warn(ctx, reasonSinkUnreachable, "Failed to send event to sink: %v", errors.New("connection refused"))
+	if events, _ = client.CoreV1().Events("default").List(ctx, metav1.ListOptions{}); len(events.Items) != 3 {
+		t.Errorf("warn() emitted %d events after interval, want 3", len(events.Items))
+	}
+
+	// failures are logged
+	client.PrependReactor("create", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	e.warn(ctx, reasonDataLoss, "Last event timestamp in checkpoint is older than configured maximum")
+}
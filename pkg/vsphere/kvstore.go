@@ -0,0 +1,57 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// kvStoreNamespace returns the namespace of the checkpoint ConfigMap, i.e. the
+// configured kvstore namespace or the adapter namespace if not set
+func kvStoreNamespace(env *envConfig) string {
+	if env.KVNamespace != "" {
+		return env.KVNamespace
+	}
+	return env.Namespace
+}
+
+// kvStoreVerbs returns the permissions on configmaps the adapter requires to
+// store checkpoints. A pre-created checkpoint ConfigMap is never created by
+// the adapter.
+func kvStoreVerbs(precreated bool) string {
+	if precreated {
+		return "get and update"
+	}
+	return "get, create and update"
+}
+
+// kvStoreInitError returns a descriptive startup error for a failed
+// initialization of the checkpoint ConfigMap namespace/name, pointing to the
+// required RBAC permissions if access was denied. Denied access is a
+// configuration error, other failures are connectivity errors unless
+// classified otherwise.
+func kvStoreInitError(err error, namespace, name string, precreated bool) error {
+	if apierrors.IsForbidden(err) {
+		return configError("access to checkpoint configmap %s/%s denied: the adapter service account requires %s permissions on configmaps in namespace %q, e.g. by binding the vsphere-receive-adapter-cm cluster role: %w",
+			namespace, name, kvStoreVerbs(precreated), namespace, err)
+	}
+	return connectivityError("could not initialize kv store %s/%s: %w", namespace, name, err)
+}
+
+// checkPrecreated returns an error if the pre-created checkpoint ConfigMap
+// does not exist. Initializing the kvstore only creates missing ConfigMaps, so
+// the kvstore never creates a ConfigMap once this check passes.
+func checkPrecreated(ctx context.Context, client corev1client.ConfigMapInterface, namespace, name string) error {
+	_, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return configError("checkpoint configmap %s/%s does not exist: it must be pre-created when VSPHERE_KVSTORE_PRECREATED is enabled", namespace, name)
+	}
+	return err
+}
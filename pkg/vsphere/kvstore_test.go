@@ -0,0 +1,84 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+func Test_kvStoreNamespace(t *testing.T) {
+	tests := []struct {
+		name string
+		env  envConfig
+		want string
+	}{
+		{name: "adapter namespace", env: envConfig{EnvConfig: adapter.EnvConfig{Namespace: "default"}}, want: "default"},
+		{name: "override", env: envConfig{EnvConfig: adapter.EnvConfig{Namespace: "default"}, KVNamespace: "shared-ops"}, want: "shared-ops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kvStoreNamespace(&tt.env); got != tt.want {
+				t.Errorf("kvStoreNamespace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_kvStoreInitError(t *testing.T) {
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "vsphere-checkpoint", errors.New("no access"))
+
+	tests := []struct {
+		name       string
+		err        error
+		want       string
+		precreated bool
+		wantKind   StartupErrorKind
+	}{
+		{name: "forbidden", err: forbidden, want: `requires get, create and update permissions on configmaps in namespace "shared-ops"`, wantKind: ConfigError},
+		{name: "forbidden pre-created", err: forbidden, precreated: true, want: `requires get and update permissions`, wantKind: ConfigError},
+		{name: "other error", err: errors.New("timeout"), want: "could not initialize kv store shared-ops/vsphere-checkpoint: timeout", wantKind: ConnectivityError},
+		{name: "missing pre-created", err: configError("checkpoint configmap shared-ops/vsphere-checkpoint does not exist"), precreated: true, want: "does not exist", wantKind: ConfigError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := kvStoreInitError(tt.err, "shared-ops", "vsphere-checkpoint", tt.precreated)
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("kvStoreInitError() = %q, want containing %q", err, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("kvStoreInitError() does not wrap %v", tt.err)
+			}
+			var se *StartupError
+			if !errors.As(err, &se) || se.Kind != tt.wantKind {
+				t.Errorf("kvStoreInitError() = %#v, want startup error of kind %s", err, tt.wantKind)
+			}
+		})
+	}
+}
+
+func Test_checkPrecreated(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "shared-ops", Name: "vsphere-checkpoint"}}
+	client := k8sfake.NewSimpleClientset(cm).CoreV1().ConfigMaps("shared-ops")
+
+	if err := checkPrecreated(context.Background(), client, "shared-ops", "vsphere-checkpoint"); err != nil {
+		t.Errorf("checkPrecreated() error = %v for existing configmap", err)
+	}
+
+	err := checkPrecreated(context.Background(), client, "shared-ops", "missing")
+	if err == nil || !strings.Contains(err.Error(), "must be pre-created") {
+		t.Errorf("checkPrecreated() error = %v for missing configmap, want pre-created error", err)
+	}
+}
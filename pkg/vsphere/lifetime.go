@@ -0,0 +1,17 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import "time"
+
+// lifetimeExpired returns whether the configured maximum lifetime of the
+// adapter elapsed at now since the event stream was started
+func (a *vAdapter) lifetimeExpired(now time.Time) bool {
+	if a.MaxLifetime <= 0 || a.started.IsZero() {
+		return false
+	}
+	return now.Sub(a.started) >= a.MaxLifetime
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_vAdapter_lifetimeExpired(t *testing.T) {
+	started := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		maxLifetime time.Duration
+		started     time.Time
+		now         time.Time
+		want        bool
+	}{
+		{name: "disabled", started: started, now: started.Add(24 * time.Hour), want: false},
+		{name: "not started", maxLifetime: time.Hour, now: started.Add(24 * time.Hour), want: false},
+		{name: "within lifetime", maxLifetime: time.Hour, started: started, now: started.Add(time.Minute), want: false},
+		{name: "lifetime elapsed", maxLifetime: time.Hour, started: started, now: started.Add(time.Hour), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &vAdapter{MaxLifetime: tt.maxLifetime, started: tt.started}
+			if got := a.lifetimeExpired(tt.now); got != tt.want {
+				t.Errorf("lifetimeExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_maxLifetime(t *testing.T) {
+	events := createTestEvents(3, source, time.Now().UTC()).vEvents
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{
+		Logger:   zaptest.NewLogger(t).Sugar(),
+		Source:   source,
+		CEClient: &fakeCEClient{},
+		KVStore:  kv,
+		// checkpoint period and write interval never elapse during the test
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		CpMinWrite:      time.Hour,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		MaxLifetime:     100 * time.Millisecond,
+		started:         time.Now(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(context.Background(), &fakeCollector{batches: [][]types.BaseEvent{events}})
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("readEvents() error = %v, want nil after maximum lifetime", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readEvents() did not return after maximum lifetime")
+	}
+
+	select {
+	case data := <-kv.dataChan:
+		var cp checkpoint
+		if err := json.Unmarshal([]byte(data), &cp); err != nil {
+			t.Fatalf("unmarshal data from KV store: %v", err)
+		}
+		if cp.LastEventKey != 1002 {
+			t.Errorf("final checkpoint key = %d, want %d", cp.LastEventKey, 1002)
+		}
+	default:
+		t.Error("final checkpoint not written before exiting")
+	}
+}
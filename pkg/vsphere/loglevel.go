@@ -0,0 +1,109 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// path of the log level endpoint
+	logLevelPath = "/loglevel"
+
+	logLevelShutdownTimeout = 5 * time.Second
+)
+
+// GetLogger overrides adapter.EnvConfig to keep the atomic level of the
+// adapter logger, which allows changing the log level at runtime
+func (e *envConfig) GetLogger() *zap.SugaredLogger {
+	if e.logger == nil {
+		loggingConfig, err := logging.JSONToConfig(e.LoggingConfigJson)
+		if err != nil {
+			// Use default logging config.
+			if loggingConfig, err = logging.NewConfigFromMap(map[string]string{}); err != nil {
+				// If this fails, there is no recovering.
+				panic(err)
+			}
+		}
+
+		e.logger, e.logLevel = logging.NewLoggerFromConfig(loggingConfig, e.Component)
+	}
+	return e.logger
+}
+
+// logLevelListenAddr returns the address the unauthenticated log level
+// endpoint listens on for the configured addr. Without a host it listens on
+// the loopback interface unless expose is set. A host other than a loopback
+// address requires expose.
+func logLevelListenAddr(addr string, expose bool) (string, error) {
+	if addr == "" {
+		return "", nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+
+	switch {
+	case expose:
+		return addr, nil
+	case host == "":
+		return net.JoinHostPort("127.0.0.1", port), nil
+	case host == "localhost":
+		return addr, nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", fmt.Errorf("address %q is not a loopback address: exposing the unauthenticated endpoint requires VSPHERE_LOG_LEVEL_EXPOSE", addr)
+	}
+	return addr, nil
+}
+
+// newLogLevelServer returns a server listening on addr which reports (GET)
+// and changes (PUT) the given log level at /loglevel, e.g.
+//
+//	curl -X PUT -d '{"level":"debug"}' localhost:8081/loglevel
+//
+// It returns nil if addr is empty, i.e. the log level cannot be changed.
+func newLogLevelServer(addr string, level zap.AtomicLevel) *http.Server {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(logLevelPath, level)
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// serveLogLevel runs the log level server until ctx is canceled
+func serveLogLevel(ctx context.Context, srv *http.Server) {
+	logger := logging.FromContext(ctx)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), logLevelShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Infow("serving log level endpoint", zap.String("addr", srv.Addr), zap.String("path", logLevelPath))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Errorw("log level endpoint failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_envConfig_GetLogger(t *testing.T) {
+	env := &envConfig{}
+	env.LoggingConfigJson = `{"zap-logger-config":"{\"level\":\"info\",\"encoding\":\"json\",\"outputPaths\":[\"stdout\"],\"errorOutputPaths\":[\"stderr\"]}"}`
+
+	logger := env.GetLogger()
+	if logger != env.GetLogger() {
+		t.Error("GetLogger() returned a different logger on second call")
+	}
+
+	if logger.Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("GetLogger() debug level enabled, want info")
+	}
+
+	env.logLevel.SetLevel(zapcore.DebugLevel)
+	if !logger.Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("GetLogger() debug level not enabled after changing log level")
+	}
+}
+
+func Test_newLogLevelServer(t *testing.T) {
+	if srv := newLogLevelServer("", zap.NewAtomicLevel()); srv != nil {
+		t.Errorf("newLogLevelServer() = %v, want nil for empty address", srv)
+	}
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	srv := newLogLevelServer(":0", level)
+	if srv == nil {
+		t.Fatal("newLogLevelServer() = nil")
+	}
+
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPut, ts.URL+logLevelPath, strings.NewReader(`{"level":"debug"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("PUT %s status = %d, want %d", logLevelPath, resp.StatusCode, http.StatusOK)
+	}
+	if got := level.Level(); got != zapcore.DebugLevel {
+		t.Errorf("log level = %v, want %v", got, zapcore.DebugLevel)
+	}
+}
+
+func Test_logLevelListenAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		expose  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", addr: "", want: ""},
+		{name: "port only binds loopback", addr: ":8081", want: "127.0.0.1:8081"},
+		{name: "loopback IPv4", addr: "127.0.0.1:8081", want: "127.0.0.1:8081"},
+		{name: "loopback IPv6", addr: "[::1]:8081", want: "[::1]:8081"},
+		{name: "localhost", addr: "localhost:8081", want: "localhost:8081"},
+		{name: "all interfaces", addr: "0.0.0.0:8081", wantErr: true},
+		{name: "pod IP", addr: "10.0.0.5:8081", wantErr: true},
+		{name: "hostname", addr: "adapter:8081", wantErr: true},
+		{name: "exposed port only", addr: ":8081", expose: true, want: ":8081"},
+		{name: "exposed all interfaces", addr: "0.0.0.0:8081", expose: true, want: "0.0.0.0:8081"},
+		{name: "missing port", addr: "localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := logLevelListenAddr(tt.addr, tt.expose)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("logLevelListenAddr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("logLevelListenAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// maintenanceWindowConfig configures a recurring maintenance window starting
+// at the times of a standard cron schedule, e.g. "0 2 * * SAT", and lasting
+// for the given duration. The schedule is evaluated in the given IANA time
+// zone, e.g. "Europe/Berlin", which defaults to UTC.
+type maintenanceWindowConfig struct {
+	Schedule string `json:"schedule"`
+	Duration string `json:"duration"`
+	TimeZone string `json:"timezone"`
+}
+
+type maintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// maintenanceWindows drops events created during scheduled maintenance
+// windows
+type maintenanceWindows []maintenanceWindow
+
+// newMaintenanceWindows returns the maintenance windows for the given
+// JSON-encoded list of window configurations, e.g.
+// [{"schedule":"0 2 * * SAT","duration":"2h","timezone":"Europe/Berlin"}]. It
+// returns nil if config is empty, i.e. no events are dropped.
+func newMaintenanceWindows(config string) (maintenanceWindows, error) {
+	if config == "" {
+		return nil, nil
+	}
+
+	var in []maintenanceWindowConfig
+	if err := json.Unmarshal([]byte(config), &in); err != nil {
+		return nil, fmt.Errorf("unmarshal maintenance windows: %w", err)
+	}
+
+	windows := make(maintenanceWindows, 0, len(in))
+	for _, c := range in {
+		schedule, err := cron.ParseStandard(c.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window schedule %q: %w", c.Schedule, err)
+		}
+
+		duration, err := time.ParseDuration(c.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window duration %q: %w", c.Duration, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("invalid maintenance window duration %q: must be greater than 0", c.Duration)
+		}
+
+		location := time.UTC
+		if c.TimeZone != "" {
+			if location, err = time.LoadLocation(c.TimeZone); err != nil {
+				return nil, fmt.Errorf("invalid maintenance window time zone %q: %w", c.TimeZone, err)
+			}
+		}
+
+		windows = append(windows, maintenanceWindow{
+			schedule: schedule,
+			duration: duration,
+			location: location,
+		})
+	}
+	return windows, nil
+}
+
+// active returns true if t falls inside any of the maintenance windows
+func (m maintenanceWindows) active(t time.Time) bool {
+	for _, w := range m {
+		// first window start after the earliest start still covering t
+		start := w.schedule.Next(t.In(w.location).Add(-w.duration))
+		if !start.After(t) {
+			return true
+		}
+	}
+	return false
+}
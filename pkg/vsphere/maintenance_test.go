@@ -0,0 +1,108 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newMaintenanceWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "disabled", config: ""},
+		{name: "valid window", config: `[{"schedule":"0 2 * * SAT","duration":"2h"}]`, wantLen: 1},
+		{name: "valid window with time zone", config: `[{"schedule":"0 2 * * *","duration":"30m","timezone":"Europe/Berlin"}]`, wantLen: 1},
+		{name: "invalid schedule", config: `[{"schedule":"every day","duration":"2h"}]`, wantErr: true},
+		{name: "invalid duration", config: `[{"schedule":"0 2 * * SAT","duration":"2"}]`, wantErr: true},
+		{name: "zero duration", config: `[{"schedule":"0 2 * * SAT","duration":"0s"}]`, wantErr: true},
+		{name: "invalid time zone", config: `[{"schedule":"0 2 * * SAT","duration":"2h","timezone":"Mars/Olympus"}]`, wantErr: true},
+		{name: "invalid config", config: `{"schedule":"0 2 * * SAT"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMaintenanceWindows(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newMaintenanceWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("newMaintenanceWindows() windows = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func Test_maintenanceWindows_active(t *testing.T) {
+	// daily from 02:00 to 04:00 in Berlin (UTC+2 in summer)
+	m, err := newMaintenanceWindows(`[{"schedule":"0 2 * * *","duration":"2h","timezone":"Europe/Berlin"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "before window", t: time.Date(2020, 7, 1, 23, 59, 0, 0, time.UTC), want: false},
+		{name: "window start", t: time.Date(2020, 7, 2, 0, 0, 0, 0, time.UTC), want: true},
+		{name: "inside window", t: time.Date(2020, 7, 2, 1, 30, 0, 0, time.UTC), want: true},
+		{name: "window end", t: time.Date(2020, 7, 2, 2, 0, 0, 0, time.UTC), want: false},
+		{name: "window start in UTC", t: time.Date(2020, 7, 2, 2, 30, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.active(tt.t); got != tt.want {
+				t.Errorf("active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var disabled maintenanceWindows
+	if disabled.active(time.Now()) {
+		t.Error("active() for disabled maintenance windows returned true")
+	}
+}
+
+func Test_vAdapter_sendEvents_maintenance(t *testing.T) {
+	m, err := newMaintenanceWindows(`[{"schedule":"0 2 * * *","duration":"2h"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []types.BaseEvent{
+		createBaseEvent(1000, time.Date(2020, 7, 2, 1, 0, 0, 0, time.UTC)),
+		createBaseEvent(1001, time.Date(2020, 7, 2, 3, 0, 0, 0, time.UTC)),
+		createBaseEvent(1002, time.Date(2020, 7, 2, 5, 0, 0, 0, time.UTC)),
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		Maintenance:     m,
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil || n != len(events) {
+		t.Fatalf("sendEvents() = %d, %v, want %d, nil", n, err, len(events))
+	}
+
+	if len(ce.sent) != 2 || ce.sent[0].ID() != "1000" || ce.sent[1].ID() != "1002" {
+		t.Errorf("sendEvents() sent %d events, want events 1000 and 1002 outside of maintenance window", len(ce.sent))
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// tee events which could not be queued
+	teeResultDropped = "dropped"
+	// tee events which were not ACK-ed by the tee sink
+	teeResultFailed = "failed"
+)
+
+var (
+	// replayRateM is a gauge which records the effective rate (events per
+	// second) used when sending events to the sink
+	replayRateM = stats.Float64(
+		"replay_rate",
+		"Effective rate of events per second sent to the sink",
+		"1/s",
+	)
+
+	// clockSkewM is a gauge which records the clock skew (seconds) between
+	// adapter and vCenter measured at startup. A positive value indicates the
+	// adapter clock is ahead of vCenter.
+	clockSkewM = stats.Float64(
+		"clock_skew",
+		"Clock skew in seconds between adapter and vCenter",
+		"s",
+	)
+
+	// backfillEventsM is a gauge which records the number of events processed
+	// since start while replaying a backlog of events
+	backfillEventsM = stats.Int64(
+		"backfill_events",
+		"Number of events processed since start during backfill",
+		stats.UnitDimensionless,
+	)
+
+	// backfillProgressM is a gauge which records the estimated progress [0,1]
+	// of replaying a backlog of events
+	backfillProgressM = stats.Float64(
+		"backfill_progress",
+		"Estimated progress of the backfill between 0 and 1",
+		stats.UnitDimensionless,
+	)
+
+	// teeFailuresM is a counter which records the number of events not
+	// delivered to the tee sink
+	teeFailuresM = stats.Int64(
+		"tee_failures",
+		"Number of events not delivered to the tee sink",
+		stats.UnitDimensionless,
+	)
+
+	// reconnectWaitM is a gauge which records the time (seconds) a vCenter
+	// login was delayed to enforce the minimum reconnect interval
+	reconnectWaitM = stats.Float64(
+		"reconnect_wait",
+		"Time in seconds a vCenter login was delayed by the minimum reconnect interval",
+		"s",
+	)
+
+	// batchSizeM is a histogram which records the number of events returned
+	// by each vCenter read
+	batchSizeM = stats.Int64(
+		"batch_size",
+		"Number of events returned by each read from vCenter",
+		stats.UnitDimensionless,
+	)
+
+	// sendLatencyM is a histogram which records the latency (milliseconds) of
+	// sending an event to the sink
+	sendLatencyM = stats.Float64(
+		"send_latency",
+		"Latency in milliseconds of sending an event to the sink",
+		stats.UnitMilliseconds,
+	)
+
+	// collectorWindowUsageM is a gauge which records the estimated fraction
+	// of the collector page capacity occupied by unread events. Unread
+	// events are overwritten when it reaches 1.
+	collectorWindowUsageM = stats.Float64(
+		"collector_window_usage",
+		"Estimated fraction of the event collector page capacity occupied by unread events",
+		stats.UnitDimensionless,
+	)
+
+	// activeEventTypesM is a gauge which records the number of distinct event
+	// types seen within the configured rolling window
+	activeEventTypesM = stats.Int64(
+		"active_event_types",
+		"Number of distinct event types seen within the rolling window",
+		stats.UnitDimensionless,
+	)
+
+	// missingCreatedTimeM is a counter which records the number of events
+	// received without CreatedTime for which a substitute time was used
+	missingCreatedTimeM = stats.Int64(
+		"missing_created_time",
+		"Number of events received without created time",
+		stats.UnitDimensionless,
+	)
+
+	// pollBackoffM is a gauge which records the effective backoff (seconds)
+	// after a poll returning no events
+	pollBackoffM = stats.Float64(
+		"poll_backoff",
+		"Time in seconds waited after a poll returning no events",
+		"s",
+	)
+
+	// contentDuplicatesM is a counter which records the number of events
+	// suppressed by content deduplication
+	contentDuplicatesM = stats.Int64(
+		"content_duplicates",
+		"Number of events suppressed as duplicates of recently sent event content",
+		stats.UnitDimensionless,
+	)
+
+	// typeThrottledM is a counter which records the number of events
+	// exceeding the rate limit of their event type
+	typeThrottledM = stats.Int64(
+		"type_throttled",
+		"Number of events exceeding the rate limit of their event type",
+		stats.UnitDimensionless,
+	)
+
+	// eventsReadM is a counter which records the number of events read from
+	// vCenter
+	eventsReadM = stats.Int64(
+		"events_read",
+		"Number of events read from vCenter",
+		stats.UnitDimensionless,
+	)
+
+	// eventsSentM is a counter which records the number of events ACK-ed by
+	// the sink
+	eventsSentM = stats.Int64(
+		"events_sent",
+		"Number of events successfully sent to the sink",
+		stats.UnitDimensionless,
+	)
+
+	// sendFailuresM is a counter which records the number of events (or
+	// batches) not ACK-ed by the sink after all retries
+	sendFailuresM = stats.Int64(
+		"send_failures",
+		"Number of failed attempts to send events to the sink",
+		stats.UnitDimensionless,
+	)
+
+	// checkpointLagM is a gauge which records the time (seconds) between the
+	// creation of the last checkpointed event and now. It is updated after
+	// each attempt to send events, i.e. it grows while the sink is failing.
+	checkpointLagM = stats.Float64(
+		"checkpoint_lag",
+		"Time in seconds between now and the creation of the last checkpointed event",
+		"s",
+	)
+
+	teeResultKey      = tag.MustNewKey("result")
+	eventTypeKey      = tag.MustNewKey("event_type")
+	throttleActionKey = tag.MustNewKey("action")
+)
+
+func init() {
+	register()
+}
+
+func register() {
+	if err := view.Register(
+		&view.View{
+			Description: replayRateM.Description(),
+			Measure:     replayRateM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: clockSkewM.Description(),
+			Measure:     clockSkewM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: backfillEventsM.Description(),
+			Measure:     backfillEventsM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: backfillProgressM.Description(),
+			Measure:     backfillProgressM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: teeFailuresM.Description(),
+			Measure:     teeFailuresM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{teeResultKey},
+		},
+		&view.View{
+			Description: reconnectWaitM.Description(),
+			Measure:     reconnectWaitM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: batchSizeM.Description(),
+			Measure:     batchSizeM,
+			// cover batch sizes up to the configurable read limit
+			Aggregation: view.Distribution(1, 5, 10, 25, 50, 75, 99, maxEventsBatch,
+				maxEventsBatchLimit/4, maxEventsBatchLimit/2, maxEventsBatchLimit),
+		},
+		&view.View{
+			Description: sendLatencyM.Description(),
+			Measure:     sendLatencyM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 100000)...),
+		},
+		&view.View{
+			Description: collectorWindowUsageM.Description(),
+			Measure:     collectorWindowUsageM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: activeEventTypesM.Description(),
+			Measure:     activeEventTypesM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: missingCreatedTimeM.Description(),
+			Measure:     missingCreatedTimeM,
+			Aggregation: view.Count(),
+		},
+		&view.View{
+			Description: pollBackoffM.Description(),
+			Measure:     pollBackoffM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: contentDuplicatesM.Description(),
+			Measure:     contentDuplicatesM,
+			Aggregation: view.Count(),
+		},
+		&view.View{
+			Description: typeThrottledM.Description(),
+			Measure:     typeThrottledM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{eventTypeKey, throttleActionKey},
+		},
+		&view.View{
+			Description: eventsReadM.Description(),
+			Measure:     eventsReadM,
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Description: eventsSentM.Description(),
+			Measure:     eventsSentM,
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Description: sendFailuresM.Description(),
+			Measure:     sendFailuresM,
+			Aggregation: view.Count(),
+		},
+		&view.View{
+			Description: checkpointLagM.Description(),
+			Measure:     checkpointLagM,
+			Aggregation: view.LastValue(),
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// reportReplayRate records the current effective send rate
+func reportReplayRate(ctx context.Context, r float64) {
+	metrics.Record(ctx, replayRateM.M(r))
+}
+
+// reportClockSkew records the clock skew between adapter and vCenter
+func reportClockSkew(ctx context.Context, skew time.Duration) {
+	metrics.Record(ctx, clockSkewM.M(skew.Seconds()))
+}
+
+// reportBackfill records the number of processed events and estimated progress
+// of a backfill
+func reportBackfill(ctx context.Context, processed int64, progress float64) {
+	metrics.Record(ctx, backfillEventsM.M(processed))
+	metrics.Record(ctx, backfillProgressM.M(progress))
+}
+
+// reportTeeFailure records an event not delivered to the tee sink with the
+// given result, i.e. dropped or failed
+func reportTeeFailure(ctx context.Context, result string) {
+	metrics.Record(ctx, teeFailuresM.M(1), stats.WithTags(tag.Insert(teeResultKey, result)))
+}
+
+// reportReconnectWait records the time a vCenter login was delayed
+func reportReconnectWait(ctx context.Context, delay time.Duration) {
+	metrics.Record(ctx, reconnectWaitM.M(delay.Seconds()))
+}
+
+// reportBatchSize records the number of events returned by a vCenter read
+func reportBatchSize(ctx context.Context, n int) {
+	metrics.Record(ctx, batchSizeM.M(int64(n)))
+}
+
+// reportSendLatency records the latency of sending an event to the sink
+func reportSendLatency(ctx context.Context, latency time.Duration) {
+	metrics.Record(ctx, sendLatencyM.M(float64(latency)/float64(time.Millisecond)))
+}
+
+// reportCollectorWindowUsage records the estimated fraction of the collector
+// page capacity occupied by unread events
+func reportCollectorWindowUsage(ctx context.Context, usage float64) {
+	metrics.Record(ctx, collectorWindowUsageM.M(usage))
+}
+
+// reportActiveEventTypes records the number of distinct event types seen
+// within the rolling window
+func reportActiveEventTypes(ctx context.Context, n int) {
+	metrics.Record(ctx, activeEventTypesM.M(int64(n)))
+}
+
+// reportMissingCreatedTime records an event received without created time
+func reportMissingCreatedTime(ctx context.Context) {
+	metrics.Record(ctx, missingCreatedTimeM.M(1))
+}
+
+// reportPollBackoff records the backoff after a poll returning no events
+func reportPollBackoff(ctx context.Context, delay time.Duration) {
+	metrics.Record(ctx, pollBackoffM.M(delay.Seconds()))
+}
+
+// reportContentDuplicate records an event suppressed by content deduplication
+func reportContentDuplicate(ctx context.Context) {
+	metrics.Record(ctx, contentDuplicatesM.M(1))
+}
+
+// reportTypeThrottled records an event of the given type exceeding its rate
+// limit which was dropped or deferred (action)
+func reportTypeThrottled(ctx context.Context, eventType, action string) {
+	metrics.Record(ctx, typeThrottledM.M(1), stats.WithTags(tag.Insert(eventTypeKey, eventType),
+		tag.Insert(throttleActionKey, action)))
+}
+
+// reportEventsRead records the number of events returned by a vCenter read
+func reportEventsRead(ctx context.Context, n int) {
+	metrics.Record(ctx, eventsReadM.M(int64(n)))
+}
+
+// reportEventsSent records the number of events ACK-ed by the sink
+func reportEventsSent(ctx context.Context, n int) {
+	metrics.Record(ctx, eventsSentM.M(int64(n)))
+}
+
+// reportSendFailure records a failed attempt to send events to the sink
+func reportSendFailure(ctx context.Context) {
+	metrics.Record(ctx, sendFailuresM.M(1))
+}
+
+// reportCheckpointLag records the lag of the last checkpointed event
+func reportCheckpointLag(ctx context.Context, lag time.Duration) {
+	metrics.Record(ctx, checkpointLagM.M(lag.Seconds()))
+}
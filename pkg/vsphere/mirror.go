@@ -0,0 +1,56 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"knative.dev/pkg/kvstore"
+)
+
+// checkpointMirror writes a best-effort copy of each checkpoint to a secondary
+// ConfigMap which is never read by the adapter but can be promoted to the
+// primary checkpoint for disaster recovery
+type checkpointMirror struct {
+	store kvstore.Interface
+	ref   string
+}
+
+// newCheckpointMirror returns a mirror for the given initialized kvstore
+func newCheckpointMirror(store kvstore.Interface, ref string) *checkpointMirror {
+	return &checkpointMirror{
+		store: store,
+		ref:   ref,
+	}
+}
+
+// mirror writes the given checkpoint to the mirror ConfigMap
+func (m *checkpointMirror) mirror(ctx context.Context, cp checkpoint) error {
+	if err := m.store.Set(ctx, CheckpointKey, cp); err != nil {
+		return fmt.Errorf("set mirror checkpoint: %w", err)
+	}
+	if err := m.store.Save(ctx); err != nil {
+		return fmt.Errorf("save mirror checkpoint in %q: %w", m.ref, err)
+	}
+	return nil
+}
+
+// ParseConfigMapRef parses a ConfigMap reference in the format
+// [namespace/]name. The given default namespace is used if the reference does
+// not specify a namespace.
+func ParseConfigMapRef(ref, defaultNamespace string) (string, string, error) {
+	parts := strings.Split(ref, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return defaultNamespace, parts[0], nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("invalid configmap reference %q: must be in the format [namespace/]name", ref)
+	}
+}
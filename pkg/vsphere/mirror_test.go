@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/logging"
+)
+
+func TestParseConfigMapRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref           string
+		wantNamespace string
+		wantName      string
+		wantErr       bool
+	}{
+		{name: "name only", ref: "vc-mirror", wantNamespace: "default", wantName: "vc-mirror"},
+		{name: "namespace and name", ref: "dr/vc-mirror", wantNamespace: "dr", wantName: "vc-mirror"},
+		{name: "empty", ref: "", wantErr: true},
+		{name: "empty namespace", ref: "/vc-mirror", wantErr: true},
+		{name: "empty name", ref: "dr/", wantErr: true},
+		{name: "too many segments", ref: "dr/vc/mirror", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns, name, err := ParseConfigMapRef(tt.ref, "default")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConfigMapRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ns != tt.wantNamespace || name != tt.wantName {
+				t.Errorf("ParseConfigMapRef() = %q, %q, want %q, %q", ns, name, tt.wantNamespace, tt.wantName)
+			}
+		})
+	}
+}
+
+// failingKVStore fails to save
+type failingKVStore struct {
+	fakeKVStore
+}
+
+func (f *failingKVStore) Save(ctx context.Context) error {
+	return errors.New("forbidden")
+}
+
+func Test_vAdapter_saveCheckpoint_mirror(t *testing.T) {
+	ts := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("mirrors saved checkpoint", func(t *testing.T) {
+		ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+		mirrorStore := &fakeKVStore{dataChan: make(chan string, 1)}
+		a := &vAdapter{
+			KVStore: &fakeKVStore{
+				data:     map[string]string{CheckpointKey: createCheckpoint(t, ts)},
+				dataChan: make(chan string, 1),
+			},
+			Mirror: newCheckpointMirror(mirrorStore, "dr/vc-mirror"),
+		}
+
+		if err := a.saveCheckpoint(ctx); err != nil {
+			t.Fatalf("saveCheckpoint() error = %v", err)
+		}
+
+		var got checkpoint
+		if err := mirrorStore.Get(ctx, CheckpointKey, &got); err != nil {
+			t.Fatalf("get mirror checkpoint: %v", err)
+		}
+		if !got.LastEventKeyTimestamp.Equal(ts) {
+			t.Errorf("mirror checkpoint LastEventKeyTimestamp = %v, want %v", got.LastEventKeyTimestamp, ts)
+		}
+		if len(mirrorStore.dataChan) != 1 {
+			t.Error("saveCheckpoint() did not save mirror checkpoint")
+		}
+	})
+
+	t.Run("mirror failure does not fail primary checkpoint", func(t *testing.T) {
+		ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+		primary := &fakeKVStore{
+			data:     map[string]string{CheckpointKey: createCheckpoint(t, ts)},
+			dataChan: make(chan string, 1),
+		}
+		a := &vAdapter{
+			KVStore: primary,
+			Mirror:  newCheckpointMirror(&failingKVStore{}, "dr/vc-mirror"),
+		}
+
+		if err := a.saveCheckpoint(ctx); err != nil {
+			t.Fatalf("saveCheckpoint() error = %v", err)
+		}
+		if len(primary.dataChan) != 1 {
+			t.Error("saveCheckpoint() did not save primary checkpoint")
+		}
+	})
+}
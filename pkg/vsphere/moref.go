@@ -0,0 +1,33 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// extended attribute carrying the managed object reference of the event's
+	// primary entity, e.g. VirtualMachine:vm-42
+	ceVSphereMoRef = "vspheremoref"
+	// extended attribute carrying the managed object ID of the event's
+	// datacenter, e.g. datacenter-2
+	ceVSphereDatacenter = "vspheredatacenter"
+)
+
+// setMoRefExtensions sets the managed object reference extensions permitted by
+// allowed on the given CloudEvent. Extensions are omitted if the event does not
+// reference the respective entity.
+func setMoRefExtensions(ev *cloudevents.Event, be types.BaseEvent, allowed extensionAllowlist) {
+	e := be.GetEvent()
+	if ref := primaryEntity(e); ref != nil && ref.Value != "" {
+		allowed.set(ev, ceVSphereMoRef, ref.String())
+	}
+	if e.Datacenter != nil && e.Datacenter.Datacenter.Value != "" {
+		allowed.set(ev, ceVSphereDatacenter, e.Datacenter.Datacenter.Value)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_setMoRefExtensions(t *testing.T) {
+	dc := &types.DatacenterEventArgument{Datacenter: types.ManagedObjectReference{Type: "Datacenter", Value: "datacenter-2"}}
+	vm := &types.VmEventArgument{Vm: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}}
+	host := &types.HostEventArgument{Host: types.ManagedObjectReference{Type: "HostSystem", Value: "host-7"}}
+
+	tests := []struct {
+		name    string
+		be      types.BaseEvent
+		allowed extensionAllowlist
+		want    map[string]interface{}
+	}{
+		{
+			name: "VM event",
+			be:   &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Datacenter: dc, Host: host, Vm: vm}}},
+			want: map[string]interface{}{ceVSphereMoRef: "VirtualMachine:vm-42", ceVSphereDatacenter: "datacenter-2"},
+		},
+		{
+			name: "host event without datacenter",
+			be:   &types.HostConnectedEvent{HostEvent: types.HostEvent{Event: types.Event{Host: host}}},
+			want: map[string]interface{}{ceVSphereMoRef: "HostSystem:host-7"},
+		},
+		{
+			name: "no entity",
+			be:   &types.UserLoginSessionEvent{},
+			want: nil,
+		},
+		{
+			name:    "not allowed",
+			be:      &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Datacenter: dc, Vm: vm}}},
+			allowed: extensionAllowlist{ceVSphereDatacenter: {}},
+			want:    map[string]interface{}{ceVSphereDatacenter: "datacenter-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := cloudevents.NewEvent()
+			setMoRefExtensions(&ev, tt.be, tt.allowed)
+			if diff := cmp.Diff(tt.want, ev.Extensions()); diff != "" {
+				t.Errorf("setMoRefExtensions() extensions (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// JSONEvent is the normalized payload of vSphere events encoded as
+// application/json. The class, type and key identify the event regardless of
+// its concrete govmomi type, the fields hold the JSON encoding of the event as
+// returned by vCenter.
+//
+//	{
+//	  "class": "event",
+//	  "type": "VmPoweredOnEvent",
+//	  "key": 41,
+//	  "fields": {"Key": 41, "ChainId": 41, "CreatedTime": "...", "Vm": {...}, ...}
+//	}
+type JSONEvent struct {
+	// Class is the event class: event, eventex or extendedevent
+	Class string `json:"class"`
+	// Type is the vSphere event type, e.g. VmPoweredOnEvent or the event type
+	// ID of EventEx and ExtendedEvent events
+	Type string `json:"type"`
+	// Key is the vCenter event key
+	Key int32 `json:"key"`
+	// Fields are the fields of the concrete vSphere event type
+	Fields json.RawMessage `json:"fields"`
+}
+
+// validatePayloadEncoding returns an error if the given payload encoding is
+// not supported
+func validatePayloadEncoding(encoding string) error {
+	switch encoding {
+	case cloudevents.ApplicationXML, cloudevents.ApplicationJSON, PayloadEncodingEventV1:
+		return nil
+	}
+	return fmt.Errorf("unsupported payload encoding %q: must be %s, %s or %s", encoding,
+		cloudevents.ApplicationXML, cloudevents.ApplicationJSON, PayloadEncodingEventV1)
+}
+
+// eventData returns the data to set on the CloudEvent for the given vSphere
+// event. JSON encoded payloads use the JSONEvent representation. If
+// omitEmpty is set, null values, empty strings and empty arrays and objects
+// are omitted from its fields. The EventV1 representation is used for
+// PayloadEncodingEventV1.
+func eventData(be types.BaseEvent, encoding string, omitEmpty bool) (interface{}, error) {
+	switch encoding {
+	case PayloadEncodingEventV1:
+		return marshalEventV1(be)
+	case cloudevents.ApplicationJSON:
+		return marshalJSONEvent(be, omitEmpty)
+	}
+	return be, nil
+}
+
+// marshalJSONEvent returns the JSONEvent encoding of the given event
+func marshalJSONEvent(be types.BaseEvent, omitEmpty bool) ([]byte, error) {
+	var (
+		fields []byte
+		err    error
+	)
+	if omitEmpty {
+		fields, err = marshalJSONOmitEmpty(be)
+	} else {
+		fields, err = json.Marshal(be)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode event fields: %w", err)
+	}
+
+	details := getEventDetails(be)
+	return json.Marshal(JSONEvent{
+		Class:  details.Class,
+		Type:   details.Type,
+		Key:    be.GetEvent().Key,
+		Fields: fields,
+	})
+}
+
+// marshalJSONOmitEmpty returns the JSON encoding of v without null values,
+// empty strings and empty arrays and objects. Numbers and booleans are always
+// preserved since zero values carry meaning in vSphere events, e.g. a chain ID
+// of 0.
+func marshalJSONOmitEmpty(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// preserve number representation
+	dec.UseNumber()
+
+	var generic interface{}
+	if err = dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	pruned, _ := pruneEmpty(generic)
+	return json.Marshal(pruned)
+}
+
+// pruneEmpty recursively removes empty values from the decoded JSON value v.
+// It returns false if v itself is empty.
+func pruneEmpty(v interface{}) (interface{}, bool) {
+	switch t := v.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return t, t != ""
+	case map[string]interface{}:
+		for k, e := range t {
+			p, ok := pruneEmpty(e)
+			if !ok {
+				delete(t, k)
+				continue
+			}
+			t[k] = p
+		}
+		return t, len(t) > 0
+	case []interface{}:
+		// preserve array elements to keep positions
+		if len(t) == 0 {
+			return t, false
+		}
+		for i, e := range t {
+			if p, ok := pruneEmpty(e); ok {
+				t[i] = p
+			}
+		}
+		return t, true
+	default:
+		return t, true
+	}
+}
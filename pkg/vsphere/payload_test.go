@@ -0,0 +1,167 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// jsonEventFields returns the fields of the given JSONEvent encoded data
+func jsonEventFields(t *testing.T, data interface{}) []byte {
+	t.Helper()
+
+	var payload JSONEvent
+	if err := json.Unmarshal(data.([]byte), &payload); err != nil {
+		t.Fatalf("unmarshal data: %v", err)
+	}
+	return payload.Fields
+}
+
+func Test_eventData(t *testing.T) {
+	be := &types.VmPoweredOnEvent{
+		VmEvent: types.VmEvent{
+			Event: types.Event{
+				Key:                  0,
+				CreatedTime:          time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+				FullFormattedMessage: "vm powered on",
+				Vm: &types.VmEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "MyVM"},
+				},
+			},
+		},
+	}
+
+	t.Run("xml encoding ignores omitempty", func(t *testing.T) {
+		got, err := eventData(be, cloudevents.ApplicationXML, true)
+		if err != nil {
+			t.Fatalf("eventData() error = %v", err)
+		}
+		if got != types.BaseEvent(be) {
+			t.Errorf("eventData() = %v, want unmodified event", got)
+		}
+	})
+
+	t.Run("json encoding without omitempty", func(t *testing.T) {
+		got, err := eventData(be, cloudevents.ApplicationJSON, false)
+		if err != nil {
+			t.Fatalf("eventData() error = %v", err)
+		}
+
+		var m map[string]interface{}
+		if err = json.Unmarshal(jsonEventFields(t, got), &m); err != nil {
+			t.Fatalf("unmarshal data: %v", err)
+		}
+		if v, ok := m["UserName"]; !ok || v != "" {
+			t.Errorf("eventData() UserName = %v, %v, want empty field", v, ok)
+		}
+		if v, ok := m["Host"]; !ok || v != nil {
+			t.Errorf("eventData() Host = %v, %v, want null field", v, ok)
+		}
+	})
+
+	t.Run("json encoding with omitempty", func(t *testing.T) {
+		got, err := eventData(be, cloudevents.ApplicationJSON, true)
+		if err != nil {
+			t.Fatalf("eventData() error = %v", err)
+		}
+
+		full, err := json.Marshal(be)
+		if err != nil {
+			t.Fatalf("marshal event: %v", err)
+		}
+		fields := jsonEventFields(t, got)
+		if len(fields) >= len(full) {
+			t.Errorf("eventData() fields size = %d, want less than %d", len(fields), len(full))
+		}
+
+		var m map[string]interface{}
+		if err = json.Unmarshal(fields, &m); err != nil {
+			t.Fatalf("unmarshal data: %v", err)
+		}
+
+		want := map[string]interface{}{
+			"Key":                  float64(0),
+			"ChainId":              float64(0),
+			"CreatedTime":          "2020-10-01T12:00:00Z",
+			"FullFormattedMessage": "vm powered on",
+			// empty managed object reference is omitted
+			"Vm": map[string]interface{}{
+				"Name": "MyVM",
+			},
+			"Template": false,
+		}
+
+		if diff := cmp.Diff(want, m); diff != "" {
+			t.Errorf("eventData() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func Test_eventData_jsonEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		be        types.BaseEvent
+		wantClass string
+		wantType  string
+	}{
+		{
+			name:      "event",
+			be:        &types.VmPoweredOffEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 41}}},
+			wantClass: "event",
+			wantType:  "VmPoweredOffEvent",
+		},
+		{
+			name:      "eventex",
+			be:        &types.EventEx{Event: types.Event{Key: 42}, EventTypeId: "com.vmware.vc.HA.ClusterFailoverActionCompletedEvent"},
+			wantClass: "eventex",
+			wantType:  "com.vmware.vc.HA.ClusterFailoverActionCompletedEvent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := eventData(tt.be, cloudevents.ApplicationJSON, false)
+			if err != nil {
+				t.Fatalf("eventData() error = %v", err)
+			}
+
+			var payload JSONEvent
+			if err = json.Unmarshal(got.([]byte), &payload); err != nil {
+				t.Fatalf("unmarshal data: %v", err)
+			}
+			if payload.Class != tt.wantClass || payload.Type != tt.wantType || payload.Key != tt.be.GetEvent().Key {
+				t.Errorf("eventData() = %s/%s/%d, want %s/%s/%d", payload.Class, payload.Type, payload.Key,
+					tt.wantClass, tt.wantType, tt.be.GetEvent().Key)
+			}
+
+			var fields map[string]interface{}
+			if err = json.Unmarshal(payload.Fields, &fields); err != nil {
+				t.Fatalf("unmarshal fields: %v", err)
+			}
+			if fields["Key"] != float64(tt.be.GetEvent().Key) {
+				t.Errorf("eventData() fields Key = %v, want %d", fields["Key"], tt.be.GetEvent().Key)
+			}
+		})
+	}
+}
+
+func Test_validatePayloadEncoding(t *testing.T) {
+	for _, encoding := range []string{cloudevents.ApplicationXML, cloudevents.ApplicationJSON, PayloadEncodingEventV1} {
+		if err := validatePayloadEncoding(encoding); err != nil {
+			t.Errorf("validatePayloadEncoding(%q) error = %v", encoding, err)
+		}
+	}
+	for _, encoding := range []string{"", "text/plain", "application/JSON"} {
+		if err := validatePayloadEncoding(encoding); err == nil {
+			t.Errorf("validatePayloadEncoding(%q) error = nil, want error", encoding)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// default minimum and maximum backoff between polls returning no events
+	pollBackoffMin = time.Second
+	pollBackoffMax = 5 * time.Second
+	// default growth of the backoff after each poll returning no events
+	pollBackoffFactor = 2
+)
+
+// newPollBackoff returns the backoff between polls returning no events growing
+// by factor from min up to max
+func newPollBackoff(min, max time.Duration, factor float64) (backoff.Backoff, error) {
+	if min <= 0 || max < min {
+		return backoff.Backoff{}, fmt.Errorf("poll backoff minimum %s must be greater than 0 and not greater than maximum %s", min, max)
+	}
+
+	if factor < 1 {
+		return backoff.Backoff{}, fmt.Errorf("poll backoff factor %v must not be less than 1", factor)
+	}
+
+	return backoff.Backoff{
+		Factor: factor,
+		Jitter: false,
+		Min:    min,
+		Max:    max,
+	}, nil
+}
+
+// pollBackoff returns a new backoff between polls returning no events, using
+// the defaults if not configured
+func (a *vAdapter) pollBackoff() backoff.Backoff {
+	if a.PollBackoffBase.Max == 0 {
+		return backoff.Backoff{
+			Factor: pollBackoffFactor,
+			Jitter: false,
+			Min:    pollBackoffMin,
+			Max:    pollBackoffMax,
+		}
+	}
+	return a.PollBackoffBase
+}
+
+// adaptiveBackoff adapts the maximum backoff between empty polls to the event
+// rate. The ceiling doubles after each quiet period without events up to max,
+// reducing vCenter API calls during sustained quiet times, e.g. overnight, and
+// is reset to the default ceiling as soon as events are received again.
+type adaptiveBackoff struct {
+	max         time.Duration
+	quietPeriod time.Duration
+	// default ceiling
+	base time.Duration
+
+	ceiling time.Duration
+	// start of the current quiet period, zero while events are received
+	quietSince time.Time
+	// last time the ceiling was raised
+	raised time.Time
+}
+
+// newAdaptiveBackoff returns an adaptive backoff raising the ceiling from base
+// up to max after each quiet period. It returns nil if max is 0, i.e. the
+// ceiling is fixed.
+func newAdaptiveBackoff(max, base, quietPeriod time.Duration) (*adaptiveBackoff, error) {
+	if max == 0 {
+		return nil, nil
+	}
+
+	if max < base {
+		return nil, fmt.Errorf("adaptive backoff maximum %s must not be less than %s", max, base)
+	}
+
+	if quietPeriod <= 0 {
+		return nil, fmt.Errorf("quiet period %s must be greater than 0", quietPeriod)
+	}
+
+	return &adaptiveBackoff{
+		max:         max,
+		quietPeriod: quietPeriod,
+		base:        base,
+		ceiling:     base,
+	}, nil
+}
+
+// quiet records a poll without events at now and returns the backoff ceiling
+func (b *adaptiveBackoff) quiet(ctx context.Context, now time.Time) time.Duration {
+	if b == nil {
+		return pollBackoffMax
+	}
+
+	if b.quietSince.IsZero() {
+		b.quietSince, b.raised = now, now
+		return b.ceiling
+	}
+
+	if b.ceiling < b.max && now.Sub(b.raised) >= b.quietPeriod {
+		b.ceiling *= 2
+		if b.ceiling > b.max {
+			b.ceiling = b.max
+		}
+		b.raised = now
+		logging.FromContext(ctx).Infow("raised poll backoff: no events received",
+			zap.Duration("quietFor", now.Sub(b.quietSince)), zap.Duration("maxBackoff", b.ceiling))
+	}
+	return b.ceiling
+}
+
+// active records that events were received and resets the ceiling
+func (b *adaptiveBackoff) active(ctx context.Context) {
+	if b == nil || b.quietSince.IsZero() {
+		return
+	}
+
+	if b.ceiling != b.base {
+		logging.FromContext(ctx).Infow("reset poll backoff: events received", zap.Duration("maxBackoff", b.base))
+	}
+	b.ceiling = b.base
+	b.quietSince = time.Time{}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_newPollBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     time.Duration
+		max     time.Duration
+		factor  float64
+		wantErr bool
+	}{
+		{name: "defaults", min: time.Second, max: 5 * time.Second, factor: 2},
+		{name: "fixed", min: time.Second, max: time.Second, factor: 1},
+		{name: "invalid minimum", max: 5 * time.Second, factor: 2, wantErr: true},
+		{name: "minimum above maximum", min: 10 * time.Second, max: 5 * time.Second, factor: 2, wantErr: true},
+		{name: "invalid factor", min: time.Second, max: 5 * time.Second, factor: 0.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newPollBackoff(tt.min, tt.max, tt.factor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newPollBackoff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Min != tt.min || got.Max != tt.max || got.Factor != tt.factor) {
+				t.Errorf("newPollBackoff() = %+v, want min %s, max %s and factor %v", got, tt.min, tt.max, tt.factor)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_pollBackoff(t *testing.T) {
+	a := &vAdapter{}
+	if got := a.pollBackoff(); got.Min != pollBackoffMin || got.Max != pollBackoffMax || got.Factor != pollBackoffFactor {
+		t.Errorf("pollBackoff() = %+v, want defaults if not configured", got)
+	}
+
+	base, err := newPollBackoff(2*time.Second, 30*time.Second, 3)
+	if err != nil {
+		t.Fatalf("newPollBackoff() error = %v", err)
+	}
+	a.PollBackoffBase = base
+
+	b := a.pollBackoff()
+	for _, want := range []time.Duration{2 * time.Second, 6 * time.Second, 18 * time.Second, 30 * time.Second} {
+		if got := b.Duration(); got != want {
+			t.Errorf("Duration() = %s, want %s", got, want)
+		}
+	}
+	// the configured backoff is not modified
+	b = a.pollBackoff()
+	if got := b.Duration(); got != 2*time.Second {
+		t.Errorf("Duration() of new backoff = %s, want %s", got, 2*time.Second)
+	}
+}
+
+func Test_newAdaptiveBackoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         time.Duration
+		quietPeriod time.Duration
+		wantNil     bool
+		wantErr     bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "maximum below poll backoff maximum", max: time.Second, quietPeriod: time.Minute, wantErr: true},
+		{name: "invalid quiet period", max: time.Minute, wantErr: true},
+		{name: "valid", max: time.Minute, quietPeriod: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAdaptiveBackoff(tt.max, pollBackoffMax, tt.quietPeriod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAdaptiveBackoff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newAdaptiveBackoff() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_adaptiveBackoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 22, 0, 0, 0, time.UTC)
+
+	var disabled *adaptiveBackoff
+	if got := disabled.quiet(ctx, now); got != pollBackoffMax {
+		t.Errorf("quiet() on disabled backoff = %s, want %s", got, pollBackoffMax)
+	}
+
+	b, err := newAdaptiveBackoff(12*time.Second, pollBackoffMax, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("newAdaptiveBackoff() error = %v", err)
+	}
+
+	steps := []struct {
+		elapsed time.Duration
+		events  bool
+		want    time.Duration
+	}{
+		{elapsed: 0, want: 5 * time.Second},
+		{elapsed: 5 * time.Minute, want: 5 * time.Second},
+		{elapsed: 10 * time.Minute, want: 10 * time.Second},
+		{elapsed: 15 * time.Minute, want: 10 * time.Second},
+		// capped at maximum
+		{elapsed: 20 * time.Minute, want: 12 * time.Second},
+		{elapsed: 40 * time.Minute, want: 12 * time.Second},
+		// events resume
+		{elapsed: 41 * time.Minute, events: true},
+		{elapsed: 42 * time.Minute, want: 5 * time.Second},
+		{elapsed: 51 * time.Minute, want: 5 * time.Second},
+		{elapsed: 52 * time.Minute, want: 10 * time.Second},
+	}
+	for _, step := range steps {
+		if step.events {
+			b.active(ctx)
+			continue
+		}
+		if got := b.quiet(ctx, now.Add(step.elapsed)); got != step.want {
+			t.Errorf("quiet() after %s = %s, want %s", step.elapsed, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// send events to a Google Cloud Pub/Sub topic
+	sinkProtocolPubSub = "pubsub"
+
+	defaultPubSubEndpoint = "https://pubsub.googleapis.com"
+	pubSubScope           = "https://www.googleapis.com/auth/pubsub"
+	pubSubAttributePrefix = "ce-"
+	// Pub/Sub attribute holding the datacontenttype
+	pubSubContentTypeAttribute = "content-type"
+)
+
+// pubSubMessage is a message of a Pub/Sub publish request
+type pubSubMessage struct {
+	Data       []byte            `json:"data,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type pubSubPublishRequest struct {
+	Messages []pubSubMessage `json:"messages"`
+}
+
+type pubSubPublishResponse struct {
+	MessageIDs []string `json:"messageIds"`
+}
+
+// pubSubSender implements a CloudEvents protocol.Sender which publishes events
+// to a Google Cloud Pub/Sub topic using the binary content mode of the Pub/Sub
+// protocol binding, i.e. the event data is used as the message data and
+// CloudEvent attributes are added as message attributes. Send returns when the
+// message is acknowledged by Pub/Sub.
+type pubSubSender struct {
+	publishURL string
+	client     *http.Client
+}
+
+var _ protocol.Sender = (*pubSubSender)(nil)
+
+// newPubSubClient returns a CloudEvents client publishing events to the given
+// Pub/Sub topic using Google application default credentials
+func newPubSubClient(ctx context.Context, endpoint, project, topic string) (cloudevents.Client, error) {
+	ts, err := google.DefaultTokenSource(ctx, pubSubScope)
+	if err != nil {
+		return nil, fmt.Errorf("get Google credentials: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &oauth2.Transport{
+			Source: ts,
+			Base:   http.DefaultTransport,
+		},
+	}
+
+	sender, err := newPubSubSender(endpoint, project, topic, client)
+	if err != nil {
+		return nil, err
+	}
+	return cloudevents.NewClient(sender)
+}
+
+// newPubSubSender returns a sender for the given Pub/Sub endpoint, project
+// and topic
+func newPubSubSender(endpoint, project, topic string, client *http.Client) (*pubSubSender, error) {
+	if project == "" {
+		return nil, errors.New("Pub/Sub project must be set")
+	}
+
+	if topic == "" {
+		return nil, errors.New("Pub/Sub topic must be set")
+	}
+
+	if endpoint == "" {
+		endpoint = defaultPubSubEndpoint
+	}
+
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("parse Pub/Sub endpoint: %w", err)
+	}
+
+	return &pubSubSender{
+		publishURL: fmt.Sprintf("%s/v1/projects/%s/topics/%s:publish", strings.TrimSuffix(endpoint, "/"),
+			url.PathEscape(project), url.PathEscape(topic)),
+		client: client,
+	}, nil
+}
+
+// Send implements protocol.Sender
+func (s *pubSubSender) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() { _ = m.Finish(err) }()
+
+	ev, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return fmt.Errorf("convert message to event: %w", err)
+	}
+
+	body, err := json.Marshal(pubSubPublishRequest{
+		Messages: []pubSubMessage{{
+			Data:       ev.Data(),
+			Attributes: pubSubMessageAttributes(ev),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal publish request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.publishURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create Pub/Sub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("publish message to Pub/Sub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("publish message to Pub/Sub: %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var published pubSubPublishResponse
+	if err = json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return fmt.Errorf("decode Pub/Sub publish response: %w", err)
+	}
+
+	if len(published.MessageIDs) != 1 {
+		return fmt.Errorf("publish message to Pub/Sub: got %d message IDs, want 1", len(published.MessageIDs))
+	}
+	return nil
+}
+
+// pubSubMessageAttributes returns the CloudEvent attributes and extensions as
+// Pub/Sub message attributes
+func pubSubMessageAttributes(ev *event.Event) map[string]string {
+	attrs := map[string]string{
+		pubSubAttributePrefix + "specversion": ev.SpecVersion(),
+		pubSubAttributePrefix + "id":          ev.ID(),
+		pubSubAttributePrefix + "source":      ev.Source(),
+		pubSubAttributePrefix + "type":        ev.Type(),
+	}
+
+	if !ev.Time().IsZero() {
+		attrs[pubSubAttributePrefix+"time"] = ev.Time().Format(time.RFC3339Nano)
+	}
+
+	if ct := ev.DataContentType(); ct != "" {
+		attrs[pubSubContentTypeAttribute] = ct
+	}
+
+	if schema := ev.DataSchema(); schema != "" {
+		attrs[pubSubAttributePrefix+"dataschema"] = schema
+	}
+
+	if subject := ev.Subject(); subject != "" {
+		attrs[pubSubAttributePrefix+"subject"] = subject
+	}
+
+	for name, value := range ev.Extensions() {
+		attrs[pubSubAttributePrefix+name] = fmt.Sprintf("%v", value)
+	}
+	return attrs
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func Test_pubSubSender_Send(t *testing.T) {
+	now := time.Now().UTC()
+	events := createTestEvents(1, source, now)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		response   string
+		wantACK    bool
+	}{
+		{
+			name:       "message published",
+			statusCode: http.StatusOK,
+			response:   `{"messageIds":["1"]}`,
+			wantACK:    true,
+		},
+		{
+			name:       "message rejected",
+			statusCode: http.StatusForbidden,
+			response:   `{"error":{"code":403}}`,
+			wantACK:    false,
+		},
+		{
+			name:       "message not acknowledged",
+			statusCode: http.StatusOK,
+			response:   `{}`,
+			wantACK:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				path    string
+				publish pubSubPublishRequest
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				if err := json.NewDecoder(r.Body).Decode(&publish); err != nil {
+					t.Errorf("decode publish request: %v", err)
+				}
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			sender, err := newPubSubSender(srv.URL, "my-project", "vsphere-events", srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := cloudevents.NewClient(sender)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev := events.ceEvents[0]
+			result := c.Send(context.Background(), *ev)
+			if cloudevents.IsACK(result) != tt.wantACK {
+				t.Fatalf("Send() ACK = %v, want %v (result: %v)", cloudevents.IsACK(result), tt.wantACK, result)
+			}
+
+			if want := "/v1/projects/my-project/topics/vsphere-events:publish"; path != want {
+				t.Errorf("publish path = %q, want %q", path, want)
+			}
+
+			if len(publish.Messages) != 1 {
+				t.Fatalf("published %d messages, want 1", len(publish.Messages))
+			}
+			msg := publish.Messages[0]
+
+			if string(msg.Data) != string(ev.Data()) {
+				t.Errorf("message data = %q, want %q", msg.Data, ev.Data())
+			}
+			if msg.Attributes["ce-id"] != ev.ID() {
+				t.Errorf("ce-id attribute = %q, want %q", msg.Attributes["ce-id"], ev.ID())
+			}
+			if msg.Attributes["ce-type"] != ev.Type() {
+				t.Errorf("ce-type attribute = %q, want %q", msg.Attributes["ce-type"], ev.Type())
+			}
+			if msg.Attributes["content-type"] != ev.DataContentType() {
+				t.Errorf("content-type attribute = %q, want %q", msg.Attributes["content-type"], ev.DataContentType())
+			}
+			if msg.Attributes["ce-"+ceVSphereEventClass] != "event" {
+				t.Errorf("ce-%s attribute = %q, want %q", ceVSphereEventClass, msg.Attributes["ce-"+ceVSphereEventClass], "event")
+			}
+		})
+	}
+}
+
+func Test_newPubSubSender(t *testing.T) {
+	if _, err := newPubSubSender("", "", "topic", http.DefaultClient); err == nil {
+		t.Error("newPubSubSender() expected error for empty project")
+	}
+
+	if _, err := newPubSubSender("", "project", "", http.DefaultClient); err == nil {
+		t.Error("newPubSubSender() expected error for empty topic")
+	}
+
+	s, err := newPubSubSender("", "project", "topic", http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://pubsub.googleapis.com/v1/projects/project/topics/topic:publish"; s.publishURL != want {
+		t.Errorf("newPubSubSender() publishURL = %q, want %q", s.publishURL, want)
+	}
+}
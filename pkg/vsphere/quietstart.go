@@ -0,0 +1,56 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// fastForward skips the events replayed on startup up to and including the
+// last event key of the checkpoint. The event stream begins at the timestamp
+// of the last checkpointed event, so without fast-forwarding events already
+// sent before a restart are sent again.
+type fastForward struct {
+	lastKey int32
+	skipped int
+	done    bool
+}
+
+// newFastForward returns a fast-forward to the last event key of the given
+// checkpoint. It returns nil if the checkpoint is empty, i.e. there is nothing
+// to skip.
+func newFastForward(cp checkpoint) *fastForward {
+	if cp.LastEventKeyTimestamp.IsZero() {
+		return nil
+	}
+	return &fastForward{lastKey: cp.LastEventKey}
+}
+
+// skip returns the given events without the leading events with a key not
+// greater than the last checkpointed key. Skipping stops at the first event
+// with a greater key.
+func (f *fastForward) skip(ctx context.Context, events []types.BaseEvent) []types.BaseEvent {
+	if f == nil || f.done {
+		return events
+	}
+
+	for i, be := range events {
+		if be.GetEvent().Key > f.lastKey {
+			f.done = true
+			f.skipped += i
+			logging.FromContext(ctx).Infow("fast-forwarded to last checkpointed event",
+				zap.Int32("eventKey", f.lastKey), zap.Int("skipped", f.skipped))
+			return events[i:]
+		}
+	}
+
+	f.skipped += len(events)
+	return nil
+}
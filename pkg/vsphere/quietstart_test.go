@@ -0,0 +1,141 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_fastForward_skip(t *testing.T) {
+	now := time.Now().UTC()
+	keys := func(events []types.BaseEvent) []int32 {
+		var k []int32
+		for _, be := range events {
+			k = append(k, be.GetEvent().Key)
+		}
+		return k
+	}
+
+	tests := []struct {
+		name        string
+		cp          checkpoint
+		batches     [][]types.BaseEvent
+		want        [][]int32
+		wantSkipped int
+	}{
+		{
+			name:    "empty checkpoint",
+			cp:      checkpoint{},
+			batches: [][]types.BaseEvent{createTestEvents(2, source, now).vEvents},
+			want:    [][]int32{{1000, 1001}},
+		},
+		{
+			name:        "skips events up to checkpoint key",
+			cp:          checkpoint{LastEventKey: 1001, LastEventKeyTimestamp: now},
+			batches:     [][]types.BaseEvent{createTestEvents(4, source, now).vEvents},
+			want:        [][]int32{{1002, 1003}},
+			wantSkipped: 2,
+		},
+		{
+			name: "skips across batches",
+			cp:   checkpoint{LastEventKey: 1002, LastEventKeyTimestamp: now},
+			batches: [][]types.BaseEvent{
+				createTestEvents(2, source, now).vEvents,
+				{createBaseEvent(1002, now), createBaseEvent(1003, now)},
+			},
+			want:        [][]int32{nil, {1003}},
+			wantSkipped: 3,
+		},
+		{
+			name: "stops skipping at first newer event",
+			cp:   checkpoint{LastEventKey: 1000, LastEventKeyTimestamp: now},
+			batches: [][]types.BaseEvent{
+				{createBaseEvent(1001, now)},
+				// e.g. after a reset of event keys
+				{createBaseEvent(1, now)},
+			},
+			want: [][]int32{{1001}, {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFastForward(tt.cp)
+			var got [][]int32
+			for _, batch := range tt.batches {
+				got = append(got, keys(f.skip(context.Background(), batch)))
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("skip() (-want +got): %s", diff)
+			}
+			if f != nil && f.skipped != tt.wantSkipped {
+				t.Errorf("skip() skipped %d events, want %d", f.skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_quietStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().UTC()
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		KVStore:         &fakeKVStore{dataChan: make(chan string, 10)},
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		PayloadEncoding: cloudevents.ApplicationXML,
+		fastForward:     newFastForward(checkpoint{LastEventKey: 1002, LastEventKeyTimestamp: now}),
+	}
+
+	batches := [][]types.BaseEvent{
+		createTestEvents(2, source, now).vEvents,
+		{createBaseEvent(1002, now), createBaseEvent(1003, now)},
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, &fakeCollector{batches: batches})
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		ce.Lock()
+		sent := len(ce.sent)
+		ce.Unlock()
+		if sent > 0 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for events to be sent")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	ce.Lock()
+	defer ce.Unlock()
+	if len(ce.sent) != 1 || ce.sent[0].ID() != "1003" {
+		t.Errorf("readEvents() sent %d events, want only event 1003", len(ce.sent))
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// soapLogins enforces the minimum interval between all vCenter login attempts
+// of this process
+var soapLogins = &loginThrottle{now: time.Now}
+
+// loginThrottle enforces a minimum interval between successive login attempts
+// to protect a recovering vCenter from reconnect storms, independent of any
+// backoff applied by the caller
+type loginThrottle struct {
+	mu   sync.Mutex
+	last time.Time
+	now  func() time.Time
+}
+
+// wait blocks until at least interval has passed since the previous login
+// attempt and records a new attempt. It returns the time waited or an error if
+// ctx is canceled while waiting.
+func (l *loginThrottle) wait(ctx context.Context, interval time.Duration) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var delay time.Duration
+	if !l.last.IsZero() {
+		delay = l.last.Add(interval).Sub(l.now())
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	} else {
+		delay = 0
+	}
+
+	l.last = l.now()
+	return delay, nil
+}
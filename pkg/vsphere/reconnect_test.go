@@ -0,0 +1,46 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_loginThrottle_wait(t *testing.T) {
+	const interval = 50 * time.Millisecond
+
+	l := &loginThrottle{now: time.Now}
+	ctx := context.Background()
+
+	delay, err := l.wait(ctx, interval)
+	if err != nil || delay != 0 {
+		t.Fatalf("wait() first attempt = %v, %v, want no delay", delay, err)
+	}
+
+	start := time.Now()
+	delay, err = l.wait(ctx, interval)
+	if err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if delay <= 0 || time.Since(start) < interval/2 {
+		t.Errorf("wait() second attempt delay = %v, want delay enforcing interval %v", delay, interval)
+	}
+
+	// interval passed since last attempt
+	l.last = time.Now().Add(-interval)
+	if delay, err = l.wait(ctx, interval); err != nil || delay != 0 {
+		t.Errorf("wait() after interval = %v, %v, want no delay", delay, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = l.wait(canceled, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// keyRange is an inclusive range of event keys replayed in replay-only mode
+type keyRange struct {
+	from int32
+	to   int32
+}
+
+// newKeyRange returns the key range [from, to] for replay-only mode. nil is
+// returned if to is 0, i.e. replay-only mode is disabled.
+func newKeyRange(from, to int32) (*keyRange, error) {
+	if to == 0 {
+		if from != 0 {
+			return nil, fmt.Errorf("invalid replay key range: key from %d requires key to", from)
+		}
+		return nil, nil
+	}
+
+	if from < 0 || to < from {
+		return nil, fmt.Errorf("invalid replay key range [%d, %d]", from, to)
+	}
+	return &keyRange{from: from, to: to}, nil
+}
+
+// replayPacer paces replayed events relative to their original creation
+// times, e.g. a speed of 10 sends events ten times faster than they were
+// created in vCenter
+type replayPacer struct {
+	speed float64
+	now   func() time.Time
+
+	// creation time of the first paced event and when it was sent
+	origin, started time.Time
+}
+
+// newReplayPacer returns a pacer for the given speed multiplier. nil is
+// returned if speed is 0, i.e. events are replayed as fast as possible.
+func newReplayPacer(speed float64) (*replayPacer, error) {
+	if speed < 0 {
+		return nil, fmt.Errorf("invalid replay speed %v: must not be negative", speed)
+	}
+	if speed == 0 {
+		return nil, nil
+	}
+	return &replayPacer{speed: speed, now: time.Now}, nil
+}
+
+// delay returns how long to wait before sending an event created at the
+// given time
+func (p *replayPacer) delay(created time.Time) time.Duration {
+	if p.origin.IsZero() {
+		p.origin, p.started = created, p.now()
+		return 0
+	}
+
+	offset := created.Sub(p.origin)
+	if offset < 0 {
+		offset = 0
+	}
+	target := p.started.Add(time.Duration(float64(offset) / p.speed))
+	if d := target.Sub(p.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// multiplier returns the replay speed multiplier, 0 if unpaced
+func (p *replayPacer) multiplier() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.speed
+}
+
+// wait blocks until an event created at the given time is due. It returns
+// early with the context error if ctx is canceled.
+func (p *replayPacer) wait(ctx context.Context, created time.Time) error {
+	if p == nil {
+		return nil
+	}
+
+	delay := p.delay(created)
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// String implements fmt.Stringer
+func (r keyRange) String() string {
+	return fmt.Sprintf("[%d, %d]", r.from, r.to)
+}
+
+// runReplay replays all events in the configured key range from the retained
+// vCenter event history and returns when the end of the range or the event
+// stream is reached. Checkpoints are neither read nor written.
+func (a *vAdapter) runReplay(ctx context.Context) error {
+	logging.FromContext(ctx).Infow("replaying events in key range", zap.Stringer("keys", a.Replay),
+		zap.Float64("speed", a.ReplayPacer.multiplier()))
+
+	// retained vCenter event history
+	coll, err := newHistoryCollector(ctx, a.VClient.Client, time.Time{}, a.ServerTypes)
+	if err != nil {
+		return fmt.Errorf("create event collector: %w", err)
+	}
+
+	if a.Tee != nil {
+		go a.Tee.run(ctx)
+	}
+
+	return a.replayEvents(ctx, coll)
+}
+
+// replayEvents reads events from the given collector and sends the events in
+// the configured key range. Events with keys below the range are skipped.
+func (a *vAdapter) replayEvents(ctx context.Context, c eventCollector) error {
+	logger := logging.FromContext(ctx)
+
+	var sent int
+	for {
+		events, err := c.ReadNextEvents(ctx, a.readBatchSize())
+		if err != nil {
+			return fmt.Errorf("read events from vcenter: %w", err)
+		}
+		a.fillCreatedTime(ctx, events, time.Now().UTC())
+
+		if len(events) == 0 {
+			logger.Warnw("replay reached end of event stream before end of key range",
+				zap.Stringer("keys", a.Replay), zap.Int("sent", sent))
+			return nil
+		}
+
+		inRange, done := a.Replay.filter(events)
+		n, err := a.sendReplayed(ctx, inRange)
+		sent += n
+		if err != nil {
+			return fmt.Errorf("replay events: success %d (total %d): %w", n, len(inRange), err)
+		}
+
+		if done {
+			logger.Infow("replay finished", zap.Stringer("keys", a.Replay), zap.Int("sent", sent))
+			return nil
+		}
+	}
+}
+
+// sendReplayed sends the given replayed events, one at a time paced by the
+// configured replay speed or all at once if unpaced
+func (a *vAdapter) sendReplayed(ctx context.Context, events []types.BaseEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if a.ReplayPacer == nil {
+		return a.sendEvents(ctx, events)
+	}
+
+	var sent int
+	for _, be := range events {
+		if err := a.ReplayPacer.wait(ctx, be.GetEvent().CreatedTime); err != nil {
+			return sent, err
+		}
+		n, err := a.sendEvents(ctx, []types.BaseEvent{be})
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// filter returns the events with keys in the range and whether an event with
+// a key beyond the range was seen, i.e. the end of the range is reached
+func (r keyRange) filter(events []types.BaseEvent) ([]types.BaseEvent, bool) {
+	var (
+		inRange []types.BaseEvent
+		done    bool
+	)
+
+	for _, be := range events {
+		key := be.GetEvent().Key
+		switch {
+		case key < r.from:
+			continue
+		case key > r.to:
+			done = true
+		default:
+			inRange = append(inRange, be)
+		}
+	}
+	return inRange, done
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/logging"
+)
+
+func Test_newKeyRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    int32
+		to      int32
+		want    *keyRange
+		wantErr bool
+	}{
+		{name: "disabled", from: 0, to: 0, want: nil},
+		{name: "valid range", from: 10, to: 20, want: &keyRange{from: 10, to: 20}},
+		{name: "single key", from: 10, to: 10, want: &keyRange{from: 10, to: 10}},
+		{name: "open start", from: 0, to: 20, want: &keyRange{from: 0, to: 20}},
+		{name: "missing end", from: 10, to: 0, wantErr: true},
+		{name: "inverted range", from: 20, to: 10, wantErr: true},
+		{name: "negative start", from: -1, to: 10, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newKeyRange(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newKeyRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(keyRange{})); diff != "" {
+				t.Errorf("newKeyRange() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_replayEvents(t *testing.T) {
+	// keys 1000-1009
+	events := createTestEvents(10, source, time.Now().UTC()).vEvents
+
+	tests := []struct {
+		name     string
+		batches  [][]types.BaseEvent
+		keys     keyRange
+		results  []error
+		wantKeys []string
+		wantErr  bool
+		// batches not read after end of range
+		wantUnread int
+	}{
+		{
+			name:     "range within single batch",
+			batches:  [][]types.BaseEvent{events},
+			keys:     keyRange{from: 1002, to: 1004},
+			wantKeys: []string{"1002", "1003", "1004"},
+		},
+		{
+			name:       "range across batches stops after end of range",
+			batches:    [][]types.BaseEvent{events[:3], events[3:6], events[6:]},
+			keys:       keyRange{from: 1001, to: 1004},
+			wantKeys:   []string{"1001", "1002", "1003", "1004"},
+			wantUnread: 1,
+		},
+		{
+			name:     "end of event stream before end of range",
+			batches:  [][]types.BaseEvent{events[:5]},
+			keys:     keyRange{from: 1003, to: 2000},
+			wantKeys: []string{"1003", "1004"},
+		},
+		{
+			name:     "send failure",
+			batches:  [][]types.BaseEvent{events},
+			keys:     keyRange{from: 1000, to: 1004},
+			results:  []error{nil, errors.New("sink unavailable")},
+			wantKeys: []string{"1000", "1001"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			ce := &fakeCEClient{results: tt.results}
+			keys := tt.keys
+			a := &vAdapter{
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				Replay:          &keys,
+			}
+
+			coll := &fakeCollector{batches: tt.batches}
+			if err := a.replayEvents(ctx, coll); (err != nil) != tt.wantErr {
+				t.Fatalf("replayEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var gotKeys []string
+			for _, ev := range ce.sent {
+				gotKeys = append(gotKeys, ev.ID())
+			}
+			if diff := cmp.Diff(tt.wantKeys, gotKeys); diff != "" {
+				t.Errorf("replayEvents() sent events (-want +got): %s", diff)
+			}
+			if len(coll.batches) != tt.wantUnread {
+				t.Errorf("replayEvents() unread batches = %d, want %d", len(coll.batches), tt.wantUnread)
+			}
+		})
+	}
+}
+
+func Test_newReplayPacer(t *testing.T) {
+	if got, err := newReplayPacer(0); got != nil || err != nil {
+		t.Errorf("newReplayPacer(0) = %v, %v, want nil", got, err)
+	}
+	if _, err := newReplayPacer(-1); err == nil {
+		t.Error("newReplayPacer(-1) error = nil, want error")
+	}
+	if got, err := newReplayPacer(2); err != nil || got.speed != 2 {
+		t.Errorf("newReplayPacer(2) = %v, %v, want speed 2", got, err)
+	}
+}
+
+func Test_replayPacer_delay(t *testing.T) {
+	origin := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &replayPacer{speed: 10, now: func() time.Time { return now }}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		created time.Time
+		want    time.Duration
+	}{
+		{name: "first event sent immediately", created: origin, want: 0},
+		{name: "scaled interval", created: origin.Add(time.Minute), want: 6 * time.Second},
+		{name: "partially elapsed", elapsed: 2 * time.Second, created: origin.Add(time.Minute), want: 4 * time.Second},
+		{name: "overdue", elapsed: 10 * time.Second, created: origin.Add(time.Minute), want: 0},
+		{name: "created before origin", created: origin.Add(-time.Minute), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).Add(tt.elapsed)
+			if got := p.delay(tt.created); got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_replayEvents_paced(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	ce := &fakeCEClient{}
+	keys := keyRange{from: 1000, to: 1002}
+	a := &vAdapter{
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		Replay:          &keys,
+		// events are created one second apart
+		ReplayPacer: &replayPacer{speed: 100, now: time.Now},
+	}
+
+	created := time.Now().UTC()
+	events := []types.BaseEvent{
+		createBaseEvent(1000, created),
+		createBaseEvent(1001, created.Add(time.Second)),
+		createBaseEvent(1002, created.Add(2*time.Second)),
+	}
+	start := time.Now()
+	if err := a.replayEvents(ctx, &fakeCollector{batches: [][]types.BaseEvent{events}}); err != nil {
+		t.Fatalf("replayEvents() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("replayEvents() took %v, want paced replay", elapsed)
+	}
+
+	if len(ce.sent) != 3 {
+		t.Fatalf("replayEvents() sent %d events, want 3", len(ce.sent))
+	}
+	for _, ev := range ce.sent {
+		if v, ok := ev.Extensions()[ceVSphereReplay]; !ok || v != true {
+			t.Errorf("replayEvents() %s = %v, want true", ceVSphereReplay, v)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// extended attribute marking an event re-sent on startup to prime consumers or
+// replayed in replay-only mode
+const ceVSphereReplay = "vspherereplay"
+
+// eventLookupFunc returns the event with the given key created at the given
+// time or nil if the event is no longer retained by vCenter
+type eventLookupFunc func(ctx context.Context, key int32, created time.Time) (types.BaseEvent, error)
+
+// eventAtFunc returns an eventLookupFunc using the given vSphere client
+func eventAtFunc(client *vim25.Client) eventLookupFunc {
+	mgr := event.NewManager(client)
+	return func(ctx context.Context, key int32, created time.Time) (types.BaseEvent, error) {
+		// tolerate precision differences of the stored creation time
+		begin, end := created.Add(-time.Second), created.Add(time.Second)
+		events, err := mgr.QueryEvents(ctx, types.EventFilterSpec{
+			Entity: &types.EventFilterSpecByEntity{
+				Entity:    client.ServiceContent.RootFolder,
+				Recursion: types.EventFilterSpecRecursionOptionAll,
+			},
+			Time: &types.EventFilterSpecByTime{BeginTime: &begin, EndTime: &end},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, be := range events {
+			if be.GetEvent().Key == key {
+				return be, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// primingKey is the context key marking events re-sent to prime consumers
+type primingKey struct{}
+
+// isPriming returns whether events are sent to prime consumers
+func isPriming(ctx context.Context) bool {
+	v, _ := ctx.Value(primingKey{}).(bool)
+	return v
+}
+
+// sendLastCheckpointed re-sends the last checkpointed event marked as replay
+// so consumers receive the current state on startup without a full backlog
+// replay. Failures are logged since priming is best effort. The checkpoint is
+// not changed.
+func (a *vAdapter) sendLastCheckpointed(ctx context.Context, cp checkpoint) {
+	logger := logging.FromContext(ctx)
+	if cp.LastEventKeyTimestamp.IsZero() {
+		logger.Info("no checkpoint found: not re-sending last checkpointed event")
+		return
+	}
+
+	be, err := a.ReplayLast(ctx, cp.LastEventKey, cp.LastEventKeyTimestamp)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("look up last checkpointed event: %w", err)
+	case be == nil:
+		err = fmt.Errorf("event %d no longer retained by vCenter", cp.LastEventKey)
+	default:
+		_, err = a.sendEvents(context.WithValue(ctx, primingKey{}, true), []types.BaseEvent{be})
+	}
+
+	if err != nil {
+		logger.Warnw("could not re-send last checkpointed event", zap.Int32("eventKey", cp.LastEventKey), zap.Error(err))
+		return
+	}
+	logger.Infow("re-sent last checkpointed event", zap.Int32("eventKey", cp.LastEventKey))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_vAdapter_sendLastCheckpointed(t *testing.T) {
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	cp := checkpoint{LastEventKey: 1042, LastEventKeyTimestamp: created}
+
+	tests := []struct {
+		name     string
+		cp       checkpoint
+		lookup   eventLookupFunc
+		wantSent bool
+	}{
+		{
+			name: "no checkpoint",
+			lookup: func(context.Context, int32, time.Time) (types.BaseEvent, error) {
+				t.Fatal("lookup without checkpoint")
+				return nil, nil
+			},
+		},
+		{
+			name: "event no longer retained",
+			cp:   cp,
+			lookup: func(context.Context, int32, time.Time) (types.BaseEvent, error) {
+				return nil, nil
+			},
+		},
+		{
+			name: "lookup fails",
+			cp:   cp,
+			lookup: func(context.Context, int32, time.Time) (types.BaseEvent, error) {
+				return nil, errors.New("not authenticated")
+			},
+		},
+		{
+			name: "re-sent",
+			cp:   cp,
+			lookup: func(_ context.Context, key int32, ts time.Time) (types.BaseEvent, error) {
+				return powerOnEvent(key, "vm-1", ts), nil
+			},
+			wantSent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := &fakeCEClient{}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				ReplayLast:      tt.lookup,
+			}
+
+			a.sendLastCheckpointed(context.Background(), tt.cp)
+			if (len(ce.sent) == 1) != tt.wantSent {
+				t.Fatalf("sendLastCheckpointed() sent %d events, want sent %v", len(ce.sent), tt.wantSent)
+			}
+			if !tt.wantSent {
+				return
+			}
+
+			ev := ce.sent[0]
+			if ev.ID() != "1042" {
+				t.Errorf("sendLastCheckpointed() sent event %s, want 1042", ev.ID())
+			}
+			if replay, ok := ev.Extensions()[ceVSphereReplay]; !ok || replay != true {
+				t.Errorf("sendLastCheckpointed() %s = %v, want true", ceVSphereReplay, replay)
+			}
+		})
+	}
+}
+
+func Test_eventAtFunc(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		events, err := event.NewManager(vim).QueryEvents(ctx, types.EventFilterSpec{})
+		if err != nil || len(events) == 0 {
+			t.Fatalf("query events: %d events, error %v", len(events), err)
+		}
+		want := events[0].GetEvent()
+
+		got, err := eventAtFunc(vim)(ctx, want.Key, want.CreatedTime)
+		if err != nil {
+			t.Fatalf("eventAtFunc() error = %v", err)
+		}
+		if got == nil || got.GetEvent().Key != want.Key {
+			t.Errorf("eventAtFunc() = %v, want event %d", got, want.Key)
+		}
+
+		got, err = eventAtFunc(vim)(ctx, -1, want.CreatedTime)
+		if err != nil || got != nil {
+			t.Errorf("eventAtFunc() = %v, %v, want nil for unknown key", got, err)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// sessionExpired returns true if the given ReadNextEvents error is caused by
+// an expired or invalidated vCenter session, e.g. after the credentials were
+// rotated, which can be recovered by logging in again
+func sessionExpired(err error) bool {
+	var fault interface{}
+	switch {
+	case soap.IsSoapFault(err):
+		fault = soap.ToSoapFault(err).VimFault()
+	case soap.IsVimFault(err):
+		fault = soap.ToVimFault(err)
+	default:
+		return false
+	}
+
+	switch fault.(type) {
+	case types.NotAuthenticated, *types.NotAuthenticated:
+		return true
+	default:
+		return false
+	}
+}
+
+// renewSOAPSession logs in to vCenter with the credentials read from the
+// mounted secret and replaces the session of c in place, so clients created
+// from c before, e.g. for resolving entity paths, use the new session as well
+func renewSOAPSession(ctx context.Context, c *govmomi.Client) error {
+	renewed, err := NewSOAPClient(ctx)
+	if err != nil {
+		return fmt.Errorf("renew vCenter session: %w", err)
+	}
+
+	// best effort, stops the keep-alive of the expired session
+	_ = c.Logout(ctx)
+
+	*c.Client = *renewed.Client
+	c.SessionManager = renewed.SessionManager
+	return nil
+}
+
+// renewCollector renews the expired vCenter session and returns a new event
+// collector resuming after the given last successfully sent event
+func (a *vAdapter) renewCollector(ctx context.Context, last types.BaseEvent, cause error) (eventCollector, error) {
+	logging.FromContext(ctx).Warnw("vCenter session expired: logging in with credentials from secret", zap.Error(cause))
+
+	if err := a.renewSession(ctx); err != nil {
+		return nil, err
+	}
+	return a.recreateCollector(ctx, last, cause)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func notAuthenticated() error {
+	fault := &soap.Fault{Code: "ServerFaultCode", String: "not authenticated"}
+	fault.Detail.Fault = types.NotAuthenticated{}
+	return soap.WrapSoapFault(fault)
+}
+
+func Test_sessionExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not authenticated", err: notAuthenticated(), want: true},
+		{name: "vim fault not authenticated", err: soap.WrapVimFault(&types.NotAuthenticated{}), want: true},
+		{name: "collector not found", err: collectorNotFound(), want: false},
+		{name: "regular error", err: errors.New("connection reset"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionExpired(tt.err); got != tt.want {
+				t.Errorf("sessionExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_readEvents_renewSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().UTC()
+	renewResults := []error{errors.New("credentials not mounted yet"), nil}
+	var renewals int
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		KVStore:         &fakeKVStore{dataChan: make(chan string, 10)},
+		CpConfig:        CheckpointConfig{Period: time.Hour},
+		PayloadEncoding: cloudevents.ApplicationXML,
+		collectorBegin:  now,
+		newCollector: func(context.Context, time.Time) (eventCollector, error) {
+			return &fakeCollector{batches: [][]types.BaseEvent{createTestEvents(3, source, now).vEvents}}, nil
+		},
+		renewSession: func(context.Context) error {
+			renewals++
+			return renewResults[renewals-1]
+		},
+	}
+
+	expired := &failingCollector{
+		batches: [][]types.BaseEvent{createTestEvents(2, source, now).vEvents},
+		err:     notAuthenticated(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.readEvents(ctx, expired)
+	}()
+
+	deadline := time.After(10 * time.Second)
+	for {
+		ce.Lock()
+		sent := len(ce.sent)
+		ce.Unlock()
+		if sent >= 3 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for events to be sent")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("readEvents() error = %v, want %v", err, context.Canceled)
+	}
+
+	ce.Lock()
+	defer ce.Unlock()
+	var ids []string
+	for _, ev := range ce.sent {
+		ids = append(ids, ev.ID())
+	}
+	// resumes after last sent event with the renewed session
+	if len(ids) != 3 || ids[2] != "1002" {
+		t.Errorf("readEvents() sent events %v, want [1000 1001 1002]", ids)
+	}
+	if renewals != 2 {
+		t.Errorf("readEvents() renewed session %d times, want 2", renewals)
+	}
+}
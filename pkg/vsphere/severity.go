@@ -0,0 +1,110 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// event severities (categories) defined by vCenter
+var eventSeverities = map[string]struct{}{
+	"info":    {},
+	"warning": {},
+	"error":   {},
+	"user":    {},
+}
+
+// severityFunc returns the severity of the given event, e.g. warning
+type severityFunc func(ctx context.Context, be types.BaseEvent) (string, error)
+
+// eventManagerSeverity returns a severityFunc using the event categories of
+// the vCenter event manager. Categories are retrieved once and cached.
+func eventManagerSeverity(client *vim25.Client) severityFunc {
+	m := event.NewManager(client)
+	return m.EventCategory
+}
+
+// severityRouter routes events to a sink depending on their severity. Events
+// of severities without route are sent to the default sink.
+type severityRouter struct {
+	severity severityFunc
+	// severity to sink URI
+	routes map[string]string
+}
+
+// newSeverityRouter returns a router for the given JSON-encoded object of
+// severities to sink URIs, e.g. {"error":"http://incidents"}. It returns nil
+// if config is empty, i.e. all events are sent to the default sink.
+func newSeverityRouter(config string, severity severityFunc) (*severityRouter, error) {
+	if config == "" {
+		return nil, nil
+	}
+
+	var routes map[string]string
+	if err := json.Unmarshal([]byte(config), &routes); err != nil {
+		return nil, fmt.Errorf("unmarshal severity routes: %w", err)
+	}
+
+	for sev, target := range routes {
+		if _, ok := eventSeverities[sev]; !ok {
+			return nil, fmt.Errorf("invalid severity %q: must be info, warning, error or user", sev)
+		}
+
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid sink %q for severity %s: must be an absolute http or https URI", target, sev)
+		}
+	}
+
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	return &severityRouter{severity: severity, routes: routes}, nil
+}
+
+// route returns ctx routing the given event to the sink of its severity. The
+// default sink is used if the severity has no route or cannot be determined.
+func (r *severityRouter) route(ctx context.Context, be types.BaseEvent) context.Context {
+	if r == nil {
+		return ctx
+	}
+
+	sev, err := r.severity(ctx, be)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("could not determine event severity, using default sink",
+			zap.Int32("eventKey", be.GetEvent().Key), zap.Error(err))
+		return ctx
+	}
+
+	target, ok := r.routes[sev]
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routeKey{}, target)
+}
+
+// routeKey is the context key of the sink an event is routed to
+type routeKey struct{}
+
+// withRoutedTarget returns ctx sending to the sink the event was routed to, if
+// any. Only deliveries to the primary sink are routed, i.e. fallback and dead
+// letter sinks are not affected.
+func withRoutedTarget(ctx context.Context) context.Context {
+	if target, ok := ctx.Value(routeKey{}).(string); ok {
+		return cloudevents.ContextWithTarget(ctx, target)
+	}
+	return ctx
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// fakeSeverity returns error for VmFailedToPowerOnEvent, info otherwise
+func fakeSeverity(_ context.Context, be types.BaseEvent) (string, error) {
+	switch be.(type) {
+	case *types.VmFailedToPowerOnEvent:
+		return "error", nil
+	case *types.VmPoweredOffEvent:
+		return "", errors.New("event description unavailable")
+	default:
+		return "info", nil
+	}
+}
+
+func Test_newSeverityRouter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "no routes", config: "{}", wantNil: true},
+		{name: "invalid JSON", config: "error=http://incidents", wantErr: true},
+		{name: "unknown severity", config: `{"critical":"http://incidents"}`, wantErr: true},
+		{name: "relative sink", config: `{"error":"/incidents"}`, wantErr: true},
+		{name: "unsupported scheme", config: `{"error":"ftp://incidents"}`, wantErr: true},
+		{name: "routes", config: `{"error":"http://incidents","warning":"https://incidents"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSeverityRouter(tt.config, fakeSeverity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSeverityRouter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newSeverityRouter() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_severityRouter_route(t *testing.T) {
+	now := time.Now()
+	base := types.Event{Key: 1000, CreatedTime: now}
+
+	r, err := newSeverityRouter(`{"error":"http://incidents"}`, fakeSeverity)
+	if err != nil {
+		t.Fatalf("newSeverityRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		r    *severityRouter
+		be   types.BaseEvent
+		want string
+	}{
+		{name: "disabled", be: &types.VmFailedToPowerOnEvent{VmEvent: types.VmEvent{Event: base}}},
+		{name: "routed severity", r: r, be: &types.VmFailedToPowerOnEvent{VmEvent: types.VmEvent{Event: base}}, want: "http://incidents"},
+		{name: "severity without route", r: r, be: &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: base}}},
+		{name: "unknown severity", r: r, be: &types.VmPoweredOffEvent{VmEvent: types.VmEvent{Event: base}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withRoutedTarget(tt.r.route(context.Background(), tt.be))
+
+			var got string
+			if target := cecontext.TargetFrom(ctx); target != nil {
+				got = target.String()
+			}
+			if got != tt.want {
+				t.Errorf("route() target = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/plugin/ochttp"
+	"knative.dev/eventing/pkg/adapter/v2"
+	sourcemetrics "knative.dev/eventing/pkg/metrics/source"
+	"knative.dev/pkg/tracing/propagation/tracecontextb3"
+)
+
+const (
+	// compress request bodies sent to the sink with gzip
+	sinkCompressionGzip = "gzip"
+)
+
+var (
+	// HTTP header names must be tokens (RFC 7230)
+	headerNameRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+	// headers set by the CloudEvents HTTP binding and transport
+	reservedSinkHeaders = map[string]struct{}{
+		"content-type":     {},
+		"content-length":   {},
+		"content-encoding": {},
+		"host":             {},
+	}
+)
+
+// customSinkTransport returns true if the configuration requires a custom HTTP
+// transport to deliver events to the sink
+func customSinkTransport(env *envConfig) bool {
+	return env.SinkLocalAddr != "" || env.SinkCompression != "" || env.SinkFollowRedirects ||
+		env.SinkHeaders != ""
+}
+
+// parseSinkHeaders parses the given comma-separated list of key=value pairs
+// into HTTP headers. Headers managed by the CloudEvents HTTP binding are
+// rejected.
+func parseSinkHeaders(s string) (http.Header, error) {
+	headers := http.Header{}
+	if strings.TrimSpace(s) == "" {
+		return headers, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid sink header %q: must be key=value", pair)
+		}
+
+		key := strings.TrimSpace(kv[0])
+		if !headerNameRegex.MatchString(key) {
+			return nil, fmt.Errorf("invalid sink header name %q", key)
+		}
+
+		lower := strings.ToLower(key)
+		if _, ok := reservedSinkHeaders[lower]; ok || strings.HasPrefix(lower, "ce-") {
+			return nil, fmt.Errorf("invalid sink header %q: managed by CloudEvents", key)
+		}
+
+		headers.Add(key, strings.TrimSpace(kv[1]))
+	}
+	return headers, nil
+}
+
+// newSinkTransport returns the HTTP transport used to deliver events to the
+// sink as configured in env
+func newSinkTransport(env *envConfig) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if env.SinkLocalAddr != "" {
+		localAddr, err := resolveLocalAddr(env.SinkLocalAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		dialer := &net.Dialer{
+			LocalAddr: localAddr,
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+
+	var rt http.RoundTripper
+	switch env.SinkCompression {
+	case "":
+		rt = transport
+	case sinkCompressionGzip:
+		rt = &gzipTransport{base: transport}
+	default:
+		return nil, fmt.Errorf("unsupported sink compression %q", env.SinkCompression)
+	}
+
+	if env.SinkFollowRedirects {
+		if env.SinkMaxRedirects <= 0 {
+			return nil, fmt.Errorf("invalid maximum number of sink redirects %d: must be greater than 0", env.SinkMaxRedirects)
+		}
+		rt = &redirectTransport{base: rt, maxRedirects: env.SinkMaxRedirects}
+	}
+	return rt, nil
+}
+
+// gzipTransport compresses request bodies with gzip and sets the
+// Content-Encoding header accordingly. The content type of the request is
+// preserved. If the sink rejects a compressed request with 415 (Unsupported
+// Media Type), the request is retried uncompressed and compression is disabled
+// for all subsequent requests.
+type gzipTransport struct {
+	base     http.RoundTripper
+	disabled int32
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || atomic.LoadInt32(&t.disabled) == 1 {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err = zw.Write(body); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+	if err = zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
+	compressed := req.Clone(req.Context())
+	compressed.Body = ioutil.NopCloser(&buf)
+	compressed.ContentLength = int64(buf.Len())
+	compressed.Header.Set("Content-Encoding", sinkCompressionGzip)
+
+	resp, err := t.base.RoundTrip(compressed)
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		return resp, err
+	}
+
+	// sink does not support compressed requests
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	atomic.StoreInt32(&t.disabled, 1)
+
+	uncompressed := req.Clone(req.Context())
+	uncompressed.Body = ioutil.NopCloser(bytes.NewReader(body))
+	uncompressed.ContentLength = int64(len(body))
+	return t.base.RoundTrip(uncompressed)
+}
+
+// redirectTransport follows 3xx redirects returned by the sink. Unlike the
+// redirect policy of http.Client, the request is re-sent to the redirect
+// location with the original method, headers and body regardless of the
+// redirect status code. An error is returned if the sink redirects more than
+// maxRedirects times.
+type redirectTransport struct {
+	base         http.RoundTripper
+	maxRedirects int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+	}
+
+	target := req.URL
+	for redirects := 0; ; redirects++ {
+		r := req.Clone(req.Context())
+		r.URL = target
+		if target != req.URL {
+			// use host of redirect location
+			r.Host = ""
+		}
+		if req.Body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if err != nil || !isRedirect(resp.StatusCode) {
+			return resp, err
+		}
+
+		location := resp.Header.Get("Location")
+		if location == "" {
+			// nothing to follow
+			return resp, nil
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if redirects >= t.maxRedirects {
+			return nil, fmt.Errorf("stopped after %d sink redirects", t.maxRedirects)
+		}
+
+		if target, err = target.Parse(location); err != nil {
+			return nil, fmt.Errorf("parse sink redirect location %q: %w", location, err)
+		}
+	}
+}
+
+// isRedirect returns true if the given status code is a redirect followed by
+// redirectTransport
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolveLocalAddr returns the local TCP address for the given IP address or
+// network interface name. For an interface the first IPv4 address (or IPv6
+// address if no IPv4 address exists) is used.
+func resolveLocalAddr(addr string) (*net.TCPAddr, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+
+	iface, err := net.InterfaceByName(addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolve local address %q: not an IP address or network interface: %w", addr, err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("retrieve addresses of network interface %q: %w", addr, err)
+	}
+
+	var ipv6 net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return &net.TCPAddr{IP: ip4}, nil
+		}
+		if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+
+	if ipv6 != nil {
+		return &net.TCPAddr{IP: ipv6}, nil
+	}
+	return nil, fmt.Errorf("network interface %q has no IP address", addr)
+}
+
+// validateSink returns an error if no valid sink URI is configured for the
+// HTTP sink protocol, i.e. sending events would fail for every event. No sink
+// is required if events are only archived.
+func validateSink(env *envConfig) error {
+	if env.ArchiveOnly {
+		return nil
+	}
+
+	target := env.GetSink()
+	if target == "" {
+		return fmt.Errorf("no sink configured: %s must be set to the URI of the sink", adapter.EnvConfigSink)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid sink %q: %w", target, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid sink %q: must be an absolute http or https URI", target)
+	}
+	return nil
+}
+
+// sinkClientFunc returns a CloudEvents client sending to the given target
+type sinkClientFunc func(target string) (cloudevents.Client, error)
+
+// newSinkClient returns a CloudEvents client sending to target using the given
+// HTTP transport. Like the default client created by the adapter framework,
+// the client applies CloudEvent overrides, reports event metrics and
+// propagates tracing headers.
+func newSinkClient(env *envConfig, rt http.RoundTripper, target string) (cloudevents.Client, error) {
+	reporter, err := sourcemetrics.NewStatsReporter()
+	if err != nil {
+		return nil, fmt.Errorf("create stats reporter: %w", err)
+	}
+
+	ceOverrides, err := env.GetCloudEventOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("get cloud event overrides: %w", err)
+	}
+
+	// use dedicated http client to not modify http.DefaultClient
+	httpClient := http.Client{}
+	if timeout := env.GetSinktimeout(); timeout > 0 {
+		httpClient.Timeout = time.Duration(timeout) * time.Second
+	}
+	if env.SinkFollowRedirects {
+		// redirects are followed by redirectTransport
+		httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	opts := []cehttp.Option{
+		cehttp.WithClient(httpClient),
+		cloudevents.WithRoundTripper(&ochttp.Transport{
+			Base:        rt,
+			Propagation: tracecontextb3.TraceContextEgress,
+		}),
+	}
+	if target != "" {
+		opts = append(opts, cloudevents.WithTarget(target))
+	}
+
+	headers, err := parseSinkHeaders(env.SinkHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range headers {
+		for _, v := range values {
+			opts = append(opts, cehttp.WithHeader(key, v))
+		}
+	}
+
+	return adapter.NewCloudEventsClientWithOptions(ceOverrides, reporter, opts...)
+}
+
+// probeSink verifies that the sink at target is reachable by sending an
+// OPTIONS request with the given headers over the given transport of the sink
+// client. An error is returned if the request fails or the sink does not
+// respond with a 2xx status code.
+func probeSink(ctx context.Context, target string, rt http.RoundTripper, headers http.Header, timeout time.Duration) error {
+	if target == "" {
+		return fmt.Errorf("probe sink: no sink configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, target, nil)
+	if err != nil {
+		return fmt.Errorf("create sink probe request: %w", err)
+	}
+	for key, values := range headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	// use dedicated http client to not depend on http.DefaultClient
+	client := http.Client{Transport: rt, Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("probe sink %q: %w", target, err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("probe sink %q: unexpected status code %d", target, resp.StatusCode)
+	}
+	return nil
+}
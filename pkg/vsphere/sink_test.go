@@ -0,0 +1,553 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+func Test_resolveLocalAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantIP  string
+		wantErr bool
+	}{
+		{
+			name:   "IPv4 address",
+			addr:   "127.0.0.1",
+			wantIP: "127.0.0.1",
+		},
+		{
+			name:   "IPv6 address",
+			addr:   "::1",
+			wantIP: "::1",
+		},
+		{
+			name:    "unknown interface",
+			addr:    "does-not-exist0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveLocalAddr(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveLocalAddr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !got.IP.Equal(net.ParseIP(tt.wantIP)) {
+				t.Errorf("resolveLocalAddr() = %v, want %v", got.IP, tt.wantIP)
+			}
+		})
+	}
+}
+
+func Test_newSinkClient_localAddr(t *testing.T) {
+	var remoteAddr string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	env := &envConfig{
+		EnvConfig: adapter.EnvConfig{
+			Sink: srv.URL,
+		},
+		SinkLocalAddr: "127.0.0.1",
+	}
+
+	transport, err := newSinkTransport(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newSinkClient(env, transport, env.GetSink())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := createTestEvents(1, source, time.Now().UTC()).ceEvents[0]
+	if result := c.Send(context.Background(), *ev); !cloudevents.IsACK(result) {
+		t.Fatalf("Send() failed: %v", result)
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		t.Fatalf("split remote address %q: %v", remoteAddr, err)
+	}
+	if host != "127.0.0.1" {
+		t.Errorf("remote address = %q, want %q", host, "127.0.0.1")
+	}
+}
+
+func Test_newSinkClient_gzip(t *testing.T) {
+	tests := []struct {
+		name            string
+		acceptGzip      bool
+		wantCompression []bool
+	}{
+		{
+			name:            "sink accepts gzip",
+			acceptGzip:      true,
+			wantCompression: []bool{true, true},
+		},
+		{
+			name:            "sink rejects gzip",
+			acceptGzip:      false,
+			wantCompression: []bool{true, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compressed []bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				isGzip := r.Header.Get("Content-Encoding") == sinkCompressionGzip
+				compressed = append(compressed, isGzip)
+
+				if isGzip && !tt.acceptGzip {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+
+				if r.Header.Get("Content-Type") != cloudevents.ApplicationXML {
+					t.Errorf("Content-Type = %q, want %q", r.Header.Get("Content-Type"), cloudevents.ApplicationXML)
+				}
+
+				body := io.Reader(r.Body)
+				if isGzip {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Fatalf("create gzip reader: %v", err)
+					}
+					body = zr
+				}
+
+				data, err := ioutil.ReadAll(body)
+				if err != nil {
+					t.Fatalf("read body: %v", err)
+				}
+				if !strings.HasPrefix(string(data), "<") {
+					t.Errorf("unexpected body %q", string(data))
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer srv.Close()
+
+			env := &envConfig{
+				EnvConfig: adapter.EnvConfig{
+					Sink: srv.URL,
+				},
+				SinkCompression: sinkCompressionGzip,
+			}
+
+			transport, err := newSinkTransport(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := newSinkClient(env, transport, env.GetSink())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, ev := range createTestEvents(2, source, time.Now().UTC()).ceEvents {
+				if result := c.Send(context.Background(), *ev); !cloudevents.IsACK(result) {
+					t.Fatalf("Send() failed: %v", result)
+				}
+			}
+
+			if diff := cmp.Diff(tt.wantCompression, compressed); diff != "" {
+				t.Errorf("unexpected compression of requests (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_newSinkTransport_invalidCompression(t *testing.T) {
+	if _, err := newSinkTransport(&envConfig{SinkCompression: "brotli"}); err == nil {
+		t.Error("newSinkTransport() expected error for unsupported compression")
+	}
+}
+
+func Test_newSinkClient_redirect(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		redirects    int
+		maxRedirects int
+		wantACK      bool
+	}{
+		{name: "found", status: http.StatusFound, redirects: 1, maxRedirects: 10, wantACK: true},
+		{name: "temporary redirect", status: http.StatusTemporaryRedirect, redirects: 2, maxRedirects: 2, wantACK: true},
+		{name: "too many redirects", status: http.StatusPermanentRedirect, redirects: 3, maxRedirects: 2, wantACK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []string
+			final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatalf("read body: %v", err)
+				}
+				if r.Method != http.MethodPost || !strings.HasPrefix(string(data), "<") {
+					t.Errorf("unexpected %s request with body %q", r.Method, string(data))
+				}
+				received = append(received, r.Header.Get("Ce-Id"))
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer final.Close()
+
+			hops := 0
+			redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hops++
+				location := final.URL
+				if hops%tt.redirects != 0 {
+					location = "/next"
+				}
+				http.Redirect(w, r, location, tt.status)
+			}))
+			defer redirecting.Close()
+
+			env := &envConfig{
+				EnvConfig: adapter.EnvConfig{
+					Sink: redirecting.URL,
+				},
+				SinkFollowRedirects: true,
+				SinkMaxRedirects:    tt.maxRedirects,
+			}
+
+			transport, err := newSinkTransport(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := newSinkClient(env, transport, env.GetSink())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev := createTestEvents(1, source, time.Now().UTC()).ceEvents[0]
+			if result := c.Send(context.Background(), *ev); cloudevents.IsACK(result) != tt.wantACK {
+				t.Fatalf("Send() ACK = %v, want %v: %v", cloudevents.IsACK(result), tt.wantACK, result)
+			}
+
+			var want []string
+			if tt.wantACK {
+				want = []string{ev.ID()}
+			}
+			if diff := cmp.Diff(want, received); diff != "" {
+				t.Errorf("unexpected events received by final sink (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_newSinkTransport_invalidMaxRedirects(t *testing.T) {
+	if _, err := newSinkTransport(&envConfig{SinkFollowRedirects: true}); err == nil {
+		t.Error("newSinkTransport() expected error for invalid maximum number of redirects")
+	}
+}
+
+func Test_validateSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    string
+		archive bool
+		wantErr string
+	}{
+		{name: "missing sink", wantErr: "no sink configured: K_SINK must be set"},
+		{name: "missing sink in archive only mode", archive: true},
+		{name: "relative sink", sink: "broker-ingress/default", wantErr: "must be an absolute http or https URI"},
+		{name: "unsupported scheme", sink: "ftp://sink.example.com", wantErr: "must be an absolute http or https URI"},
+		{name: "invalid sink", sink: "http://[::1", wantErr: "invalid sink"},
+		{name: "valid sink", sink: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := envConfig{ArchiveOnly: tt.archive}
+			env.Sink = tt.sink
+
+			err := validateSink(&env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSink() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateSink() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_probeSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "sink accepts probe", status: http.StatusOK},
+		{name: "sink accepts probe without content", status: http.StatusNoContent},
+		{name: "sink rejects probe", status: http.StatusNotFound, wantErr: true},
+		{name: "sink fails", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodOptions {
+					t.Errorf("probe method = %s, want %s", r.Method, http.MethodOptions)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			if err := probeSink(context.Background(), srv.URL, http.DefaultTransport, nil, time.Second); (err != nil) != tt.wantErr {
+				t.Errorf("probeSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("sink unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		target := srv.URL
+		srv.Close()
+
+		if err := probeSink(context.Background(), target, http.DefaultTransport, nil, time.Second); err == nil {
+			t.Error("probeSink() expected error for unreachable sink")
+		}
+	})
+
+	t.Run("no sink configured", func(t *testing.T) {
+		if err := probeSink(context.Background(), "", http.DefaultTransport, nil, time.Second); err == nil {
+			t.Error("probeSink() expected error without sink")
+		}
+	})
+
+	t.Run("sink client configuration", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		env := &envConfig{SinkHeaders: "Authorization=Bearer token", SinkLocalAddr: "127.0.0.1"}
+		headers, err := parseSinkHeaders(env.SinkHeaders)
+		if err != nil {
+			t.Fatalf("parseSinkHeaders() error = %v", err)
+		}
+		rt, err := newSinkTransport(env)
+		if err != nil {
+			t.Fatalf("newSinkTransport() error = %v", err)
+		}
+		used := &countingTransport{base: rt}
+
+		if err = probeSink(context.Background(), srv.URL, used, nil, time.Second); err == nil {
+			t.Error("probeSink() expected error without sink headers")
+		}
+		if err = probeSink(context.Background(), srv.URL, used, headers, time.Second); err != nil {
+			t.Errorf("probeSink() error = %v", err)
+		}
+		if used.requests != 2 {
+			t.Errorf("probeSink() sent %d requests over the sink transport, want 2", used.requests)
+		}
+	})
+}
+
+// countingTransport counts the requests sent over the base transport
+type countingTransport struct {
+	base     http.RoundTripper
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return t.base.RoundTrip(req)
+}
+
+func Test_parseSinkHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		want    http.Header
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			headers: "",
+			want:    http.Header{},
+		},
+		{
+			name:    "multiple headers",
+			headers: "X-Api-Key=secret, x-tenant = team-a",
+			want:    http.Header{"X-Api-Key": {"secret"}, "X-Tenant": {"team-a"}},
+		},
+		{
+			name:    "value containing equal sign",
+			headers: "Authorization=Basic dXNlcjpwYXNz==",
+			want:    http.Header{"Authorization": {"Basic dXNlcjpwYXNz=="}},
+		},
+		{
+			name:    "missing value",
+			headers: "X-Api-Key",
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			headers: "X Api Key=secret",
+			wantErr: true,
+		},
+		{
+			name:    "CloudEvents header",
+			headers: "Ce-Id=1",
+			wantErr: true,
+		},
+		{
+			name:    "content type",
+			headers: "content-type=text/plain",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSinkHeaders(tt.headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSinkHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseSinkHeaders() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_newSinkClient_headers(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	env := &envConfig{
+		EnvConfig: adapter.EnvConfig{
+			Sink: srv.URL,
+		},
+		SinkHeaders: "X-Api-Key=secret,X-Tenant=team-a",
+	}
+
+	if !customSinkTransport(env) {
+		t.Fatal("customSinkTransport() = false, want true")
+	}
+
+	transport, err := newSinkTransport(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newSinkClient(env, transport, env.GetSink())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := createTestEvents(1, source, time.Now().UTC()).ceEvents[0]
+	if result := c.Send(context.Background(), *ev); !cloudevents.IsACK(result) {
+		t.Fatalf("Send() failed: %v", result)
+	}
+
+	if got := headers.Get("X-Api-Key"); got != "secret" {
+		t.Errorf("X-Api-Key header = %q, want %q", got, "secret")
+	}
+	if got := headers.Get("X-Tenant"); got != "team-a" {
+		t.Errorf("X-Tenant header = %q, want %q", got, "team-a")
+	}
+	if got := headers.Get("Ce-Id"); got != ev.ID() {
+		t.Errorf("Ce-Id header = %q, want %q", got, ev.ID())
+	}
+}
+
+func Test_newSinkClient_invalidHeaders(t *testing.T) {
+	env := &envConfig{SinkHeaders: "ce-type=override"}
+	if _, err := newSinkClient(env, http.DefaultTransport, env.GetSink()); err == nil {
+		t.Error("newSinkClient() expected error for CloudEvents header")
+	}
+}
+
+// testSinkClient creates sink clients of dead letter, fallback and tee sinks
+// with the default configuration
+func testSinkClient(target string) (cloudevents.Client, error) {
+	return newSinkClient(&envConfig{}, http.DefaultTransport, target)
+}
+
+func Test_newSinkClient_secondarySinks(t *testing.T) {
+	var headers []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	env := &envConfig{
+		EnvConfig: adapter.EnvConfig{
+			Sink: "http://primary.example.com",
+		},
+		SinkHeaders: "X-Api-Key=secret",
+	}
+	transport, err := newSinkTransport(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func(target string) (cloudevents.Client, error) {
+		return newSinkClient(env, transport, target)
+	}
+
+	deadLetter, err := newDeadLetterSink(srv.URL, newClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback, err := newFallbackSink(srv.URL, time.Minute, newClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tee, err := newTeeSink(srv.URL, 1, newClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := createTestEvents(1, source, time.Now().UTC()).ceEvents[0]
+	for _, c := range []cloudevents.Client{deadLetter.client, fallback.client, tee.client} {
+		if result := c.Send(context.Background(), *ev); !cloudevents.IsACK(result) {
+			t.Fatalf("Send() failed: %v", result)
+		}
+	}
+
+	if len(headers) != 3 {
+		t.Fatalf("secondary sinks received %d requests, want 3", len(headers))
+	}
+	for i, h := range headers {
+		if got := h.Get("X-Api-Key"); got != "secret" {
+			t.Errorf("request %d X-Api-Key header = %q, want %q", i, got, "secret")
+		}
+	}
+}
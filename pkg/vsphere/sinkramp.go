@@ -0,0 +1,85 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sinkRamp limits the rate of events sent right after startup to give a cold
+// sink, e.g. an autoscaled service scaled to zero, time to scale up. The rate
+// starts at initialRate and increases by initialRate every second until the
+// ramp duration has elapsed, after which sends are no longer limited. The ramp
+// starts with the first sent event.
+type sinkRamp struct {
+	initialRate float64
+	duration    time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	started time.Time
+}
+
+// newSinkRamp returns a sink ramp-up of the given duration and initial rate
+// (events per second). It returns nil if duration is 0, i.e. events are sent
+// at full rate from the start.
+func newSinkRamp(duration time.Duration, initialRate float64) (*sinkRamp, error) {
+	if duration == 0 {
+		return nil, nil
+	}
+
+	if duration < 0 || initialRate <= 0 {
+		return nil, fmt.Errorf("invalid sink ramp-up: duration (%v) and initial rate (%v) must be greater than 0", duration, initialRate)
+	}
+
+	return &sinkRamp{
+		initialRate: initialRate,
+		duration:    duration,
+		now:         time.Now,
+		// burst of 1 to smoothly distribute sends
+		limiter: rate.NewLimiter(rate.Limit(initialRate), 1),
+	}, nil
+}
+
+// limit returns the send rate at the given time and whether the ramp-up is
+// still in progress
+func (r *sinkRamp) limit(now time.Time) (float64, bool) {
+	if r.started.IsZero() {
+		r.started = now
+	}
+
+	elapsed := now.Sub(r.started)
+	if elapsed >= r.duration {
+		return 0, false
+	}
+	return r.initialRate * (1 + elapsed.Seconds()), true
+}
+
+// wait blocks until the next event may be sent during the ramp-up or ctx is
+// done. It returns immediately once the ramp-up is complete.
+func (r *sinkRamp) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	limit, ramping := r.limit(r.now())
+	if ramping {
+		r.limiter.SetLimit(rate.Limit(limit))
+	}
+	r.mu.Unlock()
+
+	if !ramping {
+		return nil
+	}
+	return r.limiter.Wait(ctx)
+}
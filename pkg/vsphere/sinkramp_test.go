@@ -0,0 +1,95 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_newSinkRamp(t *testing.T) {
+	tests := []struct {
+		name        string
+		duration    time.Duration
+		initialRate float64
+		wantNil     bool
+		wantErr     bool
+	}{
+		{name: "disabled", duration: 0, initialRate: 1, wantNil: true},
+		{name: "valid", duration: time.Minute, initialRate: 5},
+		{name: "negative duration", duration: -time.Minute, initialRate: 5, wantErr: true},
+		{name: "zero initial rate", duration: time.Minute, initialRate: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSinkRamp(tt.duration, tt.initialRate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSinkRamp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newSinkRamp() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_sinkRamp_limit(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	r, err := newSinkRamp(time.Minute, 2)
+	if err != nil {
+		t.Fatalf("newSinkRamp() error = %v", err)
+	}
+
+	tests := []struct {
+		elapsed     time.Duration
+		wantRate    float64
+		wantRamping bool
+	}{
+		{elapsed: 0, wantRate: 2, wantRamping: true},
+		{elapsed: 10 * time.Second, wantRate: 22, wantRamping: true},
+		{elapsed: 59 * time.Second, wantRate: 120, wantRamping: true},
+		{elapsed: time.Minute, wantRate: 0, wantRamping: false},
+	}
+	for _, tt := range tests {
+		rate, ramping := r.limit(start.Add(tt.elapsed))
+		if rate != tt.wantRate || ramping != tt.wantRamping {
+			t.Errorf("limit() after %v = %v, %v, want %v, %v", tt.elapsed, rate, ramping, tt.wantRate, tt.wantRamping)
+		}
+	}
+}
+
+func Test_sinkRamp_wait(t *testing.T) {
+	ctx := context.Background()
+
+	var nilRamp *sinkRamp
+	if err := nilRamp.wait(ctx); err != nil {
+		t.Errorf("wait() on disabled ramp-up error = %v, want nil", err)
+	}
+
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	r, err := newSinkRamp(time.Minute, 0.001)
+	if err != nil {
+		t.Fatalf("newSinkRamp() error = %v", err)
+	}
+	r.now = func() time.Time { return now }
+
+	// burst of 1 is sent immediately, the next event is limited
+	if err = r.wait(ctx); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err = r.wait(canceled); err == nil {
+		t.Error("wait() error = nil during ramp-up with canceled context, want error")
+	}
+
+	// ramp-up complete
+	now = now.Add(time.Minute)
+	if err = r.wait(canceled); err != nil {
+		t.Errorf("wait() error = %v after ramp-up, want nil", err)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// classPlaceholder is replaced with the event class in source suffixes
+const classPlaceholder = "{class}"
+
+// eventClasses are the event classes returned by getEventDetails
+var eventClasses = []string{"event", "eventex", "extendedevent"}
+
+// classSources maps event classes to the CloudEvent source of events of the
+// class, i.e. the adapter source with a class-specific suffix, e.g.
+// vcenter.example.com/eventex
+type classSources map[string]string
+
+// overrideSource returns override as the adapter source if not empty,
+// otherwise the vCenter host
+func overrideSource(host, override string) (string, error) {
+	if override == "" {
+		return host, nil
+	}
+
+	if strings.TrimSpace(override) != override {
+		return "", fmt.Errorf("source %q must not contain leading or trailing whitespace", override)
+	}
+	if _, err := url.Parse(override); err != nil {
+		return "", fmt.Errorf("source %q is not a valid URI-reference: %w", override, err)
+	}
+	return override, nil
+}
+
+// newClassSources returns the sources of all event classes formed by
+// appending suffix to source with the class placeholder replaced by the event
+// class. It returns nil if suffix is empty, i.e. all events use source.
+func newClassSources(source, suffix string) (classSources, error) {
+	if suffix == "" {
+		return nil, nil
+	}
+
+	if !strings.Contains(suffix, classPlaceholder) {
+		return nil, fmt.Errorf("source suffix %q must contain %s", suffix, classPlaceholder)
+	}
+
+	sources := make(classSources, len(eventClasses))
+	for _, class := range eventClasses {
+		s := source + strings.ReplaceAll(suffix, classPlaceholder, class)
+		if _, err := url.Parse(s); err != nil {
+			return nil, fmt.Errorf("source %q of event class %s is not a valid URI-reference: %w", s, class, err)
+		}
+		sources[class] = s
+	}
+	return sources, nil
+}
+
+// source returns the source of events of the given class or def if no class
+// source is configured
+func (s classSources) source(class, def string) string {
+	if src, ok := s[class]; ok {
+		return src
+	}
+	return def
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+)
+
+func Test_overrideSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{name: "disabled", want: "10.0.0.12"},
+		{name: "logical name", override: "vcenter-prod-east", want: "vcenter-prod-east"},
+		{name: "URI", override: "https://vcenter.example.com/prod", want: "https://vcenter.example.com/prod"},
+		{name: "invalid URI-reference", override: "%zz", wantErr: true},
+		{name: "whitespace", override: " vcenter-prod-east", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := overrideSource("10.0.0.12", tt.override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("overrideSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("overrideSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newClassSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		suffix  string
+		want    classSources
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "missing placeholder", suffix: "/events", wantErr: true},
+		{name: "invalid URI-reference", suffix: "%zz/{class}", wantErr: true},
+		{
+			name:   "path suffix",
+			suffix: "/{class}",
+			want: classSources{
+				"event":         "https://vcenter.local/sdk/event",
+				"eventex":       "https://vcenter.local/sdk/eventex",
+				"extendedevent": "https://vcenter.local/sdk/extendedevent",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newClassSources("https://vcenter.local/sdk", tt.suffix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newClassSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("newClassSources() = %v, want %v", got, tt.want)
+			}
+			for class, want := range tt.want {
+				if got[class] != want {
+					t.Errorf("newClassSources()[%q] = %q, want %q", class, got[class], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_classSources(t *testing.T) {
+	sources, err := newClassSources(source, "/{class}")
+	if err != nil {
+		t.Fatalf("newClassSources() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		ClassSources:    sources,
+	}
+
+	events := []types.BaseEvent{
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000}}},
+		&types.EventEx{Event: types.Event{Key: 1001}, EventTypeId: "com.vmware.vc.HA.ClusterFailoverActionTriggeredEvent"},
+	}
+	if _, err = a.sendEvents(context.Background(), events); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+
+	want := []string{source + "/event", source + "/eventex"}
+	if len(ce.sent) != len(want) {
+		t.Fatalf("sendEvents() sent %d events, want %d", len(ce.sent), len(want))
+	}
+	for i, ev := range ce.sent {
+		if ev.Source() != want[i] {
+			t.Errorf("sendEvents() event %d source = %q, want %q", i, ev.Source(), want[i])
+		}
+	}
+}
+
+func TestNewAdapter_sourceOverride(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		u := *vim.URL()
+		u.User = nil
+
+		t.Setenv("VC_URL", u.String())
+		t.Setenv("VC_INSECURE", "true")
+		t.Setenv("VC_SECRET_PATH", writeSecret(t, map[string]string{"username": "user", "password": "pass"}))
+		t.Setenv("K_SINK", "http://sink.example.com")
+		t.Setenv("NAMESPACE", "default")
+		t.Setenv("VSPHERE_KVSTORE_CONFIGMAP", "vsphere-source-configmap")
+		t.Setenv("VSPHERE_SOURCE_OVERRIDE", "vcenter-prod-east")
+
+		var env envConfig
+		if err := envconfig.Process("", &env); err != nil {
+			t.Fatalf("process environment: %v", err)
+		}
+
+		ctx = logging.WithLogger(ctx, zaptest.NewLogger(t).Sugar())
+		ctx = context.WithValue(ctx, kubeclient.Key{}, k8sfake.NewSimpleClientset())
+
+		ce := &fakeCEClient{}
+		got, err := NewAdapter(ctx, &env, ce)
+		if err != nil {
+			t.Fatalf("NewAdapter() error = %v", err)
+		}
+		a := got.(*vAdapter)
+		defer func() { _ = a.VClient.Logout(context.Background()) }()
+
+		events := []types.BaseEvent{&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000}}}}
+		if _, err = a.sendEvents(ctx, events); err != nil {
+			t.Fatalf("sendEvents() error = %v", err)
+		}
+		if len(ce.sent) != 1 {
+			t.Fatalf("sendEvents() sent %d events, want 1", len(ce.sent))
+		}
+		if got := ce.sent[0].Source(); got != "vcenter-prod-east" {
+			t.Errorf("sendEvents() source = %q, want overridden source %q", got, "vcenter-prod-east")
+		}
+	})
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+)
+
+const (
+	// send events to the sink over HTTP (default)
+	sinkProtocolHTTP = "http"
+	// send events to an AWS SQS queue
+	sinkProtocolSQS = "sqs"
+
+	sqsAPIVersion = "2012-11-05"
+	// maximum number of message attributes supported by SQS
+	sqsMaxMessageAttributes = 10
+	sqsAttributePrefix      = "ce-"
+)
+
+// awsCredentials holds the credentials used to sign requests to AWS
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// awsCredentialsFromEnv reads AWS credentials from the standard AWS
+// environment variables
+func awsCredentialsFromEnv() (awsCredentials, error) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return creds, nil
+}
+
+// sqsSender implements a CloudEvents protocol.Sender which delivers events to
+// an AWS SQS queue. The JSON-encoded (structured) CloudEvent is used as the
+// message body and CloudEvent attributes are added as message attributes.
+type sqsSender struct {
+	queueURL    *url.URL
+	region      string
+	credentials awsCredentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+var _ protocol.Sender = (*sqsSender)(nil)
+
+// newSQSClient returns a CloudEvents client sending events to the given SQS
+// queue using AWS credentials from the environment
+func newSQSClient(queueURL, region string) (cloudevents.Client, error) {
+	creds, err := awsCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := newSQSSender(queueURL, region, creds)
+	if err != nil {
+		return nil, err
+	}
+	return cloudevents.NewClient(sender)
+}
+
+// newSQSSender returns a sender for the given SQS queue URL and region
+func newSQSSender(queueURL, region string, creds awsCredentials) (*sqsSender, error) {
+	if queueURL == "" {
+		return nil, errors.New("SQS queue URL must be set")
+	}
+
+	if region == "" {
+		return nil, errors.New("SQS region must be set")
+	}
+
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse SQS queue URL: %w", err)
+	}
+
+	return &sqsSender{
+		queueURL:    u,
+		region:      region,
+		credentials: creds,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		now:         time.Now,
+	}, nil
+}
+
+// Send implements protocol.Sender
+func (s *sqsSender) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() { _ = m.Finish(err) }()
+
+	ev, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return fmt.Errorf("convert message to event: %w", err)
+	}
+
+	body, err := ev.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "SendMessage")
+	form.Set("Version", sqsAPIVersion)
+	form.Set("MessageBody", string(body))
+	for i, attr := range sqsMessageAttributes(ev) {
+		prefix := "MessageAttribute." + strconv.Itoa(i+1)
+		form.Set(prefix+".Name", attr[0])
+		form.Set(prefix+".Value.DataType", "String")
+		form.Set(prefix+".Value.StringValue", attr[1])
+	}
+
+	payload := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.queueURL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create SQS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(req, payload, "sqs", s.region, s.credentials, s.now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send message to SQS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("send message to SQS: %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sqsMessageAttributes returns the name/value pairs of the CloudEvent
+// attributes and extensions, limited to the maximum number of message
+// attributes supported by SQS
+func sqsMessageAttributes(ev *event.Event) [][2]string {
+	attrs := [][2]string{
+		{sqsAttributePrefix + "specversion", ev.SpecVersion()},
+		{sqsAttributePrefix + "id", ev.ID()},
+		{sqsAttributePrefix + "source", ev.Source()},
+		{sqsAttributePrefix + "type", ev.Type()},
+	}
+
+	if !ev.Time().IsZero() {
+		attrs = append(attrs, [2]string{sqsAttributePrefix + "time", ev.Time().Format(time.RFC3339Nano)})
+	}
+
+	if ct := ev.DataContentType(); ct != "" {
+		attrs = append(attrs, [2]string{sqsAttributePrefix + "datacontenttype", ct})
+	}
+
+	exts := make([]string, 0, len(ev.Extensions()))
+	for name := range ev.Extensions() {
+		exts = append(exts, name)
+	}
+	sort.Strings(exts)
+
+	for _, name := range exts {
+		attrs = append(attrs, [2]string{sqsAttributePrefix + name, fmt.Sprintf("%v", ev.Extensions()[name])})
+	}
+
+	if len(attrs) > sqsMaxMessageAttributes {
+		attrs = attrs[:sqsMaxMessageAttributes]
+	}
+	return attrs
+}
+
+// signAWSRequest signs the given request using AWS Signature Version 4
+func signAWSRequest(req *http.Request, payload []byte, service, region string, creds awsCredentials, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	dateStamp := t.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headerNames := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		headerNames = append(headerNames, strings.ToLower(name))
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{dateStamp, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
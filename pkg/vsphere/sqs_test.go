@@ -0,0 +1,140 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func Test_signAWSRequest(t *testing.T) {
+	// AWS Signature Version 4 test suite: get-vanilla
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	ts := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signAWSRequest(req, nil, "service", "us-east-1", creds, ts)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("signAWSRequest() Authorization = %q, want %q", got, want)
+	}
+}
+
+func Test_sqsSender_Send(t *testing.T) {
+	now := time.Now().UTC()
+	events := createTestEvents(1, source, now)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantACK    bool
+	}{
+		{
+			name:       "message accepted",
+			statusCode: http.StatusOK,
+			wantACK:    true,
+		},
+		{
+			name:       "message rejected",
+			statusCode: http.StatusForbidden,
+			wantACK:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				form       map[string][]string
+				authHeader string
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("parse form: %v", err)
+				}
+				form = r.PostForm
+				authHeader = r.Header.Get("Authorization")
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer srv.Close()
+
+			sender, err := newSQSSender(srv.URL+"/123456789012/vsphere-events", "us-west-2", awsCredentials{
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "secret",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := cloudevents.NewClient(sender)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := c.Send(context.Background(), *events.ceEvents[0])
+			if cloudevents.IsACK(result) != tt.wantACK {
+				t.Fatalf("Send() ACK = %v, want %v (result: %v)", cloudevents.IsACK(result), tt.wantACK, result)
+			}
+
+			if got := form["Action"]; len(got) != 1 || got[0] != "SendMessage" {
+				t.Errorf("Action = %v, want SendMessage", got)
+			}
+
+			var body map[string]interface{}
+			if err = json.Unmarshal([]byte(form["MessageBody"][0]), &body); err != nil {
+				t.Fatalf("unmarshal message body: %v", err)
+			}
+			if body["id"] != events.ceEvents[0].ID() {
+				t.Errorf("message body id = %v, want %v", body["id"], events.ceEvents[0].ID())
+			}
+
+			attrs := map[string]string{}
+			for i := 1; i <= sqsMaxMessageAttributes; i++ {
+				prefix := "MessageAttribute." + strconv.Itoa(i)
+				if name, ok := form[prefix+".Name"]; ok {
+					attrs[name[0]] = form[prefix+".Value.StringValue"][0]
+				}
+			}
+			if attrs["ce-type"] != events.ceEvents[0].Type() {
+				t.Errorf("ce-type attribute = %q, want %q", attrs["ce-type"], events.ceEvents[0].Type())
+			}
+			if attrs["ce-"+ceVSphereEventClass] != "event" {
+				t.Errorf("ce-%s attribute = %q, want %q", ceVSphereEventClass, attrs["ce-"+ceVSphereEventClass], "event")
+			}
+
+			if !strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+				!strings.Contains(authHeader, "/us-west-2/sqs/aws4_request") {
+				t.Errorf("unexpected Authorization header %q", authHeader)
+			}
+		})
+	}
+}
+
+func Test_newSQSSender(t *testing.T) {
+	if _, err := newSQSSender("", "us-west-2", awsCredentials{}); err == nil {
+		t.Error("newSQSSender() expected error for empty queue URL")
+	}
+
+	if _, err := newSQSSender("https://sqs.us-west-2.amazonaws.com/123456789012/q", "", awsCredentials{}); err == nil {
+		t.Error("newSQSSender() expected error for empty region")
+	}
+}
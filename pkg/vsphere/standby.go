@@ -0,0 +1,96 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// standby keeps the adapter warm without sending events until promoted by
+// writing "true" to the promotion file, e.g. a key of a mounted ConfigMap
+// flipped by an operator or written by a leader election sidecar
+type standby struct {
+	promotionFile string
+	interval      time.Duration
+}
+
+// newStandby returns a standby for the given promotion file polled at the
+// given interval. It returns nil if promotionFile is empty, i.e. the adapter
+// sends events right away.
+func newStandby(promotionFile string, interval time.Duration) (*standby, error) {
+	if promotionFile == "" {
+		return nil, nil
+	}
+	if interval <= 0 {
+		return nil, errors.New("standby poll interval must be greater than 0")
+	}
+	return &standby{promotionFile: promotionFile, interval: interval}, nil
+}
+
+// promoted returns whether the promotion file exists and contains "true"
+func (s *standby) promoted() (bool, error) {
+	b, err := ioutil.ReadFile(s.promotionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read standby promotion file: %w", err)
+	}
+	return strings.TrimSpace(string(b)) == "true", nil
+}
+
+// waitPromotion blocks until the adapter is promoted or ctx is cancelled.
+// While waiting, the vCenter session is kept alive and the shared checkpoint
+// is reloaded, so the adapter starts sending from the latest checkpoint of the
+// active adapter without logging in again. Checkpoints are never written in
+// standby.
+func (a *vAdapter) waitPromotion(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+	logger.Infow("waiting for promotion in standby", zap.String("promotionFile", a.Standby.promotionFile))
+
+	ticker := time.NewTicker(a.Standby.interval)
+	defer ticker.Stop()
+
+	for {
+		promoted, err := a.Standby.promoted()
+		if err != nil {
+			logger.Warnw("could not check standby promotion", zap.Error(err))
+		}
+		if promoted {
+			if err = a.KVStore.Load(ctx); err != nil {
+				return fmt.Errorf("reload checkpoint on promotion: %w", err)
+			}
+			logger.Info("promoted from standby: sending events")
+			return nil
+		}
+
+		// keep vCenter session from expiring
+		if _, err = methods.GetCurrentTime(ctx, a.VClient); err != nil {
+			logger.Warnw("could not keep vCenter session alive in standby", zap.Error(err))
+		}
+		if err = a.KVStore.Load(ctx); err != nil {
+			logger.Warnw("could not reload checkpoint in standby", zap.Error(err))
+		} else {
+			logger.Debugw("tracking checkpoint in standby", zap.Any("checkpoint", a.newestCheckpoint(ctx)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
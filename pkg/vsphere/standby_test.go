@@ -0,0 +1,115 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newStandby(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		interval time.Duration
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "disabled", interval: time.Second, wantNil: true},
+		{name: "invalid interval", file: "/etc/standby/promoted", wantErr: true},
+		{name: "standby", file: "/etc/standby/promoted", interval: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newStandby(tt.file, tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newStandby() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newStandby() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_standby_promoted(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		missing bool
+		want    bool
+	}{
+		{name: "missing file", missing: true, want: false},
+		{name: "not promoted", content: "false", want: false},
+		{name: "promoted", content: "true\n", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, tt.name)
+			if !tt.missing {
+				if err := ioutil.WriteFile(file, []byte(tt.content), 0600); err != nil {
+					t.Fatalf("write promotion file: %v", err)
+				}
+			}
+
+			s := &standby{promotionFile: file, interval: time.Second}
+			got, err := s.promoted()
+			if err != nil {
+				t.Fatalf("promoted() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("promoted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_waitPromotion(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vim *vim25.Client) error {
+		file := filepath.Join(t.TempDir(), "promoted")
+		kv := &fakeKVStore{data: map[string]string{CheckpointKey: createCheckpoint(t, time.Now().UTC())}}
+		a := &vAdapter{
+			Logger:  zaptest.NewLogger(t).Sugar(),
+			VClient: &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)},
+			KVStore: kv,
+			Standby: &standby{promotionFile: file, interval: 10 * time.Millisecond},
+		}
+
+		// not promoted
+		cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := a.waitPromotion(cctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("waitPromotion() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if kv.loads == 0 {
+			t.Error("waitPromotion() did not reload checkpoint in standby")
+		}
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = ioutil.WriteFile(file, []byte("true"), 0600)
+		}()
+
+		cctx, cancel = context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := a.waitPromotion(cctx); err != nil {
+			t.Errorf("waitPromotion() error = %v, want promotion", err)
+		}
+		return nil
+	})
+}
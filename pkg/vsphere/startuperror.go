@@ -0,0 +1,93 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StartupErrorKind classifies why the adapter failed to start
+type StartupErrorKind int
+
+const (
+	// ConfigError is a permanent failure caused by an invalid configuration,
+	// i.e. restarting the adapter does not help
+	ConfigError StartupErrorKind = iota + 1
+	// ConnectivityError is a transient failure to reach vCenter, the
+	// Kubernetes API or the sink, i.e. restarting the adapter may help
+	ConnectivityError
+)
+
+// exit codes of the adapter for startup errors following sysexits.h
+const (
+	ExitCodeConfig      = 78 // EX_CONFIG
+	ExitCodeUnavailable = 69 // EX_UNAVAILABLE
+	exitCodeUnknown     = 1
+)
+
+func (k StartupErrorKind) String() string {
+	switch k {
+	case ConfigError:
+		return "config"
+	case ConnectivityError:
+		return "connectivity"
+	default:
+		return "unknown"
+	}
+}
+
+// StartupError is returned by NewAdapter if the adapter cannot be started
+type StartupError struct {
+	Kind StartupErrorKind
+	Err  error
+}
+
+func (e *StartupError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// newStartupError returns a startup error of the given kind formatted like
+// fmt.Errorf. The kind of a wrapped startup error takes precedence.
+func newStartupError(kind StartupErrorKind, format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+
+	var wrapped *StartupError
+	if errors.As(err, &wrapped) {
+		kind = wrapped.Kind
+	}
+	return &StartupError{Kind: kind, Err: err}
+}
+
+func configError(format string, args ...interface{}) error {
+	return newStartupError(ConfigError, format, args...)
+}
+
+func connectivityError(format string, args ...interface{}) error {
+	return newStartupError(ConnectivityError, format, args...)
+}
+
+// ExitCode returns the exit code of the adapter for the given error returned
+// by NewAdapter
+func ExitCode(err error) int {
+	var se *StartupError
+	if !errors.As(err, &se) {
+		return exitCodeUnknown
+	}
+
+	switch se.Kind {
+	case ConfigError:
+		return ExitCodeConfig
+	case ConnectivityError:
+		return ExitCodeUnavailable
+	default:
+		return exitCodeUnknown
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/logging"
+)
+
+func Test_newStartupError(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	err := connectivityError("unable to create vSphere client: %w", cause)
+	if got := err.Error(); got != "unable to create vSphere client: connection refused" {
+		t.Errorf("Error() = %q, want formatted message", got)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("connectivityError() does not wrap %v", cause)
+	}
+
+	// kind of wrapped startup error takes precedence
+	err = connectivityError("unable to create vSphere client: %w", configError("invalid vCenter address: %w", cause))
+	var se *StartupError
+	if !errors.As(err, &se) || se.Kind != ConfigError {
+		t.Errorf("connectivityError() = %#v, want kind %s of wrapped error", err, ConfigError)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "config error", err: configError("invalid payload encoding"), want: ExitCodeConfig},
+		{name: "connectivity error", err: connectivityError("sink probe failed"), want: ExitCodeUnavailable},
+		{name: "wrapped config error", err: fmt.Errorf("start: %w", configError("invalid payload encoding")), want: ExitCodeConfig},
+		{name: "unclassified error", err: errors.New("unexpected"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAdapter_configErrorBeforeConnectivity(t *testing.T) {
+	// nothing listens on port 1, connecting to vCenter fails
+	t.Setenv("VC_URL", "https://127.0.0.1:1/sdk")
+	t.Setenv("VC_SECRET_PATH", writeSecret(t, map[string]string{"username": "user", "password": "pass"}))
+
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	env := &envConfig{PayloadEncoding: "text/plain"}
+
+	_, err := NewAdapter(ctx, env, nil)
+	if err == nil {
+		t.Fatal("NewAdapter() error = nil, want config error")
+	}
+	if got := ExitCode(err); got != ExitCodeConfig {
+		t.Errorf("ExitCode() = %d, want %d: %v", got, ExitCodeConfig, err)
+	}
+}
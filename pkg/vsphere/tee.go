@@ -0,0 +1,80 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// teeSink delivers a best-effort copy of the events ACK-ed by the primary sink
+// to a secondary sink. Events are queued and sent asynchronously so that the
+// secondary sink never blocks the event stream or checkpointing. Events are
+// dropped if the queue is full.
+type teeSink struct {
+	client cloudevents.Client
+	queue  chan cloudevents.Event
+}
+
+// newTeeSink returns a tee sink sending to the given target with a client
+// created by newClient and the given queue size. It returns nil if target is
+// empty, i.e. tee is disabled.
+func newTeeSink(target string, queueSize int, newClient sinkClientFunc) (*teeSink, error) {
+	if target == "" {
+		return nil, nil
+	}
+
+	if queueSize <= 0 {
+		return nil, fmt.Errorf("invalid tee queue size %d: must be greater than 0", queueSize)
+	}
+
+	client, err := newClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("create tee sink client: %w", err)
+	}
+
+	return newTeeSinkWithClient(client, queueSize), nil
+}
+
+func newTeeSinkWithClient(client cloudevents.Client, queueSize int) *teeSink {
+	return &teeSink{
+		client: client,
+		queue:  make(chan cloudevents.Event, queueSize),
+	}
+}
+
+// enqueue queues the given event for delivery without blocking. False is
+// returned if the event was dropped.
+func (t *teeSink) enqueue(ctx context.Context, ev cloudevents.Event) bool {
+	select {
+	case t.queue <- ev:
+		return true
+	default:
+		reportTeeFailure(ctx, teeResultDropped)
+		return false
+	}
+}
+
+// run sends queued events to the secondary sink until ctx is canceled
+func (t *teeSink) run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-t.queue:
+			if result := t.client.Send(ctx, ev); !cloudevents.IsACK(result) {
+				reportTeeFailure(ctx, teeResultFailed)
+				logger.Debugw("could not send event to tee sink", zap.String("ID", ev.ID()), zap.Error(result))
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newTeeSink(t *testing.T) {
+	tee, err := newTeeSink("", 10, testSinkClient)
+	if err != nil || tee != nil {
+		t.Errorf("newTeeSink() = %v, %v, want disabled tee", tee, err)
+	}
+
+	if _, err = newTeeSink("http://tee.example.com", 0, testSinkClient); err == nil {
+		t.Error("newTeeSink() expected error for invalid queue size")
+	}
+
+	if tee, err = newTeeSink("http://tee.example.com", 10, testSinkClient); err != nil || tee == nil {
+		t.Errorf("newTeeSink() = %v, %v, want tee", tee, err)
+	}
+}
+
+func Test_teeSink_enqueue(t *testing.T) {
+	ctx := context.Background()
+	tee := newTeeSinkWithClient(&fakeCEClient{}, 1)
+
+	ev := cloudevents.NewEvent()
+	if !tee.enqueue(ctx, ev) {
+		t.Error("enqueue() dropped event with free queue")
+	}
+	if tee.enqueue(ctx, ev) {
+		t.Error("enqueue() did not drop event with full queue")
+	}
+}
+
+func Test_vAdapter_sendEvents_tee(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := createTestEvents(3, source, time.Now().UTC()).vEvents
+
+	// tee sink fails for the first event which must not affect the primary
+	teeClient := &fakeCEClient{results: []error{errors.New("fail")}}
+	tee := newTeeSinkWithClient(teeClient, 10)
+	go tee.run(ctx)
+
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        &fakeCEClient{},
+		PayloadEncoding: cloudevents.ApplicationXML,
+		Tee:             tee,
+	}
+
+	n, err := a.sendEvents(ctx, events)
+	if err != nil || n != len(events) {
+		t.Fatalf("sendEvents() = %d, %v, want %d, nil", n, err, len(events))
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		teeClient.Lock()
+		sent := len(teeClient.sent)
+		teeClient.Unlock()
+
+		if sent == len(events) {
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for tee sink, got %d events, want %d", sent, len(events))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// lag at which the send rate is reduced to ~37% (1/e) of the configured
+	// range by default
+	defaultReplayLagScale = 5 * time.Minute
+)
+
+// replayThrottle adjusts the rate of events sent to the sink based on the
+// measured lag between the current time and the time the events were created
+// in vCenter. A large lag (e.g. when replaying events from a checkpoint)
+// results in a slower send rate whereas a small lag allows sending at full
+// speed. The rate decays exponentially with the lag:
+//
+//	rate = min + (max - min) * e^(-lag/lagScale)
+type replayThrottle struct {
+	limiter  *rate.Limiter
+	minRate  float64
+	maxRate  float64
+	lagScale time.Duration
+}
+
+// newReplayThrottle returns a replay throttle for the given rates (events per
+// second). It returns nil if maxRate is 0, i.e. throttling is disabled.
+func newReplayThrottle(minRate, maxRate float64, lagScale time.Duration) (*replayThrottle, error) {
+	if maxRate == 0 {
+		return nil, nil
+	}
+
+	if minRate <= 0 || maxRate < minRate {
+		return nil, fmt.Errorf("invalid replay rates: min (%v) must be greater than 0 and not exceed max (%v)", minRate, maxRate)
+	}
+
+	if lagScale <= 0 {
+		lagScale = defaultReplayLagScale
+	}
+
+	return &replayThrottle{
+		// burst of 1 to smoothly distribute sends
+		limiter:  rate.NewLimiter(rate.Limit(maxRate), 1),
+		minRate:  minRate,
+		maxRate:  maxRate,
+		lagScale: lagScale,
+	}, nil
+}
+
+// adjust sets the send rate for the given lag and returns the new effective
+// rate
+func (t *replayThrottle) adjust(lag time.Duration) float64 {
+	if lag < 0 {
+		lag = 0
+	}
+
+	decay := math.Exp(-float64(lag) / float64(t.lagScale))
+	r := t.minRate + (t.maxRate-t.minRate)*decay
+	t.limiter.SetLimit(rate.Limit(r))
+
+	return r
+}
+
+// wait blocks until the next event may be sent or ctx is done
+func (t *replayThrottle) wait(ctx context.Context) error {
+	return t.limiter.Wait(ctx)
+}
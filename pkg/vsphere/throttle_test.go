@@ -0,0 +1,119 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func Test_newReplayThrottle(t *testing.T) {
+	tests := []struct {
+		name         string
+		minRate      float64
+		maxRate      float64
+		wantDisabled bool
+		wantErr      bool
+	}{
+		{
+			name:         "disabled",
+			wantDisabled: true,
+		},
+		{
+			name:    "valid rates",
+			minRate: 1,
+			maxRate: 100,
+		},
+		{
+			name:    "equal rates",
+			minRate: 10,
+			maxRate: 10,
+		},
+		{
+			name:    "invalid min rate",
+			minRate: 0,
+			maxRate: 100,
+			wantErr: true,
+		},
+		{
+			name:    "min rate exceeds max rate",
+			minRate: 100,
+			maxRate: 10,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newReplayThrottle(tt.minRate, tt.maxRate, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newReplayThrottle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (got == nil) != tt.wantDisabled {
+				t.Errorf("newReplayThrottle() = %v, wantDisabled %v", got, tt.wantDisabled)
+			}
+		})
+	}
+}
+
+func Test_replayThrottle_adjust(t *testing.T) {
+	const (
+		minRate  = 10.0
+		maxRate  = 110.0
+		lagScale = time.Minute
+	)
+
+	throttle, err := newReplayThrottle(minRate, maxRate, lagScale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		lag  time.Duration
+		want float64
+	}{
+		{
+			name: "no lag uses max rate",
+			lag:  0,
+			want: maxRate,
+		},
+		{
+			name: "negative lag (clock skew) uses max rate",
+			lag:  -time.Minute,
+			want: maxRate,
+		},
+		{
+			name: "lag equal to lag scale",
+			lag:  lagScale,
+			want: minRate + (maxRate-minRate)/math.E,
+		},
+		{
+			name: "large lag approaches min rate",
+			lag:  time.Hour,
+			want: minRate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := throttle.adjust(tt.lag)
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("adjust() = %v, want %v", got, tt.want)
+			}
+
+			if limit := throttle.limiter.Limit(); math.Abs(float64(limit)-got) > 0.001 {
+				t.Errorf("limiter limit = %v, want %v", limit, rate.Limit(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// begin at the checkpoint, i.e. events created before vCenter time
+	// catches up with the checkpoint are not read
+	timeBackwardWarn = "warn"
+	// begin at the current vCenter time and skip checkpointed events
+	timeBackwardResume = "resume"
+	// refuse to start
+	timeBackwardFail = "fail"
+)
+
+// ErrTimeBackward is returned if vCenter time moved backward behind the
+// checkpoint and the configured policy is fail
+var ErrTimeBackward = errors.New("vCenter time moved backward")
+
+// validateTimeBackwardPolicy returns an error if the given policy for backward
+// vCenter time movement is invalid
+func validateTimeBackwardPolicy(policy string) error {
+	switch policy {
+	case timeBackwardWarn, timeBackwardResume, timeBackwardFail:
+		return nil
+	default:
+		return fmt.Errorf("invalid policy %q: must be %s, %s or %s", policy,
+			timeBackwardWarn, timeBackwardResume, timeBackwardFail)
+	}
+}
+
+// timeBehindCheckpoint returns how far the given vCenter time is behind the
+// last event timestamp of the checkpoint, e.g. after an NTP correction or HA
+// failover to a skewed node. It returns 0 if vCenter time did not move
+// backward.
+func timeBehindCheckpoint(vcTime time.Time, cp checkpoint) time.Duration {
+	if cp.LastEventKeyTimestamp.IsZero() || !vcTime.Before(cp.LastEventKeyTimestamp) {
+		return 0
+	}
+	return cp.LastEventKeyTimestamp.Sub(vcTime)
+}
+
+// checkTimeBackward handles vCenter time moved backward behind the checkpoint
+// according to the configured policy and returns the begin of the event
+// stream. Beginning at the checkpoint (begin) would be in the future for
+// vCenter, so with policy resume the stream begins at vcTime instead and
+// events not newer than the checkpoint are skipped to never move the effective
+// begin backward past the checkpoint.
+func (a *vAdapter) checkTimeBackward(ctx context.Context, vcTime time.Time, cp checkpoint, begin time.Time) (time.Time, error) {
+	behind := timeBehindCheckpoint(vcTime, cp)
+	if behind == 0 {
+		return begin, nil
+	}
+
+	if a.TimeBackward == timeBackwardFail {
+		return begin, fmt.Errorf("%w: current time %s is %s behind last event timestamp %s in checkpoint",
+			ErrTimeBackward, vcTime, behind, cp.LastEventKeyTimestamp)
+	}
+
+	logger := logging.FromContext(ctx)
+	if a.TimeBackward != timeBackwardResume {
+		logger.Warnw("vCenter time moved backward behind checkpoint, events are read once vCenter time catches up",
+			zap.String("behind", behind.String()), zap.Time("checkpointTimestamp", cp.LastEventKeyTimestamp))
+		return begin, nil
+	}
+
+	logger.Warnw("vCenter time moved backward behind checkpoint, beginning at current vCenter time and skipping checkpointed events",
+		zap.String("behind", behind.String()), zap.Time("checkpointTimestamp", cp.LastEventKeyTimestamp),
+		zap.Int32("eventKey", cp.LastEventKey))
+	a.checkpointed = &checkpointedFilter{lastKey: cp.LastEventKey, lastTime: cp.LastEventKeyTimestamp}
+	return vcTime, nil
+}
+
+// checkpointedFilter drops events already covered by a checkpoint, i.e. with a
+// key not greater than the checkpointed key created up to the checkpointed
+// timestamp. Unlike fastForward it is not limited to leading events since
+// events read after a backward time jump interleave with checkpointed events.
+type checkpointedFilter struct {
+	lastKey  int32
+	lastTime time.Time
+}
+
+// filter returns the given events without checkpointed events
+func (f *checkpointedFilter) filter(ctx context.Context, events []types.BaseEvent) []types.BaseEvent {
+	if f == nil {
+		return events
+	}
+
+	filtered := events[:0]
+	for _, be := range events {
+		e := be.GetEvent()
+		if e.Key <= f.lastKey && !e.CreatedTime.After(f.lastTime) {
+			logging.FromContext(ctx).Debugw("skipping checkpointed event", zap.Int32("eventKey", e.Key))
+			continue
+		}
+		filtered = append(filtered, be)
+	}
+	return filtered
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_validateTimeBackwardPolicy(t *testing.T) {
+	for _, policy := range []string{timeBackwardWarn, timeBackwardResume, timeBackwardFail} {
+		if err := validateTimeBackwardPolicy(policy); err != nil {
+			t.Errorf("validateTimeBackwardPolicy(%q) error = %v", policy, err)
+		}
+	}
+	if err := validateTimeBackwardPolicy("ignore"); err == nil {
+		t.Error("validateTimeBackwardPolicy() error = nil for invalid policy, want error")
+	}
+}
+
+func Test_vAdapter_checkTimeBackward(t *testing.T) {
+	vcTime := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	// vCenter time jumped back 10 minutes behind the last checkpointed event
+	ahead := checkpoint{LastEventKey: 1042, LastEventKeyTimestamp: vcTime.Add(10 * time.Minute)}
+	behind := checkpoint{LastEventKey: 1042, LastEventKeyTimestamp: vcTime.Add(-10 * time.Minute)}
+
+	tests := []struct {
+		name       string
+		policy     string
+		cp         checkpoint
+		wantBegin  time.Time
+		wantFilter bool
+		wantErr    error
+	}{
+		{name: "empty checkpoint", policy: timeBackwardFail, wantBegin: vcTime},
+		{name: "time moved forward", policy: timeBackwardFail, cp: behind, wantBegin: behind.LastEventKeyTimestamp},
+		{name: "warn", policy: timeBackwardWarn, cp: ahead, wantBegin: ahead.LastEventKeyTimestamp},
+		{name: "resume", policy: timeBackwardResume, cp: ahead, wantBegin: vcTime, wantFilter: true},
+		{name: "fail", policy: timeBackwardFail, cp: ahead, wantBegin: ahead.LastEventKeyTimestamp, wantErr: ErrTimeBackward},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &vAdapter{TimeBackward: tt.policy}
+			ctx := context.Background()
+
+			begin := getBeginFromCheckpoint(ctx, vcTime, tt.cp, time.Hour)
+			got, err := a.checkTimeBackward(ctx, vcTime, tt.cp, begin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkTimeBackward() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.wantBegin) {
+				t.Errorf("checkTimeBackward() begin = %v, want %v", got, tt.wantBegin)
+			}
+			if (a.checkpointed != nil) != tt.wantFilter {
+				t.Errorf("checkTimeBackward() checkpointed filter = %v, want %v", a.checkpointed, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func Test_checkpointedFilter_filter(t *testing.T) {
+	cpTime := time.Date(2020, 10, 1, 12, 10, 0, 0, time.UTC)
+	f := &checkpointedFilter{lastKey: 1001, lastTime: cpTime}
+
+	// events created after the backward jump interleave with checkpointed
+	// events
+	events := []types.BaseEvent{
+		createBaseEvent(1000, cpTime.Add(-10*time.Minute)),
+		createBaseEvent(1002, cpTime.Add(-9*time.Minute)),
+		createBaseEvent(1001, cpTime),
+		createBaseEvent(1003, cpTime.Add(-8*time.Minute)),
+	}
+
+	got := f.filter(context.Background(), events)
+	if len(got) != 2 || got[0].GetEvent().Key != 1002 || got[1].GetEvent().Key != 1003 {
+		t.Errorf("filter() = %d events, want events 1002 and 1003", len(got))
+	}
+}
+
+func Test_vAdapter_run_timeBackward(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vim *vim25.Client) error {
+		a := &vAdapter{
+			Logger:  zaptest.NewLogger(t).Sugar(),
+			Source:  source,
+			VClient: &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)},
+			KVStore: &fakeKVStore{
+				data: map[string]string{
+					// checkpointed event ahead of current vCenter time
+					CheckpointKey: createCheckpoint(t, time.Now().UTC().Add(time.Hour)),
+				},
+			},
+			CpConfig: CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: time.Millisecond,
+			},
+			TimeBackward: timeBackwardFail,
+		}
+
+		if err := a.run(ctx); !errors.Is(err, ErrTimeBackward) {
+			t.Errorf("run() error = %v, want %v", err, ErrTimeBackward)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"time"
+)
+
+// supported precisions of the CloudEvent time attribute
+var timePrecisions = map[string]time.Duration{
+	"":             0,
+	"nanoseconds":  0,
+	"microseconds": time.Microsecond,
+	"milliseconds": time.Millisecond,
+	"seconds":      time.Second,
+}
+
+// newTimePrecision returns the duration the CloudEvent time is truncated to
+// for the given precision. It returns 0 for full precision.
+func newTimePrecision(precision string) (time.Duration, error) {
+	d, ok := timePrecisions[precision]
+	if !ok {
+		return 0, fmt.Errorf("unsupported time precision %q: must be one of seconds, milliseconds, microseconds or nanoseconds", precision)
+	}
+	return d, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newTimePrecision(t *testing.T) {
+	tests := []struct {
+		precision string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{precision: "", want: 0},
+		{precision: "nanoseconds", want: 0},
+		{precision: "microseconds", want: time.Microsecond},
+		{precision: "milliseconds", want: time.Millisecond},
+		{precision: "seconds", want: time.Second},
+		{precision: "minutes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.precision, func(t *testing.T) {
+			got, err := newTimePrecision(tt.precision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTimePrecision(%q) error = %v, wantErr %v", tt.precision, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("newTimePrecision(%q) = %v, want %v", tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_timePrecision(t *testing.T) {
+	created := time.Date(2020, 10, 1, 12, 0, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name      string
+		precision time.Duration
+		want      time.Time
+	}{
+		{name: "full precision", precision: 0, want: created},
+		{name: "milliseconds", precision: time.Millisecond, want: time.Date(2020, 10, 1, 12, 0, 0, 123000000, time.UTC)},
+		{name: "seconds", precision: time.Second, want: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := &fakeCEClient{}
+			a := &vAdapter{
+				Logger:          zaptest.NewLogger(t).Sugar(),
+				Source:          source,
+				CEClient:        ce,
+				PayloadEncoding: cloudevents.ApplicationXML,
+				TimePrecision:   tt.precision,
+			}
+
+			if _, err := a.sendEvents(context.Background(), []types.BaseEvent{createBaseEvent(1000, created)}); err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+
+			if got := ce.sent[0].Time(); !got.Equal(tt.want) {
+				t.Errorf("sendEvents() event time = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ceVSphereTruncated is the CloudEvent extension set on events with truncated
+// fields
+const ceVSphereTruncated = "vspheretruncated"
+
+// fieldTruncation limits the length of the string field at the field path
+type fieldTruncation struct {
+	fields []string
+	max    int
+}
+
+// truncator truncates large string fields of vSphere events
+type truncator []fieldTruncation
+
+// newTruncator returns a truncator for the given JSON-encoded object of
+// dot-separated event field paths to maximum lengths in bytes, e.g.
+// {"FullFormattedMessage":1024}. Field paths are resolved against the
+// concrete vSphere event type and must end in a string field.
+func newTruncator(config string) (truncator, error) {
+	if config == "" {
+		return nil, nil
+	}
+
+	var in map[string]int
+	if err := json.Unmarshal([]byte(config), &in); err != nil {
+		return nil, fmt.Errorf("unmarshal truncation config: %w", err)
+	}
+
+	t := make(truncator, 0, len(in))
+	for path, max := range in {
+		fields := strings.Split(path, ".")
+		for _, f := range fields {
+			if !fieldNameRegex.MatchString(f) {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+		}
+
+		if max <= 0 {
+			return nil, fmt.Errorf("invalid maximum length %d for field path %q: must be greater than 0", max, path)
+		}
+		t = append(t, fieldTruncation{fields: fields, max: max})
+	}
+
+	return t, nil
+}
+
+// truncate truncates the configured string fields of the given event in place
+// which exceed their maximum length. It returns true if any field was
+// truncated. Field paths which cannot be resolved for the given event are
+// skipped.
+func (t truncator) truncate(be types.BaseEvent) bool {
+	var truncated bool
+	for _, ft := range t {
+		v, ok := settableField(reflect.ValueOf(be), ft.fields)
+		if !ok || v.Len() <= ft.max {
+			continue
+		}
+		v.SetString(truncateString(v.String(), ft.max))
+		truncated = true
+	}
+	return truncated
+}
+
+// settableField returns the settable string field at the field path in v.
+// False is returned if the path does not exist, traverses a nil value or does
+// not end in a string field.
+func settableField(v reflect.Value, fields []string) (reflect.Value, bool) {
+	for _, f := range fields {
+		v = indirect(v)
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		v = v.FieldByName(f)
+		if !v.IsValid() {
+			return reflect.Value{}, false
+		}
+	}
+
+	if v.Kind() != reflect.String || !v.CanSet() {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+// truncateString returns the longest prefix of s with at most max bytes which
+// does not split a multi-byte character
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newTruncator(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "disabled", config: ""},
+		{name: "valid fields", config: `{"FullFormattedMessage":1024,"Vm.Name":64}`, wantLen: 2},
+		{name: "invalid field path", config: `{"fullFormattedMessage":1024}`, wantErr: true},
+		{name: "invalid maximum length", config: `{"FullFormattedMessage":0}`, wantErr: true},
+		{name: "invalid config", config: `["FullFormattedMessage"]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTruncator(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTruncator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("newTruncator() fields = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func Test_truncator_truncate(t *testing.T) {
+	tr, err := newTruncator(`{"FullFormattedMessage":5,"Vm.Name":3,"Key":1,"Ds.Name":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		event       types.BaseEvent
+		wantMessage string
+		wantVMName  string
+		want        bool
+	}{
+		{
+			name: "fields within limits",
+			event: &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+				FullFormattedMessage: "short",
+				Vm:                   &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "vm"}},
+			}}},
+			wantMessage: "short",
+			wantVMName:  "vm",
+			want:        false,
+		},
+		{
+			name: "fields exceeding limits",
+			event: &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+				FullFormattedMessage: "a long message",
+				Vm:                   &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "vm-01"}},
+			}}},
+			wantMessage: "a lon",
+			wantVMName:  "vm-",
+			want:        true,
+		},
+		{
+			name: "nil field path",
+			event: &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+				FullFormattedMessage: "a long message",
+			}}},
+			wantMessage: "a lon",
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tr.truncate(tt.event); got != tt.want {
+				t.Errorf("truncate() = %v, want %v", got, tt.want)
+			}
+
+			e := tt.event.GetEvent()
+			if e.FullFormattedMessage != tt.wantMessage {
+				t.Errorf("truncate() FullFormattedMessage = %q, want %q", e.FullFormattedMessage, tt.wantMessage)
+			}
+			if e.Vm != nil && e.Vm.Name != tt.wantVMName {
+				t.Errorf("truncate() Vm.Name = %q, want %q", e.Vm.Name, tt.wantVMName)
+			}
+		})
+	}
+}
+
+func Test_truncateString(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{name: "shorter", s: "abc", max: 5, want: "abc"},
+		{name: "longer", s: "abcdef", max: 3, want: "abc"},
+		{name: "multi-byte character boundary", s: "aäb", max: 2, want: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateString(tt.s, tt.max); got != tt.want {
+				t.Errorf("truncateString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_truncate(t *testing.T) {
+	tr, err := newTruncator(`{"FullFormattedMessage":10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []types.BaseEvent{
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000, FullFormattedMessage: "short"}}},
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1001, FullFormattedMessage: strings.Repeat("x", 100)}}},
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationJSON,
+		Truncator:       tr,
+	}
+
+	if n, err := a.sendEvents(context.Background(), events); err != nil || n != len(events) {
+		t.Fatalf("sendEvents() = %d, %v, want %d, nil", n, err, len(events))
+	}
+
+	if _, ok := ce.sent[0].Extensions()[ceVSphereTruncated]; ok {
+		t.Errorf("sendEvents() set %s extension on event within limits", ceVSphereTruncated)
+	}
+	if v := ce.sent[1].Extensions()[ceVSphereTruncated]; v != true {
+		t.Errorf("sendEvents() %s extension = %v, want true", ceVSphereTruncated, v)
+	}
+	if data := string(ce.sent[1].Data()); strings.Contains(data, strings.Repeat("x", 11)) {
+		t.Errorf("sendEvents() sent untruncated data %s", data)
+	}
+}
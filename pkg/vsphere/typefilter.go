@@ -0,0 +1,118 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// presetVMPower is the event type preset of events changing the power state
+// of a VM
+const presetVMPower = "vm-power"
+
+// eventTypePresets are named sets of event types which can be allowed instead
+// of enumerating the event types. New presets are added here.
+var eventTypePresets = map[string][]string{
+	presetVMPower: {
+		"VmPoweredOnEvent",
+		"VmPoweredOffEvent",
+		"VmSuspendedEvent",
+		"VmResettingEvent",
+		"DrsVmPoweredOnEvent",
+		"VmPowerOffOnIsolationEvent",
+		"VmShutdownOnIsolationEvent",
+		"VmRestartedOnAlternateHostEvent",
+		"VmDasBeingResetEvent",
+		"VmDasBeingResetWithScreenshotEvent",
+	},
+}
+
+// presetEventTypes returns the given event types extended with the event types
+// of the given presets. It returns an error if a preset does not exist.
+func presetEventTypes(types []string, presets []string) ([]string, error) {
+	result := append([]string(nil), types...)
+	for _, p := range presets {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		preset, ok := eventTypePresets[p]
+		if !ok {
+			names := make([]string, 0, len(eventTypePresets))
+			for name := range eventTypePresets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown event type preset %q: must be one of %s", p, strings.Join(names, ", "))
+		}
+		result = append(result, preset...)
+	}
+	return result, nil
+}
+
+// eventTypeFilter restricts the sent events to a set of vSphere event types,
+// e.g. VmPoweredOnEvent
+type eventTypeFilter map[string]struct{}
+
+// newEventTypeFilter returns a filter allowing the given event types. It
+// returns nil if types is empty, i.e. all events are allowed.
+func newEventTypeFilter(types []string) eventTypeFilter {
+	filter := make(eventTypeFilter, len(types))
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			filter[t] = struct{}{}
+		}
+	}
+
+	if len(filter) == 0 {
+		return nil
+	}
+	return filter
+}
+
+// allows returns whether events of the given type are sent
+func (f eventTypeFilter) allows(eventType string) bool {
+	if f == nil {
+		return true
+	}
+	_, ok := f[eventType]
+	return ok
+}
+
+// baseEventType is the interface implemented by all vSphere event types
+var baseEventType = reflect.TypeOf((*types.BaseEvent)(nil)).Elem()
+
+// newServerEventFilter returns the event type IDs of the given event types to
+// filter events in vCenter, i.e. before they are sent to the adapter. Event
+// types must be vSphere event types, e.g. VmPoweredOnEvent, or dot-separated
+// event type IDs of EventEx and ExtendedEvent events, e.g.
+// com.vmware.vc.HA.ClusterFailoverActionCompletedEvent. It returns nil if
+// eventTypes is empty, i.e. all events are read.
+func newServerEventFilter(eventTypes []string) ([]string, error) {
+	var ids []string
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+
+		if !strings.Contains(t, ".") {
+			rt, ok := types.TypeFunc()(t)
+			if !ok || !reflect.PtrTo(rt).Implements(baseEventType) {
+				return nil, fmt.Errorf("unknown event type %q: must be a vSphere event type, e.g. VmPoweredOnEvent, "+
+					"or an EventEx or ExtendedEvent type ID, e.g. com.vmware.vc.HA.ClusterFailoverActionCompletedEvent", t)
+			}
+		}
+		ids = append(ids, t)
+	}
+	return ids, nil
+}
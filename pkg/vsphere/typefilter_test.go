@@ -0,0 +1,139 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_eventTypeFilter_allows(t *testing.T) {
+	tests := []struct {
+		name      string
+		types     []string
+		eventType string
+		want      bool
+	}{
+		{name: "no filter", types: nil, eventType: "VmPoweredOnEvent", want: true},
+		{name: "blank types", types: []string{" ", ""}, eventType: "VmPoweredOnEvent", want: true},
+		{name: "matching type", types: []string{"VmPoweredOnEvent", "VmPoweredOffEvent"}, eventType: "VmPoweredOffEvent", want: true},
+		{name: "matching type with spaces", types: []string{" VmPoweredOnEvent "}, eventType: "VmPoweredOnEvent", want: true},
+		{name: "other type", types: []string{"VmPoweredOnEvent"}, eventType: "VmCreatedEvent", want: false},
+		{name: "case sensitive", types: []string{"vmpoweredonevent"}, eventType: "VmPoweredOnEvent", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newEventTypeFilter(tt.types).allows(tt.eventType); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_presetEventTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   []string
+		presets []string
+		allowed []string
+		denied  []string
+		wantErr bool
+	}{
+		{name: "no presets", types: []string{"VmCreatedEvent"}, allowed: []string{"VmCreatedEvent"}, denied: []string{"VmPoweredOnEvent"}},
+		{name: "blank preset", presets: []string{" "}, allowed: []string{"VmCreatedEvent"}},
+		{
+			name:    "VM power preset",
+			presets: []string{presetVMPower},
+			allowed: []string{"VmPoweredOnEvent", "VmPoweredOffEvent", "VmSuspendedEvent", "DrsVmPoweredOnEvent"},
+			denied:  []string{"VmCreatedEvent", "VmFailedToPowerOnEvent"},
+		},
+		{
+			name:    "preset and types",
+			types:   []string{"VmCreatedEvent"},
+			presets: []string{" vm-power "},
+			allowed: []string{"VmCreatedEvent", "VmPoweredOnEvent"},
+			denied:  []string{"VmRemovedEvent"},
+		},
+		{name: "unknown preset", presets: []string{"vm-snapshot"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := presetEventTypes(tt.types, tt.presets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("presetEventTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			filter := newEventTypeFilter(got)
+			for _, eventType := range tt.allowed {
+				if !filter.allows(eventType) {
+					t.Errorf("presetEventTypes() = %v, want allowing %q", got, eventType)
+				}
+			}
+			for _, eventType := range tt.denied {
+				if filter.allows(eventType) {
+					t.Errorf("presetEventTypes() = %v, want denying %q", got, eventType)
+				}
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_eventTypes(t *testing.T) {
+	events := []types.BaseEvent{
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1000}}},
+		&types.VmCreatedEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1001}}},
+		&types.VmPoweredOffEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1002}}},
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		EventTypes:      newEventTypeFilter([]string{"VmPoweredOnEvent", "VmPoweredOffEvent"}),
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil || n != len(events) {
+		t.Fatalf("sendEvents() = %d, %v, want %d, nil", n, err, len(events))
+	}
+
+	if len(ce.sent) != 2 || ce.sent[0].ID() != "1000" || ce.sent[1].ID() != "1002" {
+		t.Errorf("sendEvents() sent %d events, want events 1000 and 1002 matching the event types", len(ce.sent))
+	}
+}
+
+func Test_newServerEventFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "disabled", types: []string{" "}, want: nil},
+		{name: "event types", types: []string{"VmPoweredOnEvent", " HostConnectedEvent "}, want: []string{"VmPoweredOnEvent", "HostConnectedEvent"}},
+		{name: "event type ID", types: []string{"com.vmware.vc.HA.ClusterFailoverActionCompletedEvent"}, want: []string{"com.vmware.vc.HA.ClusterFailoverActionCompletedEvent"}},
+		{name: "unknown event type", types: []string{"VmPoweredOnEvent", "VmPoweredUpEvent"}, wantErr: true},
+		{name: "not an event type", types: []string{"VirtualMachine"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newServerEventFilter(tt.types)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newServerEventFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("newServerEventFilter() (-want +got): %s", diff)
+			}
+		})
+	}
+}
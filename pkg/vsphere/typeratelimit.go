@@ -0,0 +1,99 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// events exceeding the rate limit of their type are dropped and
+	// checkpointed
+	typeRateLimitDrop = "drop"
+	// events exceeding the rate limit of their type are deferred until the
+	// limit permits sending them
+	typeRateLimitDefer = "defer"
+)
+
+// typeRateLimits limits the rate of sent events per event type, e.g. to
+// throttle a noisy TaskEvent without affecting other event types. Event types
+// without a limit are not throttled.
+type typeRateLimits struct {
+	limiters map[string]*rate.Limiter
+	mode     string
+}
+
+// newTypeRateLimits returns rate limits for the given JSON-encoded object of
+// event types to events per second, e.g. {"TaskEvent":0.5}. The burst of each
+// limit is the rate rounded up. It returns nil if config is empty, i.e. no
+// event type is throttled.
+func newTypeRateLimits(config, mode string) (*typeRateLimits, error) {
+	if config == "" {
+		return nil, nil
+	}
+
+	if mode != typeRateLimitDrop && mode != typeRateLimitDefer {
+		return nil, fmt.Errorf("invalid rate limit mode %q: must be %s or %s", mode, typeRateLimitDrop, typeRateLimitDefer)
+	}
+
+	var limits map[string]float64
+	if err := json.Unmarshal([]byte(config), &limits); err != nil {
+		return nil, fmt.Errorf("unmarshal event type rate limits: %w", err)
+	}
+
+	limiters := make(map[string]*rate.Limiter, len(limits))
+	for eventType, r := range limits {
+		if eventType == "" {
+			return nil, fmt.Errorf("invalid rate limit: event type must not be empty")
+		}
+		if r <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %v for event type %s: must be greater than 0", r, eventType)
+		}
+		limiters[eventType] = rate.NewLimiter(rate.Limit(r), int(math.Ceil(r)))
+	}
+
+	if len(limiters) == 0 {
+		return nil, nil
+	}
+	return &typeRateLimits{limiters: limiters, mode: mode}, nil
+}
+
+// allow returns whether an event of the given type may be sent. In defer mode
+// it blocks until the rate limit of the type permits sending the event or ctx
+// is done. In drop mode events exceeding the limit are not allowed and
+// reported as throttled.
+func (l *typeRateLimits) allow(ctx context.Context, eventType string) (bool, error) {
+	if l == nil {
+		return true, nil
+	}
+
+	limiter, ok := l.limiters[eventType]
+	if !ok {
+		return true, nil
+	}
+
+	if l.mode == typeRateLimitDefer {
+		if limiter.Allow() {
+			return true, nil
+		}
+		reportTypeThrottled(ctx, eventType, l.mode)
+		if err := limiter.Wait(ctx); err != nil {
+			return false, fmt.Errorf("wait for rate limit of event type %s: %w", eventType, err)
+		}
+		return true, nil
+	}
+
+	if limiter.Allow() {
+		return true, nil
+	}
+	reportTypeThrottled(ctx, eventType, l.mode)
+	return false, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newTypeRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		mode    string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", mode: typeRateLimitDrop, wantNil: true},
+		{name: "no limits", config: "{}", mode: typeRateLimitDrop, wantNil: true},
+		{name: "invalid mode", config: `{"TaskEvent":1}`, mode: "queue", wantErr: true},
+		{name: "invalid JSON", config: "TaskEvent=1", mode: typeRateLimitDrop, wantErr: true},
+		{name: "zero rate", config: `{"TaskEvent":0}`, mode: typeRateLimitDrop, wantErr: true},
+		{name: "drop", config: `{"TaskEvent":0.5}`, mode: typeRateLimitDrop},
+		{name: "defer", config: `{"TaskEvent":0.5}`, mode: typeRateLimitDefer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTypeRateLimits(tt.config, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTypeRateLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newTypeRateLimits() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_typeRateLimits_allow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drop", func(t *testing.T) {
+		l, err := newTypeRateLimits(`{"TaskEvent":0.001}`, typeRateLimitDrop)
+		if err != nil {
+			t.Fatalf("newTypeRateLimits() error = %v", err)
+		}
+
+		want := []bool{true, false, false}
+		for i, w := range want {
+			if got, _ := l.allow(ctx, "TaskEvent"); got != w {
+				t.Errorf("allow() #%d = %v, want %v", i, got, w)
+			}
+		}
+		if got, _ := l.allow(ctx, "AlarmStatusChangedEvent"); !got {
+			t.Error("allow() = false for event type without limit, want true")
+		}
+	})
+
+	t.Run("defer", func(t *testing.T) {
+		l, err := newTypeRateLimits(`{"TaskEvent":20}`, typeRateLimitDefer)
+		if err != nil {
+			t.Fatalf("newTypeRateLimits() error = %v", err)
+		}
+
+		start := time.Now()
+		// burst of 20 followed by 5 deferred events
+		for i := 0; i < 25; i++ {
+			if got, err := l.allow(ctx, "TaskEvent"); !got || err != nil {
+				t.Fatalf("allow() = %v, %v, want deferred event allowed", got, err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Errorf("allow() deferred events for %v, want at least 200ms", elapsed)
+		}
+
+		l, err = newTypeRateLimits(`{"TaskEvent":0.001}`, typeRateLimitDefer)
+		if err != nil {
+			t.Fatalf("newTypeRateLimits() error = %v", err)
+		}
+		if got, _ := l.allow(ctx, "TaskEvent"); !got {
+			t.Fatal("allow() = false within burst, want true")
+		}
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := l.allow(cctx, "TaskEvent"); err == nil {
+			t.Error("allow() error = nil with canceled context, want error")
+		}
+	})
+}
+
+func Test_vAdapter_sendEvents_typeRateLimits(t *testing.T) {
+	limits, err := newTypeRateLimits(`{"VmPoweredOnEvent":0.001}`, typeRateLimitDrop)
+	if err != nil {
+		t.Fatalf("newTypeRateLimits() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		TypeRateLimits:  limits,
+	}
+
+	now := time.Now().UTC()
+	events := []types.BaseEvent{
+		powerOnEvent(1000, "vm-1", now),
+		powerOnEvent(1001, "vm-2", now),
+		createBaseEvent(1002, now),
+	}
+
+	n, err := a.sendEvents(context.Background(), events)
+	if err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if n != len(events) {
+		t.Errorf("sendEvents() = %d, want %d processed events", n, len(events))
+	}
+	if len(ce.sent) != 2 {
+		t.Errorf("sendEvents() sent %d events, want 2", len(ce.sent))
+	}
+}
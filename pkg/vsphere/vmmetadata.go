@@ -0,0 +1,203 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// VM metadata properties
+	vmPropertyGuest   = "guest"
+	vmPropertyHost    = "host"
+	vmPropertyCluster = "cluster"
+
+	// extended attributes carrying the guest OS (guestFullName), host and
+	// cluster name of the VM referenced by an event
+	ceVSphereVMGuest   = "vspherevmguest"
+	ceVSphereVMHost    = "vspherevmhost"
+	ceVSphereVMCluster = "vspherevmcluster"
+)
+
+// extension names of the supported VM metadata properties
+var vmPropertyExtensions = map[string]string{
+	vmPropertyGuest:   ceVSphereVMGuest,
+	vmPropertyHost:    ceVSphereVMHost,
+	vmPropertyCluster: ceVSphereVMCluster,
+}
+
+// vmMetadataFunc looks up the given properties of a VM, returning the values
+// of the properties which are set
+type vmMetadataFunc func(ctx context.Context, vm types.ManagedObjectReference, props []string) (map[string]string, error)
+
+// vmMetadataEntry is a cached lookup of VM metadata
+type vmMetadataEntry struct {
+	values   map[string]string
+	resolved time.Time
+}
+
+// vmMetadataEnricher adds guest and host metadata of the VM referenced by an
+// event as extensions. Lookups are cached for ttl per VM. Failed lookups are
+// logged, sent without metadata and retried with the next event.
+type vmMetadataEnricher struct {
+	props  []string
+	lookup vmMetadataFunc
+	ttl    time.Duration
+	cache  *lru.Cache
+	now    func() time.Time
+}
+
+// newVMMetadataEnricher returns an enricher for the given comma-separated list
+// of VM properties, e.g. "guest,host,cluster", caching up to size VMs. It
+// returns nil if props is empty, i.e. events are not enriched.
+func newVMMetadataEnricher(props string, lookup vmMetadataFunc, size int, ttl time.Duration) (*vmMetadataEnricher, error) {
+	var enabled []string
+	for _, p := range strings.Split(props, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, ok := vmPropertyExtensions[p]; !ok {
+			return nil, fmt.Errorf("unsupported VM metadata property %q: must be %s, %s or %s", p,
+				vmPropertyGuest, vmPropertyHost, vmPropertyCluster)
+		}
+		enabled = append(enabled, p)
+	}
+
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("VM metadata cache TTL must be greater than 0")
+	}
+
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, fmt.Errorf("create VM metadata cache: %w", err)
+	}
+
+	return &vmMetadataEnricher{
+		props:  enabled,
+		lookup: lookup,
+		ttl:    ttl,
+		cache:  cache,
+		now:    time.Now,
+	}, nil
+}
+
+// vmPropertyFunc returns a vmMetadataFunc using the given vSphere client
+func vmPropertyFunc(client *vim25.Client) vmMetadataFunc {
+	pc := property.DefaultCollector(client)
+	return func(ctx context.Context, ref types.ManagedObjectReference, props []string) (map[string]string, error) {
+		var vm mo.VirtualMachine
+		if err := pc.RetrieveOne(ctx, ref, []string{"config.guestFullName", "runtime.host"}, &vm); err != nil {
+			return nil, fmt.Errorf("retrieve VM properties: %w", err)
+		}
+
+		values := make(map[string]string, len(props))
+		if vm.Config != nil && vm.Config.GuestFullName != "" {
+			values[vmPropertyGuest] = vm.Config.GuestFullName
+		}
+
+		if vm.Runtime.Host == nil || !containsString(props, vmPropertyHost) && !containsString(props, vmPropertyCluster) {
+			return values, nil
+		}
+
+		var host mo.HostSystem
+		if err := pc.RetrieveOne(ctx, *vm.Runtime.Host, []string{"name", "parent"}, &host); err != nil {
+			return nil, fmt.Errorf("retrieve host properties: %w", err)
+		}
+		values[vmPropertyHost] = host.Name
+
+		// standalone hosts have a ComputeResource parent
+		if host.Parent == nil || host.Parent.Type != "ClusterComputeResource" || !containsString(props, vmPropertyCluster) {
+			return values, nil
+		}
+
+		var cluster mo.ClusterComputeResource
+		if err := pc.RetrieveOne(ctx, *host.Parent, []string{"name"}, &cluster); err != nil {
+			return nil, fmt.Errorf("retrieve cluster properties: %w", err)
+		}
+		values[vmPropertyCluster] = cluster.Name
+		return values, nil
+	}
+}
+
+// metadata returns the configured metadata of the VM referenced by the given
+// event. False is returned if the event does not reference a VM or the lookup
+// failed.
+func (e *vmMetadataEnricher) metadata(ctx context.Context, be types.BaseEvent) (map[string]string, bool) {
+	vm := be.GetEvent().Vm
+	if vm == nil {
+		return nil, false
+	}
+
+	if v, ok := e.cache.Get(vm.Vm); ok {
+		entry := v.(vmMetadataEntry)
+		if e.now().Sub(entry.resolved) < e.ttl {
+			return entry.values, true
+		}
+	}
+
+	values, err := e.lookup(ctx, vm.Vm, e.props)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("could not look up VM metadata", zap.String("vm", vm.Vm.String()), zap.Error(err))
+		return nil, false
+	}
+
+	e.cache.Add(vm.Vm, vmMetadataEntry{values: values, resolved: e.now()})
+	return values, true
+}
+
+// apply sets the allowed VM metadata extensions of the VM referenced by be
+func (e *vmMetadataEnricher) apply(ctx context.Context, ev *cloudevents.Event, be types.BaseEvent, allowed extensionAllowlist) {
+	if e == nil || !e.allowed(allowed) {
+		return
+	}
+
+	values, ok := e.metadata(ctx, be)
+	if !ok {
+		return
+	}
+
+	for _, p := range e.props {
+		if v, ok := values[p]; ok {
+			allowed.set(ev, vmPropertyExtensions[p], v)
+		}
+	}
+}
+
+// allowed returns whether any of the configured metadata extensions is allowed
+func (e *vmMetadataEnricher) allowed(allowed extensionAllowlist) bool {
+	for _, p := range e.props {
+		if allowed.allows(vmPropertyExtensions[p]) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func vmEvent(key int32, vm string) types.BaseEvent {
+	return &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+		Key: key,
+		Vm: &types.VmEventArgument{
+			EntityEventArgument: types.EntityEventArgument{Name: vm},
+			Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: vm},
+		},
+	}}}
+}
+
+func Test_newVMMetadataEnricher(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   string
+		ttl     time.Duration
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", ttl: time.Minute, wantNil: true},
+		{name: "unsupported property", props: "guest,datastore", ttl: time.Minute, wantErr: true},
+		{name: "invalid TTL", props: "guest", wantErr: true},
+		{name: "properties", props: "guest, host,cluster", ttl: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newVMMetadataEnricher(tt.props, nil, 10, tt.ttl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newVMMetadataEnricher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newVMMetadataEnricher() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_vmMetadataEnricher_apply(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var lookups int
+	var lookupErr error
+	lookup := func(_ context.Context, vm types.ManagedObjectReference, props []string) (map[string]string, error) {
+		lookups++
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		return map[string]string{vmPropertyGuest: "Ubuntu Linux (64-bit)", vmPropertyHost: "esx-01"}, nil
+	}
+
+	e, err := newVMMetadataEnricher("guest,host,cluster", lookup, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("newVMMetadataEnricher() error = %v", err)
+	}
+	e.now = func() time.Time { return now }
+
+	ev := cloudevents.NewEvent()
+	e.apply(context.Background(), &ev, vmEvent(1, "vm-1"), nil)
+	if got := ev.Extensions()[ceVSphereVMGuest]; got != "Ubuntu Linux (64-bit)" {
+		t.Errorf("apply() %s = %v, want guest full name", ceVSphereVMGuest, got)
+	}
+	if got := ev.Extensions()[ceVSphereVMHost]; got != "esx-01" {
+		t.Errorf("apply() %s = %v, want host name", ceVSphereVMHost, got)
+	}
+	if _, ok := ev.Extensions()[ceVSphereVMCluster]; ok {
+		t.Errorf("apply() set %s for VM without cluster", ceVSphereVMCluster)
+	}
+
+	// cached
+	e.apply(context.Background(), &ev, vmEvent(2, "vm-1"), nil)
+	if lookups != 1 {
+		t.Errorf("apply() looked up metadata %d times, want 1 while cached", lookups)
+	}
+
+	// not allowed
+	ev = cloudevents.NewEvent()
+	e.apply(context.Background(), &ev, vmEvent(3, "vm-2"), extensionAllowlist{"vmname": {}})
+	if len(ev.Extensions()) != 0 || lookups != 1 {
+		t.Errorf("apply() set %v with %d lookups, want no extensions and lookup", ev.Extensions(), lookups)
+	}
+
+	// expired and failing lookup
+	now = now.Add(time.Minute)
+	lookupErr = errors.New("not authenticated")
+	ev = cloudevents.NewEvent()
+	e.apply(context.Background(), &ev, vmEvent(4, "vm-1"), nil)
+	if len(ev.Extensions()) != 0 || lookups != 2 {
+		t.Errorf("apply() set %v with %d lookups, want no extensions after failed lookup", ev.Extensions(), lookups)
+	}
+
+	// event without VM
+	e.apply(context.Background(), &ev, createBaseEvent(5, now), nil)
+	if lookups != 2 {
+		t.Errorf("apply() looked up metadata for event without VM")
+	}
+}
+
+func Test_vmPropertyFunc(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_C0_RP0_VM0")
+		if err != nil {
+			t.Fatalf("find VM: %v", err)
+		}
+
+		got, err := vmPropertyFunc(vim)(ctx, vm.Reference(), []string{vmPropertyGuest, vmPropertyHost, vmPropertyCluster})
+		if err != nil {
+			t.Fatalf("vmPropertyFunc() error = %v", err)
+		}
+		if got[vmPropertyGuest] == "" || got[vmPropertyHost] == "" || got[vmPropertyCluster] != "DC0_C0" {
+			t.Errorf("vmPropertyFunc() = %v, want guest, host and cluster DC0_C0", got)
+		}
+	})
+}
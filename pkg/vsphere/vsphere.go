@@ -15,7 +15,17 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
-func newHistoryCollector(ctx context.Context, client *vim25.Client, begin time.Time) (*event.HistoryCollector, error) {
+// eventCollector reads events from a vCenter event history collector
+type eventCollector interface {
+	ReadNextEvents(ctx context.Context, maxCount int32) ([]types.BaseEvent, error)
+}
+
+var _ eventCollector = (*event.HistoryCollector)(nil)
+
+// newHistoryCollector returns a collector of the events created since begin.
+// If eventTypes is not empty, vCenter only returns events of the given event
+// type IDs.
+func newHistoryCollector(ctx context.Context, client *vim25.Client, begin time.Time, eventTypes []string) (*event.HistoryCollector, error) {
 	mgr := event.NewManager(client)
 	root := client.ServiceContent.RootFolder
 
@@ -28,6 +38,7 @@ func newHistoryCollector(ctx context.Context, client *vim25.Client, begin time.T
 		Time: &types.EventFilterSpecByTime{
 			BeginTime: types.NewTime(begin),
 		},
+		EventTypeId: eventTypes,
 	}
 
 	return mgr.CreateCollectorForEvents(ctx, filter)
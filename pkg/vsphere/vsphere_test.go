@@ -6,9 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package vsphere
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -58,3 +62,47 @@ func Test_getEventDetails(t *testing.T) {
 		})
 	}
 }
+
+func Test_newHistoryCollector_eventTypes(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		const eventType = "VmPoweredOnEvent"
+
+		tests := []struct {
+			name       string
+			eventTypes []string
+			wantOnly   string
+		}{
+			{name: "all events"},
+			{name: "server-side filter", eventTypes: []string{eventType}, wantOnly: eventType},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c, err := newHistoryCollector(ctx, vim, time.Time{}, tt.eventTypes)
+				if err != nil {
+					t.Fatalf("newHistoryCollector() error = %v", err)
+				}
+
+				events, err := c.ReadNextEvents(ctx, 1000)
+				if err != nil {
+					t.Fatalf("ReadNextEvents() error = %v", err)
+				}
+				if len(events) == 0 {
+					t.Fatal("ReadNextEvents() returned no events")
+				}
+
+				var other bool
+				for _, be := range events {
+					if getEventDetails(be).Type != eventType {
+						other = true
+						if tt.wantOnly != "" {
+							t.Errorf("ReadNextEvents() returned %s, want only %s", getEventDetails(be).Type, tt.wantOnly)
+						}
+					}
+				}
+				if tt.wantOnly == "" && !other {
+					t.Errorf("ReadNextEvents() returned only %s events without filter", eventType)
+				}
+			})
+		}
+	})
+}
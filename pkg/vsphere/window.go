@@ -0,0 +1,141 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// latestEventKeyFunc returns the key of the latest event in vCenter
+type latestEventKeyFunc func(ctx context.Context) (int32, error)
+
+// collectorWindow widens reads from the event history collector when the
+// number of unread events approaches the capacity of the collector's latest
+// page. vCenter overwrites unread events once the page is exhausted, so
+// reading larger batches (and lifting replay throttling) while the backlog is
+// above the threshold keeps the adapter within the window instead of losing
+// events.
+type collectorWindow struct {
+	capacity  int
+	threshold float64
+	latest    latestEventKeyFunc
+	// batch size of reads while not widened
+	base int32
+
+	size  int32
+	usage float64
+}
+
+// newCollectorWindow returns a window for the given page capacity (number of
+// events) which widens reads of batchSize events when the fraction of unread
+// events reaches threshold. It returns nil if capacity is 0, i.e. reads are not
+// widened.
+func newCollectorWindow(capacity int, threshold float64, batchSize int32, latest latestEventKeyFunc) (*collectorWindow, error) {
+	if capacity == 0 {
+		return nil, nil
+	}
+
+	if capacity < int(batchSize) {
+		return nil, fmt.Errorf("collector page capacity %d must not be less than %d", capacity, batchSize)
+	}
+
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("collector page threshold %v must be in (0, 1]", threshold)
+	}
+
+	return &collectorWindow{
+		capacity:  capacity,
+		threshold: threshold,
+		latest:    latest,
+		base:      batchSize,
+		size:      batchSize,
+	}, nil
+}
+
+// batchSize returns the number of events to request with the next read
+func (w *collectorWindow) batchSize() int32 {
+	if w == nil {
+		return maxEventsBatch
+	}
+	return w.size
+}
+
+// widened returns whether the backlog is above the threshold
+func (w *collectorWindow) widened() bool {
+	return w != nil && w.usage >= w.threshold
+}
+
+// update estimates the backlog of unread events after reading events with the
+// given requested batch size and adjusts the size of the next read. A read
+// returning less than the requested events drained the collector, otherwise
+// the backlog is the distance between the last read and the latest vCenter
+// event key.
+func (w *collectorWindow) update(ctx context.Context, events []types.BaseEvent, requested int32) {
+	logger := logging.FromContext(ctx)
+
+	if len(events) < int(requested) {
+		w.usage = 0
+		w.size = w.base
+		reportCollectorWindowUsage(ctx, w.usage)
+		return
+	}
+
+	latest, err := w.latest(ctx)
+	if err != nil {
+		logger.Warnw("could not retrieve latest event key: keeping read batch size", zap.Error(err),
+			zap.Int32("batchSize", w.size))
+		return
+	}
+
+	backlog := int(latest - events[len(events)-1].GetEvent().Key)
+	if backlog < 0 {
+		backlog = 0
+	}
+	w.usage = float64(backlog) / float64(w.capacity)
+	reportCollectorWindowUsage(ctx, w.usage)
+
+	if !w.widened() {
+		w.size = w.base
+		return
+	}
+
+	size := backlog
+	if size > w.capacity {
+		size = w.capacity
+	}
+	if size < int(w.base) {
+		size = int(w.base)
+	}
+	w.size = int32(size)
+
+	logger.Warnw("unread events approaching collector page capacity: widening reads",
+		zap.Int("backlog", backlog), zap.Int("capacity", w.capacity), zap.Int32("batchSize", w.size))
+}
+
+// newLatestEventKeyFunc returns a function retrieving the key of the latest
+// event from the vCenter event manager
+func newLatestEventKeyFunc(client *vim25.Client) latestEventKeyFunc {
+	return func(ctx context.Context) (int32, error) {
+		var m mo.EventManager
+		pc := property.DefaultCollector(client)
+		if err := pc.RetrieveOne(ctx, *client.ServiceContent.EventManager, []string{"latestEvent"}, &m); err != nil {
+			return 0, fmt.Errorf("retrieve latest event: %w", err)
+		}
+
+		if m.LatestEvent == nil {
+			return 0, nil
+		}
+		return m.LatestEvent.GetEvent().Key, nil
+	}
+}
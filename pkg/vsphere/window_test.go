@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newCollectorWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		capacity  int
+		threshold float64
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "disabled", capacity: 0, threshold: 0.8, wantNil: true},
+		{name: "valid", capacity: 1000, threshold: 0.8},
+		{name: "capacity below batch size", capacity: maxEventsBatch - 1, threshold: 0.8, wantErr: true},
+		{name: "zero threshold", capacity: 1000, threshold: 0, wantErr: true},
+		{name: "threshold above 1", capacity: 1000, threshold: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCollectorWindow(tt.capacity, tt.threshold, maxEventsBatch, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCollectorWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newCollectorWindow() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_collectorWindow_update(t *testing.T) {
+	full := func(last int) []types.BaseEvent {
+		events := make([]types.BaseEvent, maxEventsBatch)
+		for i := range events {
+			events[i] = createBaseEvent(last-maxEventsBatch+1+i, time.Now())
+		}
+		return events
+	}
+
+	tests := []struct {
+		name       string
+		events     []types.BaseEvent
+		latest     int32
+		latestErr  error
+		wantSize   int32
+		wantWiden  bool
+		wantUsage  float64
+		startWiden bool
+	}{
+		{
+			name:     "partial batch drains collector",
+			events:   []types.BaseEvent{createBaseEvent(1000, time.Now())},
+			wantSize: maxEventsBatch,
+		},
+		{
+			name:      "backlog below threshold",
+			events:    full(1099),
+			latest:    1099 + 500,
+			wantSize:  maxEventsBatch,
+			wantUsage: 0.5,
+		},
+		{
+			name:      "backlog above threshold widens reads",
+			events:    full(1099),
+			latest:    1099 + 900,
+			wantSize:  900,
+			wantWiden: true,
+			wantUsage: 0.9,
+		},
+		{
+			name:      "backlog above capacity is capped",
+			events:    full(1099),
+			latest:    1099 + 5000,
+			wantSize:  1000,
+			wantWiden: true,
+			wantUsage: 5,
+		},
+		{
+			name:       "latest key error keeps size",
+			events:     full(1099),
+			latestErr:  errors.New("not available"),
+			wantSize:   800,
+			wantWiden:  true,
+			wantUsage:  0.8,
+			startWiden: true,
+		},
+		{
+			name:       "partial batch resets widened reads",
+			events:     []types.BaseEvent{createBaseEvent(1000, time.Now())},
+			wantSize:   maxEventsBatch,
+			startWiden: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newCollectorWindow(1000, 0.8, maxEventsBatch, func(context.Context) (int32, error) {
+				return tt.latest, tt.latestErr
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.startWiden {
+				w.size, w.usage = 800, 0.8
+			}
+
+			w.update(context.Background(), tt.events, maxEventsBatch)
+
+			if got := w.batchSize(); got != tt.wantSize {
+				t.Errorf("batchSize() = %d, want %d", got, tt.wantSize)
+			}
+			if got := w.widened(); got != tt.wantWiden {
+				t.Errorf("widened() = %v, want %v", got, tt.wantWiden)
+			}
+			if w.usage != tt.wantUsage {
+				t.Errorf("usage = %v, want %v", w.usage, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func Test_collectorWindow_nil(t *testing.T) {
+	var w *collectorWindow
+	if got := w.batchSize(); got != maxEventsBatch {
+		t.Errorf("batchSize() = %d, want %d", got, maxEventsBatch)
+	}
+	if w.widened() {
+		t.Error("widened() = true, want false")
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// xmlProjection restricts the XML payload of events to a set of fields
+// identified by dot-separated Go field paths, e.g. Vm.Name. Fields of a
+// projected field are included, e.g. Vm includes Vm.Name and Vm.Vm. Field
+// paths are resolved against the concrete vSphere event type when sending
+// events.
+type xmlProjection struct {
+	fields [][]string
+
+	mu sync.Mutex
+	// XML element paths of the fields per concrete event type
+	elements map[reflect.Type][][]string
+}
+
+// newXMLProjection returns a projection of the given field paths. It returns
+// nil if paths is empty, i.e. all fields are sent. Paths which cannot be
+// resolved on the base vSphere event type are logged as a warning.
+func newXMLProjection(ctx context.Context, paths []string) (*xmlProjection, error) {
+	var fields [][]string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		f := strings.Split(path, ".")
+		for _, name := range f {
+			if !fieldNameRegex.MatchString(name) {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+		}
+
+		if _, ok := xmlElementPath(reflect.TypeOf(types.Event{}), f); !ok {
+			logging.FromContext(ctx).Warnw("field path cannot be resolved on base vSphere event, "+
+				"field will only be included for events providing it", zap.String("path", path))
+		}
+		fields = append(fields, f)
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return &xmlProjection{fields: fields, elements: make(map[reflect.Type][][]string)}, nil
+}
+
+// project returns the given event restricted to the projected fields when
+// encoded as XML
+func (p *xmlProjection) project(be types.BaseEvent) interface{} {
+	return projectedEvent{event: be, elements: p.elementPaths(reflect.TypeOf(be))}
+}
+
+// elementPaths returns the XML element paths of the projected fields
+// resolvable on the given event type
+func (p *xmlProjection) elementPaths(t reflect.Type) [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if paths, ok := p.elements[t]; ok {
+		return paths
+	}
+
+	paths := [][]string{}
+	for _, f := range p.fields {
+		if path, ok := xmlElementPath(t, f); ok {
+			paths = append(paths, path)
+		}
+	}
+	p.elements[t] = paths
+	return paths
+}
+
+// xmlElementPath returns the XML element names of the given field path on t
+func xmlElementPath(t reflect.Type, fields []string) ([]string, bool) {
+	path := make([]string, 0, len(fields))
+	for _, f := range fields {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		sf, ok := t.FieldByName(f)
+		if !ok {
+			return nil, false
+		}
+
+		name := strings.Split(sf.Tag.Get("xml"), ",")[0]
+		if name == "-" {
+			return nil, false
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		path = append(path, name)
+		t = sf.Type
+	}
+	return path, true
+}
+
+// projectedEvent encodes an event as XML including only the given element
+// paths (relative to the root element)
+type projectedEvent struct {
+	event    types.BaseEvent
+	elements [][]string
+}
+
+// MarshalXML encodes the event and writes all tokens of the projected
+// elements, their ancestors and descendants
+func (p projectedEvent) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	b, err := xml.Marshal(p.event)
+	if err != nil {
+		return err
+	}
+
+	var (
+		dec = xml.NewDecoder(bytes.NewReader(b))
+		// element path relative to the root element
+		path []string
+		// nesting level within the root element
+		depth int
+		// nesting level within a dropped element
+		skip int
+	)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return e.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("decode event XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if depth > 0 {
+				path = append(path, t.Name.Local)
+				if !p.keeps(path) {
+					path = path[:len(path)-1]
+					skip = 1
+					continue
+				}
+			}
+			depth++
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			depth--
+			if depth > 0 {
+				path = path[:len(path)-1]
+			}
+		default:
+			if skip > 0 {
+				continue
+			}
+		}
+
+		if err = e.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return err
+		}
+	}
+}
+
+// keeps returns whether the element at the given path is projected, i.e. an
+// ancestor or descendant of a projected element or a projected element itself
+func (p projectedEvent) keeps(path []string) bool {
+	for _, el := range p.elements {
+		if hasPathPrefix(path, el) || hasPathPrefix(el, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix returns whether path starts with prefix
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
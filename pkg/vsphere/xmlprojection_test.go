@@ -0,0 +1,134 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newXMLProjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "blank paths", paths: []string{" ", ""}, wantNil: true},
+		{name: "invalid path", paths: []string{"vm.name"}, wantErr: true},
+		{name: "empty segment", paths: []string{"Vm..Name"}, wantErr: true},
+		{name: "base event fields", paths: []string{"Key", " Vm.Name "}},
+		{name: "concrete event field", paths: []string{"OldName"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newXMLProjection(context.Background(), tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newXMLProjection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newXMLProjection() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_xmlProjection_project(t *testing.T) {
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	be := &types.VmRenamedEvent{
+		VmEvent: types.VmEvent{Event: types.Event{
+			Key:                  1000,
+			ChainId:              1000,
+			CreatedTime:          created,
+			UserName:             "administrator@vsphere.local",
+			FullFormattedMessage: "Renamed vm-1 from old to new",
+			Host:                 &types.HostEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "host-1"}},
+			Vm: &types.VmEventArgument{
+				EntityEventArgument: types.EntityEventArgument{Name: "vm-1"},
+				Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			},
+		}},
+		OldName: "old",
+		NewName: "new",
+	}
+
+	p, err := newXMLProjection(context.Background(), []string{"Key", "Vm", "Host.Name", "OldName", "Datacenter.Name"})
+	if err != nil {
+		t.Fatalf("newXMLProjection() error = %v", err)
+	}
+
+	full, err := xml.Marshal(be)
+	if err != nil {
+		t.Fatalf("marshal full event: %v", err)
+	}
+	projected, err := xml.Marshal(p.project(be))
+	if err != nil {
+		t.Fatalf("marshal projected event: %v", err)
+	}
+
+	if len(projected) >= len(full) {
+		t.Errorf("projected XML (%d bytes) not smaller than full XML (%d bytes)", len(projected), len(full))
+	}
+
+	want := `<VmRenamedEvent><key>1000</key><host><name>host-1</name></host>` +
+		`<vm><name>vm-1</name><vm type="VirtualMachine">vm-1</vm></vm><oldName>old</oldName></VmRenamedEvent>`
+	if string(projected) != want {
+		t.Errorf("project() XML = %s, want %s", projected, want)
+	}
+
+	for _, dropped := range []string{"chainId", "createdTime", "userName", "fullFormattedMessage", "newName"} {
+		if !strings.Contains(string(full), "<"+dropped+">") {
+			t.Errorf("full XML does not contain %s", dropped)
+		}
+		if strings.Contains(string(projected), "<"+dropped+">") {
+			t.Errorf("projected XML contains %s", dropped)
+		}
+	}
+
+	var decoded types.VmRenamedEvent
+	if err = xml.Unmarshal(projected, &decoded); err != nil {
+		t.Fatalf("unmarshal projected event: %v", err)
+	}
+	if decoded.Key != be.Key || decoded.Vm.Name != "vm-1" || decoded.OldName != "old" || decoded.NewName != "" {
+		t.Errorf("decoded projected event = %+v", decoded)
+	}
+}
+
+func Test_vAdapter_sendEvents_xmlFields(t *testing.T) {
+	p, err := newXMLProjection(context.Background(), []string{"Vm.Name"})
+	if err != nil {
+		t.Fatalf("newXMLProjection() error = %v", err)
+	}
+
+	ce := &fakeCEClient{}
+	a := &vAdapter{
+		Logger:          zaptest.NewLogger(t).Sugar(),
+		Source:          source,
+		CEClient:        ce,
+		PayloadEncoding: cloudevents.ApplicationXML,
+		XMLFields:       p,
+	}
+
+	if _, err = a.sendEvents(context.Background(), []types.BaseEvent{powerOnEvent(1000, "vm-1", time.Now().UTC())}); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if len(ce.sent) != 1 {
+		t.Fatalf("sendEvents() sent %d events, want 1", len(ce.sent))
+	}
+
+	want := `<VmPoweredOnEvent><vm><name>vm-1</name></vm></VmPoweredOnEvent>`
+	if got := string(ce.sent[0].Data()); got != want {
+		t.Errorf("sendEvents() data = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+const sourceKind = "VSphereSource"
+
+// manifestExtensions are the file extensions of manifests read from a
+// directory
+var manifestExtensions = map[string]struct{}{
+	".yaml": {},
+	".yml":  {},
+	".json": {},
+}
+
+type applyOptions struct {
+	Filename string
+	DryRun   bool
+}
+
+func NewSourceApplyCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	applyOpts := applyOptions{}
+
+	result := cobra.Command{
+		Use:   "apply",
+		Short: "Create or update vSphere sources from YAML manifests",
+		Long:  "Create or update all VSphereSource manifests in a file or directory, e.g. to onboard many vCenters at once. Each manifest is applied independently: failures are reported per file and do not stop the remaining manifests from being applied.",
+		Example: `# Create or update all sources defined in the manifests of the specified directory
+kn vsphere source apply -f sources/
+
+# Show which sources would be created or updated without changing them
+kn vsphere source apply -f sources/ --dry-run
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if applyOpts.Filename == "" {
+				return fmt.Errorf("'filename' requires a nonempty file or directory provided with the --filename option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(opts.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %v", err)
+			}
+
+			files, err := manifestFiles(applyOpts.Filename)
+			if err != nil {
+				return err
+			}
+
+			var applied, failed int
+			for _, file := range files {
+				sources, err := readSourceManifests(file)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: error: %v\n", file, err)
+					failed++
+					continue
+				}
+
+				for _, src := range sources {
+					if src.Namespace == "" {
+						src.Namespace = namespace
+					}
+
+					action, err := applySource(cmd.Context(), clients, src, applyOpts.DryRun)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStdout(), "%s: error: source %s/%s: %v\n", file, src.Namespace, src.Name, err)
+						failed++
+						continue
+					}
+
+					if applyOpts.DryRun {
+						action += " (dry run)"
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: source %s/%s %s\n", file, src.Namespace, src.Name, action)
+					applied++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to apply %d manifest(s), applied %d source(s)", failed, applied)
+			}
+			return nil
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVarP(&applyOpts.Filename, "filename", "f", "", "manifest file or directory of manifests (.yaml, .yml or .json) to apply")
+	flags.BoolVar(&applyOpts.DryRun, "dry-run", false, "only print which sources would be created or updated")
+	_ = result.MarkFlagRequired("filename")
+
+	return &result
+}
+
+// manifestFiles returns the given file or the manifest files in the given
+// directory (not recursive) sorted by name
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %v", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %v", err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if _, ok := manifestExtensions[strings.ToLower(filepath.Ext(e.Name()))]; ok && !e.IsDir() {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no manifests found in directory %q", path)
+	}
+	return files, nil
+}
+
+// readSourceManifests returns the sources of all documents in the given
+// manifest file. An error is returned if a document is not a valid
+// VSphereSource.
+func readSourceManifests(file string) ([]*v1alpha1.VSphereSource, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var sources []*v1alpha1.VSphereSource
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document %d: %v", i, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		var src v1alpha1.VSphereSource
+		if err = yaml.UnmarshalStrict(doc, &src); err != nil {
+			return nil, fmt.Errorf("failed to parse document %d: %v", i, err)
+		}
+		if src.Kind != sourceKind {
+			return nil, fmt.Errorf("unsupported kind %q in document %d: must be %s", src.Kind, i, sourceKind)
+		}
+		if src.Name == "" {
+			return nil, fmt.Errorf("document %d has no name", i)
+		}
+		sources = append(sources, &src)
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("no sources found")
+	}
+	return sources, nil
+}
+
+// applySource creates the given source or updates the spec of the existing
+// source and returns the performed action. The source is not changed if
+// dryRun is true.
+func applySource(ctx context.Context, clients *pkg.Clients, src *v1alpha1.VSphereSource, dryRun bool) (string, error) {
+	client := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(src.Namespace)
+
+	existing, err := client.Get(ctx, src.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if !dryRun {
+			if _, err = client.Create(ctx, src, metav1.CreateOptions{}); err != nil {
+				return "", fmt.Errorf("failed to create: %v", err)
+			}
+		}
+		return "created", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get: %v", err)
+	}
+
+	src.ResourceVersion = existing.ResourceVersion
+	if !dryRun {
+		if _, err = client.Update(ctx, src, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("failed to update: %v", err)
+		}
+	}
+	return "updated", nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceApplyCommand(t *testing.T) {
+	const (
+		secretRef     = "street-creds"
+		sourceAddress = "https://my-vsphere-endpoint.example.com"
+		sinkURI       = "https://sink.example.com"
+	)
+
+	sourceYAML := func(name, secret string) string {
+		return `apiVersion: sources.tanzu.vmware.com/v1alpha1
+kind: VSphereSource
+metadata:
+  name: ` + name + `
+spec:
+  address: ` + sourceAddress + `
+  secretRef:
+    name: ` + secret + `
+  sink:
+    uri: ` + sinkURI + `
+`
+	}
+
+	writeDir := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+		return dir
+	}
+
+	getSource := func(t *testing.T, client *vspherefake.Clientset, name string) (*v1alpha1.VSphereSource, error) {
+		t.Helper()
+		return client.SourcesV1alpha1().VSphereSources(command.DefaultNamespace).Get(context.Background(), name, metav1.GetOptions{})
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceApplyCommand(&pkg.Clients{}, &source.Options{})
+
+		assert.Equal(t, cmd.Use, "apply")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		assert.Check(t, len(cmd.Example) > 0,
+			"command should have a nonempty example")
+		command.CheckFlag(t, cmd, "filename")
+		command.CheckFlag(t, cmd, "dry-run")
+	})
+
+	t.Run("creates and updates sources from directory", func(t *testing.T) {
+		existing := newSource(t, command.DefaultNamespace, "summer", sourceAddress, "old-creds", sinkURI)
+		dir := writeDir(t, map[string]string{
+			"spring.yaml": sourceYAML("spring", secretRef),
+			"summer.yml":  sourceYAML("summer", secretRef),
+			"README.md":   "not a manifest",
+		})
+
+		cmd, client := applyTestCommand(existing)
+		cmd.SetArgs([]string{"-f", dir})
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		assert.NilError(t, cmd.Execute())
+
+		out := buf.String()
+		assert.Check(t, strings.Contains(out, "source "+command.DefaultNamespace+"/spring created"), out)
+		assert.Check(t, strings.Contains(out, "source "+command.DefaultNamespace+"/summer updated"), out)
+
+		for _, name := range []string{"spring", "summer"} {
+			src, err := getSource(t, client, name)
+			assert.NilError(t, err)
+			assert.Equal(t, src.Spec.SecretRef.Name, secretRef)
+		}
+	})
+
+	t.Run("applies multiple documents of a file", func(t *testing.T) {
+		dir := writeDir(t, map[string]string{
+			"sources.yaml": sourceYAML("spring", secretRef) + "---\n" + sourceYAML("summer", secretRef),
+		})
+
+		cmd, client := applyTestCommand()
+		cmd.SetArgs([]string{"-f", dir})
+
+		assert.NilError(t, cmd.Execute())
+
+		for _, name := range []string{"spring", "summer"} {
+			_, err := getSource(t, client, name)
+			assert.NilError(t, err)
+		}
+	})
+
+	t.Run("continues past invalid manifests", func(t *testing.T) {
+		dir := writeDir(t, map[string]string{
+			"a-invalid.yaml": "kind: VSphereSource\nmetadata:\n  name: autumn\nunknown: field\n",
+			"b-kind.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: winter\n",
+			"c-valid.yaml":   sourceYAML("spring", secretRef),
+		})
+
+		cmd, client := applyTestCommand()
+		cmd.SetArgs([]string{"-f", dir})
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to apply 2 manifest(s), applied 1 source(s)")
+
+		out := buf.String()
+		assert.Check(t, strings.Contains(out, "a-invalid.yaml: error: failed to parse document 0"), out)
+		assert.Check(t, strings.Contains(out, `b-kind.yaml: error: unsupported kind "ConfigMap"`), out)
+
+		_, err = getSource(t, client, "spring")
+		assert.NilError(t, err)
+	})
+
+	t.Run("does not change sources in dry run", func(t *testing.T) {
+		dir := writeDir(t, map[string]string{"spring.yaml": sourceYAML("spring", secretRef)})
+
+		cmd, client := applyTestCommand()
+		cmd.SetArgs([]string{"-f", dir, "--dry-run"})
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		assert.NilError(t, cmd.Execute())
+		assert.Check(t, strings.Contains(buf.String(), "source "+command.DefaultNamespace+"/spring created (dry run)"), buf.String())
+
+		_, err := getSource(t, client, "spring")
+		assert.Check(t, apierrors.IsNotFound(err), "source created in dry run")
+	})
+
+	t.Run("fails without manifests", func(t *testing.T) {
+		cmd, _ := applyTestCommand()
+		cmd.SetArgs([]string{"-f", writeDir(t, nil)})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "no manifests found")
+	})
+}
+
+func applyTestCommand(objects ...runtime.Object) (*cobra.Command, *vspherefake.Clientset) {
+	client := vspherefake.NewSimpleClientset(objects...)
+	cmd := source.NewSourceApplyCommand(&pkg.Clients{
+		ClientConfig:     command.RegularClientConfig(),
+		VSphereClientSet: client,
+	}, &source.Options{})
+	cmd.SetErr(ioutil.Discard)
+	cmd.SetOut(ioutil.Discard)
+	return cmd, client
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// eventPrivileges are the vCenter privileges required to read events of an
+// entity
+var eventPrivileges = []string{"System.View", "System.Read"}
+
+type checkPermissionsOptions struct {
+	Username string
+	Password string
+	Entities []string
+}
+
+func NewSourceCheckPermissionsCommand(opts *Options) *cobra.Command {
+	cpOpts := checkPermissionsOptions{}
+
+	result := cobra.Command{
+		Use:   "check-permissions",
+		Short: "Validate the vCenter permissions of a vSphere source account",
+		Long:  "Validate that a vCenter account can log in, read events and access the specified inventory entities, reporting any missing privileges",
+		Example: `# Validate the vCenter permissions of an account
+kn vsphere source check-permissions --vc-address https://my-vsphere-endpoint.local --username jane-doe --password s3cr3t
+
+# Validate the vCenter permissions of an account scoped to a datacenter
+kn vsphere source check-permissions --vc-address https://my-vsphere-endpoint.local --username jane-doe --password s3cr3t --entity /dc-01
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.VCAddress == "" {
+				return fmt.Errorf("'check-permissions' requires a nonempty address provided with the --vc-address option")
+			}
+			if cpOpts.Username == "" || cpOpts.Password == "" {
+				return fmt.Errorf("'check-permissions' requires nonempty credentials provided with the --username and --password options")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return checkPermissions(cmd, opts, cpOpts)
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVarP(&opts.VCAddress, "vc-address", "a", "", "URL of vCenter instance to validate permissions against")
+	flags.BoolVarP(&opts.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the vCenter address")
+	flags.StringVar(&cpOpts.Username, "username", "", "vCenter username")
+	flags.StringVar(&cpOpts.Password, "password", "", "vCenter password")
+	flags.StringSliceVar(&cpOpts.Entities, "entity", nil, "inventory path of an entity the source is scoped to, e.g. /dc-01 (repeatable)")
+
+	return &result
+}
+
+// checkPermissions logs into vCenter with the given credentials and validates
+// that events can be read and the required privileges are granted on the root
+// folder and each specified entity
+func checkPermissions(cmd *cobra.Command, opts *Options, cpOpts checkPermissionsOptions) error {
+	ctx := cmd.Context()
+
+	parsedURL, err := soap.ParseURL(opts.VCAddress)
+	if err != nil {
+		return fmt.Errorf("failed to parse vCenter URL: %v", err)
+	}
+	parsedURL.User = url.UserPassword(cpOpts.Username, cpOpts.Password)
+
+	client, err := govmomi.NewClient(ctx, parsedURL, opts.SkipTLSVerify)
+	if err != nil {
+		return fmt.Errorf("failed to log into vCenter: %v", err)
+	}
+	defer func() {
+		_ = client.Logout(ctx) // best effort, ignoring error
+	}()
+
+	if err = readEvents(ctx, client); err != nil {
+		return fmt.Errorf("failed to read events: %v", err)
+	}
+
+	session, err := client.SessionManager.UserSession(ctx)
+	if err != nil || session == nil {
+		return fmt.Errorf("failed to retrieve user session: %v", err)
+	}
+
+	entities := map[string]types.ManagedObjectReference{
+		"/": client.ServiceContent.RootFolder,
+	}
+	paths := []string{"/"}
+	index := object.NewSearchIndex(client.Client)
+	for _, path := range cpOpts.Entities {
+		ref, err := index.FindByInventoryPath(ctx, path)
+		if err != nil {
+			return fmt.Errorf("failed to find entity %q: %v", path, err)
+		}
+		if ref == nil {
+			return fmt.Errorf("entity %q not found or not accessible", path)
+		}
+		entities[path] = ref.Reference()
+		paths = append(paths, path)
+	}
+
+	authz := object.NewAuthorizationManager(client.Client)
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "ENTITY\tPRIVILEGE\tGRANTED")
+
+	var missing []string
+	for _, path := range paths {
+		granted, err := authz.HasPrivilegeOnEntity(ctx, entities[path], session.Key, eventPrivileges)
+		if err != nil {
+			return fmt.Errorf("failed to check privileges on entity %q: %v", path, err)
+		}
+
+		for _, m := range missingPrivileges(eventPrivileges, granted) {
+			missing = append(missing, fmt.Sprintf("%s on %s", m, path))
+		}
+		for i, priv := range eventPrivileges {
+			fmt.Fprintf(w, "%s\t%s\t%t\n", path, priv, i < len(granted) && granted[i])
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing privileges: %s", strings.Join(missing, ", "))
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "All required permissions granted")
+	return nil
+}
+
+// readEvents verifies that events can be read by creating an event history
+// collector for the inventory
+func readEvents(ctx context.Context, client *govmomi.Client) error {
+	filter := types.EventFilterSpec{
+		Entity: &types.EventFilterSpecByEntity{
+			Entity:    client.ServiceContent.RootFolder,
+			Recursion: types.EventFilterSpecRecursionOptionAll,
+		},
+		Time: &types.EventFilterSpecByTime{
+			BeginTime: types.NewTime(time.Now().Add(-time.Hour)),
+		},
+	}
+
+	coll, err := event.NewManager(client.Client).CreateCollectorForEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = coll.Destroy(ctx) // best effort, ignoring error
+	}()
+
+	_, err = coll.ReadNextEvents(ctx, 1)
+	return err
+}
+
+// missingPrivileges returns the privileges which are not granted according to
+// the given results of HasPrivilegeOnEntity
+func missingPrivileges(privileges []string, granted []bool) []string {
+	var missing []string
+	for i, priv := range privileges {
+		if i >= len(granted) || !granted[i] {
+			missing = append(missing, priv)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+	"knative.dev/client/pkg/util"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceCheckPermissionsCommand(t *testing.T) {
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceCheckPermissionsCommand(&source.Options{})
+
+		assert.Equal(t, cmd.Use, "check-permissions")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		command.CheckFlag(t, cmd, "vc-address")
+		command.CheckFlag(t, cmd, "username")
+		command.CheckFlag(t, cmd, "password")
+		command.CheckFlag(t, cmd, "entity")
+	})
+
+	t.Run("fails without an address", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"check-permissions", "--username", "user", "--password", "pass"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires a nonempty address provided with the --vc-address option")
+	})
+
+	t.Run("fails without credentials", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"check-permissions", "--vc-address", "https://vcenter.example.com"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires nonempty credentials provided with the --username and --password options")
+	})
+
+	t.Run("validates permissions", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			cmd, _ := sourceTestCommand(command.RegularClientConfig())
+			cmd.SetArgs([]string{
+				"check-permissions",
+				"--vc-address", vc.URL().String(),
+				"--username", "user",
+				"--password", "pass",
+				"--skip-tls-verify", // required to pass against vc simulator
+				"--entity", "/DC0",
+			})
+
+			buf := bytes.Buffer{}
+			cmd.SetOut(&buf)
+
+			err := cmd.Execute()
+			assert.NilError(t, err)
+			assert.Check(t, util.ContainsAll(buf.String(), "/DC0", "System.Read", "All required permissions granted"))
+			return nil
+		})
+	})
+
+	t.Run("fails for unknown entity", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			cmd, _ := sourceTestCommand(command.RegularClientConfig())
+			cmd.SetArgs([]string{
+				"check-permissions",
+				"--vc-address", vc.URL().String(),
+				"--username", "user",
+				"--password", "pass",
+				"--skip-tls-verify", // required to pass against vc simulator
+				"--entity", "/unknown",
+			})
+
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, `entity "/unknown" not found or not accessible`)
+			return nil
+		})
+	})
+
+}
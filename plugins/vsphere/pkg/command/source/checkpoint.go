@@ -0,0 +1,267 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// Checkpoint describes a checkpoint retained in the checkpoint history of a
+// vSphere source
+type Checkpoint struct {
+	LastEventKey          int32     `json:"lastEventKey"`
+	LastEventType         string    `json:"lastEventType"`
+	LastEventKeyTimestamp time.Time `json:"lastEventKeyTimestamp"`
+	CreatedTimestamp      time.Time `json:"createdTimestamp"`
+}
+
+type checkpointOptions struct {
+	Index  int
+	Mirror string
+}
+
+func NewSourceCheckpointCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	result := cobra.Command{
+		Use:   "checkpoint",
+		Short: "Manage the checkpoint history of a vSphere source",
+		Long:  "Manage the checkpoint history of a vSphere source retained when VSPHERE_CHECKPOINT_HISTORY is configured and promote checkpoint mirrors configured with VSPHERE_CHECKPOINT_MIRROR",
+	}
+
+	result.AddCommand(newSourceCheckpointListCommand(clients, opts))
+	result.AddCommand(newSourceCheckpointRestoreCommand(clients, opts))
+	result.AddCommand(newSourceCheckpointPromoteCommand(clients, opts))
+
+	return &result
+}
+
+func newSourceCheckpointListCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	result := cobra.Command{
+		Use:   "list",
+		Short: "List the retained checkpoints of a vSphere source",
+		Long:  "List the retained checkpoints of a vSphere source, newest first",
+		Example: `# List the retained checkpoints of the source in the default namespace
+kn vsphere source checkpoint list --name vc-01-source
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := checkpointConfigMap(cmd.Context(), clients, opts)
+			if err != nil {
+				return err
+			}
+
+			history, err := checkpointHistory(cm)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 3, ' ', 0)
+			fmt.Fprintln(w, "INDEX\tEVENT KEY\tEVENT TYPE\tEVENT TIMESTAMP\tCREATED")
+			for i, raw := range history {
+				var cp Checkpoint
+				if err = json.Unmarshal(raw, &cp); err != nil {
+					return fmt.Errorf("failed to parse checkpoint %d: %v", i, err)
+				}
+				fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", i, cp.LastEventKey, cp.LastEventType,
+					cp.LastEventKeyTimestamp.Format(time.RFC3339), cp.CreatedTimestamp.Format(time.RFC3339))
+			}
+			return w.Flush()
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source")
+	_ = result.MarkFlagRequired("name")
+
+	return &result
+}
+
+func newSourceCheckpointRestoreCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	cpOpts := checkpointOptions{}
+
+	result := cobra.Command{
+		Use:   "restore",
+		Short: "Restore a retained checkpoint of a vSphere source",
+		Long:  "Restore a retained checkpoint of a vSphere source. The source adapter resumes from the restored checkpoint after it is restarted.",
+		Example: `# Restore the second newest checkpoint of the source in the default namespace
+kn vsphere source checkpoint restore --name vc-01-source --index 1
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if cpOpts.Index < 0 {
+				return fmt.Errorf("'index' requires a non-negative index provided with the --index option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := checkpointConfigMap(cmd.Context(), clients, opts)
+			if err != nil {
+				return err
+			}
+
+			history, err := checkpointHistory(cm)
+			if err != nil {
+				return err
+			}
+			if cpOpts.Index >= len(history) {
+				return fmt.Errorf("checkpoint index %d out of range: %d checkpoints retained", cpOpts.Index, len(history))
+			}
+
+			cm.Data[vsphere.CheckpointKey] = string(history[cpOpts.Index])
+			// a checkpoint in the flat format takes precedence when configured
+			vsphere.RemoveFlatCheckpoint(cm.Data)
+			if _, err = clients.ClientSet.
+				CoreV1().
+				ConfigMaps(cm.Namespace).
+				Update(cmd.Context(), cm, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update checkpoint: %v", err)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Restored checkpoint, restart the source adapter to resume from it")
+			return nil
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source")
+	flags.IntVar(&cpOpts.Index, "index", 0, "index of the checkpoint to restore as shown by checkpoint list")
+	_ = result.MarkFlagRequired("name")
+	_ = result.MarkFlagRequired("index")
+
+	return &result
+}
+
+func newSourceCheckpointPromoteCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	cpOpts := checkpointOptions{}
+
+	result := cobra.Command{
+		Use:   "promote",
+		Short: "Promote a checkpoint mirror of a vSphere source",
+		Long:  "Promote the checkpoint mirror of a vSphere source to its primary checkpoint, e.g. after the primary checkpoint was lost. The source adapter resumes from the promoted checkpoint after it is restarted.",
+		Example: `# Promote the checkpoint mirror dr/vc-01-mirror of the source in the default namespace
+kn vsphere source checkpoint promote --name vc-01-source --mirror dr/vc-01-mirror
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if cpOpts.Mirror == "" {
+				return fmt.Errorf("'mirror' requires a nonempty configmap reference provided with the --mirror option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := checkpointConfigMap(cmd.Context(), clients, opts)
+			if err != nil {
+				return err
+			}
+
+			mirrorNamespace, mirrorName, err := vsphere.ParseConfigMapRef(cpOpts.Mirror, cm.Namespace)
+			if err != nil {
+				return err
+			}
+
+			mirror, err := clients.ClientSet.
+				CoreV1().
+				ConfigMaps(mirrorNamespace).
+				Get(cmd.Context(), mirrorName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get checkpoint mirror: %v", err)
+			}
+
+			data, ok := mirror.Data[vsphere.CheckpointKey]
+			if !ok {
+				return fmt.Errorf("no checkpoint found in mirror %s/%s", mirrorNamespace, mirrorName)
+			}
+
+			var cp Checkpoint
+			if err = json.Unmarshal([]byte(data), &cp); err != nil {
+				return fmt.Errorf("failed to parse mirror checkpoint: %v", err)
+			}
+
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[vsphere.CheckpointKey] = data
+			vsphere.RemoveFlatCheckpoint(cm.Data)
+			if _, err = clients.ClientSet.
+				CoreV1().
+				ConfigMaps(cm.Namespace).
+				Update(cmd.Context(), cm, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update checkpoint: %v", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Promoted checkpoint mirror with event key %d, restart the source adapter to resume from it\n", cp.LastEventKey)
+			return nil
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source")
+	flags.StringVar(&cpOpts.Mirror, "mirror", "", "checkpoint mirror configmap to promote in the format [namespace/]name")
+	_ = result.MarkFlagRequired("name")
+	_ = result.MarkFlagRequired("mirror")
+
+	return &result
+}
+
+// checkpointConfigMap returns the ConfigMap storing the checkpoints of the
+// source specified in opts
+func checkpointConfigMap(ctx context.Context, clients *pkg.Clients, opts *Options) (*corev1.ConfigMap, error) {
+	namespace, err := clients.GetExplicitOrDefaultNamespace(opts.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace: %v", err)
+	}
+
+	src, err := clients.VSphereClientSet.
+		SourcesV1alpha1().
+		VSphereSources(namespace).
+		Get(ctx, opts.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source: %v", err)
+	}
+
+	cm, err := clients.ClientSet.
+		CoreV1().
+		ConfigMaps(namespace).
+		Get(ctx, names.ConfigMap(src), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint configmap: %v", err)
+	}
+	return cm, nil
+}
+
+// checkpointHistory returns the raw retained checkpoints, newest first
+func checkpointHistory(cm *corev1.ConfigMap) ([]json.RawMessage, error) {
+	data, ok := cm.Data[vsphere.CheckpointHistoryKey]
+	if !ok {
+		return nil, fmt.Errorf("no checkpoint history found: history is retained when VSPHERE_CHECKPOINT_HISTORY is configured")
+	}
+
+	var history []json.RawMessage
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint history: %v", err)
+	}
+	return history, nil
+}
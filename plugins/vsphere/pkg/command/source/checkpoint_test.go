@@ -0,0 +1,197 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceCheckpointCommand(t *testing.T) {
+	const (
+		sourceName    = "spring"
+		secretRef     = "street-creds"
+		sourceAddress = "https://my-vsphere-endpoint.example.com"
+		sinkURI       = "https://sink.example.com"
+		configMapName = sourceName + "-configmap"
+		history       = `[{"lastEventKey":3,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2020-10-01T12:02:00Z","createdTimestamp":"2020-10-01T12:02:01Z"},` +
+			`{"lastEventKey":2,"lastEventType":"VmPoweredOffEvent","lastEventKeyTimestamp":"2020-10-01T12:01:00Z","createdTimestamp":"2020-10-01T12:01:01Z"}]`
+	)
+
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: command.DefaultNamespace,
+				Name:      configMapName,
+			},
+			Data: data,
+		}
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceCheckpointCommand(&pkg.Clients{}, &source.Options{})
+
+		assert.Equal(t, cmd.Use, "checkpoint")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		assert.Check(t, command.HasLeafCommand(cmd, "list"), "command should have subcommand list")
+		assert.Check(t, command.HasLeafCommand(cmd, "restore"), "command should have subcommand restore")
+		assert.Check(t, command.HasLeafCommand(cmd, "promote"), "command should have subcommand promote")
+	})
+
+	t.Run("lists retained checkpoints", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cm := newConfigMap(map[string]string{vsphere.CheckpointHistoryKey: history})
+		cmd, _ := checkpointTestCommand(cm, existingSource)
+		cmd.SetArgs([]string{
+			"list",
+			"--name", sourceName,
+		})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, len(rows), 3)
+		assert.Check(t, strings.Contains(rows[0], "EVENT KEY"))
+		assert.Check(t, strings.Contains(rows[1], "VmPoweredOnEvent"))
+		assert.Check(t, strings.Contains(rows[2], "VmPoweredOffEvent"))
+	})
+
+	t.Run("fails to list without checkpoint history", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cmd, _ := checkpointTestCommand(newConfigMap(nil), existingSource)
+		cmd.SetArgs([]string{
+			"list",
+			"--name", sourceName,
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "no checkpoint history found")
+	})
+
+	t.Run("restores retained checkpoint", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cm := newConfigMap(map[string]string{
+			vsphere.CheckpointKey:              `{"lastEventKey":3}`,
+			vsphere.CheckpointHistoryKey:       history,
+			vsphere.FlatCheckpointLastEventKey: "3",
+		})
+		cmd, client := checkpointTestCommand(cm, existingSource)
+		cmd.SetArgs([]string{
+			"restore",
+			"--name", sourceName,
+			"--index", "1",
+		})
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		got, err := client.CoreV1().ConfigMaps(command.DefaultNamespace).Get(context.Background(), configMapName, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(got.Data[vsphere.CheckpointKey], `"lastEventKey":2`))
+		assert.Equal(t, got.Data[vsphere.CheckpointHistoryKey], history)
+		_, flat := got.Data[vsphere.FlatCheckpointLastEventKey]
+		assert.Check(t, !flat, "flat checkpoint should be removed")
+	})
+
+	t.Run("fails to restore checkpoint out of range", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cm := newConfigMap(map[string]string{vsphere.CheckpointHistoryKey: history})
+		cmd, _ := checkpointTestCommand(cm, existingSource)
+		cmd.SetArgs([]string{
+			"restore",
+			"--name", sourceName,
+			"--index", "2",
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "checkpoint index 2 out of range")
+	})
+
+	t.Run("promotes checkpoint mirror", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		mirror := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "dr",
+				Name:      "spring-mirror",
+			},
+			Data: map[string]string{vsphere.CheckpointKey: `{"lastEventKey":5}`},
+		}
+		cmd, client := checkpointTestCommand(newConfigMap(nil), existingSource)
+		_, err := client.CoreV1().ConfigMaps("dr").Create(context.Background(), mirror, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		cmd.SetArgs([]string{
+			"promote",
+			"--name", sourceName,
+			"--mirror", "dr/spring-mirror",
+		})
+
+		err = cmd.Execute()
+		assert.NilError(t, err)
+
+		got, err := client.CoreV1().ConfigMaps(command.DefaultNamespace).Get(context.Background(), configMapName, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, got.Data[vsphere.CheckpointKey], `{"lastEventKey":5}`)
+	})
+
+	t.Run("fails to promote missing checkpoint mirror", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cmd, _ := checkpointTestCommand(newConfigMap(nil), existingSource)
+		cmd.SetArgs([]string{
+			"promote",
+			"--name", sourceName,
+			"--mirror", "spring-mirror",
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to get checkpoint mirror")
+	})
+
+	t.Run("fails to execute when source does not exist", func(t *testing.T) {
+		cmd, _ := checkpointTestCommand(newConfigMap(nil))
+		cmd.SetArgs([]string{
+			"list",
+			"--name", sourceName,
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to get source")
+	})
+}
+
+func checkpointTestCommand(cm *corev1.ConfigMap, objects ...runtime.Object) (*cobra.Command, *k8sfake.Clientset) {
+	client := k8sfake.NewSimpleClientset(cm)
+	cmd := source.NewSourceCheckpointCommand(&pkg.Clients{
+		ClientSet:        client,
+		ClientConfig:     command.RegularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(objects...),
+	}, &source.Options{})
+	cmd.SetErr(ioutil.Discard)
+	cmd.SetOut(ioutil.Discard)
+	return cmd, client
+}
@@ -16,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
@@ -23,6 +24,8 @@ import (
 )
 
 func NewSourceCreateCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	var dryRun bool
+
 	result := cobra.Command{
 		Use:   "create",
 		Short: "Create a vSphere source to react to vSphere events",
@@ -35,6 +38,12 @@ kn vsphere source create --namespace ns --name vc-01-source --vc-address https:/
 
 # Create the source in the specified namespace, sending events to the specified service with custom checkpoint behavior
 kn vsphere source create --namespace ns --name vc-01-source --vc-address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --sink-api-version v1 --sink-kind Service --sink-name the-service-name --checkpoint-age 1h --checkpoint-period 30s
+
+# Create the source in the default namespace, overriding the source attribute of the emitted CloudEvents
+kn vsphere source create --name vc-01-source --vc-address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff --ce-source urn:vcenter:vc-01
+
+# Print the source as YAML without creating it, e.g. to commit it to a GitOps repository
+kn vsphere source create --name vc-01-source --vc-address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff --dry-run > vc-01-source.yaml
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if opts.Name == "" {
@@ -65,6 +74,12 @@ kn vsphere source create --namespace ns --name vc-01-source --vc-address https:/
 				return fmt.Errorf("invalid encoding scheme %q", opts.PayloadEncoding)
 			}
 
+			if opts.CESource != "" {
+				if err := v1alpha1.ValidateCESource(opts.CESource); err != nil {
+					return fmt.Errorf("invalid CloudEvent source %q: %w", opts.CESource, err)
+				}
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -80,10 +95,20 @@ kn vsphere source create --namespace ns --name vc-01-source --vc-address https:/
 			if err != nil {
 				return fmt.Errorf("failed to parse sink address: %v", err)
 			}
+			src := newSource(namespace, sinkDestination, address, *opts)
+			if dryRun {
+				b, err := yaml.Marshal(src)
+				if err != nil {
+					return fmt.Errorf("failed to encode source: %v", err)
+				}
+				_, err = cmd.OutOrStdout().Write(b)
+				return err
+			}
+
 			if _, err = clients.VSphereClientSet.
 				SourcesV1alpha1().
 				VSphereSources(namespace).
-				Create(cmd.Context(), newSource(namespace, sinkDestination, address, *opts), metav1.CreateOptions{}); err != nil {
+				Create(cmd.Context(), src, metav1.CreateOptions{}); err != nil {
 				return fmt.Errorf("failed to create source: %v", err)
 			}
 
@@ -103,10 +128,12 @@ kn vsphere source create --namespace ns --name vc-01-source --vc-address https:/
 	flags.StringVar(&opts.SinkName, "sink-name", "", "sink name")
 	flags.StringVar(&opts.ServiceAccountName, "service-account-name", "", "service account name")
 	flags.StringVar(&opts.PayloadEncoding, "encoding", "xml", "CloudEvent data encoding scheme (xml or json)")
+	flags.StringVar(&opts.CESource, "ce-source", "", "CloudEvent source attribute (URI-reference) to override the default vCenter host")
 	flags.DurationVar(&opts.CheckpointMaxAge, "checkpoint-age", vsphere.CheckpointDefaultAge,
 		"maximum allowed age for replaying events determined by last successful event in checkpoint")
 	flags.DurationVar(&opts.CheckpointPeriod, "checkpoint-period", vsphere.CheckpointDefaultPeriod,
 		"period between saving checkpoints")
+	flags.BoolVar(&dryRun, "dry-run", false, "only print the source as YAML without creating it")
 
 	_ = result.MarkFlagRequired("name")
 	_ = result.MarkFlagRequired("vc-address")
@@ -121,6 +148,10 @@ func newSource(namespace string, sinkDestination *duckv1.Destination, address *u
 		serviceAccountName = options.ServiceAccountName
 	}
 	return &v1alpha1.VSphereSource{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       sourceKind,
+		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
 			Name:      options.Name,
@@ -143,6 +174,7 @@ func newSource(namespace string, sinkDestination *duckv1.Destination, address *u
 			},
 			PayloadEncoding:    fmt.Sprintf("application/%s", strings.ToLower(options.PayloadEncoding)),
 			ServiceAccountName: serviceAccountName,
+			CESource:           options.CESource,
 		},
 	}
 }
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package source_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strconv"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stesting "k8s.io/client-go/testing"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	vsphere "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned"
@@ -51,6 +53,8 @@ func TestNewSourceCreateCommand(t *testing.T) {
 		command.CheckFlag(t, cmd, "sink-kind")
 		command.CheckFlag(t, cmd, "sink-name")
 		command.CheckFlag(t, cmd, "encoding")
+		command.CheckFlag(t, cmd, "ce-source")
+		command.CheckFlag(t, cmd, "dry-run")
 		assert.Assert(t, cmd.RunE != nil)
 	})
 
@@ -207,6 +211,89 @@ and with a nonempty name with the --sink-name`)
 		assert.Equal(t, src.Spec.PayloadEncoding, cloudevents.ApplicationJSON)
 	})
 
+	t.Run("creates basic source with CloudEvent source override", func(t *testing.T) {
+		cmd, vSphereClientSet := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{
+			"create",
+			"--name", sourceName,
+			"--vc-address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--ce-source", "urn:vcenter:vc-01",
+		})
+
+		err := cmd.Execute()
+
+		src := retrieveCreatedSource(t, err, vSphereClientSet, command.DefaultNamespace, sourceName)
+		assertBasicSource(t, &src.Spec, sourceAddress, secretRef, false)
+		assert.Equal(t, src.Spec.CESource, "urn:vcenter:vc-01")
+	})
+
+	t.Run("fails to execute with an invalid CloudEvent source override", func(t *testing.T) {
+		for _, ceSource := range []string{"vc-01\x07", "vc 01", " vc-01", "vc-01\n", "#vc-01", "?vc=01"} {
+			cmd, vSphereClientSet := sourceTestCommand(command.RegularClientConfig())
+			cmd.SetArgs([]string{
+				"create",
+				"--name", sourceName,
+				"--vc-address", sourceAddress,
+				"--secret-ref", secretRef,
+				"--sink-uri", sinkURI,
+				"--ce-source", ceSource,
+			})
+
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, "invalid CloudEvent source")
+			assert.Equal(t, len(vSphereClientSet.Actions()), 0)
+		}
+	})
+
+	t.Run("prints source without creating it in dry run", func(t *testing.T) {
+		cmd, vSphereClientSet := sourceTestCommand(command.RegularClientConfig())
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{
+			"create",
+			"--name", sourceName,
+			"--vc-address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--ce-source", "urn:vcenter:vc-01",
+			"--dry-run",
+		})
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Equal(t, len(vSphereClientSet.Actions()), 0)
+
+		var src v1alpha1.VSphereSource
+		assert.NilError(t, yaml.UnmarshalStrict(buf.Bytes(), &src))
+		assert.Equal(t, src.APIVersion, v1alpha1.SchemeGroupVersion.String())
+		assert.Equal(t, src.Kind, "VSphereSource")
+		assert.Equal(t, src.Namespace, command.DefaultNamespace)
+		assert.Equal(t, src.Name, sourceName)
+		assertBasicSource(t, &src.Spec, sourceAddress, secretRef, false)
+		assert.Equal(t, src.Spec.Sink.URI.String(), sinkURI)
+		assert.Equal(t, src.Spec.PayloadEncoding, cloudevents.ApplicationXML)
+		assert.Equal(t, src.Spec.CESource, "urn:vcenter:vc-01")
+	})
+
+	t.Run("fails to execute in dry run with missing flags", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{
+			"create",
+			"--name", sourceName,
+			"--vc-address", sourceAddress,
+			"--sink-uri", sinkURI,
+			"--dry-run",
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires a nonempty secret reference provided with the --secret-ref option")
+		assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("kind: VSphereSource")))
+	})
+
 	t.Run("creates insecure source with Service and relative sink URI in explicit namespace", func(t *testing.T) {
 		namespace := "ns"
 		sinkURI := "/relative/uri"
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+func NewSourceDescribeCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	result := cobra.Command{
+		Use:   "describe",
+		Short: "Describe a vSphere source",
+		Long:  "Describe a vSphere source including its sink, the last checkpoint read from the checkpoint configmap and its readiness conditions",
+		Example: `# Describe the source in the default namespace
+kn vsphere source describe --name vc-01-source
+
+# Describe the source in the specified namespace
+kn vsphere source describe --namespace ns --name vc-01-source
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(opts.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %v", err)
+			}
+
+			src, err := clients.VSphereClientSet.
+				SourcesV1alpha1().
+				VSphereSources(namespace).
+				Get(cmd.Context(), opts.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %v", err)
+			}
+
+			// the configmap is created by the controller, i.e. it might not
+			// exist yet
+			var cp *Checkpoint
+			cm, err := clients.ClientSet.
+				CoreV1().
+				ConfigMaps(namespace).
+				Get(cmd.Context(), names.ConfigMap(src), metav1.GetOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+			case err != nil:
+				return fmt.Errorf("failed to get checkpoint configmap: %v", err)
+			default:
+				if cp, err = currentCheckpoint(cm); err != nil {
+					return err
+				}
+			}
+
+			return printSourceDescription(cmd.OutOrStdout(), src, cp)
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source to describe")
+	_ = result.MarkFlagRequired("name")
+
+	return &result
+}
+
+// currentCheckpoint returns the checkpoint stored in the given ConfigMap, nil
+// if the adapter did not write a checkpoint yet. A checkpoint in the flat
+// format takes precedence as it is only written when configured.
+func currentCheckpoint(cm *corev1.ConfigMap) (*Checkpoint, error) {
+	if _, ok := cm.Data[vsphere.FlatCheckpointLastEventKey]; ok {
+		var cp Checkpoint
+		// values are JSON encoded
+		fields := map[string]interface{}{
+			vsphere.FlatCheckpointLastEventKey:  &cp.LastEventKey,
+			vsphere.FlatCheckpointLastEventType: &cp.LastEventType,
+			vsphere.FlatCheckpointTimestampKey:  &cp.LastEventKeyTimestamp,
+			vsphere.FlatCheckpointCreatedAtKey:  &cp.CreatedTimestamp,
+		}
+		for key, field := range fields {
+			if data, ok := cm.Data[key]; ok {
+				if err := json.Unmarshal([]byte(data), field); err != nil {
+					return nil, fmt.Errorf("failed to parse checkpoint %s: %v", key, err)
+				}
+			}
+		}
+		return &cp, nil
+	}
+
+	data, ok := cm.Data[vsphere.CheckpointKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal([]byte(data), &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	return &cp, nil
+}
+
+// printSourceDescription prints the given source and its checkpoint, which is
+// nil if none was written
+func printSourceDescription(out io.Writer, src *v1alpha1.VSphereSource, cp *Checkpoint) error {
+	sink := "<unresolved>"
+	switch {
+	case src.Status.SinkURI != nil:
+		sink = src.Status.SinkURI.String()
+	case src.Spec.Sink.Ref != nil:
+		sink = fmt.Sprintf("%s %s/%s (unresolved)", src.Spec.Sink.Ref.Kind, src.Spec.Sink.Ref.Namespace, src.Spec.Sink.Ref.Name)
+	case src.Spec.Sink.URI != nil:
+		sink = src.Spec.Sink.URI.String()
+	}
+
+	encoding := src.Spec.PayloadEncoding
+	if encoding == "" {
+		encoding = "<default>"
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", src.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", src.Namespace)
+	fmt.Fprintf(w, "Address:\t%s\n", src.Spec.Address.URL())
+	fmt.Fprintf(w, "Sink:\t%s\n", sink)
+	fmt.Fprintf(w, "Payload Encoding:\t%s\n", encoding)
+	fmt.Fprintf(w, "Checkpoint ConfigMap:\t%s\n", names.ConfigMap(src))
+	if cp == nil {
+		fmt.Fprintf(w, "Last Checkpoint:\t<none>\n")
+	} else {
+		fmt.Fprintf(w, "Last Event Key:\t%d\n", cp.LastEventKey)
+		fmt.Fprintf(w, "Last Event Type:\t%s\n", cp.LastEventType)
+		fmt.Fprintf(w, "Last Event Timestamp:\t%s\n", cp.LastEventKeyTimestamp.Format(time.RFC3339))
+		fmt.Fprintf(w, "Checkpoint Created:\t%s\n", cp.CreatedTimestamp.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Conditions:")
+	w = tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tMESSAGE")
+	for _, c := range src.Status.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, c.Message)
+	}
+	return w.Flush()
+}
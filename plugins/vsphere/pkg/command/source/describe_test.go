@@ -0,0 +1,140 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/apis"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceDescribeCommand(t *testing.T) {
+	const (
+		sourceName    = "spring"
+		secretRef     = "street-creds"
+		sourceAddress = "https://my-vsphere-endpoint.example.com"
+		sinkURI       = "https://sink.example.com"
+		configMapName = sourceName + "-configmap"
+	)
+
+	newDescribedSource := func(t *testing.T) runtime.Object {
+		src := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI).(*v1alpha1.VSphereSource)
+		src.Spec.PayloadEncoding = "application/json"
+		src.Status.Conditions = []apis.Condition{{
+			Type:    apis.ConditionReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  "SinkNotFound",
+			Message: "sink not found",
+		}}
+		return src
+	}
+
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: command.DefaultNamespace,
+				Name:      configMapName,
+			},
+			Data: data,
+		}
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceDescribeCommand(&pkg.Clients{}, &source.Options{})
+
+		assert.Equal(t, cmd.Use, "describe")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		command.CheckFlag(t, cmd, "name")
+	})
+
+	t.Run("describes source with checkpoint", func(t *testing.T) {
+		cm := newConfigMap(map[string]string{
+			vsphere.CheckpointKey: `{"lastEventKey":3,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2020-10-01T12:02:00Z","createdTimestamp":"2020-10-01T12:02:01Z"}`,
+		})
+		cmd := describeTestCommand(cm, newDescribedSource(t))
+		cmd.SetArgs([]string{"--name", sourceName})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		out := buf.String()
+		for _, want := range []string{configMapName, "application/json", "VmPoweredOnEvent", "2020-10-01T12:02:00Z", "SinkNotFound", "sink not found"} {
+			assert.Check(t, strings.Contains(out, want), "output should contain %q: %s", want, out)
+		}
+		assert.Check(t, strings.Contains(out, "Last Event Key:") && strings.Contains(out, " 3\n"), "output should contain event key: %s", out)
+	})
+
+	t.Run("describes source with flat checkpoint", func(t *testing.T) {
+		cm := newConfigMap(map[string]string{
+			vsphere.FlatCheckpointLastEventKey:  "7",
+			vsphere.FlatCheckpointLastEventType: `"VmPoweredOffEvent"`,
+			vsphere.FlatCheckpointTimestampKey:  `"2020-10-01T12:05:00Z"`,
+		})
+		cmd := describeTestCommand(cm, newDescribedSource(t))
+		cmd.SetArgs([]string{"--name", sourceName})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(buf.String(), "VmPoweredOffEvent"))
+		assert.Check(t, strings.Contains(buf.String(), "2020-10-01T12:05:00Z"))
+	})
+
+	t.Run("describes source without checkpoint", func(t *testing.T) {
+		cmd := describeTestCommand(newConfigMap(nil), newDescribedSource(t))
+		cmd.SetArgs([]string{"--name", sourceName})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(buf.String(), "<none>"))
+	})
+
+	t.Run("fails for missing source", func(t *testing.T) {
+		cmd := describeTestCommand(newConfigMap(nil))
+		cmd.SetArgs([]string{"--name", sourceName})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to get source")
+	})
+}
+
+func describeTestCommand(cm *corev1.ConfigMap, objects ...runtime.Object) *cobra.Command {
+	cmd := source.NewSourceDescribeCommand(&pkg.Clients{
+		ClientSet:        k8sfake.NewSimpleClientset(cm),
+		ClientConfig:     command.RegularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(objects...),
+	}, &source.Options{})
+	cmd.SetErr(ioutil.Discard)
+	cmd.SetOut(ioutil.Discard)
+	return cmd
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// ErrDrift is returned by the diff command when the live source differs from
+// the provided file
+var ErrDrift = errors.New("live source differs from file")
+
+type diffOptions struct {
+	Filename string
+}
+
+func NewSourceDiffCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	diffOpts := diffOptions{}
+
+	result := cobra.Command{
+		Use:   "diff",
+		Short: "Compare a vSphere source against a YAML file",
+		Long:  "Compare the spec of a live vSphere source against a YAML file and print a unified diff, failing when they differ",
+		Example: `# Compare the source in the default namespace against the specified file
+kn vsphere source diff --name vc-01-source -f vc-01-source.yaml
+
+# Compare the source in the specified namespace against the specified file
+kn vsphere source diff --namespace ns --name vc-01-source -f vc-01-source.yaml
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if diffOpts.Filename == "" {
+				return fmt.Errorf("'filename' requires a nonempty file name provided with the --filename option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(opts.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %v", err)
+			}
+
+			b, err := ioutil.ReadFile(diffOpts.Filename)
+			if err != nil {
+				return fmt.Errorf("failed to read file: %v", err)
+			}
+			var desired v1alpha1.VSphereSource
+			if err = yaml.UnmarshalStrict(b, &desired); err != nil {
+				return fmt.Errorf("failed to parse file: %v", err)
+			}
+
+			live, err := clients.VSphereClientSet.
+				SourcesV1alpha1().
+				VSphereSources(namespace).
+				Get(cmd.Context(), opts.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %v", err)
+			}
+
+			diff, err := diffSourceSpecs(cmd, namespace, live, &desired)
+			if err != nil {
+				return fmt.Errorf("failed to compare source: %v", err)
+			}
+			if diff == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No differences found")
+				return nil
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), diff)
+			return ErrDrift
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source to compare")
+	flags.StringVarP(&diffOpts.Filename, "filename", "f", "", "YAML file containing the desired source")
+	_ = result.MarkFlagRequired("name")
+	_ = result.MarkFlagRequired("filename")
+
+	return &result
+}
+
+// diffSourceSpecs returns a unified diff of the defaulted specs of the live and
+// desired sources. An empty diff is returned if the specs are equal.
+func diffSourceSpecs(cmd *cobra.Command, namespace string, live, desired *v1alpha1.VSphereSource) (string, error) {
+	// normalize both sources by applying the same defaults as the webhook
+	desired.Namespace = namespace
+	desired.SetDefaults(cmd.Context())
+	live = live.DeepCopy()
+	live.SetDefaults(cmd.Context())
+
+	liveSpec, err := yaml.Marshal(live.Spec)
+	if err != nil {
+		return "", err
+	}
+	desiredSpec, err := yaml.Marshal(desired.Spec)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(liveSpec)),
+		B:        difflib.SplitLines(string(desiredSpec)),
+		FromFile: "live",
+		ToFile:   "file",
+		Context:  3,
+	})
+}
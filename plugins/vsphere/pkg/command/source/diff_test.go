@@ -0,0 +1,145 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceDiffCommand(t *testing.T) {
+	const (
+		sourceName    = "spring"
+		secretRef     = "street-creds"
+		sourceAddress = "https://my-vsphere-endpoint.example.com"
+		sinkURI       = "https://sink.example.com"
+	)
+
+	writeFile := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "source.yaml")
+		assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	sourceYAML := func(secret string) string {
+		return `apiVersion: sources.tanzu.vmware.com/v1alpha1
+kind: VSphereSource
+metadata:
+  name: ` + sourceName + `
+spec:
+  address: ` + sinkURI + `
+  secretRef:
+    name: ` + secret + `
+  sink:
+    uri: ` + sourceAddress + `
+`
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceDiffCommand(&pkg.Clients{}, &source.Options{})
+
+		assert.Equal(t, cmd.Use, "diff")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		command.CheckFlag(t, cmd, "name")
+		command.CheckFlag(t, cmd, "filename")
+		assert.Assert(t, cmd.RunE != nil)
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{
+			"diff",
+			"--filename", "source.yaml",
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute with an empty file name", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{
+			"diff",
+			"--name", sourceName,
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires a nonempty file name provided with the --filename option")
+	})
+
+	t.Run("reports no differences for matching source", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cmd, _ := sourceTestCommand(command.RegularClientConfig(), existingSource)
+		cmd.SetArgs([]string{
+			"diff",
+			"--name", sourceName,
+			"-f", writeFile(t, sourceYAML(secretRef)),
+		})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Equal(t, buf.String(), "No differences found\n")
+	})
+
+	t.Run("reports drift for differing source", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cmd, _ := sourceTestCommand(command.RegularClientConfig(), existingSource)
+		cmd.SetArgs([]string{
+			"diff",
+			"--name", sourceName,
+			"-f", writeFile(t, sourceYAML("other-creds")),
+		})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.Check(t, errors.Is(err, source.ErrDrift))
+		assert.Check(t, bytes.Contains(buf.Bytes(), []byte("-  name: "+secretRef)))
+		assert.Check(t, bytes.Contains(buf.Bytes(), []byte("+  name: other-creds")))
+	})
+
+	t.Run("fails to execute when source does not exist", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{
+			"diff",
+			"--name", sourceName,
+			"-f", writeFile(t, sourceYAML(secretRef)),
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to get source")
+	})
+
+	t.Run("fails to execute with invalid file", func(t *testing.T) {
+		existingSource := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		cmd, _ := sourceTestCommand(command.RegularClientConfig(), existingSource)
+		cmd.SetArgs([]string{
+			"diff",
+			"--name", sourceName,
+			"-f", writeFile(t, "spec:\n  unknown: field\n"),
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "failed to parse file")
+	})
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// EventType describes a vSphere event type
+type EventType struct {
+	Type        string `json:"type"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// eventTypeCatalog is a static catalog of commonly used vSphere event types.
+// Use --from-vcenter to retrieve the complete list supported by a vCenter.
+var eventTypeCatalog = []EventType{
+	{Type: "AlarmStatusChangedEvent", Category: "info", Description: "Alarm status changed"},
+	{Type: "ClusterCreatedEvent", Category: "info", Description: "Cluster created"},
+	{Type: "ClusterDestroyedEvent", Category: "info", Description: "Cluster deleted"},
+	{Type: "DatastoreDiscoveredEvent", Category: "info", Description: "Datastore discovered"},
+	{Type: "DrsVmMigratedEvent", Category: "info", Description: "DRS VM migrated"},
+	{Type: "DrsVmPoweredOnEvent", Category: "info", Description: "DRS VM powered on"},
+	{Type: "EnteredMaintenanceModeEvent", Category: "info", Description: "Entered maintenance mode"},
+	{Type: "ExitMaintenanceModeEvent", Category: "info", Description: "Exit maintenance mode"},
+	{Type: "HostConnectedEvent", Category: "info", Description: "Host connected"},
+	{Type: "HostConnectionLostEvent", Category: "error", Description: "Host connection lost"},
+	{Type: "HostDisconnectedEvent", Category: "info", Description: "Host disconnected"},
+	{Type: "TaskEvent", Category: "info", Description: "Task event"},
+	{Type: "UserLoginSessionEvent", Category: "info", Description: "User login"},
+	{Type: "UserLogoutSessionEvent", Category: "info", Description: "User logout"},
+	{Type: "VmBeingClonedEvent", Category: "info", Description: "VM being cloned"},
+	{Type: "VmBeingCreatedEvent", Category: "info", Description: "Creating VM"},
+	{Type: "VmBeingDeployedEvent", Category: "info", Description: "VM being deployed"},
+	{Type: "VmClonedEvent", Category: "info", Description: "VM cloned"},
+	{Type: "VmCreatedEvent", Category: "info", Description: "VM created"},
+	{Type: "VmDeployedEvent", Category: "info", Description: "VM deployed"},
+	{Type: "VmGuestShutdownEvent", Category: "info", Description: "Guest OS shut down"},
+	{Type: "VmMigratedEvent", Category: "info", Description: "VM migrated"},
+	{Type: "VmPoweredOffEvent", Category: "info", Description: "VM powered off"},
+	{Type: "VmPoweredOnEvent", Category: "info", Description: "VM powered on"},
+	{Type: "VmReconfiguredEvent", Category: "info", Description: "VM reconfigured"},
+	{Type: "VmRelocatedEvent", Category: "info", Description: "VM relocated"},
+	{Type: "VmRemovedEvent", Category: "info", Description: "VM removed"},
+	{Type: "VmRenamedEvent", Category: "warning", Description: "VM renamed"},
+	{Type: "VmResettingEvent", Category: "info", Description: "VM resetting"},
+	{Type: "VmStartingEvent", Category: "info", Description: "VM starting"},
+	{Type: "VmStoppingEvent", Category: "info", Description: "VM stopping"},
+	{Type: "VmSuspendedEvent", Category: "info", Description: "VM suspended"},
+}
+
+type eventTypesOptions struct {
+	FromVCenter bool
+	Username    string
+	Password    string
+	Output      string
+}
+
+func NewSourceEventTypesCommand(opts *Options) *cobra.Command {
+	etOpts := eventTypesOptions{}
+
+	result := cobra.Command{
+		Use:   "event-types",
+		Short: "List supported vSphere event types",
+		Long:  "List supported vSphere event types from a bundled catalog or as reported by vCenter",
+		Example: `# List commonly used vSphere event types from the bundled catalog
+kn vsphere source event-types
+
+# List all event types supported by the specified vCenter with JSON output
+kn vsphere source event-types --from-vcenter --vc-address https://my-vsphere-endpoint.local --username jane-doe --password s3cr3t -o json
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if etOpts.Output != "table" && etOpts.Output != "json" {
+				return fmt.Errorf("invalid output format %q: supported formats are table and json", etOpts.Output)
+			}
+			if !etOpts.FromVCenter {
+				return nil
+			}
+			if opts.VCAddress == "" {
+				return fmt.Errorf("'--from-vcenter' requires a nonempty address provided with the --vc-address option")
+			}
+			if etOpts.Username == "" || etOpts.Password == "" {
+				return fmt.Errorf("'--from-vcenter' requires nonempty credentials provided with the --username and --password options")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventTypes := eventTypeCatalog
+
+			if etOpts.FromVCenter {
+				var err error
+				eventTypes, err = retrieveEventTypes(cmd, opts, etOpts)
+				if err != nil {
+					return fmt.Errorf("failed to retrieve event types from vCenter: %v", err)
+				}
+			}
+
+			if etOpts.Output == "json" {
+				return printEventTypesJSON(cmd.OutOrStdout(), eventTypes)
+			}
+			return printEventTypesTable(cmd.OutOrStdout(), eventTypes)
+		},
+	}
+
+	flags := result.Flags()
+	flags.BoolVar(&etOpts.FromVCenter, "from-vcenter", false, "retrieve the event types supported by vCenter instead of using the bundled catalog")
+	flags.StringVarP(&opts.VCAddress, "vc-address", "a", "", "URL of vCenter instance to retrieve event types from (used with --from-vcenter)")
+	flags.BoolVarP(&opts.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the vCenter address")
+	flags.StringVar(&etOpts.Username, "username", "", "vCenter username (used with --from-vcenter)")
+	flags.StringVar(&etOpts.Password, "password", "", "vCenter password (used with --from-vcenter)")
+	flags.StringVarP(&etOpts.Output, "output", "o", "table", "output format (table or json)")
+
+	return &result
+}
+
+// retrieveEventTypes queries the vCenter EventManager description for the
+// supported event types
+func retrieveEventTypes(cmd *cobra.Command, opts *Options, etOpts eventTypesOptions) ([]EventType, error) {
+	ctx := cmd.Context()
+
+	parsedURL, err := soap.ParseURL(opts.VCAddress)
+	if err != nil {
+		return nil, fmt.Errorf("parse vCenter URL: %w", err)
+	}
+	parsedURL.User = url.UserPassword(etOpts.Username, etOpts.Password)
+
+	client, err := govmomi.NewClient(ctx, parsedURL, opts.SkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Logout(ctx) // best effort, ignoring error
+	}()
+
+	var mgr mo.EventManager
+	pc := property.DefaultCollector(client.Client)
+	if err = pc.RetrieveOne(ctx, *client.ServiceContent.EventManager, []string{"description.eventInfo"}, &mgr); err != nil {
+		return nil, err
+	}
+
+	eventTypes := make([]EventType, 0, len(mgr.Description.EventInfo))
+	for _, info := range mgr.Description.EventInfo {
+		eventTypes = append(eventTypes, EventType{
+			Type:        info.Key,
+			Category:    info.Category,
+			Description: info.Description,
+		})
+	}
+	sort.Slice(eventTypes, func(i, j int) bool {
+		return eventTypes[i].Type < eventTypes[j].Type
+	})
+
+	return eventTypes, nil
+}
+
+func printEventTypesTable(out io.Writer, eventTypes []EventType) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tCATEGORY\tDESCRIPTION")
+	for _, et := range eventTypes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", et.Type, et.Category, strings.TrimSpace(et.Description))
+	}
+	return w.Flush()
+}
+
+func printEventTypesJSON(out io.Writer, eventTypes []EventType) error {
+	b, err := json.MarshalIndent(eventTypes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal event types: %v", err)
+	}
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+	"knative.dev/client/pkg/util"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceEventTypesCommand(t *testing.T) {
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceEventTypesCommand(&source.Options{})
+
+		assert.Equal(t, cmd.Use, "event-types")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		command.CheckFlag(t, cmd, "from-vcenter")
+		command.CheckFlag(t, cmd, "vc-address")
+		command.CheckFlag(t, cmd, "username")
+		command.CheckFlag(t, cmd, "password")
+		command.CheckFlag(t, cmd, "output")
+	})
+
+	t.Run("fails with an invalid output format", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"event-types", "-o", "yaml"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, `invalid output format "yaml"`)
+	})
+
+	t.Run("fails to query vCenter without an address", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"event-types", "--from-vcenter", "--username", "user", "--password", "pass"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires a nonempty address provided with the --vc-address option")
+	})
+
+	t.Run("fails to query vCenter without credentials", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"event-types", "--from-vcenter", "--vc-address", "https://vcenter.example.com"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "requires nonempty credentials")
+	})
+
+	t.Run("lists event types from the bundled catalog", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"event-types"})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		rows := strings.Split(buf.String(), "\n")
+		assert.Check(t, util.ContainsAll(rows[0], "TYPE", "CATEGORY", "DESCRIPTION"))
+		assert.Check(t, util.ContainsAll(buf.String(), "VmPoweredOnEvent", "VmPoweredOffEvent"))
+	})
+
+	t.Run("lists event types from the bundled catalog in JSON output", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{"event-types", "-o", "json"})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		var result []source.EventType
+		err = json.Unmarshal(buf.Bytes(), &result)
+		assert.NilError(t, err)
+		assert.Check(t, len(result) > 0)
+	})
+
+	t.Run("lists event types from vCenter", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			cmd, _ := sourceTestCommand(command.RegularClientConfig())
+			cmd.SetArgs([]string{
+				"event-types",
+				"--from-vcenter",
+				"--vc-address", vc.URL().String(),
+				"--username", "user",
+				"--password", "pass",
+				"--skip-tls-verify", // required to pass against vc simulator
+				"-o", "json",
+			})
+
+			buf := bytes.Buffer{}
+			cmd.SetOut(&buf)
+
+			err := cmd.Execute()
+			assert.NilError(t, err)
+
+			var result []source.EventType
+			err = json.Unmarshal(buf.Bytes(), &result)
+			assert.NilError(t, err)
+			assert.Check(t, len(result) > 0)
+			assert.Check(t, util.ContainsAll(buf.String(), "UserLoginSessionEvent"))
+			return nil
+		})
+	})
+}
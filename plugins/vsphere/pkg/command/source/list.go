@@ -12,6 +12,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"knative.dev/client/pkg/kn/commands"
 	"knative.dev/client/pkg/kn/commands/flags"
@@ -25,6 +26,7 @@ import (
 
 func NewSourceListCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
 	sourceListFlags := flags.NewListPrintFlags(ListHandlers)
+	var selector string
 
 	result := cobra.Command{
 		Use:     "list",
@@ -39,11 +41,17 @@ kn vsphere source list --namespace ns
 
 # List the sources in all namespaces with JSON output
 kn vsphere source list --all-namespaces -o json
+
+# List the sources with the label team=payments
+kn vsphere source list -l team=payments
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if opts.Namespace != "" && opts.AllNamespaces {
 				return fmt.Errorf("'--namespace' and '--all-namespaces' options are mutually exclusive")
 			}
+			if _, err := labels.Parse(selector); err != nil {
+				return fmt.Errorf("invalid label selector %q: %v", selector, err)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -62,7 +70,7 @@ kn vsphere source list --all-namespaces -o json
 				sourceListFlags.EnsureWithNamespace()
 			}
 
-			sourceList, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).List(cmd.Context(), metav1.ListOptions{})
+			sourceList, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).List(cmd.Context(), metav1.ListOptions{LabelSelector: selector})
 			if err != nil {
 				return fmt.Errorf("list sources: %v", err)
 			}
@@ -87,6 +95,7 @@ kn vsphere source list --all-namespaces -o json
 
 	fl := result.Flags()
 	fl.BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, "list objects in all namespaces")
+	fl.StringVarP(&selector, "selector", "l", "", "label selector to filter sources, e.g. team=payments")
 
 	sourceListFlags.AddFlags(&result)
 
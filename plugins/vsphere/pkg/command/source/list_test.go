@@ -41,6 +41,7 @@ func TestNewListCommand(t *testing.T) {
 		assert.Check(t, len(cmd.Long) > 0,
 			"command should have a nonempty long description")
 		command.CheckFlag(t, cmd, "all-namespaces")
+		command.CheckFlag(t, cmd, "selector")
 	})
 
 	t.Run("fails when '--namespace' and '--all-namespaces' are both set", func(t *testing.T) {
@@ -174,6 +175,41 @@ func TestNewListCommand(t *testing.T) {
 		assert.DeepEqual(t, testSourcesList.Items, result.Items)
 	})
 
+	t.Run("lists sources matching label selector", func(t *testing.T) {
+		src1 := newSource(t, command.DefaultNamespace, sourceName+"-1", sourceAddress, secretRef, sinkURI)
+		src2 := newSource(t, command.DefaultNamespace, sourceName+"-2", sourceAddress, secretRef, sinkURI)
+		src2.(*v1alpha1.VSphereSource).Labels = map[string]string{"team": "payments"}
+
+		cmd, _ := sourceTestCommand(command.RegularClientConfig(), src1, src2)
+		cmd.SetArgs([]string{
+			"list",
+			"-l",
+			"team=payments",
+		})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, len(rows), 2)
+		assert.Check(t, util.ContainsAll(rows[1], sourceName+"-2"))
+	})
+
+	t.Run("fails on invalid label selector", func(t *testing.T) {
+		cmd, _ := sourceTestCommand(command.RegularClientConfig())
+		cmd.SetArgs([]string{
+			"list",
+			"--selector",
+			"team=payments,=",
+		})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "invalid label selector")
+	})
+
 	t.Run("fails to execute when default namespace retrieval fails", func(t *testing.T) {
 		namespaceError := fmt.Errorf("no default namespace, oops")
 		cmd, _ := sourceTestCommand(command.FailingClientConfig(namespaceError))
@@ -0,0 +1,260 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+const (
+	// adapter environment variable configuring the maximum replay rate
+	replayMaxRateEnv = "VSPHERE_REPLAY_MAX_RATE"
+
+	rateSourceFlag       = "flag"
+	rateSourceHistory    = "checkpoint-history"
+	rateSourceDeployment = "deployment"
+)
+
+// LagProjection describes the projected backlog of a vSphere source paused
+// for a period of time
+type LagProjection struct {
+	Pause string `json:"pause"`
+	// EventRate is the estimated rate of vCenter events per second
+	EventRate       float64 `json:"eventRate"`
+	EventRateSource string  `json:"eventRateSource"`
+	// BacklogEvents is the estimated number of events accumulated while paused
+	BacklogEvents int64 `json:"backlogEvents"`
+	// MaxRate is the maximum rate of events per second sent while catching
+	// up, 0 if unlimited
+	MaxRate       float64 `json:"maxRate"`
+	MaxRateSource string  `json:"maxRateSource,omitempty"`
+	// CatchUp is the estimated time to catch up after the pause, empty if
+	// the catch-up rate is unlimited or does not exceed the event rate
+	CatchUp string `json:"catchUp,omitempty"`
+	// ExceedsReplayWindow is true if the pause is longer than the maximum
+	// age of replayed events, i.e. events will be lost
+	ExceedsReplayWindow bool `json:"exceedsReplayWindow"`
+}
+
+type projectLagOptions struct {
+	Pause     time.Duration
+	EventRate float64
+	MaxRate   float64
+	Output    string
+}
+
+func NewSourceProjectLagCommand(clients *pkg.Clients, opts *Options) *cobra.Command {
+	plOpts := projectLagOptions{}
+
+	result := cobra.Command{
+		Use:   "project-lag",
+		Short: "Project the backlog of a paused vSphere source",
+		Long:  "Estimate the backlog accumulated while a vSphere source is paused, e.g. during a maintenance window, and the time to catch up afterwards. The event rate is derived from the checkpoint history retained when VSPHERE_CHECKPOINT_HISTORY is configured and the catch-up rate from VSPHERE_REPLAY_MAX_RATE of the source adapter.",
+		Example: `# Project the backlog of the source in the default namespace paused for 30 minutes
+kn vsphere source project-lag --name vc-01-source --pause 30m
+
+# Project the backlog with an explicit event rate and JSON output
+kn vsphere source project-lag --name vc-01-source --pause 2h --event-rate 12.5 -o json
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if plOpts.Pause <= 0 {
+				return fmt.Errorf("'pause' requires a positive duration provided with the --pause option")
+			}
+			if plOpts.EventRate < 0 || plOpts.MaxRate < 0 {
+				return fmt.Errorf("'event-rate' and 'max-rate' must not be negative")
+			}
+			if plOpts.Output != "table" && plOpts.Output != "json" {
+				return fmt.Errorf("invalid output format %q: supported formats are table and json", plOpts.Output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projection, err := projectLag(cmd, clients, opts, plOpts)
+			if err != nil {
+				return err
+			}
+
+			if plOpts.Output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(projection)
+			}
+			return printLagProjection(cmd.OutOrStdout(), projection)
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVar(&opts.Name, "name", "", "name of the source")
+	flags.DurationVar(&plOpts.Pause, "pause", 0, "duration the source is paused, e.g. 30m")
+	flags.Float64Var(&plOpts.EventRate, "event-rate", 0, "vCenter events per second (derived from the checkpoint history if omitted)")
+	flags.Float64Var(&plOpts.MaxRate, "max-rate", 0, "maximum events per second sent while catching up (read from the source adapter if omitted)")
+	flags.StringVarP(&plOpts.Output, "output", "o", "table", "output format (table or json)")
+	_ = result.MarkFlagRequired("name")
+	_ = result.MarkFlagRequired("pause")
+
+	return &result
+}
+
+// projectLag estimates the backlog of the source specified in opts
+func projectLag(cmd *cobra.Command, clients *pkg.Clients, opts *Options, plOpts projectLagOptions) (*LagProjection, error) {
+	ctx := cmd.Context()
+
+	namespace, err := clients.GetExplicitOrDefaultNamespace(opts.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace: %v", err)
+	}
+
+	src, err := clients.VSphereClientSet.
+		SourcesV1alpha1().
+		VSphereSources(namespace).
+		Get(ctx, opts.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source: %v", err)
+	}
+
+	eventRate, eventRateSource := plOpts.EventRate, rateSourceFlag
+	if eventRate == 0 {
+		cm, err := clients.ClientSet.
+			CoreV1().
+			ConfigMaps(namespace).
+			Get(ctx, names.ConfigMap(src), metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get checkpoint configmap: %v", err)
+		}
+
+		history, err := checkpointHistory(cm)
+		if err != nil {
+			return nil, fmt.Errorf("%v: provide the event rate with the --event-rate option", err)
+		}
+
+		if eventRate, err = historyEventRate(history); err != nil {
+			return nil, err
+		}
+		eventRateSource = rateSourceHistory
+	}
+
+	maxRate, maxRateSource := plOpts.MaxRate, rateSourceFlag
+	if maxRate == 0 {
+		maxRateSource = ""
+		deployment, err := clients.ClientSet.
+			AppsV1().
+			Deployments(namespace).
+			Get(ctx, names.Deployment(src), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get source adapter: %v", err)
+		}
+
+		if deployment != nil && err == nil {
+			for _, c := range deployment.Spec.Template.Spec.Containers {
+				for _, env := range c.Env {
+					if env.Name != replayMaxRateEnv || env.Value == "" {
+						continue
+					}
+					if maxRate, err = strconv.ParseFloat(env.Value, 64); err != nil {
+						return nil, fmt.Errorf("failed to parse %s of source adapter: %v", replayMaxRateEnv, err)
+					}
+					maxRateSource = rateSourceDeployment
+				}
+			}
+		}
+	}
+
+	projection := newLagProjection(plOpts.Pause, eventRate, maxRate)
+	projection.EventRateSource = eventRateSource
+	projection.MaxRateSource = maxRateSource
+	if maxAge := src.Spec.CheckpointConfig.MaxAgeSeconds; maxAge > 0 {
+		projection.ExceedsReplayWindow = plOpts.Pause > time.Duration(maxAge)*time.Second
+	}
+	return projection, nil
+}
+
+// historyEventRate returns the rate of vCenter events per second between the
+// oldest and newest of the given raw checkpoints (newest first) based on the
+// sequential event keys
+func historyEventRate(history []json.RawMessage) (float64, error) {
+	if len(history) < 2 {
+		return 0, fmt.Errorf("at least 2 retained checkpoints required to derive the event rate, found %d: provide the event rate with the --event-rate option", len(history))
+	}
+
+	var newest, oldest Checkpoint
+	if err := json.Unmarshal(history[0], &newest); err != nil {
+		return 0, fmt.Errorf("failed to parse checkpoint 0: %v", err)
+	}
+	if err := json.Unmarshal(history[len(history)-1], &oldest); err != nil {
+		return 0, fmt.Errorf("failed to parse checkpoint %d: %v", len(history)-1, err)
+	}
+
+	elapsed := newest.LastEventKeyTimestamp.Sub(oldest.LastEventKeyTimestamp).Seconds()
+	events := newest.LastEventKey - oldest.LastEventKey
+	if elapsed <= 0 || events < 0 {
+		return 0, fmt.Errorf("retained checkpoints do not span a time range to derive the event rate: provide the event rate with the --event-rate option")
+	}
+	return float64(events) / elapsed, nil
+}
+
+// newLagProjection returns the projected backlog after pausing for the given
+// duration with the given event rate and maximum catch-up rate (0 if
+// unlimited). New events keep arriving while catching up, so the backlog
+// shrinks with the difference of both rates.
+func newLagProjection(pause time.Duration, eventRate, maxRate float64) *LagProjection {
+	projection := LagProjection{
+		Pause:         pause.String(),
+		EventRate:     eventRate,
+		BacklogEvents: int64(math.Ceil(eventRate * pause.Seconds())),
+		MaxRate:       maxRate,
+	}
+
+	if maxRate > eventRate {
+		seconds := float64(projection.BacklogEvents) / (maxRate - eventRate)
+		projection.CatchUp = time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+	}
+	return &projection
+}
+
+// printLagProjection prints the given projection in human-readable form
+func printLagProjection(out io.Writer, p *LagProjection) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintf(w, "Pause:\t%s\n", p.Pause)
+	fmt.Fprintf(w, "Event rate:\t%.2f events/s (%s)\n", p.EventRate, p.EventRateSource)
+	fmt.Fprintf(w, "Backlog:\t%d events\n", p.BacklogEvents)
+
+	switch {
+	case p.MaxRate == 0:
+		fmt.Fprintf(w, "Catch-up rate:\tunlimited\n")
+	default:
+		fmt.Fprintf(w, "Catch-up rate:\t%.2f events/s (%s)\n", p.MaxRate, p.MaxRateSource)
+	}
+
+	switch {
+	case p.CatchUp != "":
+		fmt.Fprintf(w, "Catch-up time:\t%s\n", p.CatchUp)
+	case p.MaxRate == 0:
+		fmt.Fprintf(w, "Catch-up time:\tlimited by sink throughput\n")
+	default:
+		fmt.Fprintf(w, "Catch-up time:\tnever, catch-up rate does not exceed event rate\n")
+	}
+
+	if p.ExceedsReplayWindow {
+		fmt.Fprintf(w, "Warning:\tpause exceeds the checkpoint maxAgeSeconds, events older than the replay window will be lost\n")
+	}
+	return w.Flush()
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package source_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command/source"
+)
+
+func TestNewSourceProjectLagCommand(t *testing.T) {
+	const (
+		sourceName    = "spring"
+		secretRef     = "street-creds"
+		sourceAddress = "https://my-vsphere-endpoint.example.com"
+		sinkURI       = "https://sink.example.com"
+		// 600 events in 10 minutes
+		history = `[{"lastEventKey":1600,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2020-10-01T12:10:00Z","createdTimestamp":"2020-10-01T12:10:01Z"},` +
+			`{"lastEventKey":1000,"lastEventType":"VmPoweredOffEvent","lastEventKeyTimestamp":"2020-10-01T12:00:00Z","createdTimestamp":"2020-10-01T12:00:01Z"}]`
+	)
+
+	newObjects := func(t *testing.T, data map[string]string, maxRate string) (runtime.Object, []runtime.Object) {
+		src := newSource(t, command.DefaultNamespace, sourceName, sourceAddress, secretRef, sinkURI).(*v1alpha1.VSphereSource)
+		src.Spec.CheckpointConfig.MaxAgeSeconds = 3600
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: command.DefaultNamespace, Name: names.ConfigMap(src)},
+			Data:       data,
+		}
+		objects := []runtime.Object{cm}
+
+		if maxRate != "" {
+			objects = append(objects, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: command.DefaultNamespace, Name: names.Deployment(src)},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "adapter",
+								Env:  []corev1.EnvVar{{Name: "VSPHERE_REPLAY_MAX_RATE", Value: maxRate}},
+							}},
+						},
+					},
+				},
+			})
+		}
+		return src, objects
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		cmd := source.NewSourceProjectLagCommand(&pkg.Clients{}, &source.Options{})
+
+		assert.Equal(t, cmd.Use, "project-lag")
+		assert.Check(t, len(cmd.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cmd.Long) > 0,
+			"command should have a nonempty long description")
+		assert.Check(t, len(cmd.Example) > 0,
+			"command should have a nonempty example")
+	})
+
+	t.Run("projects lag from checkpoint history and adapter rate limit", func(t *testing.T) {
+		src, objects := newObjects(t, map[string]string{vsphere.CheckpointHistoryKey: history}, "4")
+		cmd := projectLagTestCommand(src, objects...)
+		cmd.SetArgs([]string{"--name", sourceName, "--pause", "30m", "-o", "json"})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		var got source.LagProjection
+		assert.NilError(t, json.Unmarshal(buf.Bytes(), &got))
+		assert.Equal(t, got.EventRate, 1.0)
+		assert.Equal(t, got.BacklogEvents, int64(1800))
+		assert.Equal(t, got.MaxRate, 4.0)
+		// backlog shrinks by 3 events per second
+		assert.Equal(t, got.CatchUp, "10m0s")
+		assert.Check(t, !got.ExceedsReplayWindow)
+	})
+
+	t.Run("projects lag with explicit rates", func(t *testing.T) {
+		src, objects := newObjects(t, nil, "")
+		cmd := projectLagTestCommand(src, objects...)
+		cmd.SetArgs([]string{"--name", sourceName, "--pause", "2h", "--event-rate", "2", "--max-rate", "1"})
+
+		buf := bytes.Buffer{}
+		cmd.SetOut(&buf)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		out := buf.String()
+		assert.Check(t, strings.Contains(out, "14400 events"), out)
+		assert.Check(t, strings.Contains(out, "never"), out)
+		assert.Check(t, strings.Contains(out, "Warning"), out)
+	})
+
+	t.Run("fails without checkpoint history", func(t *testing.T) {
+		src, objects := newObjects(t, nil, "")
+		cmd := projectLagTestCommand(src, objects...)
+		cmd.SetArgs([]string{"--name", sourceName, "--pause", "30m"})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "--event-rate")
+	})
+
+	t.Run("fails without pause", func(t *testing.T) {
+		src, objects := newObjects(t, nil, "")
+		cmd := projectLagTestCommand(src, objects...)
+		cmd.SetArgs([]string{"--name", sourceName})
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, "pause")
+	})
+}
+
+func projectLagTestCommand(src runtime.Object, objects ...runtime.Object) *cobra.Command {
+	cmd := source.NewSourceProjectLagCommand(&pkg.Clients{
+		ClientSet:        k8sfake.NewSimpleClientset(objects...),
+		ClientConfig:     command.RegularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(src),
+	}, &source.Options{})
+	cmd.SetErr(ioutil.Discard)
+	cmd.SetOut(ioutil.Discard)
+	return cmd
+}
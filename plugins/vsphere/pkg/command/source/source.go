@@ -33,6 +33,7 @@ type Options struct {
 	CheckpointPeriod time.Duration
 
 	PayloadEncoding string
+	CESource        string
 }
 
 func (so *Options) AsSinkDestination(namespace string) (*duckv1.Destination, error) {
@@ -74,6 +75,13 @@ func NewSourceCommand(clients *pkg.Clients) *cobra.Command {
 	result.AddCommand(NewSourceCreateCommand(clients, &options))
 	result.AddCommand(NewSourceDeleteCommand(clients, &options))
 	result.AddCommand(NewSourceListCommand(clients, &options))
+	result.AddCommand(NewSourceDescribeCommand(clients, &options))
+	result.AddCommand(NewSourceEventTypesCommand(&options))
+	result.AddCommand(NewSourceDiffCommand(clients, &options))
+	result.AddCommand(NewSourceCheckpointCommand(clients, &options))
+	result.AddCommand(NewSourceCheckPermissionsCommand(&options))
+	result.AddCommand(NewSourceProjectLagCommand(clients, &options))
+	result.AddCommand(NewSourceApplyCommand(clients, &options))
 
 	return &result
 }
@@ -33,10 +33,17 @@ func TestNewSourceCommand(t *testing.T) {
 			"command should have a nonempty long description")
 		command.CheckFlag(t, cmd, "namespace")
 
-		assert.Check(t, len(cmd.Commands()) == 3, "unexpected number of subcommands")
+		assert.Check(t, len(cmd.Commands()) == 10, "unexpected number of subcommands")
 		assert.Check(t, command.HasLeafCommand(cmd, "create"), "command should have subcommand create")
 		assert.Check(t, command.HasLeafCommand(cmd, "delete"), "command should have subcommand delete")
 		assert.Check(t, command.HasLeafCommand(cmd, "list"), "command should have subcommand delete")
+		assert.Check(t, command.HasLeafCommand(cmd, "describe"), "command should have subcommand describe")
+		assert.Check(t, command.HasLeafCommand(cmd, "event-types"), "command should have subcommand event-types")
+		assert.Check(t, command.HasLeafCommand(cmd, "diff"), "command should have subcommand diff")
+		assert.Check(t, command.HasLeafCommand(cmd, "checkpoint"), "command should have subcommand checkpoint")
+		assert.Check(t, command.HasLeafCommand(cmd, "check-permissions"), "command should have subcommand check-permissions")
+		assert.Check(t, command.HasLeafCommand(cmd, "project-lag"), "command should have subcommand project-lag")
+		assert.Check(t, command.HasLeafCommand(cmd, "apply"), "command should have subcommand apply")
 	})
 }
 